@@ -0,0 +1,122 @@
+package vergilevhasi
+
+import (
+	"regexp"
+	"slices"
+	"strings"
+	"unicode"
+)
+
+// DefaultAddressMarkers returns the street and district markers that identify an address
+// line by default, in both the Turkish and the dotless ASCII spelling. Markers are matched
+// case-insensitively anywhere in the line.
+func DefaultAddressMarkers() []string {
+	return []string{
+		"MAH.", "MAH ", "MAHALLESİ", "MAHALLESI",
+		"CAD.", "CAD ", "CADDESİ", "CADDESI",
+		"SOK.", "SOK ", "SK.", "SK ", "SOKAĞI", "SOKAGI", "SOKAK",
+		"BLV.", "BULV.", "BULVARI",
+		"KÜME EVLERİ", "KUME EVLERI",
+		"YOLU CAD", "KAPI NO", "İÇ KAPI", "IC KAPI",
+	}
+}
+
+// AddAddressMarkers adds markers, such as "MEVKİİ" or "KÖYÜ", to the list that identifies
+// address lines in both the line-based and the GİB-format extraction. Empty markers are
+// ignored.
+func (p *Parser) AddAddressMarkers(markers ...string) {
+	if p.addressMarkers == nil {
+		p.addressMarkers = DefaultAddressMarkers()
+	}
+	for _, marker := range markers {
+		if strings.TrimSpace(marker) != "" {
+			p.addressMarkers = append(p.addressMarkers, marker)
+		}
+	}
+}
+
+// SetAddressDetector replaces the marker list with a custom test of whether a line is
+// part of the business address. A nil detector restores marker matching.
+func (p *Parser) SetAddressDetector(detector func(line string) bool) {
+	p.addressDetector = detector
+}
+
+// isAddressLine reports whether line looks like a business address line
+func (p *Parser) isAddressLine(line string) bool {
+	if p.addressDetector != nil {
+		return p.addressDetector(line)
+	}
+	markers := p.addressMarkers
+	if markers == nil {
+		markers = DefaultAddressMarkers()
+	}
+	upper := strings.ToUpperSpecial(unicode.TurkishCase, line)
+	for _, marker := range markers {
+		if strings.Contains(upper, strings.ToUpperSpecial(unicode.TurkishCase, marker)) {
+			return true
+		}
+	}
+	return false
+}
+
+// extractIsYeriAdresleri lists the distinct addresses of a plate: primary, then every
+// labelled business address and the secondary addresses, such as a billing address.
+// Addresses differing only in case, punctuation or a few misread characters are listed
+// once. It returns nil unless there is more than one address.
+func (p *Parser) extractIsYeriAdresleri(text, primary string) []string {
+	if primary == "" {
+		return nil
+	}
+	addresses := []string{primary}
+	keys := []string{addressKey(primary)}
+
+	for _, patterns := range [][]*regexp.Regexp{labelIsYeriAdresiPatterns, labelEkAdresPatterns} {
+		for _, re := range patterns {
+			for _, m := range re.FindAllStringSubmatch(text, -1) {
+				address := strings.TrimSpace(m[1])
+				key := addressKey(address)
+				if key == "" || slices.ContainsFunc(keys, func(k string) bool { return sameAddress(k, key) }) {
+					continue
+				}
+				addresses = append(addresses, address)
+				keys = append(keys, key)
+			}
+		}
+	}
+
+	if len(addresses) < 2 {
+		return nil
+	}
+	return addresses
+}
+
+// addressKey reduces an address to its folded letters and digits for comparison
+func addressKey(address string) string {
+	return strings.Map(func(r rune) rune {
+		if unicode.IsLetter(r) || unicode.IsDigit(r) {
+			return r
+		}
+		return -1
+	}, foldTurkish(address))
+}
+
+// sameAddress reports whether two address keys are near-identical: the same numbers, in
+// the same order, and at most one edit in every ten characters of the shorter one. A
+// different door or postal number is a different address however close the rest is.
+func sameAddress(a, b string) bool {
+	if a == b {
+		return true
+	}
+	digits := func(s string) string {
+		return strings.Map(func(r rune) rune {
+			if unicode.IsDigit(r) {
+				return r
+			}
+			return -1
+		}, s)
+	}
+	if digits(a) != digits(b) {
+		return false
+	}
+	return levenshtein(a, b) <= min(len(a), len(b))/10
+}
@@ -0,0 +1,143 @@
+package vergilevhasi
+
+import (
+	"slices"
+	"strings"
+	"testing"
+)
+
+func TestIsAddressLine(t *testing.T) {
+	parser := NewParser()
+
+	tests := []struct {
+		line string
+		want bool
+	}{
+		{"ATATÜRK BULVARI 45/3 ÇANKAYA/ANKARA", true},
+		{"Cumhuriyet Bulvarı 12 Konak/İzmir", true},
+		{"ATATÜRK BLV. 45/3", true},
+		{"YENİKÖY KÜME EVLERİ 12 MERKEZ/BOLU", true},
+		{"Yeniköy Küme Evleri 12", true},
+		{"İSTİKLAL CADDESİ 7 BEYOĞLU/İSTANBUL", true},
+		{"GÜL SOKAĞI 3/1", true},
+		{"ÖRNEK MAH. TEST CAD. NO:1", true},
+		{"ALİ ÖRNEK", false},
+		{"VERGİ KİMLİK NO: 1234567890", false},
+	}
+	for _, tt := range tests {
+		if got := parser.isAddressLine(tt.line); got != tt.want {
+			t.Errorf("isAddressLine(%q) = %v, want %v", tt.line, got, tt.want)
+		}
+	}
+}
+
+func TestParseContentAddressMarkers(t *testing.T) {
+	tests := []struct {
+		name    string
+		address string
+	}{
+		{"Boulevard", "ATATÜRK BULVARI 45/3 ÇANKAYA/ANKARA"},
+		{"Rural cluster", "YENİKÖY KÜME EVLERİ 12 MERKEZ/BOLU"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			text := "MÜKELLEFİN\nALİ ÖRNEK\n" + tt.address + "\nYILLIK GELİR VERGİSİ\nÇANKAYA\n10000000146\n"
+
+			vl := &VergiLevhasi{}
+			NewParser().parseContent(vl, text)
+
+			if vl.AdiSoyadi != "ALİ ÖRNEK" {
+				t.Errorf("AdiSoyadi = %q, want %q", vl.AdiSoyadi, "ALİ ÖRNEK")
+			}
+			if vl.IsYeriAdresi != tt.address {
+				t.Errorf("IsYeriAdresi = %q, want %q", vl.IsYeriAdresi, tt.address)
+			}
+		})
+	}
+}
+
+func TestAddAddressMarkers(t *testing.T) {
+	text := "MÜKELLEFİN\nALİ ÖRNEK\nKARATEPE MEVKİİ 4 MERKEZ/BOLU\nYILLIK GELİR VERGİSİ\nÇANKAYA\n10000000146\n"
+
+	parser := NewParser()
+	parser.AddAddressMarkers("mevkii", "")
+	vl := &VergiLevhasi{}
+	parser.parseContent(vl, text)
+	if vl.IsYeriAdresi != "KARATEPE MEVKİİ 4 MERKEZ/BOLU" || vl.AdiSoyadi != "ALİ ÖRNEK" {
+		t.Errorf("IsYeriAdresi = %q, AdiSoyadi = %q; want the MEVKİİ line as the address", vl.IsYeriAdresi, vl.AdiSoyadi)
+	}
+
+	// Adding markers to one parser leaves the defaults of others untouched
+	if NewParser().isAddressLine("KARATEPE MEVKİİ 4") {
+		t.Error("isAddressLine() on a new parser matched a marker added to another parser")
+	}
+}
+
+func TestSetAddressDetector(t *testing.T) {
+	parser := NewParser()
+	parser.SetAddressDetector(func(line string) bool {
+		return strings.HasPrefix(line, "ADRES ")
+	})
+
+	if parser.isAddressLine("ÖRNEK MAH. TEST CAD. NO:1") {
+		t.Error("isAddressLine() with a custom detector still matched the default markers")
+	}
+	if !parser.isAddressLine("ADRES KARATEPE 4") {
+		t.Error("isAddressLine() did not use the custom detector")
+	}
+
+	parser.SetAddressDetector(nil)
+	if !parser.isAddressLine("ÖRNEK MAH. TEST CAD. NO:1") {
+		t.Error("isAddressLine() after SetAddressDetector(nil) did not restore the markers")
+	}
+}
+
+func TestParseIsYeriAdresleri(t *testing.T) {
+	const (
+		operating = "ÖRNEK MAH. TEST CAD. NO:1 ÇANKAYA/ANKARA"
+		billing   = "YENİ MAH. FATURA SOK. NO:7 KADIKÖY/İSTANBUL"
+	)
+	text := "VERGİ LEVHASI\nTicaret Ünvanı: ÖRNEK YAZILIM LİMİTED ŞİRKETİ\n" +
+		"İş Yeri Adresi: " + operating + "\n" +
+		"Fatura Adresi: " + billing + "\n" +
+		// The same operating address again, as printed in the footer
+		"İş Yeri Adresi: ÖRNEK MAH TEST CAD NO 1 CANKAYA ANKARA\n" +
+		"Vergi Dairesi: ÇANKAYA\nVergi Kimlik No: 1234567890\n"
+
+	vl := &VergiLevhasi{}
+	NewParser().parseText(vl, text, nil)
+
+	if vl.IsYeriAdresi != operating {
+		t.Errorf("IsYeriAdresi = %q, want %q", vl.IsYeriAdresi, operating)
+	}
+	want := []string{operating, billing}
+	if !slices.Equal(vl.IsYeriAdresleri, want) {
+		t.Errorf("IsYeriAdresleri = %q, want %q", vl.IsYeriAdresleri, want)
+	}
+
+	// A plate with a single address leaves the list empty
+	vl = &VergiLevhasi{}
+	NewParser().parseText(vl, "İş Yeri Adresi: "+operating+"\nVergi Kimlik No: 1234567890\n", nil)
+	if vl.IsYeriAdresleri != nil {
+		t.Errorf("IsYeriAdresleri = %q, want nil for a single address", vl.IsYeriAdresleri)
+	}
+}
+
+func TestSameAddress(t *testing.T) {
+	base := addressKey("ÖRNEK MAH. TEST CAD. NO:1 ÇANKAYA/ANKARA")
+	if !sameAddress(base, addressKey("ORNEK MAH TEST CAD NO:1 CANKAYA / ANKARA")) {
+		t.Error("sameAddress() = false for the same address in ASCII with other punctuation")
+	}
+	if !sameAddress(base, addressKey("ÖRNEK MAH. TEST CAO. NO:1 ÇANKAYA/ANKARA")) {
+		t.Error("sameAddress() = false for one misread letter")
+	}
+	for _, other := range []string{
+		"ÖRNEK MAH. TEST CAD. NO:7 ÇANKAYA/ANKARA",
+		"YENİ MAH. TEST CAD. NO:1 ÇANKAYA/ANKARA",
+	} {
+		if sameAddress(base, addressKey(other)) {
+			t.Errorf("sameAddress() = true for %q", other)
+		}
+	}
+}
@@ -0,0 +1,556 @@
+package vergilevhasi
+
+import (
+	"bytes"
+	"fmt"
+	"image"
+	"image/color"
+	"image/png"
+	"io"
+	"sort"
+	"strings"
+
+	"github.com/pdfcpu/pdfcpu/pkg/api"
+	"github.com/pdfcpu/pdfcpu/pkg/pdfcpu"
+	"github.com/pdfcpu/pdfcpu/pkg/pdfcpu/model"
+	"github.com/pdfcpu/pdfcpu/pkg/pdfcpu/types"
+)
+
+// PageText is the extracted text of a single PDF page
+type PageText struct {
+	// Number is the 1-based page number
+	Number int
+
+	// Text is the page text, one shown string per line
+	Text string
+
+	// rows is the positioned text used for table-layout plates. Only the
+	// built-in pdfcpu backend fills it in.
+	rows []textRow
+}
+
+// PDFBackend extracts text and images from PDF documents. The default implementation
+// uses pdfcpu; set another one with Parser.SetBackend, e.g. to use a different PDF
+// library or to test without real PDFs.
+type PDFBackend interface {
+	// ExtractText returns the text of every page, in page order
+	ExtractText(data []byte) ([]PageText, error)
+
+	// ExtractImages returns the embedded images, in page order
+	ExtractImages(data []byte) ([]image.Image, error)
+
+	// PageCount returns the number of pages
+	PageCount(data []byte) (int, error)
+}
+
+// pdfcpuBackend is the default PDFBackend built on pdfcpu
+type pdfcpuBackend struct {
+	debug bool
+
+	// maxPages stops extraction after this many pages; 0 reads every page
+	maxPages int
+}
+
+// NewPDFCPUBackend returns the default pdfcpu-based PDFBackend
+func NewPDFCPUBackend() PDFBackend {
+	return &pdfcpuBackend{}
+}
+
+// SetBackend replaces the PDF backend used for text and image extraction.
+// Passing nil restores the default pdfcpu backend.
+func (p *Parser) SetBackend(backend PDFBackend) {
+	p.backend = backend
+}
+
+// pdfBackend returns the configured backend, or the pdfcpu backend by default
+func (p *Parser) pdfBackend() PDFBackend {
+	if p.backend != nil {
+		return p.backend
+	}
+	return &pdfcpuBackend{debug: p.debug, maxPages: p.maxPages}
+}
+
+// pdfDocument is the text and, when requested, the embedded images of a PDF
+type pdfDocument struct {
+	pages []PageText
+
+	// images and imagesErr are the result of image extraction; both are nil if
+	// images were not requested
+	images    []image.Image
+	imagesErr error
+
+	// truncatedPages is the document's page count when pages beyond the parser's
+	// page cap were skipped, 0 otherwise
+	truncatedPages int
+
+	// signature is the document's digital signature; only the pdfcpu backend looks for one
+	signature pdfSignature
+}
+
+// singlePassBackend is implemented by backends that can extract text and images
+// from a single read of the PDF
+type singlePassBackend interface {
+	extractDocument(data []byte, withImages bool) (*pdfDocument, error)
+}
+
+// pageTextBackend is implemented by backends that can extract the text one page at a
+// time, so that a caller needing only the first pages stops early
+type pageTextBackend interface {
+	extractTextPages(data []byte, yield func(PageText) bool) error
+}
+
+// readDocument extracts the page text and, if withImages is set, the embedded images.
+// Backends that support it read the PDF only once for both.
+func (p *Parser) readDocument(data []byte, withImages bool) (*pdfDocument, error) {
+	backend := p.pdfBackend()
+	if sp, ok := backend.(singlePassBackend); ok {
+		return sp.extractDocument(data, withImages)
+	}
+
+	pages, err := backend.ExtractText(data)
+	if err != nil {
+		return nil, err
+	}
+	// Other backends read every page; only the parsing is capped
+	doc := &pdfDocument{}
+	doc.pages, doc.truncatedPages = limitPages(pages, p.maxPages)
+	if withImages {
+		doc.images, doc.imagesErr = backend.ExtractImages(data)
+	}
+	return doc, nil
+}
+
+// readValidated and readRelaxed are the pdfcpu readers used by readContext; tests
+// replace them to simulate documents pdfcpu can only read relaxed
+var (
+	readValidated = api.ReadValidateAndOptimize
+	readRelaxed   = readUnvalidated
+)
+
+// readUnvalidated reads the PDF without validating or optimizing it
+func readUnvalidated(rs io.ReadSeeker, conf *model.Configuration) (*model.Context, error) {
+	ctx, err := api.ReadContext(rs, conf)
+	if err != nil {
+		return nil, err
+	}
+	// The page count is otherwise only set by validation
+	if err := ctx.EnsurePageCount(); err != nil {
+		return nil, err
+	}
+	return ctx, nil
+}
+
+// readContext reads, validates and optimizes the PDF. Some valid documents, PDF/A
+// exports in particular, fail pdfcpu's validation or optimization although their text
+// is extractable; those are read again without either.
+func (b *pdfcpuBackend) readContext(data []byte) (*model.Context, error) {
+	ctx, err := readValidated(bytes.NewReader(data), model.NewDefaultConfiguration())
+	if err == nil {
+		return ctx, nil
+	}
+
+	ctx, relaxedErr := readRelaxed(bytes.NewReader(data), model.NewDefaultConfiguration())
+	if relaxedErr != nil || ctx == nil {
+		return nil, fmt.Errorf("failed to read and validate PDF: %w", err)
+	}
+	if b.debug {
+		fmt.Printf("PDF read without validation after: %v\n", err)
+	}
+	return ctx, nil
+}
+
+// extractDocument reads the PDF once and extracts text and, optionally, images from
+// the same context, so both always see the same document
+func (b *pdfcpuBackend) extractDocument(data []byte, withImages bool) (*pdfDocument, error) {
+	ctx, err := b.readContext(data)
+	if err != nil {
+		return nil, err
+	}
+
+	doc := &pdfDocument{pages: b.textFromContext(ctx)}
+	if catalog, err := ctx.Catalog(); err == nil && catalog != nil {
+		doc.signature = findSignature(ctx.XRefTable, catalog)
+	}
+	if b.pageLimit(ctx) < ctx.PageCount {
+		doc.truncatedPages = ctx.PageCount
+	}
+	if withImages {
+		doc.images, doc.imagesErr = b.imagesFromContext(ctx)
+	}
+	return doc, nil
+}
+
+// ExtractText reads and validates the PDF and extracts the text of every page
+func (b *pdfcpuBackend) ExtractText(data []byte) ([]PageText, error) {
+	ctx, err := b.readContext(data)
+	if err != nil {
+		return nil, err
+	}
+	return b.textFromContext(ctx), nil
+}
+
+// textFromContext extracts the text of every page using pdfcpu's ExtractPageContent,
+// with the Form XObjects the page draws inlined into its content.
+// Form field values and annotation text are appended to the page they appear on; values
+// of fields without a widget go to the first page.
+func (b *pdfcpuBackend) textFromContext(ctx *model.Context) []PageText {
+	form := newFormText(ctx.XRefTable)
+
+	var pages []PageText
+	b.eachPageText(ctx, form, func(page PageText) bool {
+		pages = append(pages, page)
+		return true
+	})
+
+	if catalog, err := ctx.Catalog(); err == nil && catalog != nil && len(pages) > 0 {
+		pages[0].Text = appendPageText(pages[0].Text, form.fieldsText(catalog))
+	}
+
+	return pages
+}
+
+// extractTextPages extracts the text of the pages one at a time, calling yield with each
+// in page order until it returns false. Values of form fields without a widget are only
+// known once every page has been read, so they are left out.
+func (b *pdfcpuBackend) extractTextPages(data []byte, yield func(PageText) bool) error {
+	ctx, err := b.readContext(data)
+	if err != nil {
+		return err
+	}
+	b.eachPageText(ctx, newFormText(ctx.XRefTable), yield)
+	return nil
+}
+
+// eachPageText calls yield with the text of each page that has any, in page order, until
+// it returns false. Widget values and annotation text are appended to their page.
+func (b *pdfcpuBackend) eachPageText(ctx *model.Context, form *formText, yield func(PageText) bool) {
+	for pageNr := 1; pageNr <= b.pageLimit(ctx); pageNr++ {
+		pageDict, _, inherited, err := ctx.PageDict(pageNr, false)
+		if err != nil {
+			pageDict = nil
+		}
+
+		var page PageText
+		contentBytes, hasContent := pageContent(ctx, pageNr)
+		if hasContent {
+			// CID-keyed fonts carry a ToUnicode CMap; their bytes are neither Windows-1254 nor UTF-16
+			resources := pageResources(ctx, pageNr)
+			// Text drawn inside Form XObjects is part of the page
+			content, fonts := expandFormXObjects(ctx.XRefTable, string(contentBytes), resources, resourceFontCMaps(ctx.XRefTable, resources))
+			page = pageFromContent(content, fonts, pageRotation(pageDict, inherited))
+		}
+
+		annotations := ""
+		if pageDict != nil {
+			annotations = form.pageText(pageDict)
+		}
+		if !hasContent && annotations == "" {
+			continue
+		}
+
+		page.Number = pageNr
+		page.Text = appendPageText(page.Text, annotations)
+		if !yield(page) {
+			return
+		}
+	}
+}
+
+// pageLimit returns the number of pages to read: the page count, capped at maxPages
+func (b *pdfcpuBackend) pageLimit(ctx *model.Context) int {
+	if b.maxPages > 0 && ctx.PageCount > b.maxPages {
+		return b.maxPages
+	}
+	return ctx.PageCount
+}
+
+// limitPages drops the pages numbered above maxPages. If any were dropped it also returns
+// the highest page number seen, as the document's page count.
+func limitPages(pages []PageText, maxPages int) ([]PageText, int) {
+	if maxPages <= 0 {
+		return pages, 0
+	}
+	var kept []PageText
+	last := 0
+	for _, page := range pages {
+		last = max(last, page.Number)
+		if page.Number <= maxPages {
+			kept = append(kept, page)
+		}
+	}
+	if len(kept) == len(pages) {
+		return pages, 0
+	}
+	return kept, last
+}
+
+// pageRotation returns the page's /Rotate in degrees, which may be inherited from the
+// page tree
+func pageRotation(pageDict types.Dict, inherited *model.InheritedPageAttrs) int {
+	if rotate, ok := pageDict["Rotate"].(types.Integer); ok {
+		return int(rotate)
+	}
+	if inherited != nil {
+		return inherited.Rotate
+	}
+	return 0
+}
+
+// pageContent returns the decoded content stream of a page
+func pageContent(ctx *model.Context, pageNr int) ([]byte, bool) {
+	contentReader, err := pdfcpu.ExtractPageContent(ctx, pageNr)
+	if err != nil || contentReader == nil {
+		return nil, false
+	}
+	contentBytes, err := io.ReadAll(contentReader)
+	if err != nil {
+		return nil, false
+	}
+	return contentBytes, true
+}
+
+// PageCount returns the number of pages in the PDF
+func (b *pdfcpuBackend) PageCount(data []byte) (int, error) {
+	n, err := api.PageCount(bytes.NewReader(data), model.NewDefaultConfiguration())
+	if err != nil {
+		return 0, fmt.Errorf("failed to read PDF: %w", err)
+	}
+	return n, nil
+}
+
+// ExtractImages extracts all images embedded in a PDF using pdfcpu's native extraction,
+// in page order and then object order
+func (b *pdfcpuBackend) ExtractImages(pdfData []byte) ([]image.Image, error) {
+	ctx, err := b.readContext(pdfData)
+	if err != nil {
+		return nil, fmt.Errorf("failed to extract images: %w", err)
+	}
+	return b.imagesFromContext(ctx)
+}
+
+// imagesFromContext extracts the images of every page of an already-read PDF
+func (b *pdfcpuBackend) imagesFromContext(ctx *model.Context) (images []image.Image, err error) {
+	// Recover from any panics in pdfcpu
+	defer func() {
+		if r := recover(); r != nil {
+			err = fmt.Errorf("panic while extracting PDF images: %v", r)
+			images = nil
+		}
+	}()
+
+	// Process images from all pages, in page order and then object order so that
+	// the first embedded image is always tried first
+	for pageNr := 1; pageNr <= b.pageLimit(ctx); pageNr++ {
+		imgMap, err := pdfcpu.ExtractPageImages(ctx, pageNr, false)
+		if err != nil {
+			return nil, fmt.Errorf("failed to extract images: %w", err)
+		}
+		if b.debug {
+			fmt.Printf("Page %d: found %d images\n", pageNr, len(imgMap))
+		}
+
+		objNrs := make([]int, 0, len(imgMap))
+		for objNr := range imgMap {
+			objNrs = append(objNrs, objNr)
+		}
+		sort.Ints(objNrs)
+
+		for _, objNr := range objNrs {
+			pdfImage := imgMap[objNr]
+			if b.debug {
+				fmt.Printf("Image obj %d: type=%s, %dx%d, bpc=%d, comp=%d\n",
+					objNr, pdfImage.FileType, pdfImage.Width, pdfImage.Height, pdfImage.Bpc, pdfImage.Comp)
+			}
+			// Decode the image from the pdfcpu Image reader
+			img, err := b.decodePDFCPUImage(pdfImage, imageColorSpace(ctx.XRefTable, objNr))
+			if err != nil {
+				if b.debug {
+					fmt.Printf("Failed to decode image obj %d: %v\n", objNr, err)
+				}
+				continue
+			}
+			images = append(images, img)
+		}
+	}
+
+	if len(images) == 0 {
+		return nil, fmt.Errorf("no images found in PDF")
+	}
+
+	return images, nil
+}
+
+// decodePDFCPUImage decodes a pdfcpu model.Image to a Go image.Image. cs is the image's
+// color space if it could be read from the PDF, used for raw samples.
+func (b *pdfcpuBackend) decodePDFCPUImage(pdfImage model.Image, cs *rawColorSpace) (image.Image, error) {
+	// Read all data from the image reader
+	data, err := io.ReadAll(pdfImage)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read image data: %w", err)
+	}
+
+	// Create a reader from the data
+	reader := bytes.NewReader(data)
+
+	// Try to decode based on the FileType
+	fileType := strings.ToLower(pdfImage.FileType)
+
+	switch fileType {
+	case "png":
+		return png.Decode(reader)
+	case "jpg", "jpeg":
+		return decodeJPEG(reader)
+	case "gif":
+		return decodeGIF(reader)
+	default:
+		// Try standard image.Decode which handles registered formats
+		img, _, err := image.Decode(reader)
+		if err != nil {
+			// If standard decode fails, try to decode as raw image data
+			return b.decodeRawImageData(data, pdfImage, cs)
+		}
+		return img, nil
+	}
+}
+
+// cmykToRGB converts a CMYK color to RGB with the standard formula
+// R = 255 * (1-C/255) * (1-K/255), and likewise for G and B, rounding to nearest
+func cmykToRGB(c, m, y, k uint8) color.RGBA {
+	channel := func(ink uint8) uint8 {
+		return uint8(((255-int(ink))*(255-int(k)) + 127) / 255)
+	}
+	return color.RGBA{R: channel(c), G: channel(m), B: channel(y), A: 255}
+}
+
+// isInvertedCMYK reports whether CMYK samples look inverted, as written by Adobe
+// applications (0 meaning full ink, the PDF /Decode [1 0 1 0 1 0 1 0] case). pdfcpu does
+// not expose the decode array, so the samples decide: plates and barcodes are mostly white
+// paper, which in inverted data means mostly high ink values.
+func isInvertedCMYK(samples []byte) bool {
+	if len(samples) < 4 {
+		return false
+	}
+	var total int
+	for _, v := range samples {
+		total += int(v)
+	}
+	return total/len(samples) > 127
+}
+
+// decodeJPEG decodes JPEG image data
+func decodeJPEG(r io.Reader) (image.Image, error) {
+	// image/jpeg is already registered via _ "image/jpeg" import
+	img, _, err := image.Decode(r)
+	return img, err
+}
+
+// decodeGIF decodes GIF image data
+func decodeGIF(r io.Reader) (image.Image, error) {
+	// image/gif is already registered via _ "image/gif" import
+	img, _, err := image.Decode(r)
+	return img, err
+}
+
+// decodeRawImageData attempts to decode raw image data based on PDF image properties.
+// Indexed, Separation and DeviceN images need cs; without it the component count
+// reported by pdfcpu decides between gray, RGB and CMYK.
+func (b *pdfcpuBackend) decodeRawImageData(data []byte, pdfImage model.Image, cs *rawColorSpace) (image.Image, error) {
+	width := pdfImage.Width
+	height := pdfImage.Height
+	bpc := pdfImage.Bpc   // bits per component
+	comp := pdfImage.Comp // number of color components
+
+	if width <= 0 || height <= 0 {
+		return nil, fmt.Errorf("invalid image dimensions: %dx%d", width, height)
+	}
+
+	if cs != nil {
+		switch {
+		case cs.palette != nil:
+			return decodeIndexedImage(data, width, height, bpc, cs.palette)
+		case cs.ink:
+			return decodeInkImage(data, width, height, bpc, cs.components)
+		}
+		// ICCBased and calibrated spaces decode like the device space of the same size
+		comp = cs.components
+	}
+
+	// Calculate expected data size
+	expectedSize := width * height * comp * bpc / 8
+	if len(data) < expectedSize {
+		// Try image.Decode as fallback
+		img, _, err := image.Decode(bytes.NewReader(data))
+		if err == nil {
+			return img, nil
+		}
+		return nil, fmt.Errorf("data size mismatch: got %d, expected %d", len(data), expectedSize)
+	}
+
+	// Create image based on color space
+	switch {
+	case pdfImage.Cs == "DeviceGray" || comp == 1:
+		// Grayscale image
+		img := image.NewGray(image.Rect(0, 0, width, height))
+		if bpc == 8 {
+			copy(img.Pix, data[:width*height])
+		} else if bpc == 1 {
+			// 1-bit image (black and white)
+			for y := 0; y < height; y++ {
+				for x := 0; x < width; x++ {
+					byteIdx := (y*width + x) / 8
+					bitIdx := 7 - ((y*width + x) % 8)
+					if byteIdx < len(data) {
+						bit := (data[byteIdx] >> bitIdx) & 1
+						if bit == 0 {
+							img.SetGray(x, y, color.Gray{0}) // black
+						} else {
+							img.SetGray(x, y, color.Gray{255}) // white
+						}
+					}
+				}
+			}
+		}
+		return img, nil
+
+	case pdfImage.Cs == "DeviceRGB" || comp == 3:
+		// RGB image
+		img := image.NewRGBA(image.Rect(0, 0, width, height))
+		for y := 0; y < height; y++ {
+			for x := 0; x < width; x++ {
+				idx := (y*width + x) * 3
+				if idx+2 < len(data) {
+					img.SetRGBA(x, y, color.RGBA{data[idx], data[idx+1], data[idx+2], 255})
+				}
+			}
+		}
+		return img, nil
+
+	case pdfImage.Cs == "DeviceCMYK" || comp == 4:
+		// CMYK image - convert to RGBA; only 8 bits per component are supported
+		if len(data) < width*height*4 {
+			return nil, fmt.Errorf("unsupported CMYK image with %d bits per component", bpc)
+		}
+		samples := data[:width*height*4]
+		inverted := isInvertedCMYK(samples)
+		img := image.NewRGBA(image.Rect(0, 0, width, height))
+		for y := 0; y < height; y++ {
+			for x := 0; x < width; x++ {
+				idx := (y*width + x) * 4
+				c, m, yk, k := samples[idx], samples[idx+1], samples[idx+2], samples[idx+3]
+				if inverted {
+					c, m, yk, k = 255-c, 255-m, 255-yk, 255-k
+				}
+				img.SetRGBA(x, y, cmykToRGB(c, m, yk, k))
+			}
+		}
+		return img, nil
+
+	default:
+		// Try standard decode as last resort
+		img, _, err := image.Decode(bytes.NewReader(data))
+		if err != nil {
+			return nil, fmt.Errorf("unsupported color space: %s with %d components", pdfImage.Cs, comp)
+		}
+		return img, nil
+	}
+}
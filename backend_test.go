@@ -0,0 +1,379 @@
+package vergilevhasi
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"image"
+	"image/color"
+	"io"
+	"reflect"
+	"slices"
+	"strings"
+	"testing"
+
+	"github.com/pdfcpu/pdfcpu/pkg/pdfcpu/model"
+	"github.com/pdfcpu/pdfcpu/pkg/pdfcpu/types"
+)
+
+// fakeBackend serves canned pages and images instead of reading a PDF
+type fakeBackend struct {
+	pages  []PageText
+	images []image.Image
+	err    error
+	calls  int
+
+	imageCalls int
+}
+
+func (f *fakeBackend) ExtractText(data []byte) ([]PageText, error) {
+	f.calls++
+	return f.pages, f.err
+}
+
+func (f *fakeBackend) ExtractImages(data []byte) ([]image.Image, error) {
+	f.imageCalls++
+	if len(f.images) == 0 {
+		return nil, errors.New("no images")
+	}
+	return f.images, nil
+}
+
+func (f *fakeBackend) PageCount(data []byte) (int, error) {
+	return len(f.pages), f.err
+}
+
+func TestParseWithFakeBackend(t *testing.T) {
+	backend := &fakeBackend{
+		pages:  []PageText{{Number: 1, Text: "Adı Soyadı: Ali Örnek\nVergi Dairesi: Örnek VD\nİşe Başlama Tarihi: 01.01.2020\n"}},
+		images: []image.Image{drawCode128(t, "1234567890")},
+	}
+
+	parser := NewParser()
+	parser.SetBackend(backend)
+
+	vl, err := parser.Parse(bytes.NewReader([]byte("not a real pdf")))
+	if err != nil {
+		t.Fatalf("Parse() error = %v", err)
+	}
+	if backend.calls != 1 || backend.imageCalls != 1 {
+		t.Errorf("ExtractText/ExtractImages called %d/%d times, want 1/1", backend.calls, backend.imageCalls)
+	}
+	if vl.AdiSoyadi != "Ali Örnek" || vl.VergiDairesi != "Örnek VD" {
+		t.Errorf("Parse() = %+v, want fields from the fake backend text", vl)
+	}
+	if vl.VergiKimlikNo != "1234567890" {
+		t.Errorf("VergiKimlikNo = %q, want the VKN from the fake backend barcode", vl.VergiKimlikNo)
+	}
+}
+
+// singlePassFakeBackend serves pages and images from one extractDocument call
+type singlePassFakeBackend struct {
+	fakeBackend
+	documentCalls int
+}
+
+func (f *singlePassFakeBackend) extractDocument(data []byte, withImages bool) (*pdfDocument, error) {
+	f.documentCalls++
+	doc := &pdfDocument{pages: f.pages}
+	if withImages {
+		doc.images = f.images
+	}
+	return doc, f.err
+}
+
+func TestParseSinglePass(t *testing.T) {
+	backend := &singlePassFakeBackend{fakeBackend: fakeBackend{
+		pages:  []PageText{{Number: 1, Text: "Adı Soyadı: Ali Örnek\nVergi Dairesi: Örnek VD\n"}},
+		images: []image.Image{drawCode128(t, "1234567890")},
+	}}
+
+	parser := NewParser()
+	parser.SetBackend(backend)
+
+	vl, err := parser.Parse(bytes.NewReader([]byte("not a real pdf")))
+	if err != nil {
+		t.Fatalf("Parse() error = %v", err)
+	}
+	if backend.documentCalls != 1 || backend.calls != 0 || backend.imageCalls != 0 {
+		t.Errorf("document/text/image calls = %d/%d/%d, want a single document read",
+			backend.documentCalls, backend.calls, backend.imageCalls)
+	}
+	if vl.AdiSoyadi != "Ali Örnek" || vl.VergiDairesi != "Örnek VD" {
+		t.Errorf("Parse() = %+v, want the text fields", vl)
+	}
+	if vl.VergiKimlikNo != "1234567890" {
+		t.Errorf("VergiKimlikNo = %q, want the VKN from the barcode", vl.VergiKimlikNo)
+	}
+}
+
+func TestParseSkipsImagesWithoutVKN(t *testing.T) {
+	backend := &fakeBackend{
+		pages:  []PageText{{Number: 1, Text: "Adı Soyadı: Ali Örnek\n"}},
+		images: []image.Image{drawCode128(t, "1234567890")},
+	}
+
+	parser := NewParser()
+	parser.SetBackend(backend)
+	parser.SetFields(FieldAdiSoyadi)
+
+	if _, err := parser.Parse(bytes.NewReader(nil)); err != nil {
+		t.Fatalf("Parse() error = %v", err)
+	}
+	if backend.imageCalls != 0 {
+		t.Errorf("ExtractImages called %d times, want 0 when the VKN is not requested", backend.imageCalls)
+	}
+}
+
+func TestParseImageOnly(t *testing.T) {
+	backend := &fakeBackend{
+		pages:  []PageText{{Number: 1, Text: " \n"}, {Number: 2, Text: ""}},
+		images: []image.Image{drawCode128(t, "1234567890")},
+	}
+	parser := NewParser()
+	parser.SetBackend(backend)
+
+	vl, err := parser.Parse(bytes.NewReader([]byte("not a real pdf")))
+	if err != nil {
+		t.Fatalf("Parse() error = %v", err)
+	}
+	if !vl.IsImageOnly {
+		t.Error("IsImageOnly = false, want true for a PDF without a text layer")
+	}
+	if len(vl.Warnings) != 1 || !strings.Contains(vl.Warnings[0], "no text layer") {
+		t.Errorf("Warnings = %q, want a no text layer warning", vl.Warnings)
+	}
+	if vl.VergiKimlikNo != "1234567890" {
+		t.Errorf("VergiKimlikNo = %q, want the VKN from the barcode", vl.VergiKimlikNo)
+	}
+	if vl.RequiresOCR != AllFields&^FieldVergiKimlikNo {
+		t.Errorf("RequiresOCR = %b, want every field but the VKN", vl.RequiresOCR)
+	}
+	// In JSON the fields are listed by name
+	data, err := json.Marshal(vl)
+	if err != nil {
+		t.Fatalf("json.Marshal() error = %v", err)
+	}
+	var diagnostics struct {
+		RequiresOCR []string `json:"requires_ocr"`
+	}
+	if err := json.Unmarshal(data, &diagnostics); err != nil {
+		t.Fatalf("json.Unmarshal() error = %v", err)
+	}
+	if !slices.Contains(diagnostics.RequiresOCR, "adi_soyadi") || slices.Contains(diagnostics.RequiresOCR, "vergi_kimlik_no") {
+		t.Errorf("requires_ocr = %q, want the names of every field but the VKN", diagnostics.RequiresOCR)
+	}
+
+	// A PDF with a text layer is not flagged
+	backend.pages = []PageText{{Number: 1, Text: "Adı Soyadı: Ali Örnek\nTC Kimlik No: 10000000146\n"}}
+	vl, err = parser.Parse(bytes.NewReader([]byte("not a real pdf")))
+	if err != nil {
+		t.Fatalf("Parse() error = %v", err)
+	}
+	if vl.IsImageOnly || vl.RequiresOCR != 0 || len(vl.Warnings) != 0 {
+		t.Errorf("IsImageOnly = %v, RequiresOCR = %b, Warnings = %q; want no diagnostics", vl.IsImageOnly, vl.RequiresOCR, vl.Warnings)
+	}
+}
+
+func TestParseBackendError(t *testing.T) {
+	parser := NewParser()
+	parser.SetBackend(&fakeBackend{err: errors.New("backend failure")})
+
+	if _, err := parser.Parse(bytes.NewReader(nil)); err == nil {
+		t.Error("Parse() error = nil, want the backend error")
+	}
+}
+
+func TestPeekTextWithFakeBackend(t *testing.T) {
+	parser := NewParser()
+	parser.SetBackend(&fakeBackend{pages: []PageText{{Number: 1, Text: "VERGİ LEVHASI"}, {Number: 2, Text: "İKİNCİ SAYFA"}}})
+
+	text, err := parser.PeekText(bytes.NewReader(nil), 5)
+	if err != nil {
+		t.Fatalf("PeekText() error = %v", err)
+	}
+	if text != "VERGİ" {
+		t.Errorf("PeekText() = %q, want %q", text, "VERGİ")
+	}
+}
+
+func TestParseMaxPages(t *testing.T) {
+	// Five pages; the VKN is only printed on the third
+	var pages []PageText
+	for i := 1; i <= 5; i++ {
+		text := fmt.Sprintf("Sayfa %d\n", i)
+		if i == 1 {
+			text = "Adı Soyadı: Ali Örnek\nTC Kimlik No: 10000000146\nVergi Dairesi: Örnek VD\n"
+		}
+		if i == 3 {
+			text = "Vergi Kimlik No: 1234567890\n"
+		}
+		pages = append(pages, PageText{Number: i, Text: text})
+	}
+
+	parser := NewParser()
+	parser.SetBackend(&fakeBackend{pages: pages})
+	parser.SetMaxPages(2)
+
+	vl, err := parser.Parse(bytes.NewReader([]byte("not a real pdf")))
+	if err != nil {
+		t.Fatalf("Parse() error = %v", err)
+	}
+	if vl.AdiSoyadi != "Ali Örnek" {
+		t.Errorf("AdiSoyadi = %q, want the name from the first page", vl.AdiSoyadi)
+	}
+	if strings.Contains(vl.RawText, "1234567890") || strings.Contains(vl.RawText, "Sayfa 5") {
+		t.Errorf("RawText contains pages beyond the cap:\n%s", vl.RawText)
+	}
+	want := []string{"processing truncated to the first 2 of 5 pages"}
+	if !reflect.DeepEqual(vl.Warnings, want) {
+		t.Errorf("Warnings = %q, want %q", vl.Warnings, want)
+	}
+
+	// Within the cap, or with the cap disabled, nothing is dropped or reported
+	for _, maxPages := range []int{5, 0} {
+		parser.SetMaxPages(maxPages)
+		vl, err := parser.Parse(bytes.NewReader([]byte("not a real pdf")))
+		if err != nil {
+			t.Fatalf("Parse() error = %v", err)
+		}
+		if len(vl.Warnings) != 0 || !strings.Contains(vl.RawText, "Sayfa 5") {
+			t.Errorf("SetMaxPages(%d): Warnings = %q, RawText:\n%s", maxPages, vl.Warnings, vl.RawText)
+		}
+	}
+}
+
+func TestLimitPages(t *testing.T) {
+	pages := []PageText{{Number: 1}, {Number: 2}, {Number: 4}}
+
+	tests := []struct {
+		maxPages  int
+		wantKept  int
+		wantTotal int
+	}{
+		{maxPages: 0, wantKept: 3, wantTotal: 0},
+		{maxPages: 4, wantKept: 3, wantTotal: 0},
+		{maxPages: 3, wantKept: 2, wantTotal: 4},
+		{maxPages: 1, wantKept: 1, wantTotal: 4},
+	}
+	for _, tt := range tests {
+		kept, total := limitPages(pages, tt.maxPages)
+		if len(kept) != tt.wantKept || total != tt.wantTotal {
+			t.Errorf("limitPages(%d) kept %d pages, total %d; want %d, %d", tt.maxPages, len(kept), total, tt.wantKept, tt.wantTotal)
+		}
+	}
+}
+
+func TestCMYKToRGB(t *testing.T) {
+	tests := []struct {
+		c, m, y, k uint8
+		want       color.RGBA
+	}{
+		{0, 0, 0, 0, color.RGBA{255, 255, 255, 255}},
+		{0, 0, 0, 255, color.RGBA{0, 0, 0, 255}},
+		{255, 255, 255, 0, color.RGBA{0, 0, 0, 255}},
+		{255, 0, 0, 0, color.RGBA{0, 255, 255, 255}},
+		{0, 0, 0, 128, color.RGBA{127, 127, 127, 255}},
+		// Mid ink and mid black multiply rather than add: the old additive formula gave 0 here
+		{128, 0, 64, 128, color.RGBA{63, 127, 95, 255}},
+	}
+	for _, tt := range tests {
+		if got := cmykToRGB(tt.c, tt.m, tt.y, tt.k); got != tt.want {
+			t.Errorf("cmykToRGB(%d, %d, %d, %d) = %v, want %v", tt.c, tt.m, tt.y, tt.k, got, tt.want)
+		}
+	}
+}
+
+func TestDecodeRawImageDataCMYK(t *testing.T) {
+	// A 2x1 image: white paper and a black bar, in normal and Adobe-inverted CMYK
+	tests := []struct {
+		name string
+		data []byte
+	}{
+		{"Normal", []byte{0, 0, 0, 0, 0, 0, 0, 255}},
+		{"Inverted", []byte{255, 255, 255, 255, 255, 255, 255, 0}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			b := &pdfcpuBackend{}
+			img, err := b.decodeRawImageData(tt.data, model.Image{Width: 2, Height: 1, Bpc: 8, Comp: 4, Cs: "DeviceCMYK"}, nil)
+			if err != nil {
+				t.Fatalf("decodeRawImageData() error = %v", err)
+			}
+			white, black := color.RGBA{255, 255, 255, 255}, color.RGBA{0, 0, 0, 255}
+			if got := color.RGBAModel.Convert(img.At(0, 0)); got != white {
+				t.Errorf("pixel 0 = %v, want white", got)
+			}
+			if got := color.RGBAModel.Convert(img.At(1, 0)); got != black {
+				t.Errorf("pixel 1 = %v, want black", got)
+			}
+		})
+	}
+}
+
+func TestPageRotation(t *testing.T) {
+	tests := []struct {
+		name      string
+		pageDict  types.Dict
+		inherited *model.InheritedPageAttrs
+		want      int
+	}{
+		{"Page", types.Dict{"Rotate": types.Integer(90)}, &model.InheritedPageAttrs{Rotate: 270}, 90},
+		{"Inherited", types.Dict{}, &model.InheritedPageAttrs{Rotate: 270}, 270},
+		{"None", nil, nil, 0},
+	}
+	for _, tt := range tests {
+		if got := pageRotation(tt.pageDict, tt.inherited); got != tt.want {
+			t.Errorf("%s: pageRotation() = %d, want %d", tt.name, got, tt.want)
+		}
+	}
+}
+
+// stubReaders replaces the pdfcpu readers for the duration of the test
+func stubReaders(t *testing.T, validated, relaxed func(io.ReadSeeker, *model.Configuration) (*model.Context, error)) {
+	t.Helper()
+	origValidated, origRelaxed := readValidated, readRelaxed
+	readValidated, readRelaxed = validated, relaxed
+	t.Cleanup(func() { readValidated, readRelaxed = origValidated, origRelaxed })
+}
+
+func TestReadContextRelaxedFallback(t *testing.T) {
+	errOptimize := errors.New("optimize: invalid PDF/A output intent")
+	relaxedCtx := &model.Context{Configuration: model.NewDefaultConfiguration(), XRefTable: &model.XRefTable{}}
+
+	t.Run("ValidatedFirst", func(t *testing.T) {
+		validatedCtx := &model.Context{XRefTable: &model.XRefTable{}}
+		stubReaders(t,
+			func(io.ReadSeeker, *model.Configuration) (*model.Context, error) { return validatedCtx, nil },
+			func(io.ReadSeeker, *model.Configuration) (*model.Context, error) {
+				t.Fatal("relaxed read used although validation succeeded")
+				return nil, nil
+			})
+		ctx, err := (&pdfcpuBackend{}).readContext(nil)
+		if err != nil || ctx != validatedCtx {
+			t.Errorf("readContext() = %p, %v; want the validated context", ctx, err)
+		}
+	})
+
+	t.Run("RelaxedAfterFailure", func(t *testing.T) {
+		stubReaders(t,
+			func(io.ReadSeeker, *model.Configuration) (*model.Context, error) { return nil, errOptimize },
+			func(io.ReadSeeker, *model.Configuration) (*model.Context, error) { return relaxedCtx, nil })
+		ctx, err := (&pdfcpuBackend{}).readContext(nil)
+		if err != nil || ctx != relaxedCtx {
+			t.Errorf("readContext() = %p, %v; want the relaxed context", ctx, err)
+		}
+	})
+
+	t.Run("BothFail", func(t *testing.T) {
+		stubReaders(t,
+			func(io.ReadSeeker, *model.Configuration) (*model.Context, error) { return nil, errOptimize },
+			func(io.ReadSeeker, *model.Configuration) (*model.Context, error) {
+				return nil, errors.New("malformed xref")
+			})
+		if _, err := (&pdfcpuBackend{}).readContext(nil); !errors.Is(err, errOptimize) {
+			t.Errorf("readContext() error = %v, want it to wrap the validation error", err)
+		}
+	})
+}
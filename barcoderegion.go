@@ -0,0 +1,208 @@
+package vergilevhasi
+
+import (
+	"fmt"
+	"image"
+	"image/draw"
+)
+
+// barcodeBlockSize is the side of the square blocks the barcode detector works on
+const barcodeBlockSize = 8
+
+// DefaultBarcodeQuietZone is the default width, in pixels, of the white margin added
+// around a barcode crop. Code128 needs ten modules of white on either side of the bars,
+// and plate barcodes are rendered at one to two pixels per module.
+const DefaultBarcodeQuietZone = 24
+
+// SetBarcodeQuietZone sets the width, in pixels, of the white margin added on every side
+// of a barcode crop before it is decoded. Crops taken at the edge of an image, and
+// embedded barcode images cut tight to the bars, lose the quiet zone decoders require.
+// Zero or a negative value disables the padding.
+func (p *OCRParser) SetBarcodeQuietZone(pixels int) {
+	p.quietZone = max(pixels, 0)
+}
+
+// cropImage copies region of img onto a white canvas, leaving pad pixels of white on
+// every side as the barcode quiet zone. The result starts at the origin.
+func cropImage(img image.Image, region image.Rectangle, pad int) *image.RGBA {
+	crop := image.NewRGBA(image.Rect(0, 0, region.Dx()+2*pad, region.Dy()+2*pad))
+	if pad > 0 {
+		draw.Draw(crop, crop.Bounds(), image.White, image.Point{}, draw.Src)
+	}
+	draw.Draw(crop, crop.Bounds().Inset(pad), img, region.Min, draw.Src)
+	return crop
+}
+
+// findBarcodeRegion locates a 1D barcode by its texture: inside a barcode the intensity
+// changes sharply along one axis (across the bars) and barely along the other (along
+// the bars), while text changes in both directions. Blocks with that signature are
+// grouped, and the bounding box of the largest group is returned together with a quiet
+// zone margin. Both horizontal and vertical barcodes are found.
+func findBarcodeRegion(img image.Image) (image.Rectangle, bool) {
+	gray := toGrayscale(img)
+	bounds := gray.Bounds()
+	cols, rows := bounds.Dx()/barcodeBlockSize, bounds.Dy()/barcodeBlockSize
+	if cols < 4 || rows < 2 {
+		return image.Rectangle{}, false
+	}
+
+	// Gradient energy across (gx) and along (gy) each block
+	gx := make([]int, cols*rows)
+	gy := make([]int, cols*rows)
+	for y := bounds.Min.Y; y < bounds.Min.Y+rows*barcodeBlockSize; y++ {
+		for x := bounds.Min.X; x < bounds.Min.X+cols*barcodeBlockSize; x++ {
+			v := int(gray.GrayAt(x, y).Y)
+			i := ((y-bounds.Min.Y)/barcodeBlockSize)*cols + (x-bounds.Min.X)/barcodeBlockSize
+			if x+1 < bounds.Max.X {
+				gx[i] += abs(int(gray.GrayAt(x+1, y).Y) - v)
+			}
+			if y+1 < bounds.Max.Y {
+				gy[i] += abs(int(gray.GrayAt(x, y+1).Y) - v)
+			}
+		}
+	}
+
+	best, bestArea := image.Rectangle{}, 0
+	for _, vertical := range []bool{false, true} {
+		across, along := gx, gy
+		if vertical {
+			across, along = gy, gx
+		}
+
+		mask := make([]bool, cols*rows)
+		for i := range mask {
+			// At least a few strong edges, and far more across the bars than along them
+			mask[i] = across[i] >= barcodeBlockSize*255 && across[i] > 4*along[i]
+		}
+		bridgeBarcodeGaps(mask, cols, rows, vertical)
+
+		if rect, area := largestBlockGroup(mask, cols, rows); area > bestArea {
+			best, bestArea = rect, area
+		}
+	}
+
+	// A handful of blocks is noise, not a barcode
+	if bestArea < 8 {
+		return image.Rectangle{}, false
+	}
+
+	region := image.Rect(
+		bounds.Min.X+best.Min.X*barcodeBlockSize, bounds.Min.Y+best.Min.Y*barcodeBlockSize,
+		bounds.Min.X+best.Max.X*barcodeBlockSize, bounds.Min.Y+best.Max.Y*barcodeBlockSize,
+	)
+
+	// Decoders need a quiet zone around the symbol
+	margin := max(region.Dx(), region.Dy())/10 + 2*barcodeBlockSize
+	return region.Inset(-margin).Intersect(bounds), true
+}
+
+// bridgeBarcodeGaps marks blocks lying inside a wide bar or space, which have no edges of
+// their own, when barcode blocks are found on both sides of them across the bars
+func bridgeBarcodeGaps(mask []bool, cols, rows int, vertical bool) {
+	const reach = 2
+	marked := append([]bool(nil), mask...)
+	for r := 0; r < rows; r++ {
+		for c := 0; c < cols; c++ {
+			if marked[r*cols+c] {
+				continue
+			}
+			before, after := false, false
+			for d := 1; d <= reach; d++ {
+				if vertical {
+					before = before || (r-d >= 0 && marked[(r-d)*cols+c])
+					after = after || (r+d < rows && marked[(r+d)*cols+c])
+				} else {
+					before = before || (c-d >= 0 && marked[r*cols+c-d])
+					after = after || (c+d < cols && marked[r*cols+c+d])
+				}
+			}
+			mask[r*cols+c] = before && after
+		}
+	}
+}
+
+// largestBlockGroup returns the bounding box, in blocks, and the size of the largest
+// 4-connected group of marked blocks
+func largestBlockGroup(mask []bool, cols, rows int) (image.Rectangle, int) {
+	visited := make([]bool, len(mask))
+	best, bestArea := image.Rectangle{}, 0
+
+	for start := range mask {
+		if !mask[start] || visited[start] {
+			continue
+		}
+
+		rect := image.Rect(start%cols, start/cols, start%cols+1, start/cols+1)
+		area := 0
+		stack := []int{start}
+		visited[start] = true
+		for len(stack) > 0 {
+			i := stack[len(stack)-1]
+			stack = stack[:len(stack)-1]
+			area++
+
+			c, r := i%cols, i/cols
+			rect = rect.Union(image.Rect(c, r, c+1, r+1))
+
+			for _, n := range [][2]int{{c - 1, r}, {c + 1, r}, {c, r - 1}, {c, r + 1}} {
+				if n[0] < 0 || n[0] >= cols || n[1] < 0 || n[1] >= rows {
+					continue
+				}
+				j := n[1]*cols + n[0]
+				if mask[j] && !visited[j] {
+					visited[j] = true
+					stack = append(stack, j)
+				}
+			}
+		}
+
+		if area > bestArea {
+			best, bestArea = rect, area
+		}
+	}
+
+	return best, bestArea
+}
+
+// scanBarcodeRegion detects the barcode region analytically and decodes only that crop.
+// Decoders sample a limited number of rows, so a small barcode on a large page is easily
+// missed when the whole page is scanned.
+func (p *OCRParser) scanBarcodeRegion(img image.Image) (string, bool, error) {
+	region, ok := findBarcodeRegion(img)
+	if !ok {
+		return "", false, fmt.Errorf("no barcode region found")
+	}
+	if p.debug {
+		fmt.Printf("Barcode region detected at %v\n", region)
+	}
+
+	crop := cropImage(img, region, p.quietZone)
+
+	if vkn, checkDigit, err := p.scanCode128Barcode(crop); err == nil && vkn != "" {
+		return vkn, checkDigit, nil
+	}
+
+	// Small crops decode more reliably when enlarged
+	if crop.Bounds().Dx() < 500 {
+		if vkn, checkDigit, err := p.scanCode128Barcode(p.upscaleImage(crop, 2)); err == nil && vkn != "" {
+			return vkn, checkDigit, nil
+		}
+	}
+
+	// A barcode tilted a few degrees leaves no scan line crossing every bar, which none of
+	// the cardinal rotations fix
+	if vkn, checkDigit, err := p.scanSkewedBarcode(img, region); err == nil && vkn != "" {
+		return vkn, checkDigit, nil
+	}
+
+	return p.scanBarcode(crop)
+}
+
+// scanImageBarcode decodes the barcode in the detected barcode region, falling back to
+// scanning the whole image
+func (p *OCRParser) scanImageBarcode(img image.Image) (string, bool, error) {
+	if vkn, checkDigit, err := p.scanBarcodeRegion(img); err == nil && vkn != "" {
+		return vkn, checkDigit, nil
+	}
+	return p.scanBarcode(img)
+}
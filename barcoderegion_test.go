@@ -0,0 +1,143 @@
+package vergilevhasi
+
+import (
+	"image"
+	"image/color"
+	"image/draw"
+	"testing"
+
+	"github.com/makiuchi-d/gozxing"
+	"github.com/makiuchi-d/gozxing/oned"
+)
+
+// newPageWithCornerBarcode renders a large page with text in the middle and a small
+// barcode near the top right corner, away from the rows a whole-page scan samples
+func newPageWithCornerBarcode(t *testing.T) (*image.Gray, image.Rectangle) {
+	t.Helper()
+
+	page := newWhiteGray(1000, 1400)
+
+	text := renderPlateText([]string{
+		"ADI SOYADI: ALİ ÖRNEK",
+		"VERGİ DAİRESİ: ÇANKAYA",
+		"İŞE BAŞLAMA TARİHİ: 01.02.2020",
+	}, 3)
+	draw.Draw(page, text.Bounds().Add(image.Pt(60, 600)), text, image.Point{}, draw.Src)
+
+	barcode := drawCode128(t, "1234567890")
+	at := barcode.Bounds().Add(image.Pt(650, 40))
+	draw.Draw(page, at, barcode, image.Point{}, draw.Src)
+
+	return page, at
+}
+
+func TestFindBarcodeRegion(t *testing.T) {
+	page, at := newPageWithCornerBarcode(t)
+
+	region, ok := findBarcodeRegion(page)
+	if !ok {
+		t.Fatal("findBarcodeRegion() found no region")
+	}
+
+	// The bars, not necessarily the generated quiet zone, must be inside the region
+	bars := image.Rect(at.Min.X+20, at.Min.Y, at.Max.X-20, at.Max.Y)
+	if !bars.In(region) {
+		t.Errorf("region %v does not contain the barcode %v", region, bars)
+	}
+	if region.Dx() > page.Bounds().Dx()/2 || region.Dy() > page.Bounds().Dy()/4 {
+		t.Errorf("region %v is not a tight crop of the page", region)
+	}
+}
+
+func TestFindBarcodeRegionVertical(t *testing.T) {
+	page, _ := newPageWithCornerBarcode(t)
+	rotated := rotateImage(page, 90)
+
+	region, ok := findBarcodeRegion(rotated)
+	if !ok {
+		t.Fatal("findBarcodeRegion() found no region in the rotated page")
+	}
+	if region.Dy() <= region.Dx() {
+		t.Errorf("region %v, want a tall crop for vertical bars", region)
+	}
+}
+
+func TestScanImagesForVKNFindsCornerBarcode(t *testing.T) {
+	parser, err := NewOCRParser()
+	if err != nil {
+		t.Fatalf("NewOCRParser() error = %v", err)
+	}
+
+	page, _ := newPageWithCornerBarcode(t)
+
+	// Scanning the whole page misses the small barcode
+	if vkn, _, err := parser.scanCode128Barcode(page); err == nil && vkn != "" {
+		t.Fatalf("test setup: whole-page Code128 scan found %q, want a miss", vkn)
+	}
+	if vkn, _, err := parser.scanBarcode(page); err == nil && vkn != "" {
+		t.Fatalf("test setup: whole-page scan found %q, want a miss", vkn)
+	}
+
+	vkn, _, err := parser.scanImagesForVKN([]image.Image{page})
+	if err != nil {
+		t.Fatalf("scanImagesForVKN() error = %v", err)
+	}
+	if vkn != "1234567890" {
+		t.Errorf("scanImagesForVKN() = %q, want %q", vkn, "1234567890")
+	}
+}
+
+// tightBarcode draws a barcode one pixel per module and cuts it at the outer bars,
+// dropping its quiet zone
+func tightBarcode(t testing.TB, contents string) *image.Gray {
+	t.Helper()
+
+	matrix, err := oned.NewCode128Writer().Encode(contents, gozxing.BarcodeFormat_CODE_128, 1, 40, nil)
+	if err != nil {
+		t.Fatalf("failed to encode barcode: %v", err)
+	}
+	img := newWhiteGray(matrix.GetWidth(), matrix.GetHeight())
+	for y := 0; y < matrix.GetHeight(); y++ {
+		for x := 0; x < matrix.GetWidth(); x++ {
+			if matrix.Get(x, y) {
+				img.SetGray(x, y, color.Gray{0})
+			}
+		}
+	}
+
+	bounds := img.Bounds()
+	first, last := bounds.Max.X, bounds.Min.X
+	for x := bounds.Min.X; x < bounds.Max.X; x++ {
+		if img.GrayAt(x, bounds.Min.Y).Y == 0 {
+			first, last = min(first, x), max(last, x)
+		}
+	}
+	return img.SubImage(image.Rect(first, bounds.Min.Y, last+1, bounds.Max.Y)).(*image.Gray)
+}
+
+func TestScanBarcodeRegionQuietZone(t *testing.T) {
+	parser, err := NewOCRParser()
+	if err != nil {
+		t.Fatalf("NewOCRParser() error = %v", err)
+	}
+	tight := tightBarcode(t, "1234567890")
+
+	// The decoder never reads the outermost pixel columns as bars, so a one-pixel first
+	// bar at the very edge is lost
+	if vkn, _, err := parser.scanBarcodeOrientation(tight); err == nil {
+		t.Fatalf("test setup: scanBarcodeOrientation() decoded %q without a quiet zone", vkn)
+	}
+
+	padded := cropImage(tight, tight.Bounds(), DefaultBarcodeQuietZone)
+	if padded.Bounds() != image.Rect(0, 0, tight.Bounds().Dx()+2*DefaultBarcodeQuietZone, tight.Bounds().Dy()+2*DefaultBarcodeQuietZone) {
+		t.Errorf("cropImage() bounds = %v, want the region plus the padding", padded.Bounds())
+	}
+	if vkn, _, err := parser.scanBarcodeOrientation(padded); err != nil || vkn != "1234567890" {
+		t.Errorf("scanBarcodeOrientation() of the padded crop = %q, %v; want %q", vkn, err, "1234567890")
+	}
+
+	// The region crop of an image that is only the barcode is padded the same way
+	if vkn, _, err := parser.scanBarcodeRegion(tight); err != nil || vkn != "1234567890" {
+		t.Errorf("scanBarcodeRegion() = %q, %v; want %q", vkn, err, "1234567890")
+	}
+}
@@ -0,0 +1,115 @@
+package vergilevhasi
+
+import (
+	"context"
+	"fmt"
+	"sync"
+)
+
+// BatchOptions controls how ParseFiles handles a batch
+type BatchOptions struct {
+	// FailFast stops the batch at the first file that fails to parse, for pipelines where
+	// one failure points at a problem that affects every file. By default each failure is
+	// recorded in its BatchResult and the remaining files are still parsed.
+	FailFast bool
+
+	// Workers is how many files are parsed at once; zero or one parses them one at a time.
+	// Each worker parses with its own copy of the Parser, so the Parser must not be
+	// reconfigured while the batch runs and its Matches are not changed by the batch.
+	Workers int
+}
+
+// BatchResult is the outcome of parsing one file of a batch
+type BatchResult struct {
+	// Path is the file as passed to ParseFiles
+	Path string
+
+	// Result is the parsed plate, or nil when Err is set
+	Result *VergiLevhasi
+
+	// Err is the error parsing the file failed with
+	Err error
+
+	// Matches are the pattern matches recorded for the file when enabled with
+	// SetCollectMatches
+	Matches []PatternMatch
+}
+
+// batchParseFile parses one file of a batch; tests replace it to control how long a parse
+// takes and whether it fails
+var batchParseFile = (*Parser).parseFile
+
+// ParseFiles parses the tax plate PDFs at paths and returns one BatchResult per file
+// parsed, in the order of paths. A file that fails doesn't stop the others; its error is
+// in its result and the returned error is nil.
+//
+// With opts.FailFast the first failure cancels the batch: files not started yet are
+// skipped and the parses still running stop at their next step with context.Canceled.
+// The results of the files started are returned, with an error naming the failing file
+// and wrapping its error.
+//
+// Cancelling ctx stops the batch the same way; the results so far are returned with
+// ctx's error.
+func (p *Parser) ParseFiles(ctx context.Context, paths []string, opts BatchOptions) ([]BatchResult, error) {
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	var (
+		mu      sync.Mutex
+		failure error
+	)
+	started := make([]bool, len(paths))
+	results := make([]BatchResult, len(paths))
+
+	jobs := make(chan int)
+	var wg sync.WaitGroup
+	for range max(opts.Workers, 1) {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			// The matches recorded during a parse are kept on the Parser
+			worker := *p
+			for i := range jobs {
+				// The batch may have been cancelled while the job was handed over
+				if ctx.Err() != nil {
+					continue
+				}
+				started[i] = true
+				worker.matches = nil
+				vl, err := batchParseFile(&worker, ctx, paths[i])
+				results[i] = BatchResult{Path: paths[i], Result: vl, Err: err, Matches: worker.matches}
+				if err != nil && opts.FailFast {
+					mu.Lock()
+					// A parse stopped by the cancellation is not the failure
+					if failure == nil && ctx.Err() == nil {
+						failure = fmt.Errorf("batch stopped at %s: %w", paths[i], err)
+						cancel()
+					}
+					mu.Unlock()
+				}
+			}
+		}()
+	}
+
+feed:
+	for i := range paths {
+		select {
+		case jobs <- i:
+		case <-ctx.Done():
+			break feed
+		}
+	}
+	close(jobs)
+	wg.Wait()
+
+	done := results[:0]
+	for i, r := range results {
+		if started[i] {
+			done = append(done, r)
+		}
+	}
+	if failure != nil {
+		return done, failure
+	}
+	return done, ctx.Err()
+}
@@ -0,0 +1,221 @@
+package vergilevhasi
+
+import (
+	"context"
+	"errors"
+	"image"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"sync"
+	"testing"
+)
+
+// batchFixture writes two placeholder files around a missing one and returns a parser
+// reading every file as the same plate through a fake backend
+func batchFixture(t *testing.T) (*Parser, *fakeBackend, []string) {
+	t.Helper()
+	dir := t.TempDir()
+	first, missing, last := filepath.Join(dir, "a.pdf"), filepath.Join(dir, "missing.pdf"), filepath.Join(dir, "c.pdf")
+	for _, path := range []string{first, last} {
+		if err := os.WriteFile(path, []byte("not a real pdf"), 0o600); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	backend := &fakeBackend{pages: []PageText{{Number: 1, Text: "Adı Soyadı: Ali Örnek\nVergi Dairesi: Örnek VD\n"}}}
+	parser := NewParser()
+	parser.SetBackend(backend)
+	parser.SetFields(AllFields &^ FieldVergiKimlikNo)
+	return parser, backend, []string{first, missing, last}
+}
+
+func TestParseFilesIsolatesErrors(t *testing.T) {
+	parser, backend, paths := batchFixture(t)
+
+	results, err := parser.ParseFiles(context.Background(), paths, BatchOptions{})
+	if err != nil {
+		t.Fatalf("ParseFiles() error = %v", err)
+	}
+	if len(results) != 3 || backend.calls != 2 {
+		t.Fatalf("got %d results from %d parses, want 3 from 2", len(results), backend.calls)
+	}
+	for i, r := range results {
+		if r.Path != paths[i] {
+			t.Errorf("results[%d].Path = %q, want %q", i, r.Path, paths[i])
+		}
+	}
+	if results[0].Err != nil || results[0].Result.AdiSoyadi != "Ali Örnek" || results[2].Err != nil {
+		t.Errorf("parsed files: %+v, %+v", results[0], results[2])
+	}
+	if !errors.Is(results[1].Err, fs.ErrNotExist) || results[1].Result != nil {
+		t.Errorf("results[1] = %+v, want a missing file error", results[1])
+	}
+}
+
+func TestParseFilesFailFast(t *testing.T) {
+	parser, backend, paths := batchFixture(t)
+
+	results, err := parser.ParseFiles(context.Background(), paths, BatchOptions{FailFast: true})
+	if err == nil {
+		t.Fatal("ParseFiles() error = nil, want the missing file's error")
+	}
+	if !strings.Contains(err.Error(), paths[1]) || !errors.Is(err, fs.ErrNotExist) {
+		t.Errorf("ParseFiles() error = %v, want it to name %s and wrap fs.ErrNotExist", err, paths[1])
+	}
+	// The file after the failing one is never parsed
+	if len(results) != 2 || backend.calls != 1 {
+		t.Errorf("got %d results from %d parses, want 2 from 1", len(results), backend.calls)
+	}
+}
+
+func TestParseFilesCancelled(t *testing.T) {
+	parser, backend, paths := batchFixture(t)
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	results, err := parser.ParseFiles(ctx, paths, BatchOptions{})
+	if !errors.Is(err, context.Canceled) || len(results) != 0 || backend.calls != 0 {
+		t.Errorf("ParseFiles() = %d results, %v after %d parses; want none and context.Canceled", len(results), err, backend.calls)
+	}
+}
+
+// stubBatchParse replaces the parse of each batch file with parse for the test
+func stubBatchParse(t *testing.T, parse func(ctx context.Context, path string) (*VergiLevhasi, error)) {
+	t.Helper()
+	orig := batchParseFile
+	batchParseFile = func(_ *Parser, ctx context.Context, path string) (*VergiLevhasi, error) {
+		return parse(ctx, path)
+	}
+	t.Cleanup(func() { batchParseFile = orig })
+}
+
+func TestParseFilesFailFastCancelsRemainingWork(t *testing.T) {
+	var mu sync.Mutex
+	var parsed []string
+	stubBatchParse(t, func(ctx context.Context, path string) (*VergiLevhasi, error) {
+		mu.Lock()
+		parsed = append(parsed, path)
+		mu.Unlock()
+		switch path {
+		case "slow.pdf":
+			// Runs until the batch is cancelled; without the cancellation the test hangs
+			<-ctx.Done()
+			return nil, ctx.Err()
+		case "bad.pdf":
+			return nil, ErrPDFTooLarge
+		}
+		return &VergiLevhasi{}, nil
+	})
+
+	paths := []string{"slow.pdf", "bad.pdf", "c.pdf", "d.pdf", "e.pdf"}
+	results, err := NewParser().ParseFiles(context.Background(), paths, BatchOptions{FailFast: true, Workers: 2})
+	if !errors.Is(err, ErrPDFTooLarge) || !strings.Contains(err.Error(), "bad.pdf") {
+		t.Errorf("ParseFiles() error = %v, want it to name bad.pdf and wrap its error", err)
+	}
+	slices.Sort(parsed)
+	if !slices.Equal(parsed, []string{"bad.pdf", "slow.pdf"}) {
+		t.Errorf("parsed %v, want only the files started before the failure", parsed)
+	}
+	if len(results) != 2 || results[0].Path != "slow.pdf" || !errors.Is(results[0].Err, context.Canceled) ||
+		results[1].Path != "bad.pdf" {
+		t.Errorf("results = %+v, want the cancelled slow.pdf and the failing bad.pdf", results)
+	}
+}
+
+func TestParseFilesWorkersKeepOrder(t *testing.T) {
+	stubBatchParse(t, func(ctx context.Context, path string) (*VergiLevhasi, error) {
+		return &VergiLevhasi{AdiSoyadi: path}, nil
+	})
+
+	paths := []string{"a.pdf", "b.pdf", "c.pdf", "d.pdf", "e.pdf"}
+	results, err := NewParser().ParseFiles(context.Background(), paths, BatchOptions{Workers: 3})
+	if err != nil || len(results) != len(paths) {
+		t.Fatalf("ParseFiles() = %d results, %v; want %d", len(results), err, len(paths))
+	}
+	for i, r := range results {
+		if r.Path != paths[i] || r.Result.AdiSoyadi != paths[i] {
+			t.Errorf("results[%d] = %+v, want %s", i, r, paths[i])
+		}
+	}
+}
+
+func TestParseDataCancelled(t *testing.T) {
+	backend := &fakeBackend{
+		pages:  []PageText{{Number: 1, Text: "Adı Soyadı: Ali Örnek\n"}},
+		images: []image.Image{drawCode128(t, "1234567890")},
+	}
+	parser := NewParser()
+	parser.SetBackend(backend)
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	if vl, err := parser.parseData(ctx, nil, nil); !errors.Is(err, context.Canceled) || vl != nil {
+		t.Errorf("parseData() = %+v, %v; want context.Canceled", vl, err)
+	}
+}
+
+// fileTextBackend reads each file's content as its only page; unlike fakeBackend it keeps
+// no state, so concurrent parses can share it
+type fileTextBackend struct{}
+
+func (fileTextBackend) ExtractText(data []byte) ([]PageText, error) {
+	return []PageText{{Number: 1, Text: string(data)}}, nil
+}
+
+func (fileTextBackend) ExtractImages(data []byte) ([]image.Image, error) {
+	return nil, errors.New("no images")
+}
+
+func (fileTextBackend) PageCount(data []byte) (int, error) {
+	return 1, nil
+}
+
+func TestParseFilesWorkersCollectMatches(t *testing.T) {
+	dir := t.TempDir()
+	texts := map[string]string{
+		filepath.Join(dir, "a.pdf"): "Adı Soyadı: Ali Örnek\nVergi Dairesi: Örnek VD\nVergi Kimlik No: 1234567890\n",
+		filepath.Join(dir, "b.pdf"): "Adı Soyadı: Ayşe Deneme\nVergi Dairesi: Deneme VD\nVergi Kimlik No: 4827193056\n",
+		filepath.Join(dir, "c.pdf"): "Ticaret Unvanı: Örnek Ltd. Şti.\nVergi Dairesi: Diğer VD\nVergi Kimlik No: 1234567890\n",
+	}
+	var paths []string
+	for path, text := range texts {
+		if err := os.WriteFile(path, []byte(text), 0o600); err != nil {
+			t.Fatal(err)
+		}
+		paths = append(paths, path)
+	}
+	slices.Sort(paths)
+
+	parser := NewParser()
+	parser.SetBackend(fileTextBackend{})
+	parser.SetFields(AllFields &^ FieldVergiKimlikNo)
+	parser.SetCollectMatches(true)
+
+	// Run with -race: the workers must not share the matches being collected
+	results, err := parser.ParseFiles(context.Background(), paths, BatchOptions{Workers: 2})
+	if err != nil {
+		t.Fatalf("ParseFiles() error = %v", err)
+	}
+	if len(results) != len(paths) {
+		t.Fatalf("ParseFiles() returned %d results, want %d", len(results), len(paths))
+	}
+	for _, r := range results {
+		if r.Err != nil {
+			t.Fatalf("%s: error = %v", r.Path, r.Err)
+		}
+		if len(r.Matches) == 0 {
+			t.Errorf("%s: no matches recorded", r.Path)
+		}
+		for _, m := range r.Matches {
+			if !strings.Contains(texts[r.Path], m.Text) {
+				t.Errorf("%s: match %q is from another file", r.Path, m.Text)
+			}
+		}
+	}
+	if got := parser.Matches(); got != nil {
+		t.Errorf("Matches() = %d matches after the batch, want none on the parser", len(got))
+	}
+}
@@ -0,0 +1,50 @@
+package vergilevhasi
+
+import (
+	"io"
+	"regexp"
+)
+
+// IdentifierCandidate is a 10-digit (VKN) or 11-digit (TCKN) number found in the page text
+type IdentifierCandidate struct {
+	Value         string `json:"value"`
+	ChecksumValid bool   `json:"checksum_valid"`
+}
+
+// digitRunRe matches a maximal run of digits
+var digitRunRe = regexp.MustCompile(`\d+`)
+
+// CandidateIdentifiers returns every distinct 10-digit and 11-digit number in the page text,
+// in order of first appearance, with checksum-valid ones flagged. It is a diagnostic for
+// when automatic disambiguation picks the wrong VKN/TCKN; OCR and field parsing are not run.
+func (p *Parser) CandidateIdentifiers(reader io.ReadSeeker) (vkn []IdentifierCandidate, tckn []IdentifierCandidate, err error) {
+	data, err := p.readPDF(reader)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	pages, err := p.pdfBackend().ExtractText(data)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	seen := make(map[string]bool)
+	for _, page := range pages {
+		for _, run := range digitRunRe.FindAllString(page.Text, -1) {
+			if seen[run] {
+				continue
+			}
+			switch len(run) {
+			case 10:
+				vkn = append(vkn, IdentifierCandidate{Value: run, ChecksumValid: IsValidVKNChecksum(run)})
+			case 11:
+				tckn = append(tckn, IdentifierCandidate{Value: run, ChecksumValid: isValidTCKN(run)})
+			default:
+				continue
+			}
+			seen[run] = true
+		}
+	}
+
+	return vkn, tckn, nil
+}
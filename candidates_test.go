@@ -0,0 +1,46 @@
+package vergilevhasi
+
+import (
+	"bytes"
+	"reflect"
+	"testing"
+)
+
+func TestCandidateIdentifiers(t *testing.T) {
+	// Synthetic plate text with several numeric runs, including duplicates and
+	// numbers of other lengths that must be ignored
+	backend := &fakeBackend{pages: []PageText{
+		{Number: 1, Text: "Vergi Kimlik No: 1234567891\nTel: 03121234567\nReferans: 1234567890\nTarih: 01.01.2020\n"},
+		{Number: 2, Text: "TC Kimlik No: 10000000146\nVKN 1234567891\nSicil: 123456789012\nNACE 620100\n"},
+	}}
+
+	parser := NewParser()
+	parser.SetBackend(backend)
+
+	vkn, tckn, err := parser.CandidateIdentifiers(bytes.NewReader([]byte("not a real pdf")))
+	if err != nil {
+		t.Fatalf("CandidateIdentifiers() error = %v", err)
+	}
+
+	wantVKN := []IdentifierCandidate{
+		{Value: "1234567891", ChecksumValid: false},
+		{Value: "1234567890", ChecksumValid: true},
+	}
+	wantTCKN := []IdentifierCandidate{
+		{Value: "03121234567", ChecksumValid: false},
+		{Value: "10000000146", ChecksumValid: true},
+	}
+	if !reflect.DeepEqual(vkn, wantVKN) {
+		t.Errorf("vkn = %+v, want %+v", vkn, wantVKN)
+	}
+	if !reflect.DeepEqual(tckn, wantTCKN) {
+		t.Errorf("tckn = %+v, want %+v", tckn, wantTCKN)
+	}
+}
+
+func TestCandidateIdentifiersInvalidPDF(t *testing.T) {
+	parser := NewParser()
+	if _, _, err := parser.CandidateIdentifiers(bytes.NewReader([]byte("not a pdf"))); err == nil {
+		t.Error("CandidateIdentifiers() with invalid PDF should return an error")
+	}
+}
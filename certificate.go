@@ -0,0 +1,77 @@
+package vergilevhasi
+
+import (
+	"strings"
+)
+
+// isFaaliyetBelgesi reports whether the text belongs to an activity certificate
+// (Faaliyet Belgesi) rather than a tax plate
+func isFaaliyetBelgesi(text string) bool {
+	upper := strings.ToUpper(text)
+	if !strings.Contains(upper, "FAALİYET BELGESİ") && !strings.Contains(upper, "FAALIYET BELGESI") {
+		return false
+	}
+	// Tax plates never carry the certificate title, but guard against a plate
+	// that merely mentions the certificate somewhere in its text
+	return !strings.Contains(upper, "VERGİ LEVHASI") && !strings.Contains(upper, "VERGI LEVHASI")
+}
+
+// Activity certificate label patterns for extractField
+var (
+	certAdiSoyadiPatterns = mustCompileAll(
+		`(?i)ad[ıi]\s*(?:ve\s*)?soyad[ıi]\s*[:：]\s*(.+?)(?:\n|$)`,
+	)
+	certTicaretUnvaniPatterns = mustCompileAll(
+		`(?i)ticaret\s*[üu]nvan[ıi]\s*[:：]\s*(.+?)(?:\n|$)`,
+		`(?im)^\s*[üu]nvan[ıi]\s*[:：]\s*(.+?)(?:\n|$)`,
+	)
+	certIsYeriAdresiPatterns = mustCompileAll(
+		`(?i)[iİ][şs]\s*yeri\s*adres[iİ]\s*[:：]\s*(.+?)(?:\n|$)`,
+		`(?im)^\s*adres[iİ]?\s*[:：]\s*(.+?)(?:\n|$)`,
+	)
+	certVergiDairesiPatterns = mustCompileAll(
+		`(?i)ba[ğg]l[ıi]\s*oldu[ğg]u\s*vergi\s*daires[iİ]\s*[:：]\s*(.+?)(?:\n|$)`,
+		`(?i)vergi\s*daires[iİ]\s*[:：]\s*(.+?)(?:\n|$)`,
+	)
+	certVKNPatterns = mustCompileAll(
+		`(?i)vergi\s*kimlik\s*(?:no|numaras[ıi])\s*[:：]\s*(\d{10})\b`,
+		`(?i)v\.?k\.?n\.?\s*[:：]\s*(\d{10})\b`,
+	)
+	certTCKNPatterns = mustCompileAll(
+		`(?i)t\.?\s*c\.?\s*kimlik\s*(?:no|numaras[ıi])\s*[:：]\s*(\d{11})\b`,
+	)
+	certIseBaslamaPatterns = mustCompileAll(
+		`(?i)[iİ][şs]e\s*ba[şs]lama\s*tarih[iİ]\s*[:：]\s*(\d{2}[./-]\d{2}[./-]\d{4})`,
+	)
+)
+
+// parseFaaliyetBelgesi extracts fields from an activity certificate.
+// The certificate lists "Label : Value" pairs, one per line, so the traditional
+// colon-based patterns are used with the certificate's label wording.
+func (p *Parser) parseFaaliyetBelgesi(vl *VergiLevhasi, text string) {
+	vl.AdiSoyadi = p.extractField(text, certAdiSoyadiPatterns)
+
+	vl.TicaretUnvani = p.extractField(text, certTicaretUnvaniPatterns)
+
+	vl.IsYeriAdresi = p.extractField(text, certIsYeriAdresiPatterns)
+
+	vl.VergiDairesi = p.extractField(text, certVergiDairesiPatterns)
+
+	vl.VergiKimlikNo = p.extractField(text, certVKNPatterns)
+
+	vl.TCKimlikNo = p.extractField(text, certTCKNPatterns)
+
+	dateStr := p.extractField(text, certIseBaslamaPatterns)
+	if dateStr != "" {
+		if date, err := p.parseDate(dateStr); err == nil {
+			vl.IseBaslamaTarihi = &date
+		}
+	}
+
+	if p.wants(FieldVergiTuru) {
+		vl.VergiTuru = p.extractTaxTypes(text)
+	}
+	if p.wants(FieldFaaliyetKodlari) {
+		vl.FaaliyetKodlari = p.extractActivities(text)
+	}
+}
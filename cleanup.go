@@ -0,0 +1,80 @@
+package vergilevhasi
+
+import (
+	"regexp"
+	"slices"
+	"strings"
+)
+
+// fieldLabelRemnantRe matches the tail of a label left at the start of a value when the
+// label was split over two strings, e.g. "SOYADI : ALİ ÖRNEK" after "ADI" was consumed
+var fieldLabelRemnantRe = regexp.MustCompile(expandTurkishLetters(`(?i)^(?:(?:adı\s+)?soyadı|ticaret\s+ünvanı|ünvanı|adresi|dairesi|adı)\s*[:：]\s*`))
+
+// Stray punctuation trimmed from field values. A trailing period is kept, since it ends
+// abbreviations such as "LTD. ŞTİ." and "A.Ş.".
+const (
+	fieldLeadingPunctuation  = ":：;,.-–_/|*"
+	fieldTrailingPunctuation = ":：;,-–_/|*"
+)
+
+// SetFieldCleanup enables or disables the final cleanup of the free-text fields: runs of
+// whitespace are collapsed, stray punctuation such as a trailing colon is trimmed and
+// label remnants ("SOYADI :") are dropped from the start. Enabled by default; disable it
+// for the values as assembled from the PDF strings. TicaretUnvani is cleaned by
+// SetUnvanNormalization instead, and HamUnvan is never cleaned.
+func (p *Parser) SetFieldCleanup(enabled bool) {
+	p.cleanupFields = enabled
+}
+
+// cleanupFieldValues applies cleanFieldValue to the free-text fields when enabled.
+// Identifiers and codes are left alone; their patterns admit no stray characters.
+func (p *Parser) cleanupFieldValues(vl *VergiLevhasi) {
+	if !p.cleanupFields {
+		return
+	}
+	vl.AdiSoyadi = cleanFieldValue(vl.AdiSoyadi)
+	vl.IsYeriAdresi = cleanFieldValue(vl.IsYeriAdresi)
+	vl.VergiDairesi = cleanFieldValue(vl.VergiDairesi)
+	vl.GelirUnsuru = cleanFieldValue(vl.GelirUnsuru)
+	vl.Uyruk = cleanFieldValue(vl.Uyruk)
+	vl.Ortaklar = cleanFieldValues(vl.Ortaklar)
+	vl.VergiTuru = cleanFieldValues(vl.VergiTuru)
+	for i := range vl.FaaliyetKodlari {
+		vl.FaaliyetKodlari[i].Ad = cleanFieldValue(vl.FaaliyetKodlari[i].Ad)
+	}
+
+	// Addresses that differed only in spacing are the same address now
+	vl.IsYeriAdresleri = cleanFieldValues(vl.IsYeriAdresleri)
+	if len(vl.IsYeriAdresleri) < 2 {
+		vl.IsYeriAdresleri = nil
+	}
+}
+
+// cleanFieldValues cleans each value, dropping values left empty and repeated ones
+func cleanFieldValues(values []string) []string {
+	if values == nil {
+		return nil
+	}
+	cleaned := make([]string, 0, len(values))
+	for _, v := range values {
+		if v = cleanFieldValue(v); v != "" && !slices.Contains(cleaned, v) {
+			cleaned = append(cleaned, v)
+		}
+	}
+	return cleaned
+}
+
+// cleanFieldValue collapses whitespace, drops a leading label remnant and trims stray
+// punctuation from both ends
+func cleanFieldValue(s string) string {
+	s = strings.Join(strings.Fields(s), " ")
+	for {
+		trimmed := strings.TrimLeft(s, fieldLeadingPunctuation+" ")
+		trimmed = fieldLabelRemnantRe.ReplaceAllString(trimmed, "")
+		trimmed = strings.TrimRight(trimmed, fieldTrailingPunctuation+" ")
+		if trimmed == s {
+			return s
+		}
+		s = trimmed
+	}
+}
@@ -0,0 +1,52 @@
+package vergilevhasi
+
+import (
+	"bytes"
+	"testing"
+)
+
+func TestCleanFieldValue(t *testing.T) {
+	tests := []struct {
+		in, want string
+	}{
+		{"ALİ  ÖRNEK", "ALİ ÖRNEK"},
+		{"ÇANKAYA:", "ÇANKAYA"},
+		{"  : ÇANKAYA ; ", "ÇANKAYA"},
+		{"SOYADI : ALİ ÖRNEK", "ALİ ÖRNEK"},
+		{"Adresi: ATATÜRK CAD.  NO:5 ÇANKAYA/ANKARA", "ATATÜRK CAD. NO:5 ÇANKAYA/ANKARA"},
+		{"ÖRNEK TİCARET A.Ş.", "ÖRNEK TİCARET A.Ş."},
+		{"ADIYAMAN", "ADIYAMAN"},
+		{":", ""},
+	}
+	for _, tt := range tests {
+		if got := cleanFieldValue(tt.in); got != tt.want {
+			t.Errorf("cleanFieldValue(%q) = %q, want %q", tt.in, got, tt.want)
+		}
+	}
+}
+
+func TestParseFieldCleanup(t *testing.T) {
+	text := "Adı Soyadı: ALİ   ÖRNEK :\nVergi Dairesi: ÇANKAYA  VD:\nİş Yeri Adresi: ATATÜRK  CAD. NO:5 ÇANKAYA/ANKARA\nTC Kimlik No: 10000000146\n"
+
+	for _, tt := range []struct {
+		enabled                      bool
+		wantAd, wantDaire, wantAdres string
+	}{
+		{true, "ALİ ÖRNEK", "ÇANKAYA VD", "ATATÜRK CAD. NO:5 ÇANKAYA/ANKARA"},
+		{false, "ALİ   ÖRNEK :", "ÇANKAYA  VD:", "ATATÜRK  CAD. NO:5 ÇANKAYA/ANKARA"},
+	} {
+		parser := NewParser()
+		parser.SetBackend(&fakeBackend{pages: []PageText{{Number: 1, Text: text}}})
+		parser.SetFields(AllFields &^ FieldVergiKimlikNo)
+		parser.SetFieldCleanup(tt.enabled)
+
+		vl, err := parser.Parse(bytes.NewReader(nil))
+		if err != nil {
+			t.Fatalf("Parse() error = %v", err)
+		}
+		if vl.AdiSoyadi != tt.wantAd || vl.VergiDairesi != tt.wantDaire || vl.IsYeriAdresi != tt.wantAdres {
+			t.Errorf("SetFieldCleanup(%v): AdiSoyadi = %q, VergiDairesi = %q, IsYeriAdresi = %q; want %q, %q, %q",
+				tt.enabled, vl.AdiSoyadi, vl.VergiDairesi, vl.IsYeriAdresi, tt.wantAd, tt.wantDaire, tt.wantAdres)
+		}
+	}
+}
@@ -0,0 +1,331 @@
+package vergilevhasi
+
+import (
+	"sort"
+	"strconv"
+	"strings"
+	"unicode/utf16"
+
+	"github.com/pdfcpu/pdfcpu/pkg/pdfcpu/model"
+	"github.com/pdfcpu/pdfcpu/pkg/pdfcpu/types"
+)
+
+// maxCMapRangeSize caps how many codes a single bfrange may expand to,
+// protecting against malformed CMaps that declare huge ranges
+const maxCMapRangeSize = 1 << 16
+
+// toUnicodeCMap maps character codes of a (typically CID-keyed) font to Unicode text,
+// as described by the font's ToUnicode CMap
+type toUnicodeCMap struct {
+	// codeLengths lists the code byte lengths from the codespace ranges, longest first
+	codeLengths []int
+
+	// mapping is keyed by the raw code bytes
+	mapping map[string]string
+}
+
+// fontCMaps maps font resource names (without the leading slash) to their ToUnicode CMaps
+type fontCMaps map[string]*toUnicodeCMap
+
+// parseToUnicodeCMap parses the bfchar and bfrange sections of a ToUnicode CMap.
+// It returns nil if the CMap defines no mappings.
+func parseToUnicodeCMap(data string) *toUnicodeCMap {
+	cmap := &toUnicodeCMap{mapping: make(map[string]string)}
+	tokens := tokenizeCMap(data)
+	lengths := make(map[int]bool)
+
+	section := ""
+	for i := 0; i < len(tokens); i++ {
+		tok := tokens[i]
+		switch tok {
+		case "begincodespacerange", "beginbfchar", "beginbfrange":
+			section = tok
+			continue
+		case "endcodespacerange", "endbfchar", "endbfrange":
+			section = ""
+			continue
+		}
+
+		switch section {
+		case "begincodespacerange":
+			if i+1 < len(tokens) && isCMapHex(tok) {
+				if lo := hexToBytes(cmapHexBody(tok)); len(lo) > 0 {
+					lengths[len(lo)] = true
+				}
+				i++
+			}
+		case "beginbfchar":
+			if i+1 < len(tokens) && isCMapHex(tok) && isCMapHex(tokens[i+1]) {
+				src := hexToBytes(cmapHexBody(tok))
+				cmap.mapping[string(src)] = decodeCMapDestination(hexToBytes(cmapHexBody(tokens[i+1])))
+				if len(lengths) == 0 {
+					lengths[len(src)] = true
+				}
+				i++
+			}
+		case "beginbfrange":
+			if i+2 >= len(tokens) || !isCMapHex(tok) || !isCMapHex(tokens[i+1]) {
+				continue
+			}
+			lo := hexToBytes(cmapHexBody(tok))
+			hi := hexToBytes(cmapHexBody(tokens[i+1]))
+			if len(lengths) == 0 {
+				lengths[len(lo)] = true
+			}
+			i += 2
+
+			if tokens[i] == "[" {
+				// Each code in the range maps to its own destination string
+				code := lo
+				for i++; i < len(tokens) && tokens[i] != "]"; i++ {
+					if isCMapHex(tokens[i]) && compareCodes(code, hi) <= 0 {
+						cmap.mapping[string(code)] = decodeCMapDestination(hexToBytes(cmapHexBody(tokens[i])))
+						code = incrementCode(code)
+					}
+				}
+				continue
+			}
+
+			if !isCMapHex(tokens[i]) {
+				continue
+			}
+			// Consecutive codes map to consecutive destinations, incrementing the last code unit
+			dst := hexToBytes(cmapHexBody(tokens[i]))
+			code := lo
+			for n := 0; n < maxCMapRangeSize && compareCodes(code, hi) <= 0; n++ {
+				cmap.mapping[string(code)] = decodeCMapDestination(dst)
+				next := incrementCode(code)
+				if compareCodes(next, code) <= 0 {
+					break
+				}
+				code = next
+				dst = incrementCode(dst)
+			}
+		}
+	}
+
+	if len(cmap.mapping) == 0 {
+		return nil
+	}
+
+	for l := range lengths {
+		cmap.codeLengths = append(cmap.codeLengths, l)
+	}
+	sort.Sort(sort.Reverse(sort.IntSlice(cmap.codeLengths)))
+
+	return cmap
+}
+
+// decode maps raw show-text bytes to Unicode, matching the longest code first.
+// Codes without a mapping are dropped rather than emitted as gibberish.
+func (c *toUnicodeCMap) decode(raw []byte) string {
+	var result strings.Builder
+	shortest := c.codeLengths[len(c.codeLengths)-1]
+
+	for i := 0; i < len(raw); {
+		matched := false
+		for _, l := range c.codeLengths {
+			if i+l > len(raw) {
+				continue
+			}
+			if text, ok := c.mapping[string(raw[i:i+l])]; ok {
+				result.WriteString(text)
+				i += l
+				matched = true
+				break
+			}
+		}
+		if !matched {
+			i += shortest
+		}
+	}
+
+	return result.String()
+}
+
+// tokenizeCMap splits a CMap program into hex strings, array brackets and bare words
+func tokenizeCMap(data string) []string {
+	var tokens []string
+	i := 0
+	for i < len(data) {
+		ch := data[i]
+		switch {
+		case isPDFWhitespace(ch):
+			i++
+		case ch == '%':
+			for i < len(data) && data[i] != '\n' && data[i] != '\r' {
+				i++
+			}
+		case ch == '<' && i+1 < len(data) && data[i+1] == '<', ch == '>' && i+1 < len(data) && data[i+1] == '>':
+			i += 2
+		case ch == '<':
+			end := strings.IndexByte(data[i:], '>')
+			if end < 0 {
+				return tokens
+			}
+			tokens = append(tokens, "<"+stripPDFWhitespace(data[i+1:i+end])+">")
+			i += end + 1
+		case ch == '[' || ch == ']':
+			tokens = append(tokens, string(ch))
+			i++
+		case ch == '(':
+			// Literal strings only appear in the CMap header (e.g. /Registry); skip them
+			_, end := extractPDFString(data, i)
+			if end <= i {
+				end = i + 1
+			}
+			i = end
+		default:
+			j := i
+			for j < len(data) && !isPDFWhitespace(data[j]) && !isPDFDelimiter(data[j]) {
+				j++
+			}
+			if j == i {
+				j++
+			}
+			tokens = append(tokens, data[i:j])
+			i = j
+		}
+	}
+	return tokens
+}
+
+// isCMapHex reports whether a CMap token is a hex string
+func isCMapHex(tok string) bool {
+	return len(tok) >= 2 && tok[0] == '<' && tok[len(tok)-1] == '>'
+}
+
+// cmapHexBody returns the digits of a hex string token
+func cmapHexBody(tok string) string {
+	return tok[1 : len(tok)-1]
+}
+
+// decodeCMapDestination decodes a bfchar/bfrange destination, which is UTF-16BE
+func decodeCMapDestination(dst []byte) string {
+	if len(dst) == 1 {
+		return string(rune(dst[0]))
+	}
+	if len(dst)%2 != 0 {
+		dst = append(dst, 0)
+	}
+	u16 := make([]uint16, len(dst)/2)
+	for i := 0; i < len(dst); i += 2 {
+		u16[i/2] = uint16(dst[i])<<8 | uint16(dst[i+1])
+	}
+	return string(utf16.Decode(u16))
+}
+
+// compareCodes compares two equal-length big-endian codes
+func compareCodes(a, b []byte) int {
+	if len(a) != len(b) {
+		return len(a) - len(b)
+	}
+	return strings.Compare(string(a), string(b))
+}
+
+// incrementCode returns code + 1 as a big-endian number of the same length, wrapping on overflow
+func incrementCode(code []byte) []byte {
+	next := make([]byte, len(code))
+	copy(next, code)
+	for i := len(next) - 1; i >= 0; i-- {
+		next[i]++
+		if next[i] != 0 {
+			break
+		}
+	}
+	return next
+}
+
+// pageResources returns the resource dictionary of a page, which may be inherited from
+// the page tree
+func pageResources(ctx *model.Context, pageNr int) types.Dict {
+	pageDict, _, inherited, err := ctx.PageDict(pageNr, false)
+	if err != nil || pageDict == nil {
+		return nil
+	}
+
+	var resources types.Dict
+	if obj, ok := pageDict["Resources"]; ok {
+		resources, _ = ctx.DereferenceDict(obj)
+	}
+	if resources == nil && inherited != nil {
+		resources = inherited.Resources
+	}
+	return resources
+}
+
+// resourceFontCMaps loads the ToUnicode CMaps of the fonts in a resource dictionary, of
+// a page or of a Form XObject. Fonts without a ToUnicode entry are omitted, so an empty
+// result means the byte-level heuristics should be used.
+func resourceFontCMaps(r objectResolver, resources types.Dict) fontCMaps {
+	if resources == nil {
+		return nil
+	}
+	fontObj, ok := resources["Font"]
+	if !ok {
+		return nil
+	}
+	fonts, err := r.Dereference(fontObj)
+	if err != nil {
+		return nil
+	}
+	fontDicts, ok := fonts.(types.Dict)
+	if !ok {
+		return nil
+	}
+
+	cmaps := make(fontCMaps)
+	for name, obj := range fontDicts {
+		resolved, err := r.Dereference(obj)
+		if err != nil {
+			continue
+		}
+		fontDict, ok := resolved.(types.Dict)
+		if !ok {
+			continue
+		}
+		toUnicode, ok := fontDict["ToUnicode"]
+		if !ok {
+			continue
+		}
+		stream, err := r.Dereference(toUnicode)
+		if err != nil {
+			continue
+		}
+		sd := streamOf(stream)
+		if sd == nil {
+			continue
+		}
+		if sd.Content == nil {
+			if err := sd.Decode(); err != nil {
+				continue
+			}
+		}
+		if cmap := parseToUnicodeCMap(string(sd.Content)); cmap != nil {
+			cmaps[name] = cmap
+		}
+	}
+
+	return cmaps
+}
+
+// fontResourceName strips the leading slash from a font name operand and parses it as a key
+func fontResourceName(operand string) string {
+	name := strings.TrimPrefix(operand, "/")
+	// Names may contain #xx escapes for delimiter characters
+	if strings.Contains(name, "#") {
+		var b strings.Builder
+		for i := 0; i < len(name); i++ {
+			if name[i] == '#' && i+2 < len(name) {
+				if v, err := strconv.ParseUint(name[i+1:i+3], 16, 8); err == nil {
+					b.WriteByte(byte(v))
+					i += 2
+					continue
+				}
+			}
+			b.WriteByte(name[i])
+		}
+		name = b.String()
+	}
+	return name
+}
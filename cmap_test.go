@@ -0,0 +1,111 @@
+package vergilevhasi
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+)
+
+// testCIDCMap is a ToUnicode CMap for a subset font whose glyph IDs bear no relation to
+// the characters they show, as produced by typical CID font embedding
+const testCIDCMap = `/CIDInit /ProcSet findresource begin
+12 dict begin
+begincmap
+/CIDSystemInfo << /Registry (Adobe) /Ordering (UCS) /Supplement 0 >> def
+/CMapName /Adobe-Identity-UCS def
+/CMapType 2 def
+1 begincodespacerange
+<0000> <FFFF>
+endcodespacerange
+6 beginbfchar
+<0003> <0020>
+<0011> <0130>
+<0012> <015E>
+<0013> <011E>
+<0014> <00DC>
+<0015> <00C7>
+endbfchar
+2 beginbfrange
+<0020> <0039> <0041>
+<0040> <0042> [<00D6> <0131> <015F>]
+endbfrange
+endcmap
+CMapName currentdict /CMap defineresource pop
+end
+end`
+
+// encodeCIDs renders glyph IDs as the body of a hex string
+func encodeCIDs(cids ...int) string {
+	var b strings.Builder
+	for _, cid := range cids {
+		fmt.Fprintf(&b, "%04X", cid)
+	}
+	return b.String()
+}
+
+func TestParseToUnicodeCMap(t *testing.T) {
+	cmap := parseToUnicodeCMap(testCIDCMap)
+	if cmap == nil {
+		t.Fatal("parseToUnicodeCMap() returned nil")
+	}
+	if len(cmap.codeLengths) != 1 || cmap.codeLengths[0] != 2 {
+		t.Errorf("codeLengths = %v, want [2]", cmap.codeLengths)
+	}
+
+	tests := []struct {
+		code []byte
+		want string
+	}{
+		{[]byte{0x00, 0x11}, "İ"},
+		{[]byte{0x00, 0x20}, "A"},
+		{[]byte{0x00, 0x39}, "Z"},
+		{[]byte{0x00, 0x41}, "ı"},
+		{[]byte{0x00, 0x42}, "ş"},
+	}
+	for _, tt := range tests {
+		if got := cmap.decode(tt.code); got != tt.want {
+			t.Errorf("decode(%X) = %q, want %q", tt.code, got, tt.want)
+		}
+	}
+}
+
+func TestParseToUnicodeCMapEmpty(t *testing.T) {
+	if cmap := parseToUnicodeCMap("begincmap endcmap"); cmap != nil {
+		t.Errorf("parseToUnicodeCMap() = %+v, want nil", cmap)
+	}
+}
+
+func TestExtractTextFragmentsWithCIDFont(t *testing.T) {
+	fonts := fontCMaps{"F1": parseToUnicodeCMap(testCIDCMap)}
+
+	// Glyph ID of an uppercase ASCII letter in the range <0020>-<0039>
+	letter := func(r rune) int { return 0x20 + int(r-'A') }
+
+	// "VERGİ DAİRESİ" and "ŞİŞLİ" in glyph IDs; F2 has no CMap and uses the byte heuristics
+	vergiDairesi := encodeCIDs(letter('V'), letter('E'), letter('R'), letter('G'), 0x11, 0x03,
+		letter('D'), letter('A'), 0x11, letter('R'), letter('E'), letter('S'), 0x11)
+	sisli := encodeCIDs(0x12, 0x11, 0x12, letter('L'), 0x11)
+	content := "BT /F1 10 Tf 50 700 Td <" + vergiDairesi + "> Tj 0 -20 Td [<" + sisli + ">] TJ " +
+		"/F2 10 Tf 0 -20 Td (PLAIN) Tj ET"
+
+	fragments := extractTextFragmentsWithFonts(content, fonts)
+	want := []string{"VERGİ DAİRESİ", "ŞİŞLİ", "PLAIN"}
+	if len(fragments) != len(want) {
+		t.Fatalf("extractTextFragmentsWithFonts() returned %d fragments, want %d: %+v", len(fragments), len(want), fragments)
+	}
+	for i, w := range want {
+		if fragments[i].Text != w {
+			t.Errorf("fragment %d = %q, want %q", i, fragments[i].Text, w)
+		}
+	}
+
+	text := pageTextFromContent(content, fonts, nil)
+	if !strings.Contains(text, "VERGİ DAİRESİ\nŞİŞLİ\n") {
+		t.Errorf("pageTextFromContent() = %q, want decoded Turkish text", text)
+	}
+
+	// Without CMaps the same content falls back to the byte heuristics
+	if text := pageTextFromContent(content, nil, nil); strings.Contains(text, "DAİRESİ") {
+		t.Errorf("pageTextFromContent() without fonts = %q, want heuristic decoding", text)
+	}
+}
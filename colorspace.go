@@ -0,0 +1,221 @@
+package vergilevhasi
+
+import (
+	"fmt"
+	"image"
+	"image/color"
+
+	"github.com/pdfcpu/pdfcpu/pkg/pdfcpu/types"
+)
+
+// rawColorSpace is the color space of an image XObject, as needed to decode its raw samples
+type rawColorSpace struct {
+	// components is the number of color components per sample; 1 for Indexed
+	components int
+
+	// palette maps sample values to colors in an Indexed color space; nil otherwise
+	palette color.Palette
+
+	// ink is set for Separation and DeviceN spaces, whose components are ink amounts
+	// (0 meaning no ink) rather than light
+	ink bool
+}
+
+// imageColorSpace reads the /ColorSpace of image XObject objNr. It returns nil when the
+// object or its color space can't be resolved; decoding then falls back to pdfcpu's
+// component count.
+func imageColorSpace(r objectResolver, objNr int) *rawColorSpace {
+	obj, err := r.Dereference(types.IndirectRef{ObjectNumber: types.Integer(objNr)})
+	if err != nil {
+		return nil
+	}
+	dict := streamDictOf(obj)
+	if dict == nil {
+		return nil
+	}
+	return parseColorSpace(r, dict["ColorSpace"], 0)
+}
+
+// parseColorSpace parses a color space name or array. ICCBased spaces are treated as the
+// device space with the same number of components; the profile itself is ignored.
+func parseColorSpace(r objectResolver, obj types.Object, depth int) *rawColorSpace {
+	if obj == nil || depth > 2 {
+		return nil
+	}
+	obj, err := r.Dereference(obj)
+	if err != nil {
+		return nil
+	}
+
+	switch v := obj.(type) {
+	case types.Name:
+		switch v {
+		case "DeviceGray", "CalGray", "G":
+			return &rawColorSpace{components: 1}
+		case "DeviceRGB", "CalRGB", "RGB":
+			return &rawColorSpace{components: 3}
+		case "DeviceCMYK", "CMYK":
+			return &rawColorSpace{components: 4}
+		}
+	case types.Array:
+		if len(v) == 0 {
+			return nil
+		}
+		family, _ := v[0].(types.Name)
+		switch family {
+		case "CalGray", "CalRGB":
+			return parseColorSpace(r, family, depth+1)
+		case "ICCBased":
+			if len(v) < 2 {
+				return nil
+			}
+			profile, err := r.Dereference(v[1])
+			if err != nil {
+				return nil
+			}
+			if n, ok := streamDictOf(profile)["N"].(types.Integer); ok && (n == 1 || n == 3 || n == 4) {
+				return &rawColorSpace{components: int(n)}
+			}
+		case "Indexed", "I":
+			return parseIndexedColorSpace(r, v, depth)
+		case "Separation":
+			return &rawColorSpace{components: 1, ink: true}
+		case "DeviceN":
+			if len(v) < 2 {
+				return nil
+			}
+			if names, err := r.Dereference(v[1]); err == nil {
+				if names, ok := names.(types.Array); ok && len(names) > 0 {
+					return &rawColorSpace{components: len(names), ink: true}
+				}
+			}
+		}
+	}
+	return nil
+}
+
+// parseIndexedColorSpace parses [/Indexed base hival lookup] into a palette of hival+1
+// colors. The lookup table may be a literal string, a hex string or a stream.
+func parseIndexedColorSpace(r objectResolver, v types.Array, depth int) *rawColorSpace {
+	if len(v) < 4 {
+		return nil
+	}
+	base := parseColorSpace(r, v[1], depth+1)
+	if base == nil || base.palette != nil || base.ink {
+		return nil
+	}
+	hival, ok := v[2].(types.Integer)
+	if !ok || hival < 0 || hival > 255 {
+		return nil
+	}
+
+	var lookup []byte
+	obj, err := r.Dereference(v[3])
+	if err != nil {
+		return nil
+	}
+	switch l := obj.(type) {
+	case types.StringLiteral:
+		lookup = []byte(unescapePDFString(string(l)))
+	case types.HexLiteral:
+		lookup = hexToBytes(string(l))
+	case types.StreamDict:
+		if l.Decode() == nil {
+			lookup = l.Content
+		}
+	case *types.StreamDict:
+		if l.Decode() == nil {
+			lookup = l.Content
+		}
+	}
+
+	palette := make(color.Palette, 0, int(hival)+1)
+	for i := 0; i <= int(hival); i++ {
+		start := i * base.components
+		if start+base.components > len(lookup) {
+			break
+		}
+		palette = append(palette, sampleColor(lookup[start:start+base.components]))
+	}
+	if len(palette) == 0 {
+		return nil
+	}
+	return &rawColorSpace{components: 1, palette: palette}
+}
+
+// streamDictOf returns the dictionary of a stream object, or nil
+func streamDictOf(obj types.Object) types.Dict {
+	switch sd := obj.(type) {
+	case types.StreamDict:
+		return sd.Dict
+	case *types.StreamDict:
+		if sd != nil {
+			return sd.Dict
+		}
+	}
+	return nil
+}
+
+// sampleColor converts one gray, RGB or CMYK sample to RGBA
+func sampleColor(sample []byte) color.RGBA {
+	switch len(sample) {
+	case 1:
+		return color.RGBA{sample[0], sample[0], sample[0], 255}
+	case 3:
+		return color.RGBA{sample[0], sample[1], sample[2], 255}
+	case 4:
+		return cmykToRGB(sample[0], sample[1], sample[2], sample[3])
+	}
+	return color.RGBA{A: 255}
+}
+
+// decodeIndexedImage looks up each sample of an Indexed image in palette. Samples of 1,
+// 2, 4 or 8 bits are packed into rows that start on a byte boundary; indexes past the
+// end of the palette are drawn black.
+func decodeIndexedImage(data []byte, width, height, bpc int, palette color.Palette) (image.Image, error) {
+	if bpc != 1 && bpc != 2 && bpc != 4 && bpc != 8 {
+		return nil, fmt.Errorf("unsupported Indexed image with %d bits per component", bpc)
+	}
+	stride := (width*bpc + 7) / 8
+	if len(data) < stride*height {
+		return nil, fmt.Errorf("data size mismatch: got %d, expected %d", len(data), stride*height)
+	}
+
+	img := image.NewRGBA(image.Rect(0, 0, width, height))
+	mask := byte(1<<bpc - 1)
+	for y := 0; y < height; y++ {
+		row := data[y*stride : (y+1)*stride]
+		for x := 0; x < width; x++ {
+			bit := x * bpc
+			index := int(row[bit/8]>>(8-bpc-bit%8)) & int(mask)
+			c := color.RGBA{A: 255}
+			if index < len(palette) {
+				c = color.RGBAModel.Convert(palette[index]).(color.RGBA)
+			}
+			img.SetRGBA(x, y, c)
+		}
+	}
+	return img, nil
+}
+
+// decodeInkImage renders an 8-bit Separation or DeviceN image as gray, the darkness of
+// each pixel being its strongest ink. The tint transform to the alternate space is not
+// evaluated; for the black separations barcodes are printed with this is exact.
+func decodeInkImage(data []byte, width, height, bpc, components int) (image.Image, error) {
+	if bpc != 8 {
+		return nil, fmt.Errorf("unsupported ink image with %d bits per component", bpc)
+	}
+	if len(data) < width*height*components {
+		return nil, fmt.Errorf("data size mismatch: got %d, expected %d", len(data), width*height*components)
+	}
+
+	img := image.NewGray(image.Rect(0, 0, width, height))
+	for i := range img.Pix {
+		var ink byte
+		for _, v := range data[i*components : (i+1)*components] {
+			ink = max(ink, v)
+		}
+		img.Pix[i] = 255 - ink
+	}
+	return img, nil
+}
@@ -0,0 +1,119 @@
+package vergilevhasi
+
+import (
+	"image/color"
+	"reflect"
+	"testing"
+
+	"github.com/pdfcpu/pdfcpu/pkg/pdfcpu/model"
+	"github.com/pdfcpu/pdfcpu/pkg/pdfcpu/types"
+)
+
+var (
+	white = color.RGBA{255, 255, 255, 255}
+	black = color.RGBA{0, 0, 0, 255}
+	red   = color.RGBA{255, 0, 0, 255}
+)
+
+func TestParseColorSpace(t *testing.T) {
+	objects := objectTable{
+		5: types.StreamDict{Dict: types.Dict{"N": types.Integer(3)}},
+		6: types.Array{types.Name("Black")},
+		7: types.StreamDict{Dict: types.Dict{"N": types.Integer(4)}},
+	}
+
+	tests := []struct {
+		name string
+		cs   types.Object
+		want *rawColorSpace
+	}{
+		{"DeviceGray", types.Name("DeviceGray"), &rawColorSpace{components: 1}},
+		{"ICCBased RGB", types.Array{types.Name("ICCBased"), ref(5)}, &rawColorSpace{components: 3}},
+		{
+			"Indexed RGB",
+			types.Array{types.Name("Indexed"), types.Name("DeviceRGB"), types.Integer(2), types.HexLiteral("FFFFFF000000FF0000")},
+			&rawColorSpace{components: 1, palette: color.Palette{white, black, red}},
+		},
+		{
+			"Indexed over ICCBased CMYK",
+			types.Array{types.Name("Indexed"), types.Array{types.Name("ICCBased"), ref(7)}, types.Integer(1), types.StringLiteral(`\000\000\000\000\000\000\000\377`)},
+			&rawColorSpace{components: 1, palette: color.Palette{white, black}},
+		},
+		{"Separation", types.Array{types.Name("Separation"), types.Name("Black"), types.Name("DeviceCMYK")}, &rawColorSpace{components: 1, ink: true}},
+		{"DeviceN", types.Array{types.Name("DeviceN"), ref(6), types.Name("DeviceCMYK")}, &rawColorSpace{components: 1, ink: true}},
+		{"Unknown", types.Name("Pattern"), nil},
+		{"Short lookup", types.Array{types.Name("Indexed"), types.Name("DeviceRGB"), types.Integer(1), types.HexLiteral("FF")}, nil},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := parseColorSpace(objects, tt.cs, 0); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("parseColorSpace() = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestImageColorSpace(t *testing.T) {
+	objects := objectTable{
+		9: types.StreamDict{Dict: types.Dict{
+			"Subtype":    types.Name("Image"),
+			"ColorSpace": types.Array{types.Name("Indexed"), types.Name("DeviceGray"), types.Integer(1), types.HexLiteral("FF00")},
+		}},
+	}
+
+	want := &rawColorSpace{components: 1, palette: color.Palette{white, black}}
+	if got := imageColorSpace(objects, 9); !reflect.DeepEqual(got, want) {
+		t.Errorf("imageColorSpace() = %+v, want %+v", got, want)
+	}
+	if got := imageColorSpace(objects, 10); got != nil {
+		t.Errorf("imageColorSpace() of a missing object = %+v, want nil", got)
+	}
+}
+
+func TestDecodeRawImageDataIndexed(t *testing.T) {
+	// A two-color barcode strip: white, black, black, white, red as palette indexes
+	palette := color.Palette{white, black, red}
+	cs := &rawColorSpace{components: 1, palette: palette}
+	want := []color.RGBA{white, black, black, white, red}
+
+	tests := []struct {
+		name string
+		bpc  int
+		data []byte
+	}{
+		{"8 bits", 8, []byte{0, 1, 1, 0, 2, 0, 1, 1, 0, 2}},
+		// Two rows of 5 two-bit samples, each row padded to 2 bytes
+		{"2 bits", 2, []byte{0b00010100, 0b10000000, 0b00010100, 0b10000000}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			b := &pdfcpuBackend{}
+			// pdfcpu reports Indexed images as one gray component
+			img, err := b.decodeRawImageData(tt.data, model.Image{Width: 5, Height: 2, Bpc: tt.bpc, Comp: 1, Cs: "Indexed"}, cs)
+			if err != nil {
+				t.Fatalf("decodeRawImageData() error = %v", err)
+			}
+			for y := 0; y < 2; y++ {
+				for x, w := range want {
+					if got := color.RGBAModel.Convert(img.At(x, y)); got != w {
+						t.Errorf("pixel (%d,%d) = %v, want %v", x, y, got, w)
+					}
+				}
+			}
+		})
+	}
+}
+
+func TestDecodeRawImageDataSeparation(t *testing.T) {
+	b := &pdfcpuBackend{}
+	img, err := b.decodeRawImageData([]byte{0, 255, 128}, model.Image{Width: 3, Height: 1, Bpc: 8, Comp: 1, Cs: "Separation"}, &rawColorSpace{components: 1, ink: true})
+	if err != nil {
+		t.Fatalf("decodeRawImageData() error = %v", err)
+	}
+	for x, want := range []uint8{255, 0, 127} {
+		if got := color.GrayModel.Convert(img.At(x, 0)).(color.Gray).Y; got != want {
+			t.Errorf("pixel %d = %d, want %d", x, got, want)
+		}
+	}
+}
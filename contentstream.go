@@ -0,0 +1,187 @@
+package vergilevhasi
+
+import "strings"
+
+// contentTokenKind identifies the kind of a content stream token
+type contentTokenKind int
+
+const (
+	// tokenOperator is an operator such as Tj, Td or BT
+	tokenOperator contentTokenKind = iota
+	// tokenNumber is an integer or real operand
+	tokenNumber
+	// tokenName is a name operand such as /F1, including the leading slash
+	tokenName
+	// tokenLiteralString is a (...) string; Value holds the contents with escapes unresolved
+	tokenLiteralString
+	// tokenHexString is a <...> string; Value holds the hex digits without whitespace
+	tokenHexString
+	// tokenArray is a [...] array; Elems holds its elements
+	tokenArray
+	// tokenDict is an inline << ... >> dictionary; Elems holds its keys and values
+	tokenDict
+
+	// tokenArrayEnd and tokenDictEnd close an array or dictionary; next never returns them
+	tokenArrayEnd
+	tokenDictEnd
+)
+
+// contentToken is a lexical token of a page content stream
+type contentToken struct {
+	Kind  contentTokenKind
+	Value string
+	Elems []contentToken
+}
+
+// isString reports whether the token is a literal or hex string
+func (t contentToken) isString() bool {
+	return t.Kind == tokenLiteralString || t.Kind == tokenHexString
+}
+
+// contentTokenizer splits a page content stream into tokens in document order, so that
+// literal strings, hex strings and arrays are seen in the order they are shown
+type contentTokenizer struct {
+	content string
+	pos     int
+	depth   int
+}
+
+// maxContentNesting bounds array and dictionary nesting; content streams never nest deeply,
+// and the limit keeps hostile input from recursing without bound
+const maxContentNesting = 32
+
+// newContentTokenizer returns a tokenizer positioned at the start of content
+func newContentTokenizer(content string) *contentTokenizer {
+	return &contentTokenizer{content: content}
+}
+
+// tokenizeContent returns all tokens of content
+func tokenizeContent(content string) []contentToken {
+	var tokens []contentToken
+	t := newContentTokenizer(content)
+	for {
+		tok, ok := t.next()
+		if !ok {
+			return tokens
+		}
+		tokens = append(tokens, tok)
+	}
+}
+
+// next returns the next token, or false at the end of the stream. Comments, stray closing
+// delimiters and inline image data (BI ... EI, returned as the BI operator) are skipped.
+func (t *contentTokenizer) next() (contentToken, bool) {
+	for {
+		tok, ok := t.read()
+		if !ok {
+			return contentToken{}, false
+		}
+		if tok.Kind != tokenArrayEnd && tok.Kind != tokenDictEnd {
+			return tok, true
+		}
+	}
+}
+
+// read returns the next token including array and dictionary ends
+func (t *contentTokenizer) read() (contentToken, bool) {
+	content := t.content
+	for t.pos < len(content) {
+		ch := content[t.pos]
+		switch {
+		case isPDFWhitespace(ch):
+			t.pos++
+		case ch == '%':
+			for t.pos < len(content) && content[t.pos] != '\n' && content[t.pos] != '\r' {
+				t.pos++
+			}
+		case ch == '(':
+			str, end := extractPDFString(content, t.pos)
+			if end <= t.pos {
+				t.pos++
+				continue
+			}
+			t.pos = end
+			return contentToken{Kind: tokenLiteralString, Value: str}, true
+		case ch == '<' && t.pos+1 < len(content) && content[t.pos+1] == '<':
+			t.pos += 2
+			if t.depth >= maxContentNesting {
+				continue
+			}
+			return contentToken{Kind: tokenDict, Elems: t.readUntil(tokenDictEnd)}, true
+		case ch == '>' && t.pos+1 < len(content) && content[t.pos+1] == '>':
+			t.pos += 2
+			return contentToken{Kind: tokenDictEnd}, true
+		case ch == '<':
+			end := strings.IndexByte(content[t.pos:], '>')
+			if end < 0 {
+				t.pos = len(content)
+				return contentToken{}, false
+			}
+			hex := stripPDFWhitespace(content[t.pos+1 : t.pos+end])
+			t.pos += end + 1
+			return contentToken{Kind: tokenHexString, Value: hex}, true
+		case ch == '[':
+			t.pos++
+			if t.depth >= maxContentNesting {
+				continue
+			}
+			return contentToken{Kind: tokenArray, Elems: t.readUntil(tokenArrayEnd)}, true
+		case ch == ']':
+			t.pos++
+			return contentToken{Kind: tokenArrayEnd}, true
+		case ch == '{' || ch == '}' || ch == '>' || ch == ')':
+			t.pos++
+		case ch == '/':
+			j := t.pos + 1
+			for j < len(content) && !isPDFWhitespace(content[j]) && !isPDFDelimiter(content[j]) {
+				j++
+			}
+			name := content[t.pos:j]
+			t.pos = j
+			return contentToken{Kind: tokenName, Value: name}, true
+		default:
+			j := t.pos
+			for j < len(content) && !isPDFWhitespace(content[j]) && !isPDFDelimiter(content[j]) {
+				j++
+			}
+			if j == t.pos {
+				j++
+			}
+			word := content[t.pos:j]
+			t.pos = j
+
+			if isPDFNumber(word) {
+				return contentToken{Kind: tokenNumber, Value: word}, true
+			}
+			if word == "BI" {
+				// Skip inline image data up to the EI operator
+				if end := strings.Index(content[t.pos:], "EI"); end >= 0 {
+					t.pos += end + 2
+				} else {
+					t.pos = len(content)
+				}
+			}
+			return contentToken{Kind: tokenOperator, Value: word}, true
+		}
+	}
+	return contentToken{}, false
+}
+
+// readUntil reads the elements of an array or dictionary up to its closing token
+func (t *contentTokenizer) readUntil(end contentTokenKind) []contentToken {
+	t.depth++
+	defer func() { t.depth-- }()
+
+	var elems []contentToken
+	for {
+		tok, ok := t.read()
+		if !ok || tok.Kind == end {
+			return elems
+		}
+		if tok.Kind == tokenArrayEnd || tok.Kind == tokenDictEnd {
+			// Mismatched closing delimiter; ignore it as the byte scanner did
+			continue
+		}
+		elems = append(elems, tok)
+	}
+}
@@ -0,0 +1,121 @@
+package vergilevhasi
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestTokenizeContent(t *testing.T) {
+	content := "% page 1\n" +
+		"/P << /MCID 0 >> BDC\n" +
+		"BT /F1 12 Tf 1 0 0 1 50.5 700 Tm\n" +
+		"(Ali \\(Ornek\\)) Tj\n" +
+		"[(Ver) -250 <4749> (\\335)] TJ\n" +
+		"BI /W 2 /H 1 ID \x00\xff EI\n" +
+		"ET EMC"
+
+	want := []contentToken{
+		{Kind: tokenName, Value: "/P"},
+		{Kind: tokenDict, Elems: []contentToken{{Kind: tokenName, Value: "/MCID"}, {Kind: tokenNumber, Value: "0"}}},
+		{Kind: tokenOperator, Value: "BDC"},
+		{Kind: tokenOperator, Value: "BT"},
+		{Kind: tokenName, Value: "/F1"},
+		{Kind: tokenNumber, Value: "12"},
+		{Kind: tokenOperator, Value: "Tf"},
+		{Kind: tokenNumber, Value: "1"},
+		{Kind: tokenNumber, Value: "0"},
+		{Kind: tokenNumber, Value: "0"},
+		{Kind: tokenNumber, Value: "1"},
+		{Kind: tokenNumber, Value: "50.5"},
+		{Kind: tokenNumber, Value: "700"},
+		{Kind: tokenOperator, Value: "Tm"},
+		{Kind: tokenLiteralString, Value: `Ali \(Ornek\)`},
+		{Kind: tokenOperator, Value: "Tj"},
+		{Kind: tokenArray, Elems: []contentToken{
+			{Kind: tokenLiteralString, Value: "Ver"},
+			{Kind: tokenNumber, Value: "-250"},
+			{Kind: tokenHexString, Value: "4749"},
+			{Kind: tokenLiteralString, Value: `\335`},
+		}},
+		{Kind: tokenOperator, Value: "TJ"},
+		{Kind: tokenOperator, Value: "BI"},
+		{Kind: tokenOperator, Value: "ET"},
+		{Kind: tokenOperator, Value: "EMC"},
+	}
+
+	if got := tokenizeContent(content); !reflect.DeepEqual(got, want) {
+		t.Errorf("tokenizeContent() =\n%+v\nwant\n%+v", got, want)
+	}
+}
+
+func TestTokenizeContentMalformed(t *testing.T) {
+	// Unterminated constructs end the stream instead of panicking or looping
+	for _, content := range []string{"[(A) (B", "<< /A (x)", "<4142", "(unterminated", "]]>>) Tj", "BI /W 1 ID xx"} {
+		tokenizeContent(content)
+	}
+
+	// An unterminated string runs to the end of the stream and closes the array
+	if got := tokenizeContent("[(A) (B"); len(got) != 1 || got[0].Kind != tokenArray || len(got[0].Elems) != 2 {
+		t.Errorf("tokenizeContent() of an unterminated array = %+v, want one array with two strings", got)
+	}
+}
+
+func TestExtractTextFromPDFContentDocumentOrder(t *testing.T) {
+	// Literal and hex strings interleaved, and a word split across a TJ array
+	content := "BT (ADI SOYADI) Tj 0 -20 Td <414C49204F524E454B> Tj 0 -20 Td " +
+		"[(VERG) 20 <DD> -15 ( DA) <DD> (RES) <DD>] TJ 0 -20 Td (ORNEK VD) ' ET"
+
+	want := "ADI SOYADI\nALI ORNEK\nVERGİ DAİRESİ\nORNEK VD\n"
+	if got := extractTextFromPDFContent(content); got != want {
+		t.Errorf("extractTextFromPDFContent() = %q, want %q", got, want)
+	}
+}
+
+func TestExtractTextFromPDFContentSplitLabel(t *testing.T) {
+	tests := []struct {
+		name    string
+		content string
+		want    string
+	}{
+		{
+			// Each Windows-1254 İ shown by its own operator, with no move in between
+			"Pieces drawn straight after each other",
+			"BT /F1 10 Tf 50 700 Td (VERG) Tj <DD> Tj ( DA) Tj <DD> Tj (RES) Tj <DD> Tj 0 -20 Td (ORNEK VD) Tj ET",
+			"VERGİ DAİRESİ\nORNEK VD\n",
+		},
+		{
+			"Words moved apart on the same line",
+			"BT /F1 10 Tf 50 700 Td (VERGI) Tj 32 0 Td (DAIRESI) Tj 0 -20 Td (ORNEK VD) Tj ET",
+			"VERGI DAIRESI\nORNEK VD\n",
+		},
+		{
+			"Value column stays apart",
+			"BT /F1 10 Tf 50 700 Td (VERGI DAIRESI) Tj 200 0 Td (ORNEK VD) Tj ET",
+			"VERGI DAIRESI\nORNEK VD\n",
+		},
+		{
+			"New text object starts a new line",
+			"BT /F1 10 Tf 50 700 Td (VERGI) Tj ET BT /F1 10 Tf 82 700 Td (DAIRESI) Tj ET",
+			"VERGI\nDAIRESI\n",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := extractTextFromPDFContent(tt.content); got != tt.want {
+				t.Errorf("extractTextFromPDFContent() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestParseContentSplitLabel(t *testing.T) {
+	// The name label shown word by word, with the name on the line below
+	content := "BT /F1 10 Tf 50 700 Td (ADI) Tj 20 0 Td (SOYADI) Tj 0 -20 Td (ALI ORNEK) Tj ET"
+
+	vl := &VergiLevhasi{}
+	NewParser().parseContent(vl, extractTextFromPDFContent(content))
+	if vl.AdiSoyadi != "ALI ORNEK" {
+		t.Errorf("AdiSoyadi = %q, want %q", vl.AdiSoyadi, "ALI ORNEK")
+	}
+}
@@ -0,0 +1,128 @@
+package vergilevhasi
+
+import (
+	"encoding/json"
+	"fmt"
+	"sync/atomic"
+	"time"
+)
+
+// DateFormat selects how the dates of a VergiLevhasi are written to JSON
+type DateFormat int32
+
+const (
+	// DateFormatISO writes dates as RFC 3339 / ISO-8601 timestamps
+	// ("2020-02-01T00:00:00Z"), like encoding/json does for time.Time. This is the default.
+	DateFormatISO DateFormat = iota
+
+	// DateFormatTurkish writes dates as printed on the plate, "01.02.2020", adding the
+	// time of day ("01.02.2020 14:30:05") when there is one, as for OlusturulmaTarihi
+	DateFormatTurkish
+)
+
+// Turkish date layouts, with and without the time of day
+const (
+	turkishDateLayout     = "02.01.2006"
+	turkishDateTimeLayout = "02.01.2006 15:04:05"
+)
+
+// jsonDateFormat is the format used by VergiLevhasi.MarshalJSON
+var jsonDateFormat atomic.Int32
+
+// SetJSONDateFormat sets how VergiLevhasi dates are serialized to JSON, for every
+// VergiLevhasi in the program. Unmarshaling accepts both formats regardless.
+func SetJSONDateFormat(format DateFormat) {
+	jsonDateFormat.Store(int32(format))
+}
+
+// JSONDateFormat returns the format set with SetJSONDateFormat
+func JSONDateFormat() DateFormat {
+	return DateFormat(jsonDateFormat.Load())
+}
+
+// jsonDate is a date that marshals in the configured DateFormat and unmarshals from either
+type jsonDate struct {
+	time.Time
+}
+
+// newJSONDate wraps t, keeping nil as nil so omitempty still applies
+func newJSONDate(t *time.Time) *jsonDate {
+	if t == nil {
+		return nil
+	}
+	return &jsonDate{*t}
+}
+
+// timePtr unwraps d
+func (d *jsonDate) timePtr() *time.Time {
+	if d == nil {
+		return nil
+	}
+	t := d.Time
+	return &t
+}
+
+// MarshalJSON writes the date in the configured DateFormat
+func (d jsonDate) MarshalJSON() ([]byte, error) {
+	if JSONDateFormat() != DateFormatTurkish {
+		return d.Time.MarshalJSON()
+	}
+	return json.Marshal(formatDate(d.Time))
+}
+
+// formatDate writes t as a string in the configured DateFormat
+func formatDate(t time.Time) string {
+	if JSONDateFormat() != DateFormatTurkish {
+		return t.Format(time.RFC3339Nano)
+	}
+	layout := turkishDateLayout
+	if h, m, s := t.Clock(); h != 0 || m != 0 || s != 0 {
+		layout = turkishDateTimeLayout
+	}
+	return t.Format(layout)
+}
+
+// UnmarshalJSON reads an RFC 3339 timestamp or a Turkish date
+func (d *jsonDate) UnmarshalJSON(data []byte) error {
+	var s string
+	if err := json.Unmarshal(data, &s); err != nil {
+		return fmt.Errorf("invalid date %s: %w", data, err)
+	}
+	for _, layout := range []string{time.RFC3339Nano, turkishDateTimeLayout, turkishDateLayout} {
+		if t, err := time.Parse(layout, s); err == nil {
+			d.Time = t
+			return nil
+		}
+	}
+	return fmt.Errorf("invalid date %q", s)
+}
+
+// vergiLevhasiJSON is VergiLevhasi without its JSON methods
+type vergiLevhasiJSON VergiLevhasi
+
+// vergiLevhasiDates overrides the date fields of VergiLevhasi with jsonDate
+type vergiLevhasiDates struct {
+	*vergiLevhasiJSON
+	IseBaslamaTarihi  *jsonDate `json:"ise_baslama_tarihi,omitempty"`
+	OlusturulmaTarihi *jsonDate `json:"olusturulma_tarihi,omitempty"`
+}
+
+// MarshalJSON writes the dates in the format set with SetJSONDateFormat
+func (v VergiLevhasi) MarshalJSON() ([]byte, error) {
+	return json.Marshal(vergiLevhasiDates{
+		vergiLevhasiJSON:  (*vergiLevhasiJSON)(&v),
+		IseBaslamaTarihi:  newJSONDate(v.IseBaslamaTarihi),
+		OlusturulmaTarihi: newJSONDate(v.OlusturulmaTarihi),
+	})
+}
+
+// UnmarshalJSON reads dates in either format
+func (v *VergiLevhasi) UnmarshalJSON(data []byte) error {
+	aux := vergiLevhasiDates{vergiLevhasiJSON: (*vergiLevhasiJSON)(v)}
+	if err := json.Unmarshal(data, &aux); err != nil {
+		return err
+	}
+	v.IseBaslamaTarihi = aux.IseBaslamaTarihi.timePtr()
+	v.OlusturulmaTarihi = aux.OlusturulmaTarihi.timePtr()
+	return nil
+}
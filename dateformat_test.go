@@ -0,0 +1,74 @@
+package vergilevhasi
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestMarshalJSONDateFormat(t *testing.T) {
+	t.Cleanup(func() { SetJSONDateFormat(DateFormatISO) })
+
+	start := time.Date(2020, 2, 1, 0, 0, 0, 0, time.UTC)
+	generated := time.Date(2024, 3, 15, 14, 30, 5, 0, time.UTC)
+	vl := VergiLevhasi{
+		AdiSoyadi:         "ALİ ÖRNEK",
+		VergiKimlikNo:     "1234567890",
+		IseBaslamaTarihi:  &start,
+		OlusturulmaTarihi: &generated,
+	}
+
+	tests := []struct {
+		format DateFormat
+		want   []string
+	}{
+		{DateFormatISO, []string{`"ise_baslama_tarihi":"2020-02-01T00:00:00Z"`, `"olusturulma_tarihi":"2024-03-15T14:30:05Z"`}},
+		{DateFormatTurkish, []string{`"ise_baslama_tarihi":"01.02.2020"`, `"olusturulma_tarihi":"15.03.2024 14:30:05"`}},
+	}
+
+	for _, tt := range tests {
+		SetJSONDateFormat(tt.format)
+
+		data, err := json.Marshal(vl)
+		if err != nil {
+			t.Fatalf("json.Marshal() error = %v", err)
+		}
+		for _, want := range append(tt.want, `"adi_soyadi":"ALİ ÖRNEK"`, `"vergi_kimlik_no":"1234567890"`) {
+			if !strings.Contains(string(data), want) {
+				t.Errorf("format %d: json.Marshal() = %s, want it to contain %s", tt.format, data, want)
+			}
+		}
+
+		// Pointers marshal the same, and both formats read back to the same dates
+		ptrData, err := json.Marshal(&vl)
+		if err != nil || string(ptrData) != string(data) {
+			t.Errorf("format %d: json.Marshal(&vl) = %s, %v; want %s", tt.format, ptrData, err, data)
+		}
+		var got VergiLevhasi
+		if err := json.Unmarshal(data, &got); err != nil {
+			t.Fatalf("format %d: json.Unmarshal() error = %v", tt.format, err)
+		}
+		if !got.Equal(&vl) || got.OlusturulmaTarihi == nil || !got.OlusturulmaTarihi.Equal(generated) {
+			t.Errorf("format %d: round trip = %+v, want %+v", tt.format, got, vl)
+		}
+	}
+}
+
+func TestMarshalJSONOmitsMissingDates(t *testing.T) {
+	t.Cleanup(func() { SetJSONDateFormat(DateFormatISO) })
+	SetJSONDateFormat(DateFormatTurkish)
+
+	data, err := json.Marshal(VergiLevhasi{AdiSoyadi: "ALİ ÖRNEK"})
+	if err != nil {
+		t.Fatalf("json.Marshal() error = %v", err)
+	}
+	if strings.Contains(string(data), "tarihi") {
+		t.Errorf("json.Marshal() = %s, want no date fields", data)
+	}
+
+	var vl VergiLevhasi
+	if err := json.Unmarshal([]byte(`{"ise_baslama_tarihi":"2020-13-45"}`), &vl); err == nil {
+		t.Error("json.Unmarshal() of an invalid date should return an error")
+	}
+}
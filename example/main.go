@@ -88,6 +88,10 @@ func main() {
 	if len(result.GecmisMatra) > 0 {
 		fmt.Println("\nGeçmiş Matrahlar:")
 		for _, gm := range result.GecmisMatra {
+			if gm.Vergi > 0 {
+				fmt.Printf("  • %d: %.2f TL (tahakkuk eden vergi: %.2f TL)\n", gm.Yil, gm.Tutar, gm.Vergi)
+				continue
+			}
 			fmt.Printf("  • %d: %.2f TL\n", gm.Yil, gm.Tutar)
 		}
 	}
@@ -0,0 +1,77 @@
+package vergilevhasi
+
+import "strings"
+
+// SetFieldPages enables recording the source page of each extracted field in
+// VergiLevhasi.FieldPages. It is off by default.
+func (p *Parser) SetFieldPages(enabled bool) {
+	p.fieldPages = enabled
+}
+
+// attributeFieldPages maps each populated field, by its JSON name, to the 1-based number
+// of the first page whose text contains the field's value. Comparison ignores case and
+// whitespace differences. List fields are attributed by their first entry, dates by their
+// printed DD.MM.YYYY form. Values that appear on no page (for example a VKN read only from
+// the barcode image, or a snapped tax office name) are left out.
+func attributeFieldPages(vl *VergiLevhasi, pages []PageText) map[string]int {
+	normalized := make([]string, len(pages))
+	for i, page := range pages {
+		normalized[i] = normalizeForPageSearch(page.Text)
+	}
+
+	result := make(map[string]int)
+	attribute := func(key, value string) {
+		value = normalizeForPageSearch(value)
+		if value == "" {
+			return
+		}
+		for i, text := range normalized {
+			if strings.Contains(text, value) {
+				result[key] = pages[i].Number
+				return
+			}
+		}
+	}
+
+	attribute("adi_soyadi", vl.AdiSoyadi)
+	attribute("ticaret_unvani", vl.TicaretUnvani)
+	attribute("is_yeri_adresi", vl.IsYeriAdresi)
+	attribute("gelir_unsuru", vl.GelirUnsuru)
+	attribute("vergi_dairesi", vl.VergiDairesi)
+	attribute("vergi_kimlik_no", vl.VergiKimlikNo)
+	attribute("sube_kodu", vl.SubeKodu)
+	attribute("tc_kimlik_no", vl.TCKimlikNo)
+	attribute("uyruk", vl.Uyruk)
+	attribute("pasaport_no", vl.PasaportNo)
+	attribute("kayit_no", vl.KayitNo)
+	attribute("yabanci_vergi_no", vl.YabanciVergiNo)
+
+	if len(vl.VergiTuru) > 0 {
+		attribute("vergi_turu", vl.VergiTuru[0])
+	}
+	if len(vl.Ortaklar) > 0 {
+		attribute("ortaklar", vl.Ortaklar[0])
+	}
+	if len(vl.OkcSeriNolari) > 0 {
+		attribute("okc_seri_nolari", vl.OkcSeriNolari[0])
+	}
+	if len(vl.FaaliyetKodlari) > 0 {
+		attribute("faaliyet_kodlari", vl.FaaliyetKodlari[0].Kod)
+	}
+	if vl.IseBaslamaTarihi != nil {
+		attribute("ise_baslama_tarihi", vl.IseBaslamaTarihi.Format("02.01.2006"))
+	}
+	if vl.OlusturulmaTarihi != nil {
+		attribute("olusturulma_tarihi", vl.OlusturulmaTarihi.Format("02.01.2006"))
+	}
+
+	if len(result) == 0 {
+		return nil
+	}
+	return result
+}
+
+// normalizeForPageSearch lowercases s and collapses runs of whitespace into single spaces
+func normalizeForPageSearch(s string) string {
+	return strings.Join(strings.Fields(strings.ToLower(s)), " ")
+}
@@ -0,0 +1,50 @@
+package vergilevhasi
+
+import (
+	"bytes"
+	"reflect"
+	"testing"
+)
+
+func TestParseFieldPages(t *testing.T) {
+	// Synthetic two-page document: identity on the first page, the rest on the second
+	backend := &fakeBackend{pages: []PageText{
+		{Number: 1, Text: "Adı Soyadı: Ali Örnek\nVergi Kimlik No: 1234567890\n"},
+		{Number: 2, Text: "İş Yeri Adresi: Örnek Mah. Test Cad.  No:1 Çankaya/Ankara\nVergi Dairesi: Örnek VD\nİşe Başlama Tarihi: 01.02.2020\n"},
+	}}
+
+	parser := NewParser()
+	parser.SetBackend(backend)
+	parser.SetFieldPages(true)
+
+	vl, err := parser.Parse(bytes.NewReader([]byte("not a real pdf")))
+	if err != nil {
+		t.Fatalf("Parse() error = %v", err)
+	}
+
+	want := map[string]int{
+		"adi_soyadi":         1,
+		"vergi_kimlik_no":    1,
+		"is_yeri_adresi":     2,
+		"vergi_dairesi":      2,
+		"ise_baslama_tarihi": 2,
+	}
+	if !reflect.DeepEqual(vl.FieldPages, want) {
+		t.Errorf("FieldPages = %v, want %v", vl.FieldPages, want)
+	}
+}
+
+func TestParseFieldPagesDisabledByDefault(t *testing.T) {
+	backend := &fakeBackend{pages: []PageText{{Number: 1, Text: "Adı Soyadı: Ali Örnek\n"}}}
+
+	parser := NewParser()
+	parser.SetBackend(backend)
+
+	vl, err := parser.Parse(bytes.NewReader([]byte("not a real pdf")))
+	if err != nil {
+		t.Fatalf("Parse() error = %v", err)
+	}
+	if vl.FieldPages != nil {
+		t.Errorf("FieldPages = %v, want nil when not enabled", vl.FieldPages)
+	}
+}
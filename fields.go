@@ -0,0 +1,207 @@
+package vergilevhasi
+
+import (
+	"encoding/json"
+	"fmt"
+)
+
+// FieldSet is a bit mask selecting which VergiLevhasi fields the parser extracts
+type FieldSet uint32
+
+// Fields that can be selected with Parser.SetFields
+const (
+	FieldAdiSoyadi FieldSet = 1 << iota
+	FieldTicaretUnvani
+	FieldIsYeriAdresi
+	FieldVergiTuru
+	FieldFaaliyetKodlari
+	FieldVergiDairesi
+	FieldVergiKimlikNo
+	FieldSubeKodu
+	FieldTCKimlikNo
+	FieldIseBaslamaTarihi
+	FieldOlusturulmaTarihi
+	FieldGecmisMatrahlar
+	FieldGelirUnsuru
+	FieldUyruk
+	FieldPasaportNo
+	FieldKayitNo
+	FieldIsYeriTuru
+	FieldOrtaklar
+	FieldYabanciVergiNo
+	FieldVergiUsulu
+	FieldOkcSeriNolari
+
+	// AllFields selects every field; this is the default
+	AllFields FieldSet = 1<<iota - 1
+)
+
+// fieldNames pairs each field with its JSON name, the name FieldSet is written with in
+// JSON. The names are listed explicitly so that reordering the fields above does not
+// change what a written FieldSet means.
+var fieldNames = []struct {
+	field FieldSet
+	name  string
+}{
+	{FieldAdiSoyadi, "adi_soyadi"},
+	{FieldTicaretUnvani, "ticaret_unvani"},
+	{FieldIsYeriAdresi, "is_yeri_adresi"},
+	{FieldVergiTuru, "vergi_turu"},
+	{FieldFaaliyetKodlari, "faaliyet_kodlari"},
+	{FieldVergiDairesi, "vergi_dairesi"},
+	{FieldVergiKimlikNo, "vergi_kimlik_no"},
+	{FieldSubeKodu, "sube_kodu"},
+	{FieldTCKimlikNo, "tc_kimlik_no"},
+	{FieldIseBaslamaTarihi, "ise_baslama_tarihi"},
+	{FieldOlusturulmaTarihi, "olusturulma_tarihi"},
+	{FieldGecmisMatrahlar, "gecmis_matrahlar"},
+	{FieldGelirUnsuru, "gelir_unsuru"},
+	{FieldUyruk, "uyruk"},
+	{FieldPasaportNo, "pasaport_no"},
+	{FieldKayitNo, "kayit_no"},
+	{FieldIsYeriTuru, "is_yeri_turu"},
+	{FieldOrtaklar, "ortaklar"},
+	{FieldYabanciVergiNo, "yabanci_vergi_no"},
+	{FieldVergiUsulu, "vergi_usulu"},
+	{FieldOkcSeriNolari, "okc_seri_nolari"},
+}
+
+// MarshalJSON writes the set as a list of the JSON names of its fields, e.g.
+// ["adi_soyadi","vergi_dairesi"]
+func (s FieldSet) MarshalJSON() ([]byte, error) {
+	names := []string{}
+	for _, f := range fieldNames {
+		if s.Has(f.field) {
+			names = append(names, f.name)
+		}
+	}
+	return json.Marshal(names)
+}
+
+// UnmarshalJSON reads a list of field JSON names written by MarshalJSON
+func (s *FieldSet) UnmarshalJSON(data []byte) error {
+	var names []string
+	if err := json.Unmarshal(data, &names); err != nil {
+		return fmt.Errorf("invalid field set %s: %w", data, err)
+	}
+	var set FieldSet
+	for _, name := range names {
+		field, ok := fieldByName(name)
+		if !ok {
+			return fmt.Errorf("unknown field %q", name)
+		}
+		set |= field
+	}
+	*s = set
+	return nil
+}
+
+// fieldByName returns the field with the given JSON name
+func fieldByName(name string) (FieldSet, bool) {
+	for _, f := range fieldNames {
+		if f.name == name {
+			return f.field, true
+		}
+	}
+	return 0, false
+}
+
+// Has reports whether every field in f is selected
+func (s FieldSet) Has(f FieldSet) bool {
+	return s&f == f
+}
+
+// SetFields restricts extraction to the fields in mask. Extraction passes for unselected
+// fields (such as activity codes or tax bases) are skipped, and any value an unselected
+// field would have picked up as a side effect of a shared pass is cleared.
+// DocumentType and RawText are always populated.
+func (p *Parser) SetFields(mask FieldSet) {
+	p.fields = mask
+}
+
+// wants reports whether field f should be extracted, notifying the extraction hook if so
+func (p *Parser) wants(f FieldSet) bool {
+	if !p.fields.Has(f) {
+		return false
+	}
+	if p.extractHook != nil {
+		p.extractHook(f)
+	}
+	return true
+}
+
+// applyFieldMask clears the fields that were not requested
+func (p *Parser) applyFieldMask(vl *VergiLevhasi) {
+	if p.fields == AllFields {
+		return
+	}
+	if !p.fields.Has(FieldAdiSoyadi) {
+		vl.AdiSoyadi = ""
+	}
+	if !p.fields.Has(FieldTicaretUnvani) {
+		vl.TicaretUnvani = ""
+	}
+	if !p.fields.Has(FieldAdiSoyadi) && !p.fields.Has(FieldTicaretUnvani) {
+		vl.HamUnvan = ""
+	}
+	if !p.fields.Has(FieldIsYeriAdresi) {
+		vl.IsYeriAdresi = ""
+		vl.IsYeriAdresleri = nil
+	}
+	if !p.fields.Has(FieldVergiTuru) {
+		vl.VergiTuru = nil
+	}
+	if !p.fields.Has(FieldFaaliyetKodlari) {
+		vl.FaaliyetKodlari = nil
+	}
+	if !p.fields.Has(FieldVergiDairesi) {
+		vl.VergiDairesi = ""
+	}
+	if !p.fields.Has(FieldVergiKimlikNo) {
+		vl.VergiKimlikNo = ""
+		vl.IsPotansiyelVKN = false
+	}
+	if !p.fields.Has(FieldSubeKodu) {
+		vl.SubeKodu = ""
+	}
+	if !p.fields.Has(FieldTCKimlikNo) {
+		vl.TCKimlikNo = ""
+		vl.UsesTCKNAsVergiNo = false
+	}
+	if !p.fields.Has(FieldIseBaslamaTarihi) {
+		vl.IseBaslamaTarihi = nil
+	}
+	if !p.fields.Has(FieldOlusturulmaTarihi) {
+		vl.OlusturulmaTarihi = nil
+	}
+	if !p.fields.Has(FieldGecmisMatrahlar) {
+		vl.GecmisMatra = nil
+	}
+	if !p.fields.Has(FieldGelirUnsuru) {
+		vl.GelirUnsuru = ""
+	}
+	if !p.fields.Has(FieldUyruk) {
+		vl.Uyruk = ""
+	}
+	if !p.fields.Has(FieldPasaportNo) {
+		vl.PasaportNo = ""
+	}
+	if !p.fields.Has(FieldKayitNo) {
+		vl.KayitNo = ""
+	}
+	if !p.fields.Has(FieldIsYeriTuru) {
+		vl.IsYeriTuru = IsYeriTuruBilinmiyor
+	}
+	if !p.fields.Has(FieldOrtaklar) {
+		vl.Ortaklar = nil
+	}
+	if !p.fields.Has(FieldYabanciVergiNo) {
+		vl.YabanciVergiNo = ""
+	}
+	if !p.fields.Has(FieldVergiUsulu) {
+		vl.VergiUsulu = VergiUsuluBilinmiyor
+	}
+	if !p.fields.Has(FieldOkcSeriNolari) {
+		vl.OkcSeriNolari = nil
+	}
+}
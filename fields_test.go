@@ -0,0 +1,106 @@
+package vergilevhasi
+
+import (
+	"encoding/json"
+	"testing"
+)
+
+const fieldMaskText = `
+Adı Soyadı: Ali Örnek
+TC Kimlik No: 11111111110
+Vergi Kimlik No: 1234567890
+Vergi Dairesi: Örnek VD
+İş Yeri Adresi: Örnek Mah. Test Cad. No:1, Ankara
+İşe Başlama Tarihi: 01.01.2020
+Gelir Vergisi
+4711 - Gıda, içecek ve tütün satışı
+2020 150.000,00 TL
+`
+
+func TestSetFieldsSkipsUnselectedFields(t *testing.T) {
+	parser := NewParser()
+	parser.SetFields(FieldVergiKimlikNo | FieldAdiSoyadi)
+
+	var ran FieldSet
+	parser.extractHook = func(f FieldSet) { ran |= f }
+
+	vl := &VergiLevhasi{}
+	parser.parseContent(vl, fieldMaskText)
+
+	if vl.VergiKimlikNo != "1234567890" {
+		t.Errorf("VergiKimlikNo = %q, want %q", vl.VergiKimlikNo, "1234567890")
+	}
+	if vl.AdiSoyadi != "Ali Örnek" {
+		t.Errorf("AdiSoyadi = %q, want %q", vl.AdiSoyadi, "Ali Örnek")
+	}
+
+	if vl.TCKimlikNo != "" || vl.VergiDairesi != "" || vl.IsYeriAdresi != "" {
+		t.Errorf("unselected string fields populated: TCKimlikNo=%q VergiDairesi=%q IsYeriAdresi=%q",
+			vl.TCKimlikNo, vl.VergiDairesi, vl.IsYeriAdresi)
+	}
+	if vl.IseBaslamaTarihi != nil {
+		t.Errorf("IseBaslamaTarihi = %v, want nil", vl.IseBaslamaTarihi)
+	}
+	if vl.VergiTuru != nil || vl.FaaliyetKodlari != nil || vl.GecmisMatra != nil {
+		t.Errorf("unselected slice fields populated: VergiTuru=%v FaaliyetKodlari=%v GecmisMatra=%v",
+			vl.VergiTuru, vl.FaaliyetKodlari, vl.GecmisMatra)
+	}
+
+	// The activity, tax base and ID passes must not run at all
+	for _, f := range []FieldSet{FieldFaaliyetKodlari, FieldGecmisMatrahlar, FieldTCKimlikNo, FieldIseBaslamaTarihi} {
+		if ran&f != 0 {
+			t.Errorf("extraction pass for field %b ran although it was not selected", f)
+		}
+	}
+}
+
+func TestSetFieldsDefaultAll(t *testing.T) {
+	parser := NewParser()
+
+	var ran FieldSet
+	parser.extractHook = func(f FieldSet) { ran |= f }
+
+	vl := &VergiLevhasi{}
+	parser.parseContent(vl, fieldMaskText)
+
+	if !ran.Has(FieldFaaliyetKodlari | FieldGecmisMatrahlar) {
+		t.Errorf("extraction passes ran = %b, want activity and tax base passes", ran)
+	}
+	if len(vl.FaaliyetKodlari) == 0 || len(vl.GecmisMatra) == 0 || vl.TCKimlikNo == "" {
+		t.Errorf("default parse missing fields: %+v", vl)
+	}
+}
+
+func TestFieldSetJSON(t *testing.T) {
+	// Every field has a name
+	for f := FieldSet(1); f&AllFields != 0; f <<= 1 {
+		data, err := json.Marshal(f)
+		if err != nil || string(data) == "[]" {
+			t.Errorf("json.Marshal(%b) = %s, %v; want the field's name", f, data, err)
+		}
+	}
+
+	data, err := json.Marshal(FieldAdiSoyadi | FieldVergiDairesi)
+	if err != nil {
+		t.Fatalf("json.Marshal() error = %v", err)
+	}
+	if string(data) != `["adi_soyadi","vergi_dairesi"]` {
+		t.Errorf("json.Marshal() = %s, want the field names", data)
+	}
+
+	for _, set := range []FieldSet{0, FieldVergiKimlikNo, AllFields &^ FieldVergiKimlikNo, AllFields} {
+		data, err := json.Marshal(set)
+		if err != nil {
+			t.Fatalf("json.Marshal(%b) error = %v", set, err)
+		}
+		var got FieldSet
+		if err := json.Unmarshal(data, &got); err != nil || got != set {
+			t.Errorf("json.Unmarshal(%s) = %b, %v; want %b", data, got, err, set)
+		}
+	}
+
+	var got FieldSet
+	if err := json.Unmarshal([]byte(`["adi_soyadi","bilinmeyen"]`), &got); err == nil {
+		t.Error("json.Unmarshal() of an unknown field name error = nil")
+	}
+}
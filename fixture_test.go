@@ -0,0 +1,135 @@
+package vergilevhasi
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+	"unicode"
+)
+
+// syntheticPlateText renders vl as the labelled text of a GİB tax plate, so text parsing
+// can be tested round-trip without PDF fixtures that carry real taxpayer data. Empty
+// fields are left out; tax bases are written as "YYYY 1.234.567,89 TL" rows.
+func syntheticPlateText(vl *VergiLevhasi) string {
+	var b strings.Builder
+	b.WriteString("GELİR İDARESİ BAŞKANLIĞI\nVERGİ LEVHASI\n")
+
+	label := func(name, value string) {
+		if value != "" {
+			fmt.Fprintf(&b, "%s: %s\n", name, value)
+		}
+	}
+	label("Adı Soyadı", vl.AdiSoyadi)
+	label("Ticaret Ünvanı", vl.TicaretUnvani)
+	label("İş Yeri Adresi", vl.IsYeriAdresi)
+	label("Vergi Dairesi", vl.VergiDairesi)
+	label("Vergi Kimlik No", vl.VergiKimlikNo)
+	label("TC Kimlik No", vl.TCKimlikNo)
+	if vl.IseBaslamaTarihi != nil {
+		label("İşe Başlama Tarihi", vl.IseBaslamaTarihi.Format("02.01.2006"))
+	}
+
+	for _, vt := range vl.VergiTuru {
+		b.WriteString(strings.ToUpperSpecial(unicode.TurkishCase, vt) + "\n")
+	}
+	for _, f := range vl.FaaliyetKodlari {
+		fmt.Fprintf(&b, "%s - %s\n", f.Kod, f.Ad)
+	}
+	for _, m := range vl.GecmisMatra {
+		fmt.Fprintf(&b, "%d %s TL\n", m.Yil, formatTutar(m.Tutar))
+	}
+
+	return b.String()
+}
+
+// formatTutar formats an amount the Turkish way, e.g. 1234567.89 as "1.234.567,89"
+func formatTutar(amount float64) string {
+	whole, frac, _ := strings.Cut(strconv.FormatFloat(amount, 'f', 2, 64), ".")
+
+	var grouped []string
+	for len(whole) > 3 {
+		grouped = append([]string{whole[len(whole)-3:]}, grouped...)
+		whole = whole[:len(whole)-3]
+	}
+	grouped = append([]string{whole}, grouped...)
+
+	return strings.Join(grouped, ".") + "," + frac
+}
+
+func TestFormatTutar(t *testing.T) {
+	for amount, want := range map[float64]string{
+		1000:       "1.000,00",
+		450000.5:   "450.000,50",
+		1234567.89: "1.234.567,89",
+	} {
+		if got := formatTutar(amount); got != want {
+			t.Errorf("formatTutar(%v) = %q, want %q", amount, got, want)
+		}
+	}
+}
+
+func TestSyntheticPlateTextRoundTrip(t *testing.T) {
+	date := time.Date(2020, 2, 1, 0, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		name string
+		vl   VergiLevhasi
+	}{
+		{
+			name: "Company",
+			vl: VergiLevhasi{
+				TicaretUnvani:    "ÖRNEK TEKNOLOJİ LİMİTED ŞİRKETİ",
+				IsYeriAdresi:     "ÖRNEK MAH. TEST CAD. NO:1 ÇANKAYA/ANKARA",
+				VergiDairesi:     "ÖRNEK VERGİ DAİRESİ",
+				VergiKimlikNo:    "1234567890",
+				IseBaslamaTarihi: &date,
+				VergiTuru:        []string{"Kurumlar Vergisi"},
+				FaaliyetKodlari:  []Faaliyet{{Kod: "620100", Ad: "BİLGİSAYAR PROGRAMLAMA FAALİYETLERİ"}},
+				GecmisMatra:      []Matrah{{Yil: 2022, Tutar: 400000, TutarKurus: 40000000}, {Yil: 2023, Tutar: 1234567.89, TutarKurus: 123456789}},
+				DocumentType:     DocumentTypeVergiLevhasi,
+				MukellefTuru:     MukellefTuruKurumsal,
+			},
+		},
+		{
+			name: "Individual",
+			vl: VergiLevhasi{
+				AdiSoyadi:        "ALİ ÖRNEK",
+				IsYeriAdresi:     "ÖRNEK MAH. TEST SOK. NO:2 KADIKÖY/İSTANBUL",
+				VergiDairesi:     "ÖRNEK VERGİ DAİRESİ",
+				TCKimlikNo:       "10000000146",
+				IseBaslamaTarihi: &date,
+				VergiTuru:        []string{"Gelir Vergisi"},
+				FaaliyetKodlari:  []Faaliyet{{Kod: "471101", Ad: "BAKKAL VE MARKETLERDE YAPILAN PERAKENDE TİCARET"}},
+				GecmisMatra:      []Matrah{{Yil: 2023, Tutar: 250000.5, TutarKurus: 25000050}},
+				DocumentType:     DocumentTypeVergiLevhasi,
+				MukellefTuru:     MukellefTuruBireysel,
+			},
+		},
+		{
+			name: "New business without tax bases",
+			vl: VergiLevhasi{
+				AdiSoyadi:     "AYŞE ÖRNEK",
+				VergiDairesi:  "ÖRNEK VERGİ DAİRESİ",
+				VergiKimlikNo: "1234567890",
+				VergiTuru:     []string{"Gelir Vergisi"},
+				DocumentType:  DocumentTypeVergiLevhasi,
+				MukellefTuru:  MukellefTuruBireysel,
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			text := syntheticPlateText(&tt.vl)
+
+			got := &VergiLevhasi{}
+			NewParser().parseContent(got, text)
+
+			if !got.Equal(&tt.vl) {
+				t.Errorf("parseContent(syntheticPlateText()) =\n%+v\nwant\n%+v\ntext:\n%s", *got, tt.vl, text)
+			}
+		})
+	}
+}
@@ -0,0 +1,200 @@
+package vergilevhasi
+
+import (
+	"strings"
+
+	"github.com/pdfcpu/pdfcpu/pkg/pdfcpu/types"
+)
+
+// objectResolver resolves indirect references; pdfcpu's *model.XRefTable implements it
+type objectResolver interface {
+	Dereference(o types.Object) (types.Object, error)
+}
+
+// maxFieldDepth bounds how far /Parent and /Kids chains of form fields are followed;
+// broken PDFs may contain cycles
+const maxFieldDepth = 32
+
+// formText collects the text that some plates carry in AcroForm fields and annotations
+// instead of the page content stream. Field values are written as "name: value" lines,
+// so a field named after a plate label ("Vergi Kimlik No") parses like printed text;
+// other annotations contribute their /Contents. Each line is written once per document.
+type formText struct {
+	r    objectResolver
+	seen map[string]bool
+}
+
+// newFormText returns a formText resolving objects through r
+func newFormText(r objectResolver) *formText {
+	return &formText{r: r, seen: make(map[string]bool)}
+}
+
+// pageText returns the text of the annotations of a page: widget field values and the
+// contents of other annotations such as notes and free text
+func (f *formText) pageText(pageDict types.Dict) string {
+	var b strings.Builder
+	for _, obj := range f.array(pageDict["Annots"]) {
+		annot := f.dict(obj)
+		if annot == nil {
+			continue
+		}
+		if subtype, ok := f.deref(annot["Subtype"]).(types.Name); ok && subtype == "Widget" {
+			f.writeField(&b, annot)
+			continue
+		}
+		if contents, ok := f.textString(annot["Contents"]); ok {
+			f.writeLines(&b, "", contents)
+		}
+	}
+	return b.String()
+}
+
+// fieldsText returns the values of the catalog's AcroForm fields that were not already
+// written for a widget on a page, e.g. fields without a visible widget
+func (f *formText) fieldsText(catalog types.Dict) string {
+	acroForm := f.dict(catalog["AcroForm"])
+	if acroForm == nil {
+		return ""
+	}
+	var b strings.Builder
+	f.writeFields(&b, f.array(acroForm["Fields"]), 0)
+	return b.String()
+}
+
+// writeFields writes the fields that have a value of their own and descends into their kids
+func (f *formText) writeFields(b *strings.Builder, fields types.Array, depth int) {
+	if depth >= maxFieldDepth {
+		return
+	}
+	for _, obj := range fields {
+		field := f.dict(obj)
+		if field == nil {
+			continue
+		}
+		if _, ok := field["V"]; ok {
+			f.writeField(b, field)
+		}
+		f.writeFields(b, f.array(field["Kids"]), depth+1)
+	}
+}
+
+// writeField writes a field or widget as "name: value". The value and the name (the
+// user-facing /TU, else the partial /T) are inherited from the nearest /Parent that
+// has them, since widgets of a field usually carry neither.
+func (f *formText) writeField(b *strings.Builder, field types.Dict) {
+	var name, value string
+	for node, depth := field, 0; node != nil && depth < maxFieldDepth; node, depth = f.dict(node["Parent"]), depth+1 {
+		if value == "" {
+			value = f.fieldValue(node["V"])
+		}
+		if name == "" {
+			if s, ok := f.textString(node["TU"]); ok {
+				name = strings.TrimSpace(s)
+			} else if s, ok := f.textString(node["T"]); ok {
+				name = strings.TrimSpace(s)
+			}
+		}
+		if name != "" && value != "" {
+			break
+		}
+	}
+	f.writeLines(b, name, value)
+}
+
+// fieldValue returns a text field's value, or the selected options of a list box joined
+// by commas. Check box and radio button states are names and yield no text.
+func (f *formText) fieldValue(obj types.Object) string {
+	if s, ok := f.textString(obj); ok {
+		return s
+	}
+	var values []string
+	for _, elem := range f.array(obj) {
+		if s, ok := f.textString(elem); ok && strings.TrimSpace(s) != "" {
+			values = append(values, strings.TrimSpace(s))
+		}
+	}
+	return strings.Join(values, ", ")
+}
+
+// writeLines writes each non-empty line of value, prefixed with "name: " if name is set,
+// skipping lines already written
+func (f *formText) writeLines(b *strings.Builder, name, value string) {
+	value = strings.ReplaceAll(strings.ReplaceAll(value, "\r\n", "\n"), "\r", "\n")
+	for _, line := range strings.Split(value, "\n") {
+		line = strings.TrimSpace(line)
+		if line == "" {
+			continue
+		}
+		if name != "" {
+			line = name + ": " + line
+		}
+		if f.seen[line] {
+			continue
+		}
+		f.seen[line] = true
+		b.WriteString(line)
+		b.WriteString("\n")
+	}
+}
+
+// textString decodes a literal or hex text string
+func (f *formText) textString(obj types.Object) (string, bool) {
+	switch v := f.deref(obj).(type) {
+	case types.StringLiteral:
+		return decodePDFTextString(unescapePDFString(string(v))), true
+	case types.HexLiteral:
+		return decodePDFTextString(string(hexToBytes(string(v)))), true
+	}
+	return "", false
+}
+
+// deref resolves obj if it is an indirect reference
+func (f *formText) deref(obj types.Object) types.Object {
+	if obj == nil {
+		return nil
+	}
+	resolved, err := f.r.Dereference(obj)
+	if err != nil {
+		return nil
+	}
+	return resolved
+}
+
+// dict resolves obj to a dictionary, or nil
+func (f *formText) dict(obj types.Object) types.Dict {
+	d, _ := f.deref(obj).(types.Dict)
+	return d
+}
+
+// array resolves obj to an array, or nil
+func (f *formText) array(obj types.Object) types.Array {
+	a, _ := f.deref(obj).(types.Array)
+	return a
+}
+
+// decodePDFTextString decodes the bytes of a PDF text string: UTF-16BE or UTF-8 when
+// marked by a byte order mark, otherwise Windows-1254 like the content stream strings
+func decodePDFTextString(raw string) string {
+	switch {
+	case strings.HasPrefix(raw, "\xfe\xff"):
+		return decodeUTF16BE([]byte(raw[2:]))
+	case strings.HasPrefix(raw, "\xef\xbb\xbf"):
+		return raw[3:]
+	case containsHighBytes(raw):
+		if converted, err := convertWindows1254ToUTF8(raw); err == nil {
+			return converted
+		}
+	}
+	return raw
+}
+
+// appendPageText appends extra lines to a page's text, keeping them on lines of their own
+func appendPageText(text, extra string) string {
+	if extra == "" {
+		return text
+	}
+	if text != "" && !strings.HasSuffix(text, "\n") {
+		text += "\n"
+	}
+	return text + extra
+}
@@ -0,0 +1,134 @@
+package vergilevhasi
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/pdfcpu/pdfcpu/pkg/pdfcpu/types"
+)
+
+// objectTable resolves indirect references by object number, standing in for a PDF's
+// cross-reference table
+type objectTable map[int]types.Object
+
+func (t objectTable) Dereference(o types.Object) (types.Object, error) {
+	ref, ok := o.(types.IndirectRef)
+	if !ok {
+		return o, nil
+	}
+	obj, ok := t[int(ref.ObjectNumber)]
+	if !ok {
+		return nil, fmt.Errorf("object %d not found", ref.ObjectNumber)
+	}
+	return obj, nil
+}
+
+func ref(n int) types.IndirectRef {
+	return types.IndirectRef{ObjectNumber: types.Integer(n)}
+}
+
+// formFixture is a synthetic plate whose VKN is only in a form field. The VKN field has
+// a widget on the page, the tax office field has none, and a free text annotation
+// carries the name.
+func formFixture() (objectTable, types.Dict, types.Dict) {
+	objects := objectTable{
+		10: types.Dict{
+			"T":    types.StringLiteral("vkn"),
+			"TU":   types.StringLiteral("Vergi Kimlik No"),
+			"V":    types.StringLiteral("1234567890"),
+			"Kids": types.Array{ref(11)},
+		},
+		11: types.Dict{
+			"Type":    types.Name("Annot"),
+			"Subtype": types.Name("Widget"),
+			"Parent":  ref(10),
+		},
+		12: types.Dict{
+			"T": types.StringLiteral("Vergi Dairesi"),
+			"V": types.HexLiteral("FEFF00D60052004E0045004B00200056004500520047013000200044004101300052004500530130"),
+		},
+		13: types.Dict{
+			"Subtype":  types.Name("FreeText"),
+			"Contents": types.StringLiteral(`Ad\375 Soyad\375: AL\335 \326RNEK`),
+		},
+		14: types.Dict{
+			"Subtype": types.Name("Widget"),
+			"T":       types.StringLiteral("onay"),
+			"V":       types.Name("Yes"),
+		},
+	}
+
+	catalog := types.Dict{
+		"AcroForm": types.Dict{"Fields": types.Array{ref(10), ref(12), ref(14)}},
+	}
+	page := types.Dict{
+		"Annots": types.Array{ref(11), ref(13), ref(14)},
+	}
+	return objects, catalog, page
+}
+
+func TestFormText(t *testing.T) {
+	objects, catalog, page := formFixture()
+	form := newFormText(objects)
+
+	wantPage := "Vergi Kimlik No: 1234567890\nAdı Soyadı: ALİ ÖRNEK\n"
+	if got := form.pageText(page); got != wantPage {
+		t.Errorf("pageText() = %q, want %q", got, wantPage)
+	}
+
+	// The VKN was already written for its widget; only the widgetless field remains
+	wantFields := "Vergi Dairesi: ÖRNEK VERGİ DAİRESİ\n"
+	if got := form.fieldsText(catalog); got != wantFields {
+		t.Errorf("fieldsText() = %q, want %q", got, wantFields)
+	}
+}
+
+func TestFormTextParsesVKN(t *testing.T) {
+	objects, catalog, page := formFixture()
+	form := newFormText(objects)
+
+	text := appendPageText("GELİR İDARESİ BAŞKANLIĞI\nVERGİ LEVHASI", form.pageText(page))
+	text = appendPageText(text, form.fieldsText(catalog))
+
+	vl := &VergiLevhasi{}
+	NewParser().parseContent(vl, text)
+
+	if vl.VergiKimlikNo != "1234567890" {
+		t.Errorf("VergiKimlikNo = %q, want %q\ntext:\n%s", vl.VergiKimlikNo, "1234567890", text)
+	}
+	if vl.VergiDairesi != "ÖRNEK VERGİ DAİRESİ" {
+		t.Errorf("VergiDairesi = %q, want %q", vl.VergiDairesi, "ÖRNEK VERGİ DAİRESİ")
+	}
+}
+
+func TestFormTextParentCycle(t *testing.T) {
+	objects := objectTable{
+		1: types.Dict{"Subtype": types.Name("Widget"), "Parent": ref(2)},
+		2: types.Dict{"Parent": ref(1), "Kids": types.Array{ref(1), ref(2)}},
+	}
+	form := newFormText(objects)
+
+	if got := form.pageText(types.Dict{"Annots": types.Array{ref(1)}}); got != "" {
+		t.Errorf("pageText() = %q, want empty", got)
+	}
+	if got := form.fieldsText(types.Dict{"AcroForm": types.Dict{"Fields": types.Array{ref(2)}}}); got != "" {
+		t.Errorf("fieldsText() = %q, want empty", got)
+	}
+}
+
+func TestDecodePDFTextString(t *testing.T) {
+	tests := []struct {
+		raw  string
+		want string
+	}{
+		{"\xfe\xff\x00\xd6\x00R\x00N\x00E\x00K", "ÖRNEK"},
+		{"\xef\xbb\xbfÖRNEK", "ÖRNEK"},
+		{"\xdeUBE", "ŞUBE"},
+		{"1234567890", "1234567890"},
+	}
+	for _, tt := range tests {
+		if got := decodePDFTextString(tt.raw); got != tt.want {
+			t.Errorf("decodePDFTextString(%q) = %q, want %q", tt.raw, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,111 @@
+package vergilevhasi
+
+import (
+	"strings"
+)
+
+// DefaultFuzzyMaxDistance is the default maximum edit distance for snapping a
+// near-match tax type or office name to its reference entry
+const DefaultFuzzyMaxDistance = 2
+
+// DefaultTaxTypeReferences returns the tax type names recognized by the parser,
+// used as the reference list for fuzzy matching
+func DefaultTaxTypeReferences() []string {
+	return []string{
+		"Yıllık Gelir Vergisi",
+		"Kurumlar Vergisi",
+		"Katma Değer Vergisi",
+		"Geçici Vergi",
+		"Damga Vergisi",
+		"Muhtasar",
+		"Stopaj",
+		"Gelir Vergisi",
+	}
+}
+
+// SetFuzzyMatchThreshold sets the maximum edit distance used when snapping tax types and
+// tax office names to their reference lists. Zero or a negative value disables fuzzy matching.
+// Short names allow proportionally fewer edits, so "KDV" is never snapped.
+func (p *Parser) SetFuzzyMatchThreshold(maxDistance int) {
+	if maxDistance < 0 {
+		maxDistance = 0
+	}
+	p.fuzzyMaxDistance = maxDistance
+}
+
+// SetTaxTypeReferences replaces the reference list that near-match tax types are snapped to
+func (p *Parser) SetTaxTypeReferences(refs []string) {
+	p.taxTypeRefs = append([]string(nil), refs...)
+}
+
+// SetTaxOfficeReferences sets the reference list that near-match tax office names are snapped to.
+// The list is empty by default, so tax office names are kept as extracted.
+func (p *Parser) SetTaxOfficeReferences(refs []string) {
+	p.taxOfficeRefs = append([]string(nil), refs...)
+}
+
+// snapToReference returns the reference entry closest to s if it is within the allowed
+// edit distance. Comparison ignores case and Turkish diacritics.
+func snapToReference(s string, refs []string, maxDistance int) (string, bool) {
+	if maxDistance <= 0 || len(refs) == 0 {
+		return "", false
+	}
+
+	norm := foldTurkish(s)
+	if norm == "" {
+		return "", false
+	}
+
+	best, bestDist := "", -1
+	for _, ref := range refs {
+		refNorm := foldTurkish(ref)
+		// Allow roughly one edit per five characters, capped by maxDistance
+		allowed := min(maxDistance, len([]rune(refNorm))/5)
+		if abs(len([]rune(norm))-len([]rune(refNorm))) > allowed {
+			continue
+		}
+		dist := levenshtein(norm, refNorm)
+		if dist <= allowed && (bestDist < 0 || dist < bestDist) {
+			best, bestDist = ref, dist
+		}
+	}
+
+	return best, bestDist >= 0
+}
+
+// foldTurkish lowercases s, maps Turkish letters to their ASCII base and collapses whitespace
+func foldTurkish(s string) string {
+	replacer := strings.NewReplacer(
+		"İ", "i", "I", "i", "ı", "i",
+		"Ş", "s", "ş", "s",
+		"Ğ", "g", "ğ", "g",
+		"Ü", "u", "ü", "u",
+		"Ö", "o", "ö", "o",
+		"Ç", "c", "ç", "c",
+	)
+	return strings.Join(strings.Fields(strings.ToLower(replacer.Replace(s))), " ")
+}
+
+// levenshtein returns the edit distance between a and b, counted in runes
+func levenshtein(a, b string) int {
+	ra, rb := []rune(a), []rune(b)
+	prev := make([]int, len(rb)+1)
+	curr := make([]int, len(rb)+1)
+	for j := range prev {
+		prev[j] = j
+	}
+
+	for i := 1; i <= len(ra); i++ {
+		curr[0] = i
+		for j := 1; j <= len(rb); j++ {
+			cost := 1
+			if ra[i-1] == rb[j-1] {
+				cost = 0
+			}
+			curr[j] = min(prev[j]+1, curr[j-1]+1, prev[j-1]+cost)
+		}
+		prev, curr = curr, prev
+	}
+
+	return prev[len(rb)]
+}
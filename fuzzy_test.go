@@ -0,0 +1,75 @@
+package vergilevhasi
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestLevenshtein(t *testing.T) {
+	tests := []struct {
+		a, b string
+		want int
+	}{
+		{"", "", 0},
+		{"abc", "", 3},
+		{"kurumlap", "kurumlar", 1},
+		{"kitten", "sitting", 3},
+		{"şişli", "sisli", 2},
+	}
+	for _, tt := range tests {
+		if got := levenshtein(tt.a, tt.b); got != tt.want {
+			t.Errorf("levenshtein(%q, %q) = %d, want %d", tt.a, tt.b, got, tt.want)
+		}
+	}
+}
+
+func TestExtractTaxTypesFuzzy(t *testing.T) {
+	parser := NewParser()
+
+	tests := []struct {
+		name string
+		text string
+		want []string
+	}{
+		{"Substituted letter", "KURUMLAP VERGISI", []string{"Kurumlar Vergisi"}},
+		{"Dropped letter", "KATMA DEGER VERGSI", []string{"Katma Değer Vergisi"}},
+		{"After label", "VERGİ TÜRÜ: DAMGA VERGlSl", []string{"Damga Vergisi"}},
+		{"Two errors", "YILIK GELIR VERGlSI", []string{"Yıllık Gelir Vergisi"}},
+		{"Too many errors", "KURUMXAP VXRGXSX", []string{}},
+		{"Short names need exact match", "KDX", []string{}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := parser.extractTaxTypes(tt.text)
+			if len(got) == 0 && len(tt.want) == 0 {
+				return
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("extractTaxTypes(%q) = %v, want %v", tt.text, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestExtractTaxTypesFuzzyDisabled(t *testing.T) {
+	parser := NewParser()
+	parser.SetFuzzyMatchThreshold(0)
+
+	if got := parser.extractTaxTypes("KURUMLAP VERGISI"); len(got) != 0 {
+		t.Errorf("extractTaxTypes() = %v, want none with fuzzy matching disabled", got)
+	}
+}
+
+func TestSnapToReferenceCustomList(t *testing.T) {
+	refs := []string{"Örnek Vergi Dairesi", "Deneme Vergi Dairesi"}
+
+	got, ok := snapToReference("ORNEK VERGl DAIRESl", refs, DefaultFuzzyMaxDistance)
+	if !ok || got != "Örnek Vergi Dairesi" {
+		t.Errorf("snapToReference() = %q, %v, want %q", got, ok, "Örnek Vergi Dairesi")
+	}
+
+	if got, ok := snapToReference("Başka Yer", refs, DefaultFuzzyMaxDistance); ok {
+		t.Errorf("snapToReference() = %q, want no match", got)
+	}
+}
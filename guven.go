@@ -0,0 +1,84 @@
+package vergilevhasi
+
+import "fmt"
+
+// Taxpayer type confidence by the strongest signal behind the classification
+const (
+	// guvenBeyan is for a plate that states "Gerçek Kişi" or "Tüzel Kişi" outright
+	guvenBeyan = 1.0
+
+	// guvenGuclu is for a tax type or label only one kind of taxpayer has, such as
+	// Kurumlar Vergisi, backed by the name
+	guvenGuclu = 0.9
+
+	// guvenOrta is for one strong signal on its own, such as Kurumlar Vergisi without a
+	// legal-form suffix in the name, or an individual's valid TCKN
+	guvenOrta = 0.7
+
+	// guvenZayif is for a classification resting on the form of the name alone
+	guvenZayif = 0.4
+
+	// guvenCelisik is for a classification the name contradicts, such as an individual
+	// whose name ends in "LTD. ŞTİ."
+	guvenCelisik = 0.2
+)
+
+// minMukellefTuruGuveni is the confidence below which the taxpayer type is flagged in
+// Warnings for review
+const minMukellefTuruGuveni = 0.5
+
+// mukellefTuruGuveni scores, from 0 to 1, how strongly the plate supports the taxpayer
+// type vl was classified as. kisiTuru is the stated kind of person, if any, and
+// kurumlarVergisi is set when Kurumlar Vergisi is among the tax types.
+func mukellefTuruGuveni(vl *VergiLevhasi, kisiTuru string, kurumlarVergisi bool) float64 {
+	if vl.MukellefTuru == "" {
+		return 0
+	}
+	if kisiTuru != "" {
+		return guvenBeyan
+	}
+
+	companyName := hasLegalFormSuffix(vl.TicaretUnvani) || hasLegalFormSuffix(vl.AdiSoyadi)
+	switch vl.MukellefTuru {
+	case MukellefTuruKurumsal:
+		if kurumlarVergisi && companyName {
+			return guvenGuclu
+		}
+		if kurumlarVergisi {
+			return guvenOrta
+		}
+		return guvenZayif
+
+	case MukellefTuruDernek, MukellefTuruVakif:
+		// Recognized by a word of the name, which Kurumlar Vergisi backs up
+		if kurumlarVergisi {
+			return guvenGuclu
+		}
+		return guvenOrta
+
+	case MukellefTuruAdiOrtaklik:
+		if len(vl.Ortaklar) > 0 {
+			return guvenGuclu
+		}
+		return guvenOrta
+
+	case MukellefTuruBireysel:
+		switch {
+		case companyName:
+			return guvenCelisik
+		case isValidTCKN(vl.TCKimlikNo) || vl.GelirUnsuru != "":
+			return guvenOrta
+		}
+		return guvenZayif
+	}
+	return 0
+}
+
+// mukellefTuruWarning returns the warning for a taxpayer type classified with low
+// confidence, or ""
+func mukellefTuruWarning(vl *VergiLevhasi) string {
+	if vl.MukellefTuru == "" || vl.MukellefTuruGuveni >= minMukellefTuruGuveni {
+		return ""
+	}
+	return fmt.Sprintf("taxpayer type %q classified with low confidence (%.1f); review it manually", vl.MukellefTuru, vl.MukellefTuruGuveni)
+}
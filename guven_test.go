@@ -0,0 +1,84 @@
+package vergilevhasi
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestMukellefTuruGuveni(t *testing.T) {
+	tests := []struct {
+		name     string
+		text     string
+		wantTuru MukellefTuru
+		want     float64
+	}{
+		{
+			name:     "stated legal person",
+			text:     "Ticaret Ünvanı: ÖRNEK YAZILIM\nMükellefiyet Türü: Tüzel Kişi\nVergi Kimlik No: 1234567890\n",
+			wantTuru: MukellefTuruKurumsal,
+			want:     guvenBeyan,
+		},
+		{
+			name:     "stated natural person",
+			text:     "Adı Soyadı: ALİ ÖRNEK\nMükellefiyet Türü: Gerçek Kişi\n",
+			wantTuru: MukellefTuruBireysel,
+			want:     guvenBeyan,
+		},
+		{
+			name:     "corporate tax and company name",
+			text:     "Ticaret Ünvanı: ÖRNEK YAZILIM LİMİTED ŞİRKETİ\nVergi Kimlik No: 1234567890\nKURUMLAR VERGİSİ\n",
+			wantTuru: MukellefTuruKurumsal,
+			want:     guvenGuclu,
+		},
+		{
+			name:     "individual with TCKN",
+			text:     "Adı Soyadı: ALİ ÖRNEK\nTC Kimlik No: 10000000146\nGELİR VERGİSİ\n",
+			wantTuru: MukellefTuruBireysel,
+			want:     guvenOrta,
+		},
+		{
+			name:     "name only",
+			text:     "Adı Soyadı: ALİ ÖRNEK\nVergi Dairesi: ÇANKAYA\n",
+			wantTuru: MukellefTuruBireysel,
+			want:     guvenZayif,
+		},
+		{
+			name:     "company suffix without corporate tax",
+			text:     "Ticaret Ünvanı: ÖRNEK TİCARET LTD. ŞTİ.\nVergi Dairesi: ÇANKAYA\nGELİR VERGİSİ\n",
+			wantTuru: MukellefTuruBireysel,
+			want:     guvenCelisik,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			vl := &VergiLevhasi{}
+			NewParser().parseContent(vl, tt.text)
+
+			if vl.MukellefTuru != tt.wantTuru {
+				t.Fatalf("MukellefTuru = %q, want %q", vl.MukellefTuru, tt.wantTuru)
+			}
+			if vl.MukellefTuruGuveni != tt.want {
+				t.Errorf("MukellefTuruGuveni = %v, want %v", vl.MukellefTuruGuveni, tt.want)
+			}
+
+			flagged := false
+			for _, w := range vl.Warnings {
+				if strings.Contains(w, "low confidence") {
+					flagged = true
+				}
+			}
+			if wantFlag := tt.want < minMukellefTuruGuveni; flagged != wantFlag {
+				t.Errorf("Warnings = %q, want a low confidence warning: %v", vl.Warnings, wantFlag)
+			}
+		})
+	}
+}
+
+func TestMukellefTuruGuveniUnclassified(t *testing.T) {
+	vl := &VergiLevhasi{}
+	NewParser().parseContent(vl, "Vergi Dairesi: ÇANKAYA\n")
+	if vl.MukellefTuru != "" || vl.MukellefTuruGuveni != 0 || len(vl.Warnings) != 0 {
+		t.Errorf("MukellefTuru = %q, MukellefTuruGuveni = %v, Warnings = %q; want none", vl.MukellefTuru, vl.MukellefTuruGuveni, vl.Warnings)
+	}
+}
@@ -0,0 +1,81 @@
+package vergilevhasi
+
+import (
+	"strings"
+)
+
+// isInteraktifVergiDairesi reports whether the text is the tax plate exported by the
+// İnteraktif Vergi Dairesi portal, identified by the portal name in its header or its
+// address in the footer
+func isInteraktifVergiDairesi(text string) bool {
+	folded := foldTurkish(text)
+	return strings.Contains(folded, "interaktif vergi dairesi") || strings.Contains(folded, "ivd.gib.gov.tr")
+}
+
+// interaktifLabels lists the labels of the interactive tax office export, folded with
+// foldTurkish, with the table label field each one fills. More specific labels come first.
+var interaktifLabels = []tableLabel{
+	{"vergi_kimlik_no", []string{"vergi kimlik numarasi", "vergi kimlik no", "vkn"}},
+	{"tc_kimlik_no", []string{"t.c. kimlik numarasi", "tc kimlik numarasi", "t.c. kimlik no", "tc kimlik no"}},
+	{"adi_soyadi", []string{"mukellefin adi soyadi", "adi soyadi", "adi ve soyadi"}},
+	{"ticaret_unvani", []string{"mukellefin unvani", "ticaret unvani", "unvani", "unvan"}},
+	{"vergi_dairesi", []string{"bagli oldugu vergi dairesi", "vergi dairesi adi", "vergi dairesi"}},
+	{"is_yeri_adresi", []string{"is yeri adresi", "isyeri adresi", "adresi", "adres"}},
+	{"is_yeri_turu", []string{"is yeri turu", "isyeri turu"}},
+	{"ise_baslama_tarihi", []string{"ise baslama tarihi"}},
+	{"vergi_turu", []string{"vergi turleri", "vergi turu"}},
+}
+
+// matchInteraktifLabel returns the field labelled by s, or "" if s is not a label
+func matchInteraktifLabel(s string) string {
+	folded := foldTurkish(strings.TrimRight(strings.TrimSpace(s), ":："))
+	for _, label := range interaktifLabels {
+		for _, kw := range label.keywords {
+			if folded == kw {
+				return label.field
+			}
+		}
+	}
+	return ""
+}
+
+// cutInteraktifLabel splits a line into the field its label names and the value after
+// it. The label is either followed by a colon or, without one, by the value itself.
+func cutInteraktifLabel(line string) (field, value string) {
+	if head, rest, ok := strings.Cut(line, ":"); ok {
+		if field := matchInteraktifLabel(head); field != "" {
+			return field, strings.TrimSpace(rest)
+		}
+	}
+
+	words := strings.Fields(line)
+	for n := min(len(words), 4); n > 0; n-- {
+		if field := matchInteraktifLabel(strings.Join(words[:n], " ")); field != "" {
+			return field, strings.TrimLeft(strings.Join(words[n:], " "), ":： ")
+		}
+	}
+	return "", ""
+}
+
+// parseInteraktif extracts the labelled fields of the interactive tax office export.
+// Its labels are worded and ordered differently from the classic GİB plate, and a value
+// is printed either after its label or on the line below it, so values are paired with
+// their labels instead of being found by position.
+func (p *Parser) parseInteraktif(vl *VergiLevhasi, lines []string) {
+	for i := 0; i < len(lines); i++ {
+		field, value := cutInteraktifLabel(strings.TrimSpace(lines[i]))
+		if field == "" {
+			continue
+		}
+		if value == "" && i+1 < len(lines) {
+			next := strings.TrimSpace(lines[i+1])
+			if nextField, _ := cutInteraktifLabel(next); nextField == "" && next != "" {
+				value = next
+				i++
+			}
+		}
+		if value != "" {
+			p.setTableField(vl, field, value)
+		}
+	}
+}
@@ -0,0 +1,78 @@
+package vergilevhasi
+
+import (
+	"testing"
+)
+
+// interaktifPlateText is the text of a tax plate exported by the İnteraktif Vergi Dairesi
+// portal: labels worded differently from the classic plate, some values on the line
+// below their label, and the portal address in the footer
+const interaktifPlateText = `T.C.
+HAZİNE VE MALİYE BAKANLIĞI
+GELİR İDARESİ BAŞKANLIĞI
+İnteraktif Vergi Dairesi
+VERGİ LEVHASI
+Bağlı Olduğu Vergi Dairesi : KADIKÖY
+Vergi Kimlik Numarası : 4827193056
+Unvanı
+ÖRNEK YAZILIM LİMİTED ŞİRKETİ
+Vergi Türü
+KURUMLAR VERGİSİ
+İşyeri Adresi
+CAFERAĞA MAH. MODA CAD. NO:5 KADIKÖY/İSTANBUL
+İşe Başlama Tarihi : 01.02.2020
+620100 - BİLGİSAYAR PROGRAMLAMA FAALİYETLERİ
+Bu belge ivd.gib.gov.tr adresinden 12.03.2024 tarihinde alınmıştır.
+`
+
+func TestParseContentInteraktif(t *testing.T) {
+	vl := &VergiLevhasi{}
+	NewParser().parseContent(vl, interaktifPlateText)
+
+	if vl.VergiKimlikNo != "4827193056" {
+		t.Errorf("VergiKimlikNo = %q, want %q", vl.VergiKimlikNo, "4827193056")
+	}
+	if vl.TicaretUnvani != "ÖRNEK YAZILIM LİMİTED ŞİRKETİ" {
+		t.Errorf("TicaretUnvani = %q, want %q", vl.TicaretUnvani, "ÖRNEK YAZILIM LİMİTED ŞİRKETİ")
+	}
+	if vl.VergiDairesi != "KADIKÖY" {
+		t.Errorf("VergiDairesi = %q, want %q", vl.VergiDairesi, "KADIKÖY")
+	}
+	if vl.IsYeriAdresi != "CAFERAĞA MAH. MODA CAD. NO:5 KADIKÖY/İSTANBUL" {
+		t.Errorf("IsYeriAdresi = %q, want the address below its label", vl.IsYeriAdresi)
+	}
+	if vl.IseBaslamaTarihi == nil || vl.IseBaslamaTarihi.Format("02.01.2006") != "01.02.2020" {
+		t.Errorf("IseBaslamaTarihi = %v, want 01.02.2020", vl.IseBaslamaTarihi)
+	}
+	if vl.AdiSoyadi != "" || vl.MukellefTuru != MukellefTuruKurumsal {
+		t.Errorf("AdiSoyadi = %q, MukellefTuru = %q; want a company", vl.AdiSoyadi, vl.MukellefTuru)
+	}
+	if len(vl.FaaliyetKodlari) != 1 || vl.FaaliyetKodlari[0].Kod != "620100" {
+		t.Errorf("FaaliyetKodlari = %+v, want 620100", vl.FaaliyetKodlari)
+	}
+}
+
+func TestParseContentInteraktifIndividual(t *testing.T) {
+	text := "İnteraktif Vergi Dairesi\nVERGİ LEVHASI\nAdı Soyadı : ALİ ÖRNEK\nT.C. Kimlik Numarası : 10000000146\n" +
+		"Vergi Dairesi Adı : ÇANKAYA\nVergi Türü : YILLIK GELİR VERGİSİ\n"
+
+	vl := &VergiLevhasi{}
+	NewParser().parseContent(vl, text)
+
+	if vl.AdiSoyadi != "ALİ ÖRNEK" || vl.TCKimlikNo != "10000000146" || vl.VergiDairesi != "ÇANKAYA" {
+		t.Errorf("AdiSoyadi = %q, TCKimlikNo = %q, VergiDairesi = %q; want ALİ ÖRNEK, 10000000146, ÇANKAYA",
+			vl.AdiSoyadi, vl.TCKimlikNo, vl.VergiDairesi)
+	}
+	if vl.MukellefTuru != MukellefTuruBireysel {
+		t.Errorf("MukellefTuru = %q, want %q", vl.MukellefTuru, MukellefTuruBireysel)
+	}
+}
+
+func TestIsInteraktifVergiDairesi(t *testing.T) {
+	if isInteraktifVergiDairesi(syntheticPlateText(&VergiLevhasi{AdiSoyadi: "ALİ ÖRNEK"})) {
+		t.Error("isInteraktifVergiDairesi() matched a classic plate")
+	}
+	if !isInteraktifVergiDairesi("VERGİ LEVHASI\nhttps://ivd.gib.gov.tr") {
+		t.Error("isInteraktifVergiDairesi() missed the portal address in the footer")
+	}
+}
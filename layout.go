@@ -0,0 +1,479 @@
+package vergilevhasi
+
+import (
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+)
+
+// textFragment is a piece of text shown by a single text-showing operator,
+// positioned at the user-space origin where it starts
+type textFragment struct {
+	Text string
+	X, Y float64
+
+	// Continues is set when the fragment is shown on the text line of the previous
+	// fragment, and Gap when it was moved right of it rather than drawn straight after
+	Continues, Gap bool
+}
+
+// fragmentsText joins fragments in content-stream order, one text line per line. A
+// fragment continuing the previous one's line is appended to it, separated by a space
+// when it was moved apart, so a label shown in pieces stays one phrase.
+func fragmentsText(fragments []textFragment) string {
+	var result strings.Builder
+	for i, f := range fragments {
+		if i > 0 {
+			switch {
+			case !f.Continues:
+				result.WriteString("\n")
+			case f.Gap && !strings.HasSuffix(fragments[i-1].Text, " ") && !strings.HasPrefix(f.Text, " "):
+				result.WriteString(" ")
+			}
+		}
+		result.WriteString(f.Text)
+	}
+	if len(fragments) > 0 {
+		result.WriteString("\n")
+	}
+	return result.String()
+}
+
+// textRow is a group of fragments sharing the same visual line (y-band), sorted left to right
+type textRow struct {
+	Y     float64
+	Cells []textFragment
+}
+
+// rowTolerance is the maximum vertical distance for two fragments to share a row
+const rowTolerance = 3.0
+
+// matrix is a PDF transformation matrix [a b c d e f]
+type matrix [6]float64
+
+var identityMatrix = matrix{1, 0, 0, 1, 0, 0}
+
+// multiply returns m × n
+func (m matrix) multiply(n matrix) matrix {
+	return matrix{
+		m[0]*n[0] + m[1]*n[2],
+		m[0]*n[1] + m[1]*n[3],
+		m[2]*n[0] + m[3]*n[2],
+		m[2]*n[1] + m[3]*n[3],
+		m[4]*n[0] + m[5]*n[2] + n[4],
+		m[4]*n[1] + m[5]*n[3] + n[5],
+	}
+}
+
+// extractTextFragments walks a page content stream and returns every shown string
+// together with its position, tracking the text matrix (Tm, Td, TD, T*) and the CTM (cm, q, Q)
+func extractTextFragments(content string) []textFragment {
+	return extractTextFragmentsWithFonts(content, nil)
+}
+
+// extractTextFragmentsWithFonts is extractTextFragments with show-text operands decoded through
+// the ToUnicode CMap of the current font (Tf) when one exists, falling back to the byte heuristics
+func extractTextFragmentsWithFonts(content string, fonts fontCMaps) []textFragment {
+	var fragments []textFragment
+
+	ctm := identityMatrix
+	var ctmStack []matrix
+	var font *toUnicodeCMap
+	var fontStack []*toUnicodeCMap
+	tm, tlm := identityMatrix, identityMatrix
+	leading := 0.0
+	fontSize := 0.0
+
+	// sameLine is set once a string is shown on the current text line, lineRunes counts
+	// the characters shown since the line start and gap records a move to the right
+	sameLine, gap := false, false
+	lineRunes := 0
+	breakLine := func() {
+		sameLine, gap = false, false
+		lineRunes = 0
+	}
+
+	var operands []contentToken
+
+	nextLine := func(tx, ty float64) {
+		// A short move to the right on the same baseline continues the line, e.g. between
+		// the words of a label. Wider moves are taken as separate columns.
+		if sameLine && ty == 0 && tx > 0 && tx <= float64(lineRunes+1)*fontSize {
+			gap = true
+			lineRunes = 0
+		} else {
+			breakLine()
+		}
+		tlm = matrix{1, 0, 0, 1, tx, ty}.multiply(tlm)
+		tm = tlm
+	}
+	decode := func(tok contentToken) string {
+		switch {
+		case tok.Kind == tokenLiteralString && font != nil:
+			return font.decode([]byte(unescapePDFString(tok.Value)))
+		case tok.Kind == tokenLiteralString:
+			return decodePDFString(tok.Value)
+		case font != nil:
+			return font.decode(hexToBytes(tok.Value))
+		default:
+			return decodeHexString(tok.Value)
+		}
+	}
+	// show emits the strings among the operands, including those of a TJ array, as one fragment
+	show := func() {
+		var text strings.Builder
+		for _, op := range operands {
+			if op.Kind == tokenArray {
+				// Kerning numbers between the pieces are skipped
+				for _, elem := range op.Elems {
+					if elem.isString() {
+						text.WriteString(decode(elem))
+					}
+				}
+			} else if op.isString() {
+				text.WriteString(decode(op))
+			}
+		}
+		lineRunes += utf8.RuneCountInString(text.String())
+		if strings.TrimSpace(text.String()) == "" {
+			if text.Len() > 0 {
+				gap = true
+			}
+			return
+		}
+		pos := tm.multiply(ctm)
+		fragments = append(fragments, textFragment{Text: text.String(), X: pos[4], Y: pos[5], Continues: sameLine, Gap: gap})
+		sameLine, gap = true, false
+	}
+	lastNumbers := func(n int) []float64 {
+		vals := make([]float64, n)
+		start := len(operands) - n
+		for i := 0; i < n; i++ {
+			vals[i], _ = strconv.ParseFloat(operands[start+i].Value, 64)
+		}
+		return vals
+	}
+
+	tokens := newContentTokenizer(content)
+	for {
+		tok, ok := tokens.next()
+		if !ok {
+			break
+		}
+		if tok.Kind != tokenOperator {
+			operands = append(operands, tok)
+			continue
+		}
+
+		switch tok.Value {
+		case "q":
+			ctmStack = append(ctmStack, ctm)
+			fontStack = append(fontStack, font)
+		case "Q":
+			if n := len(ctmStack); n > 0 {
+				ctm = ctmStack[n-1]
+				ctmStack = ctmStack[:n-1]
+				font = fontStack[n-1]
+				fontStack = fontStack[:n-1]
+			}
+		case "cm":
+			breakLine()
+			if len(operands) >= 6 {
+				v := lastNumbers(6)
+				ctm = matrix{v[0], v[1], v[2], v[3], v[4], v[5]}.multiply(ctm)
+			}
+		case "BT":
+			breakLine()
+			tm, tlm = identityMatrix, identityMatrix
+		case "Tm":
+			if len(operands) >= 6 {
+				v := lastNumbers(6)
+				breakLine()
+				tlm = matrix{v[0], v[1], v[2], v[3], v[4], v[5]}
+				tm = tlm
+			}
+		case "Td":
+			if len(operands) >= 2 {
+				v := lastNumbers(2)
+				nextLine(v[0], v[1])
+			}
+		case "TD":
+			if len(operands) >= 2 {
+				v := lastNumbers(2)
+				leading = -v[1]
+				nextLine(v[0], v[1])
+			}
+		case "Tf":
+			// Operands are the font resource name and size
+			if n := len(operands); n >= 2 {
+				font = fonts[fontResourceName(operands[n-2].Value)]
+				fontSize, _ = strconv.ParseFloat(operands[n-1].Value, 64)
+			}
+		case "TL":
+			if len(operands) >= 1 {
+				leading = lastNumbers(1)[0]
+			}
+		case "T*":
+			nextLine(0, -leading)
+		case "Tj", "TJ":
+			show()
+		case "'", "\"":
+			nextLine(0, -leading)
+			show()
+		}
+		operands = operands[:0]
+	}
+
+	return fragments
+}
+
+// isPDFWhitespace reports whether ch is a PDF whitespace character
+func isPDFWhitespace(ch byte) bool {
+	return ch == ' ' || ch == '\t' || ch == '\n' || ch == '\r' || ch == '\f' || ch == 0
+}
+
+// isPDFDelimiter reports whether ch is a PDF delimiter character
+func isPDFDelimiter(ch byte) bool {
+	return strings.IndexByte("()<>[]{}/%", ch) >= 0
+}
+
+// isPDFNumber reports whether token is a PDF numeric operand
+func isPDFNumber(token string) bool {
+	if token == "" {
+		return false
+	}
+	_, err := strconv.ParseFloat(token, 64)
+	return err == nil
+}
+
+// stripPDFWhitespace removes whitespace that may appear inside hex strings
+func stripPDFWhitespace(s string) string {
+	return strings.Map(func(r rune) rune {
+		if r < 128 && isPDFWhitespace(byte(r)) {
+			return -1
+		}
+		return r
+	}, s)
+}
+
+// pageFromContent extracts the text and the layout rows of a page content stream. rotate
+// is the page's /Rotate: rows are grouped as the page is displayed, so the table layout
+// of a landscape plate stored with rotated content reads the right way round.
+func pageFromContent(content string, fonts fontCMaps, rotate int) PageText {
+	fragments := extractTextFragmentsWithFonts(content, fonts)
+	return PageText{
+		Text: pageTextFromContent(content, fonts, fragments),
+		rows: groupTextRows(rotateFragments(fragments, rotate)),
+	}
+}
+
+// rotateFragments maps fragment positions from user space to the orientation a viewer
+// shows after turning the page clockwise by rotate degrees (a multiple of 90). Only the
+// relative positions matter for grouping rows, so no translation is applied.
+func rotateFragments(fragments []textFragment, rotate int) []textFragment {
+	rotate = ((rotate % 360) + 360) % 360
+	if rotate == 0 || rotate%90 != 0 {
+		return fragments
+	}
+
+	rotated := make([]textFragment, len(fragments))
+	for i, f := range fragments {
+		x, y := f.X, f.Y
+		switch rotate {
+		case 90:
+			x, y = f.Y, -f.X
+		case 180:
+			x, y = -f.X, -f.Y
+		case 270:
+			x, y = -f.Y, f.X
+		}
+		rotated[i] = textFragment{Text: f.Text, X: x, Y: y}
+	}
+	return rotated
+}
+
+// groupTextRows groups fragments into visual rows: top to bottom, and left to right within a row
+func groupTextRows(fragments []textFragment) []textRow {
+	sorted := make([]textFragment, len(fragments))
+	copy(sorted, fragments)
+	// PDF user space grows upwards, so higher Y comes first
+	sort.SliceStable(sorted, func(i, j int) bool {
+		return sorted[i].Y > sorted[j].Y
+	})
+
+	var rows []textRow
+	for _, f := range sorted {
+		if n := len(rows); n > 0 && rows[n-1].Y-f.Y <= rowTolerance {
+			rows[n-1].Cells = append(rows[n-1].Cells, f)
+			continue
+		}
+		rows = append(rows, textRow{Y: f.Y, Cells: []textFragment{f}})
+	}
+
+	for i := range rows {
+		cells := rows[i].Cells
+		sort.SliceStable(cells, func(a, b int) bool {
+			return cells[a].X < cells[b].X
+		})
+	}
+
+	return rows
+}
+
+// tableLabel maps label keywords of a table-layout plate to the field they describe
+type tableLabel struct {
+	field    string
+	keywords []string
+}
+
+// tableLabels lists the labels recognized in the left column of a two-column plate.
+// More specific labels come first (e.g. "TC KİMLİK NO" before a bare "KİMLİK NO").
+var tableLabels = []tableLabel{
+	{"adi_soyadi", []string{"ADI SOYADI", "ADI VE SOYADI", "ADISOYADI"}},
+	{"ticaret_unvani", []string{"TİCARET ÜNVANI", "TICARET UNVANI", "TİCARET UNVANI", "ÜNVANI"}},
+	{"is_yeri_adresi", []string{"İŞ YERİ ADRESİ", "IS YERI ADRESI", "İŞYERİ ADRESİ", "ISYERI ADRESI"}},
+	{"is_yeri_turu", []string{"İŞ YERİ TÜRÜ", "IS YERI TURU", "İŞYERİ TÜRÜ", "ISYERI TURU"}},
+	{"vergi_dairesi", []string{"VERGİ DAİRESİ", "VERGI DAIRESI"}},
+	{"tc_kimlik_no", []string{"TC KİMLİK NO", "T.C. KİMLİK NO", "TC KIMLIK NO", "T.C. KIMLIK NO"}},
+	{"vergi_kimlik_no", []string{"VERGİ KİMLİK NO", "VERGI KIMLIK NO", "VKN"}},
+	{"ise_baslama_tarihi", []string{"İŞE BAŞLAMA TARİHİ", "ISE BASLAMA TARIHI"}},
+	{"vergi_turu", []string{"VERGİ TÜRÜ", "VERGI TURU"}},
+	{"uyruk", []string{"UYRUĞU", "UYRUK", "UYRUGU"}},
+	{"pasaport_no", []string{"PASAPORT NO", "PASAPORT NUMARASI"}},
+	{"yabanci_vergi_no", []string{"YABANCI VERGİ NO", "YABANCI VERGI NO", "YABANCI VERGİ NUMARASI", "YABANCI VERGI NUMARASI"}},
+	{"kayit_no", []string{"KURUM KAYIT NO", "DERNEK KAYIT NO", "VAKIF KAYIT NO", "DERNEK KÜTÜK NO", "VAKIF KÜTÜK NO"}},
+}
+
+// tableFieldMasks maps table label fields to the FieldSet bit that selects them
+var tableFieldMasks = map[string]FieldSet{
+	"adi_soyadi":         FieldAdiSoyadi,
+	"ticaret_unvani":     FieldTicaretUnvani,
+	"is_yeri_adresi":     FieldIsYeriAdresi,
+	"is_yeri_turu":       FieldIsYeriTuru,
+	"vergi_dairesi":      FieldVergiDairesi,
+	"tc_kimlik_no":       FieldTCKimlikNo,
+	"vergi_kimlik_no":    FieldVergiKimlikNo,
+	"ise_baslama_tarihi": FieldIseBaslamaTarihi,
+	"vergi_turu":         FieldVergiTuru,
+	"uyruk":              FieldUyruk,
+	"pasaport_no":        FieldPasaportNo,
+	"kayit_no":           FieldKayitNo,
+	"yabanci_vergi_no":   FieldYabanciVergiNo,
+}
+
+var (
+	tableVKNRe  = regexp.MustCompile(`\b(\d{10})\b`)
+	tableTCKNRe = regexp.MustCompile(`\b(\d{11})\b`)
+	tableDateRe = regexp.MustCompile(`(\d{1,2}[./-]\d{1,2}[./-]\d{4})`)
+
+	tablePassportRe = regexp.MustCompile(`\b([A-Z0-9]{5,15})\b`)
+	tableKayitNoRe  = regexp.MustCompile(`(\d+(?:[./-]\d+)*)`)
+
+	tableYabanciVergiNoRe = regexp.MustCompile(`\b([A-Z0-9](?:[A-Z0-9./-]*[A-Z0-9])?)\b`)
+
+	tableIsYeriTuruRe = mustCompileLabel(`(?i)(merkez|[şs]ube)`)
+)
+
+// matchTableLabel returns the field a cell labels, or "" if the cell is not a label.
+// A label cell must be (almost) entirely the label so that values containing
+// label words are not mistaken for labels.
+func matchTableLabel(cell string) string {
+	text := strings.ToUpper(strings.TrimSpace(strings.TrimRight(strings.TrimSpace(cell), ":：")))
+	for _, label := range tableLabels {
+		for _, kw := range label.keywords {
+			if text == kw {
+				return label.field
+			}
+		}
+	}
+	return ""
+}
+
+// parseTableLayout pairs label cells with the value cells to their right on the same row.
+// This handles plates rendered as a two-column table, where the content stream emits the
+// columns in an unpredictable order. Values found this way take precedence over the
+// stream-order heuristics because they are tied to their label visually.
+func (p *Parser) parseTableLayout(vl *VergiLevhasi, rows []textRow) {
+	for _, row := range rows {
+		for i := 0; i < len(row.Cells); i++ {
+			field := matchTableLabel(row.Cells[i].Text)
+			if field == "" {
+				continue
+			}
+
+			// Collect value cells up to the next label on the same row
+			var parts []string
+			j := i + 1
+			for ; j < len(row.Cells); j++ {
+				if matchTableLabel(row.Cells[j].Text) != "" {
+					break
+				}
+				part := strings.TrimSpace(strings.TrimLeft(strings.TrimSpace(row.Cells[j].Text), ":："))
+				if part != "" {
+					parts = append(parts, part)
+				}
+			}
+			i = j - 1
+
+			if len(parts) > 0 {
+				p.setTableField(vl, field, normalizeDigits(strings.Join(parts, " ")))
+			}
+		}
+	}
+}
+
+// setTableField stores a value paired with its label, validating identifiers and dates
+func (p *Parser) setTableField(vl *VergiLevhasi, field, value string) {
+	if mask, ok := tableFieldMasks[field]; ok && !p.fields.Has(mask) {
+		return
+	}
+
+	switch field {
+	case "adi_soyadi":
+		vl.AdiSoyadi = value
+	case "ticaret_unvani":
+		vl.TicaretUnvani = value
+	case "is_yeri_adresi":
+		vl.IsYeriAdresi = value
+	case "is_yeri_turu":
+		if m := tableIsYeriTuruRe.FindStringSubmatch(value); len(m) > 1 {
+			vl.IsYeriTuru = parseIsYeriTuru(m[1])
+		}
+	case "vergi_dairesi":
+		vl.VergiDairesi = value
+	case "vergi_kimlik_no":
+		if m := tableVKNRe.FindStringSubmatch(value); len(m) > 1 {
+			vl.VergiKimlikNo = m[1]
+		}
+	case "tc_kimlik_no":
+		if m := tableTCKNRe.FindStringSubmatch(value); len(m) > 1 {
+			vl.TCKimlikNo = m[1]
+		}
+	case "ise_baslama_tarihi":
+		if m := tableDateRe.FindStringSubmatch(value); len(m) > 1 {
+			if date, err := p.parseDate(m[1]); err == nil {
+				vl.IseBaslamaTarihi = &date
+			}
+		}
+	case "uyruk":
+		vl.Uyruk = value
+	case "pasaport_no":
+		if m := tablePassportRe.FindStringSubmatch(strings.ToUpper(value)); len(m) > 1 {
+			vl.PasaportNo = m[1]
+		}
+	case "kayit_no":
+		if m := tableKayitNoRe.FindStringSubmatch(value); len(m) > 1 {
+			vl.KayitNo = m[1]
+		}
+	case "yabanci_vergi_no":
+		if m := tableYabanciVergiNoRe.FindStringSubmatch(strings.ToUpper(value)); len(m) > 1 {
+			vl.YabanciVergiNo = m[1]
+		}
+	case "vergi_turu":
+		if types := p.extractTaxTypes(value); len(types) > 0 {
+			vl.VergiTuru = types
+		}
+	}
+}
@@ -0,0 +1,177 @@
+package vergilevhasi
+
+import (
+	"testing"
+	"time"
+)
+
+func TestExtractTextFragments(t *testing.T) {
+	content := `BT
+/F1 10 Tf
+1 0 0 1 50 700 Tm
+(Left) Tj
+200 0 Td
+[(Ri) -20 (ght)] TJ
+ET
+q 1 0 0 1 0 -100 cm
+BT 50 700 Td <416263> Tj ET
+Q`
+
+	fragments := extractTextFragments(content)
+	want := []textFragment{
+		{Text: "Left", X: 50, Y: 700},
+		{Text: "Right", X: 250, Y: 700},
+		{Text: "Abc", X: 50, Y: 600},
+	}
+	if len(fragments) != len(want) {
+		t.Fatalf("extractTextFragments() returned %d fragments, want %d: %+v", len(fragments), len(want), fragments)
+	}
+	for i, w := range want {
+		if fragments[i] != w {
+			t.Errorf("fragment %d = %+v, want %+v", i, fragments[i], w)
+		}
+	}
+}
+
+func TestParseTableLayout(t *testing.T) {
+	parser := NewParser()
+
+	// Two-column plate with clearly fictional data. The value column is emitted
+	// first and in a different order than the labels, as some generators do.
+	// The tax office label is hex-encoded Windows-1254 ("VERGİ DAİRESİ").
+	content := `BT
+1 0 0 1 250 640 Tm (ORNEK VD) Tj
+1 0 0 1 250 700 Tm (ALI ORNEK) Tj
+1 0 0 1 250 620 Tm (1234567890) Tj
+1 0 0 1 250 680 Tm (ORNEK MAH. TEST CAD. NO:1) Tj
+1 0 0 1 250 600 Tm (11111111110) Tj
+1 0 0 1 250 660 Tm (YILLIK GELIR VERGISI) Tj
+1 0 0 1 250 580 Tm (01.01.2020) Tj
+ET
+BT
+1 0 0 1 50 700 Tm (ADI SOYADI) Tj
+0 -20 Td (IS YERI ADRESI) Tj
+0 -20 Td (VERGI TURU) Tj
+0 -20 Td <56455247DD204441DD524553DD> Tj
+0 -20 Td (VERGI KIMLIK NO) Tj
+0 -20 Td (TC KIMLIK NO) Tj
+0 -20 Td (ISE BASLAMA TARIHI) Tj
+ET`
+
+	vl := &VergiLevhasi{}
+	parser.parseTableLayout(vl, groupTextRows(extractTextFragments(content)))
+
+	checks := []struct {
+		field string
+		got   string
+		want  string
+	}{
+		{"AdiSoyadi", vl.AdiSoyadi, "ALI ORNEK"},
+		{"IsYeriAdresi", vl.IsYeriAdresi, "ORNEK MAH. TEST CAD. NO:1"},
+		{"VergiDairesi", vl.VergiDairesi, "ORNEK VD"},
+		{"VergiKimlikNo", vl.VergiKimlikNo, "1234567890"},
+		{"TCKimlikNo", vl.TCKimlikNo, "11111111110"},
+	}
+	for _, c := range checks {
+		if c.got != c.want {
+			t.Errorf("%s = %q, want %q", c.field, c.got, c.want)
+		}
+	}
+
+	if len(vl.VergiTuru) != 1 || vl.VergiTuru[0] != "Yıllık Gelir Vergisi" {
+		t.Errorf("VergiTuru = %v, want [Yıllık Gelir Vergisi]", vl.VergiTuru)
+	}
+
+	if vl.IseBaslamaTarihi == nil {
+		t.Error("IseBaslamaTarihi is nil")
+	} else if !vl.IseBaslamaTarihi.Equal(time.Date(2020, 1, 1, 0, 0, 0, 0, time.UTC)) {
+		t.Errorf("IseBaslamaTarihi = %v, want 2020-01-01", vl.IseBaslamaTarihi)
+	}
+}
+
+func TestParseTableLayoutForeignIndividual(t *testing.T) {
+	parser := NewParser()
+
+	content := `BT
+1 0 0 1 50 700 Tm (UYRUK) Tj
+1 0 0 1 250 700 Tm (ALMANYA) Tj
+1 0 0 1 50 680 Tm (PASAPORT NO:) Tj
+1 0 0 1 250 680 Tm (c01x00t47) Tj
+ET`
+
+	vl := &VergiLevhasi{}
+	parser.parseTableLayout(vl, groupTextRows(extractTextFragments(content)))
+
+	if vl.Uyruk != "ALMANYA" {
+		t.Errorf("Uyruk = %q, want %q", vl.Uyruk, "ALMANYA")
+	}
+	if vl.PasaportNo != "C01X00T47" {
+		t.Errorf("PasaportNo = %q, want %q", vl.PasaportNo, "C01X00T47")
+	}
+}
+
+func TestParseTableLayoutIgnoresLabelWithoutValue(t *testing.T) {
+	parser := NewParser()
+
+	rows := []textRow{
+		{Y: 700, Cells: []textFragment{{Text: "VERGİ DAİRESİ", X: 50, Y: 700}}},
+		{Y: 680, Cells: []textFragment{{Text: "VERGİ KİMLİK NO:", X: 50, Y: 680}, {Text: "not a number", X: 250, Y: 680}}},
+	}
+
+	vl := &VergiLevhasi{VergiDairesi: "Örnek VD"}
+	parser.parseTableLayout(vl, rows)
+
+	if vl.VergiDairesi != "Örnek VD" {
+		t.Errorf("VergiDairesi = %q, want existing value kept", vl.VergiDairesi)
+	}
+	if vl.VergiKimlikNo != "" {
+		t.Errorf("VergiKimlikNo = %q, want empty", vl.VergiKimlikNo)
+	}
+}
+
+func TestPageFromContentRotated(t *testing.T) {
+	// A landscape plate stored on a /Rotate 90 page: glyphs run up the page, so each
+	// displayed row shares an x coordinate and its cells are stacked along y
+	content := `BT
+0 1 -1 0 100 50 Tm (ADI SOYADI) Tj
+0 1 -1 0 100 250 Tm (ALI ORNEK) Tj
+0 1 -1 0 120 50 Tm (VERGI KIMLIK NO) Tj
+0 1 -1 0 120 250 Tm (1234567890) Tj
+0 1 -1 0 140 50 Tm (VERGI DAIRESI) Tj
+0 1 -1 0 140 250 Tm (ORNEK VD) Tj
+ET`
+
+	parser := NewParser()
+
+	vl := &VergiLevhasi{}
+	parser.parseTableLayout(vl, pageFromContent(content, nil, 90).rows)
+	if vl.AdiSoyadi != "ALI ORNEK" || vl.VergiKimlikNo != "1234567890" || vl.VergiDairesi != "ORNEK VD" {
+		t.Errorf("parseTableLayout() of a /Rotate 90 page = %+v, want the name, VKN and tax office", vl)
+	}
+
+	// Grouped in user space, the labels form one row and the values another
+	unrotated := &VergiLevhasi{}
+	parser.parseTableLayout(unrotated, pageFromContent(content, nil, 0).rows)
+	if unrotated.AdiSoyadi != "" || unrotated.VergiKimlikNo != "" {
+		t.Errorf("parseTableLayout() ignoring /Rotate = %+v, want no fields", unrotated)
+	}
+}
+
+func TestRotateFragments(t *testing.T) {
+	fragments := []textFragment{{Text: "A", X: 10, Y: 20}}
+
+	for rotate, want := range map[int]textFragment{
+		0:    {Text: "A", X: 10, Y: 20},
+		90:   {Text: "A", X: 20, Y: -10},
+		180:  {Text: "A", X: -10, Y: -20},
+		270:  {Text: "A", X: -20, Y: 10},
+		-90:  {Text: "A", X: -20, Y: 10},
+		450:  {Text: "A", X: 20, Y: -10},
+		45:   {Text: "A", X: 10, Y: 20},
+		-360: {Text: "A", X: 10, Y: 20},
+	} {
+		if got := rotateFragments(fragments, rotate); got[0] != want {
+			t.Errorf("rotateFragments(%d) = %+v, want %+v", rotate, got[0], want)
+		}
+	}
+}
@@ -0,0 +1,115 @@
+package vergilevhasi
+
+import (
+	"fmt"
+	"regexp"
+)
+
+// PatternMatch is one regex match recorded while parsing, for bug reports on extraction
+// failures. Start and End are byte offsets into the text the pattern ran on: the page
+// text with Unicode digits folded to ASCII and the generation timestamp blanked out, so
+// they may differ slightly from RawText.
+type PatternMatch struct {
+	// Pattern names the pattern, e.g. "labelVKN[1]" for the second label VKN pattern
+	Pattern string `json:"pattern"`
+
+	// Text is the whole matched text
+	Text  string `json:"text"`
+	Start int    `json:"start"`
+	End   int    `json:"end"`
+
+	// Selected is set for matches whose value was used; the others were rejected by a
+	// later check or lost to an earlier pattern
+	Selected bool `json:"selected"`
+}
+
+// patternNames names the shared regexes in collected matches. Pattern lists are named
+// after their variable, with the index of the pattern in the list.
+var patternNames = func() map[*regexp.Regexp]string {
+	names := map[*regexp.Regexp]string{
+		dateRe:                "date",
+		gibNameRe:             "gibName",
+		gibAddressRe:          "gibAddress",
+		gibTaxOfficeRe:        "gibTaxOffice",
+		singleLineActivityRe:  "singleLineActivity",
+		taxBaseRe:             "taxBase",
+		generationTimestampRe: "generationTimestamp",
+	}
+	lists := map[string][]*regexp.Regexp{
+		"labelAdiSoyadi":      labelAdiSoyadiPatterns,
+		"labelTicaretUnvani":  labelTicaretUnvaniPatterns,
+		"labelIsYeriAdresi":   labelIsYeriAdresiPatterns,
+		"labelEkAdres":        labelEkAdresPatterns,
+		"labelVergiDairesi":   labelVergiDairesiPatterns,
+		"labelVKN":            labelVKNPatterns,
+		"labelTCKN":           labelTCKNPatterns,
+		"labelUyruk":          labelUyrukPatterns,
+		"labelPasaportNo":     labelPasaportNoPatterns,
+		"labelKayitNo":        labelKayitNoPatterns,
+		"labelPotansiyelVKN":  labelPotansiyelVKNPatterns,
+		"labelYabanciVergiNo": labelYabanciVergiNoPatterns,
+		"labelIseBaslama":     labelIseBaslamaPatterns,
+		"bareVKN":             bareVKNPatterns,
+		"subeKodu":            subeKoduPatterns,
+		"bareTCKN":            bareTCKNPatterns,
+		"certAdiSoyadi":       certAdiSoyadiPatterns,
+		"certTicaretUnvani":   certTicaretUnvaniPatterns,
+		"certIsYeriAdresi":    certIsYeriAdresiPatterns,
+		"certVergiDairesi":    certVergiDairesiPatterns,
+		"certVKN":             certVKNPatterns,
+		"certTCKN":            certTCKNPatterns,
+		"certIseBaslama":      certIseBaslamaPatterns,
+	}
+	for name, list := range lists {
+		for i, re := range list {
+			names[re] = fmt.Sprintf("%s[%d]", name, i)
+		}
+	}
+	return names
+}()
+
+// SetCollectMatches enables recording every match of the field patterns during Parse,
+// including matches that were rejected or lost to an earlier pattern. The matches of the
+// last parse are returned by Matches. Collection is off by default since it runs each
+// pattern over the whole text.
+func (p *Parser) SetCollectMatches(enabled bool) {
+	p.collectMatches = enabled
+	if !enabled {
+		p.matches = nil
+	}
+}
+
+// Matches returns the pattern matches recorded by the last Parse, in the order the
+// patterns ran, when enabled with SetCollectMatches
+func (p *Parser) Matches() []PatternMatch {
+	return p.matches
+}
+
+// recordMatches records every match of re in text, marking the selected-th match (0 for
+// the first) as used; -1 marks none
+func (p *Parser) recordMatches(re *regexp.Regexp, text string, selected int) {
+	if !p.collectMatches {
+		return
+	}
+	for i, loc := range re.FindAllStringIndex(text, -1) {
+		p.recordMatch(re, text, loc, i == selected)
+	}
+}
+
+// recordMatch records the match of re at loc in text
+func (p *Parser) recordMatch(re *regexp.Regexp, text string, loc []int, selected bool) {
+	if !p.collectMatches {
+		return
+	}
+	name, ok := patternNames[re]
+	if !ok {
+		name = re.String()
+	}
+	p.matches = append(p.matches, PatternMatch{
+		Pattern:  name,
+		Text:     text[loc[0]:loc[1]],
+		Start:    loc[0],
+		End:      loc[1],
+		Selected: selected,
+	})
+}
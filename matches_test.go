@@ -0,0 +1,75 @@
+package vergilevhasi
+
+import (
+	"bytes"
+	"testing"
+)
+
+func TestCollectMatches(t *testing.T) {
+	backend := &fakeBackend{pages: []PageText{{Number: 1, Text: "Adı Soyadı: Ali Örnek\n" +
+		"Vergi Dairesi: Örnek VD\n" +
+		"Vergi Kimlik No: 1234567890\n" +
+		"İşe Başlama Tarihi: 01.02.2020\n" +
+		"1999 450.000,00 TL\n" +
+		"2022 450.000,00 TL\n"}}}
+
+	parser := NewParser()
+	parser.SetBackend(backend)
+
+	if _, err := parser.Parse(bytes.NewReader(nil)); err != nil {
+		t.Fatalf("Parse() error = %v", err)
+	}
+	if got := parser.Matches(); got != nil {
+		t.Fatalf("Matches() without SetCollectMatches = %+v, want nil", got)
+	}
+
+	parser.SetCollectMatches(true)
+	if _, err := parser.Parse(bytes.NewReader(nil)); err != nil {
+		t.Fatalf("Parse() error = %v", err)
+	}
+	matches := parser.Matches()
+
+	find := func(pattern, text string) *PatternMatch {
+		for i, m := range matches {
+			if m.Pattern == pattern && m.Text == text {
+				return &matches[i]
+			}
+		}
+		return nil
+	}
+
+	tests := []struct {
+		pattern  string
+		text     string
+		selected bool
+	}{
+		{"labelVKN[0]", "Vergi Kimlik No: 1234567890", true},
+		{"labelAdiSoyadi[0]", "Adı Soyadı: Ali Örnek\n", true},
+		// Also matches, but the first pattern already won
+		{"labelAdiSoyadi[1]", "Adı Soyadı: Ali Örnek\n", false},
+		{"taxBase", "2022 450.000,00 TL", true},
+		// Rejected: the year is out of range
+		{"taxBase", "1999 450.000,00 TL", false},
+	}
+	for _, tt := range tests {
+		m := find(tt.pattern, tt.text)
+		if m == nil {
+			t.Errorf("no %s match of %q in %+v", tt.pattern, tt.text, matches)
+			continue
+		}
+		if m.Selected != tt.selected {
+			t.Errorf("%s match of %q: Selected = %v, want %v", tt.pattern, tt.text, m.Selected, tt.selected)
+		}
+		if m.End-m.Start != len(m.Text) {
+			t.Errorf("%s match of %q: offsets %d-%d do not span the text", tt.pattern, tt.text, m.Start, m.End)
+		}
+	}
+
+	// Each parse starts a new list
+	if _, err := parser.Parse(bytes.NewReader(nil)); err != nil {
+		t.Fatalf("Parse() error = %v", err)
+	}
+	if got := len(parser.Matches()); got != len(matches) {
+		t.Errorf("len(Matches()) after a second Parse = %d, want %d", got, len(matches))
+	}
+}
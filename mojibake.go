@@ -0,0 +1,55 @@
+package vergilevhasi
+
+import (
+	"strings"
+	"unicode/utf8"
+
+	"golang.org/x/text/encoding/charmap"
+)
+
+// latin1TurkishReplacer fixes Windows-1254 text that was decoded as Windows-1252 or
+// ISO-8859-1. Only the six Turkish letters differ between those code pages, and their
+// Latin-1 counterparts (Icelandic Ý, Þ, Ð, ...) never occur in Turkish text.
+var latin1TurkishReplacer = strings.NewReplacer(
+	"Ý", "İ", "ý", "ı",
+	"Þ", "Ş", "þ", "ş",
+	"Ð", "Ğ", "ð", "ğ",
+)
+
+// SetMojibakeRepair enables or disables repairing mis-decoded Turkish letters
+// (e.g. "ÞÝRKET" → "ŞİRKET", "ÅžÄ°RKET" → "ŞİRKET") in names, address and tax office.
+// Enabled by default.
+func (p *Parser) SetMojibakeRepair(enabled bool) {
+	p.repairMojibake = enabled
+}
+
+// repairMojibakeFields applies repairMojibake to the free-text fields
+func (p *Parser) repairMojibakeFields(vl *VergiLevhasi) {
+	if !p.repairMojibake {
+		return
+	}
+	vl.AdiSoyadi = repairMojibake(vl.AdiSoyadi)
+	vl.TicaretUnvani = repairMojibake(vl.TicaretUnvani)
+	vl.IsYeriAdresi = repairMojibake(vl.IsYeriAdresi)
+	for i, address := range vl.IsYeriAdresleri {
+		vl.IsYeriAdresleri[i] = repairMojibake(address)
+	}
+	vl.VergiDairesi = repairMojibake(vl.VergiDairesi)
+}
+
+// repairMojibake fixes the common code page confusions for Turkish letters:
+// UTF-8 bytes read as a single-byte code page ("Ä°" for "İ") and Windows-1254
+// bytes read as Latin-1 ("Ý" for "İ")
+func repairMojibake(s string) string {
+	if strings.ContainsAny(s, "ÃÄÅ") {
+		for _, cm := range []*charmap.Charmap{charmap.Windows1254, charmap.Windows1252} {
+			raw, err := cm.NewEncoder().String(s)
+			if err == nil && raw != s && utf8.ValidString(raw) {
+				s = raw
+				break
+			}
+		}
+	}
+
+	return latin1TurkishReplacer.Replace(s)
+}
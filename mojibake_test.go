@@ -0,0 +1,57 @@
+package vergilevhasi
+
+import (
+	"testing"
+)
+
+func TestRepairMojibake(t *testing.T) {
+	tests := []struct {
+		name  string
+		input string
+		want  string
+	}{
+		{"Windows-1254 read as Latin-1", "ÖRNEK ÞÝRKETÝ", "ÖRNEK ŞİRKETİ"},
+		{"Lowercase Latin-1 confusions", "Iþýk Daðý", "Işık Dağı"},
+		{"UTF-8 read as Windows-1254", "Ã–RNEK ÅžÄ°RKETÄ°", "ÖRNEK ŞİRKETİ"},
+		{"UTF-8 read as Windows-1252", "Ã§iÃ§ek ÅŸiÅŸli", "çiçek şişli"},
+		{"Correct text unchanged", "Çağrı Öztürk İş Merkezi", "Çağrı Öztürk İş Merkezi"},
+		{"ASCII unchanged", "ORNEK VD", "ORNEK VD"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := repairMojibake(tt.input); got != tt.want {
+				t.Errorf("repairMojibake(%q) = %q, want %q", tt.input, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestRepairMojibakeFields(t *testing.T) {
+	vl := &VergiLevhasi{
+		AdiSoyadi:     "ALÝ ÖRNEK",
+		TicaretUnvani: "ÖRNEK ÞÝRKETÝ",
+		IsYeriAdresi:  "ÖRNEK MAH. ÞÝÞLÝ",
+		VergiDairesi:  "ÞÝÞLÝ",
+	}
+
+	parser := NewParser()
+	parser.SetMojibakeRepair(false)
+	parser.repairMojibakeFields(vl)
+	if vl.VergiDairesi != "ÞÝÞLÝ" {
+		t.Errorf("VergiDairesi = %q, want unchanged when repair is disabled", vl.VergiDairesi)
+	}
+
+	parser.SetMojibakeRepair(true)
+	parser.repairMojibakeFields(vl)
+	want := VergiLevhasi{
+		AdiSoyadi:     "ALİ ÖRNEK",
+		TicaretUnvani: "ÖRNEK ŞİRKETİ",
+		IsYeriAdresi:  "ÖRNEK MAH. ŞİŞLİ",
+		VergiDairesi:  "ŞİŞLİ",
+	}
+	if vl.AdiSoyadi != want.AdiSoyadi || vl.TicaretUnvani != want.TicaretUnvani ||
+		vl.IsYeriAdresi != want.IsYeriAdresi || vl.VergiDairesi != want.VergiDairesi {
+		t.Errorf("repairMojibakeFields() = %+v, want %+v", *vl, want)
+	}
+}
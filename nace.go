@@ -0,0 +1,54 @@
+package vergilevhasi
+
+import "strconv"
+
+// naceSection is a NACE Rev. 2 section: the range of two-digit divisions it spans and
+// its Turkish name
+type naceSection struct {
+	first, last int
+	name        string
+}
+
+// naceSections lists the NACE Rev. 2 sections in division order, named as in the
+// Turkish classification (TÜİK)
+var naceSections = []naceSection{
+	{1, 3, "Tarım, Ormancılık ve Balıkçılık"},
+	{5, 9, "Madencilik ve Taş Ocakçılığı"},
+	{10, 33, "İmalat"},
+	{35, 35, "Elektrik, Gaz, Buhar ve İklimlendirme Üretimi ve Dağıtımı"},
+	{36, 39, "Su Temini; Kanalizasyon, Atık Yönetimi ve İyileştirme Faaliyetleri"},
+	{41, 43, "İnşaat"},
+	{45, 47, "Toptan ve Perakende Ticaret"},
+	{49, 53, "Ulaştırma ve Depolama"},
+	{55, 56, "Konaklama ve Yiyecek Hizmeti Faaliyetleri"},
+	{58, 63, "Bilgi ve İletişim"},
+	{64, 66, "Finans ve Sigorta Faaliyetleri"},
+	{68, 68, "Gayrimenkul Faaliyetleri"},
+	{69, 75, "Mesleki, Bilimsel ve Teknik Faaliyetler"},
+	{77, 82, "İdari ve Destek Hizmet Faaliyetleri"},
+	{84, 84, "Kamu Yönetimi ve Savunma; Zorunlu Sosyal Güvenlik"},
+	{85, 85, "Eğitim"},
+	{86, 88, "İnsan Sağlığı ve Sosyal Hizmet Faaliyetleri"},
+	{90, 93, "Kültür, Sanat, Eğlence, Dinlence ve Spor"},
+	{94, 96, "Diğer Hizmet Faaliyetleri"},
+	{97, 98, "Hanehalklarının İşveren Olarak Faaliyetleri"},
+	{99, 99, "Uluslararası Örgütler ve Temsilciliklerinin Faaliyetleri"},
+}
+
+// naceSektorGrubu returns the name of the NACE section an activity code belongs to,
+// found from its two-digit division, or "" if the division is not a NACE Rev. 2 one
+func naceSektorGrubu(kod string) string {
+	if len(kod) < 2 {
+		return ""
+	}
+	division, err := strconv.Atoi(kod[:2])
+	if err != nil {
+		return ""
+	}
+	for _, section := range naceSections {
+		if division >= section.first && division <= section.last {
+			return section.name
+		}
+	}
+	return ""
+}
@@ -0,0 +1,54 @@
+package vergilevhasi
+
+import (
+	"bytes"
+	"testing"
+)
+
+func TestNaceSektorGrubu(t *testing.T) {
+	tests := []struct {
+		kod  string
+		want string
+	}{
+		{"471101", "Toptan ve Perakende Ticaret"},
+		{"4711", "Toptan ve Perakende Ticaret"},
+		{"012345", "Tarım, Ormancılık ve Balıkçılık"},
+		{"620100", "Bilgi ve İletişim"},
+		{"351100", "Elektrik, Gaz, Buhar ve İklimlendirme Üretimi ve Dağıtımı"},
+		// Division 04 is not used by NACE Rev. 2
+		{"040000", ""},
+		{"7", ""},
+	}
+
+	for _, tt := range tests {
+		if got := naceSektorGrubu(tt.kod); got != tt.want {
+			t.Errorf("naceSektorGrubu(%q) = %q, want %q", tt.kod, got, tt.want)
+		}
+	}
+}
+
+func TestParseSektorGrubu(t *testing.T) {
+	parser := NewParser()
+	parser.SetBackend(&fakeBackend{pages: []PageText{{Number: 1, Text: syntheticPlateText(&VergiLevhasi{
+		AdiSoyadi:     "ALİ ÖRNEK",
+		VergiKimlikNo: "1234567890",
+		VergiTuru:     []string{"Gelir Vergisi"},
+		FaaliyetKodlari: []Faaliyet{
+			{Kod: "471101", Ad: "BAKKAL VE MARKETLERDE YAPILAN PERAKENDE TİCARET"},
+			{Kod: "620100", Ad: "BİLGİSAYAR PROGRAMLAMA FAALİYETLERİ"},
+		},
+	})}}})
+
+	vl, err := parser.Parse(bytes.NewReader(nil))
+	if err != nil {
+		t.Fatalf("Parse() error = %v", err)
+	}
+	if vl.SektorGrubu != "Toptan ve Perakende Ticaret" {
+		t.Errorf("SektorGrubu = %q, want the sector of the first activity", vl.SektorGrubu)
+	}
+
+	parser.SetFields(AllFields &^ FieldFaaliyetKodlari)
+	if vl, err := parser.Parse(bytes.NewReader(nil)); err != nil || vl.SektorGrubu != "" {
+		t.Errorf("SektorGrubu without activities = %q, %v; want empty", vl.SektorGrubu, err)
+	}
+}
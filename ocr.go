@@ -25,24 +25,29 @@ package vergilevhasi
 
 import (
 	"bytes"
+	"context"
+	"errors"
 	"fmt"
 	"image"
 	"image/color"
 	"image/png"
 	"io"
+	"log"
 	"math"
 	"os"
+	"path/filepath"
 	"regexp"
 	"sort"
 	"strings"
+	"sync"
+	"time"
+	"unicode"
 
 	_ "image/gif"
 	_ "image/jpeg"
 
 	"github.com/makiuchi-d/gozxing"
 	"github.com/makiuchi-d/gozxing/oned"
-	"github.com/pdfcpu/pdfcpu/pkg/api"
-	"github.com/pdfcpu/pdfcpu/pkg/pdfcpu/model"
 )
 
 // OCRParser provides OCR capabilities for VKN extraction
@@ -51,14 +56,72 @@ type OCRParser struct {
 	*Parser
 	classifier *DigitClassifier
 	debug      bool
+
+	// thorough enables a second, heavier barcode pass over every embedded image
+	thorough bool
+
+	// alternateDigits enables the top-2 candidate search when the greedy VKN fails the checksum
+	alternateDigits bool
+
+	// minVKNConfidence rejects OCR digit results whose weakest digit is below it; 0 disables
+	minVKNConfidence float64
+
+	// expectedVKN is the VKN the caller expects; candidates matching it are accepted first
+	expectedVKN string
+
+	// regionFilter selects the connected regions read as digits
+	regionFilter DigitRegionFilter
+
+	// quietZone is the white margin, in pixels, added around barcode crops
+	quietZone int
+
+	// debugDir is where the per-extraction debug image directories are created; "" means
+	// the working directory
+	debugDir string
+
+	// scanBudget limits the time of one extraction from images; 0 means no limit
+	scanBudget time.Duration
+
+	// digitFont selects the recognizer of printed digits
+	digitFont DigitFont
+
+	// deadline is when the current extraction runs out of budget. It is only set on the
+	// per-extraction copy made by withScanDeadline.
+	deadline time.Time
+
+	// ctx cancels the extractions of a parse, which give up as if out of budget; nil
+	// never cancels
+	ctx context.Context
 }
 
+// ErrLowConfidence is returned when the only VKN found comes from OCR digits whose
+// confidence is below the floor set with OCRParser.SetMinVKNConfidence
+var ErrLowConfidence = errors.New("VKN confidence below minimum")
+
+// ErrNoBarcodePresent is returned when no image of the document contains anything that
+// looks like a barcode, as on older text-only plates; a better scan will not help
+var ErrNoBarcodePresent = errors.New("no barcode present")
+
+// ErrBarcodeUnreadable is returned when a barcode-like region was found but could not be
+// decoded into a VKN; a sharper or higher-resolution scan may succeed
+var ErrBarcodeUnreadable = errors.New("barcode unreadable")
+
+// ErrBarcodeCheckDigit is returned when a barcode payload carries the VKN's check digit
+// apart from its first nine digits and it does not match them, which usually means a bar
+// was misread
+var ErrBarcodeCheckDigit = errors.New("barcode check digit mismatch")
+
 // NewOCRParser creates a new OCR parser with zero dependencies
 func NewOCRParser() (*OCRParser, error) {
 	return &OCRParser{
 		Parser:     NewParser(),
-		classifier: NewDigitClassifier(),
+		classifier: sharedDigitClassifier(),
 		debug:      false,
+
+		regionFilter: DefaultDigitRegionFilter(),
+		quietZone:    DefaultBarcodeQuietZone,
+		scanBudget:   DefaultScanBudget,
+		digitFont:    DigitFontGeneric,
 	}, nil
 }
 
@@ -72,207 +135,93 @@ func (p *OCRParser) SetOCRDebug(debug bool) {
 	p.debug = debug
 }
 
-// ExtractVKNFromPDFWithImage extracts VKN from a PDF by extracting embedded images and scanning barcodes
-// Uses pdfcpu for image extraction (pure Go, no external dependencies)
-func (p *OCRParser) ExtractVKNFromPDFWithImage(data []byte) (string, error) {
-	if p.debug {
-		fmt.Println("Extracting images from PDF using pdfcpu...")
-	}
-
-	vkn, err := p.ExtractVKNFromPDFBytes(data)
-
-	return vkn, err
+// SetDebugDir sets the directory under which debug images are written when debug output
+// is enabled. Each extraction writes into its own new subdirectory, so concurrent
+// extractions never overwrite each other's images. "" (the default) is the working directory.
+func (p *OCRParser) SetDebugDir(dir string) {
+	p.debugDir = dir
 }
 
-// extractAllPDFImages extracts all images embedded in a PDF using pdfcpu's native extraction
-func (p *OCRParser) extractAllPDFImages(pdfData []byte) (images []image.Image, err error) {
-	// Recover from any panics in pdfcpu
-	defer func() {
-		if r := recover(); r != nil {
-			err = fmt.Errorf("panic while extracting PDF images: %v", r)
-			images = nil
-		}
-	}()
-
-	// Create a reader from the data
-	rs := bytes.NewReader(pdfData)
-
-	// Create pdfcpu configuration
-	conf := model.NewDefaultConfiguration()
-
-	// Use api.ExtractImagesRaw to get all images
-	pageImages, err := api.ExtractImagesRaw(rs, nil, conf)
-	if err != nil {
-		return nil, fmt.Errorf("failed to extract images: %w", err)
-	}
-
-	if p.debug {
-		fmt.Printf("Found images on %d pages\n", len(pageImages))
-	}
-
-	// Process images from all pages
-	for pageNr, imgMap := range pageImages {
-		if p.debug {
-			fmt.Printf("Page %d: found %d images\n", pageNr+1, len(imgMap))
-		}
-
-		for objNr, pdfImage := range imgMap {
-			if p.debug {
-				fmt.Printf("Image obj %d: type=%s, %dx%d, bpc=%d, comp=%d\n",
-					objNr, pdfImage.FileType, pdfImage.Width, pdfImage.Height, pdfImage.Bpc, pdfImage.Comp)
-			}
-			// Decode the image from the pdfcpu Image reader
-			img, err := p.decodePDFCPUImage(pdfImage)
-			if err != nil {
-				if p.debug {
-					fmt.Printf("Failed to decode image obj %d: %v\n", objNr, err)
-				}
-				continue
-			}
-			images = append(images, img)
-		}
+// newDebugImageDir creates a directory, unique to one extraction, for its debug images
+func (p *OCRParser) newDebugImageDir() (string, error) {
+	base := p.debugDir
+	if base == "" {
+		base = "."
 	}
-
-	if len(images) == 0 {
-		return nil, fmt.Errorf("no images found in PDF")
-	}
-
-	return images, nil
-}
-
-// decodePDFCPUImage decodes a pdfcpu model.Image to a Go image.Image
-func (p *OCRParser) decodePDFCPUImage(pdfImage model.Image) (image.Image, error) {
-	// Read all data from the image reader
-	data, err := io.ReadAll(pdfImage)
+	dir, err := os.MkdirTemp(base, "vergilevhasi-debug-")
 	if err != nil {
-		return nil, fmt.Errorf("failed to read image data: %w", err)
+		return "", fmt.Errorf("failed to create debug image directory: %w", err)
 	}
+	fmt.Printf("Writing debug images to %s\n", dir)
+	return dir, nil
+}
 
-	// Create a reader from the data
-	reader := bytes.NewReader(data)
+// SetThoroughBarcodeScan enables thorough barcode scanning for PDFs whose barcode is not the
+// first embedded image (multi-page or reordered documents). Images are still tried in page
+// order, first image first; when the regular pass finds nothing, every image is scanned again
+// upscaled and with the decoder's try-harder mode. This is slower on PDFs without a barcode.
+func (p *OCRParser) SetThoroughBarcodeScan(enabled bool) {
+	p.thorough = enabled
+}
 
-	// Try to decode based on the FileType
-	fileType := strings.ToLower(pdfImage.FileType)
+// SetAlternateDigitSearch enables a fallback for image OCR: when the greedily recognized VKN
+// fails the checksum, the second most likely digit at each position is tried as well and
+// the most likely checksum-valid candidate is used
+func (p *OCRParser) SetAlternateDigitSearch(enabled bool) {
+	p.alternateDigits = enabled
+}
 
-	switch fileType {
-	case "png":
-		return png.Decode(reader)
-	case "jpg", "jpeg":
-		return decodeJPEG(reader)
-	case "gif":
-		return decodeGIF(reader)
-	default:
-		// Try standard image.Decode which handles registered formats
-		img, _, err := image.Decode(reader)
-		if err != nil {
-			// If standard decode fails, try to decode as raw image data
-			return p.decodeRawImageData(data, pdfImage)
-		}
-		return img, nil
-	}
+// SetMinVKNConfidence sets a confidence floor for VKNs read by image OCR. When the only
+// VKN found comes from recognized digits and its least confident digit scores below min,
+// image extraction fails with ErrLowConfidence instead of returning a best guess.
+// Barcode results are not affected. 0 (the default) disables the floor.
+func (p *OCRParser) SetMinVKNConfidence(min float64) {
+	p.minVKNConfidence = min
 }
 
-// decodeJPEG decodes JPEG image data
-func decodeJPEG(r io.Reader) (image.Image, error) {
-	// image/jpeg is already registered via _ "image/jpeg" import
-	img, _, err := image.Decode(r)
-	return img, err
+// SetDigitRegionFilter sets the bounds on the connected regions read as digits by image
+// OCR, e.g. to accept narrower or wider glyphs of an unusual font
+func (p *OCRParser) SetDigitRegionFilter(f DigitRegionFilter) {
+	p.regionFilter = f
 }
 
-// decodeGIF decodes GIF image data
-func decodeGIF(r io.Reader) (image.Image, error) {
-	// image/gif is already registered via _ "image/gif" import
-	img, _, err := image.Decode(r)
-	return img, err
+// SetExpectedVKN sets the VKN the caller already expects, e.g. from an invoice, so that
+// extraction verifies it rather than re-deriving it blindly. A barcode or digit reading that
+// matches it is accepted immediately, even when the digits are ambiguous or below the
+// confidence floor. When only a different VKN is found it is still returned, with a
+// warning logged and ExtractVKNResult.ExpectedVKNMismatch set. "" (the default) disables it.
+func (p *OCRParser) SetExpectedVKN(vkn string) {
+	p.expectedVKN = vkn
 }
 
-// decodeRawImageData attempts to decode raw image data based on PDF image properties
-func (p *OCRParser) decodeRawImageData(data []byte, pdfImage model.Image) (image.Image, error) {
-	width := pdfImage.Width
-	height := pdfImage.Height
-	bpc := pdfImage.Bpc   // bits per component
-	comp := pdfImage.Comp // number of color components
+// checkExpectedVKN reports whether vkn contradicts the expected VKN, logging a warning if so
+func (p *OCRParser) checkExpectedVKN(vkn string) bool {
+	if p.expectedVKN == "" || vkn == "" || vkn == p.expectedVKN {
+		return false
+	}
+	log.Printf("Warning: extracted VKN %s does not match the expected VKN %s", vkn, p.expectedVKN)
+	return true
+}
 
-	if width <= 0 || height <= 0 {
-		return nil, fmt.Errorf("invalid image dimensions: %dx%d", width, height)
+// ExtractVKNFromPDFWithImage extracts VKN from a PDF by extracting embedded images and scanning barcodes
+// Uses pdfcpu for image extraction (pure Go, no external dependencies)
+func (p *OCRParser) ExtractVKNFromPDFWithImage(data []byte) (string, error) {
+	if p.debug {
+		fmt.Println("Extracting images from PDF using pdfcpu...")
 	}
 
-	// Calculate expected data size
-	expectedSize := width * height * comp * bpc / 8
-	if len(data) < expectedSize {
-		// Try image.Decode as fallback
-		img, _, err := image.Decode(bytes.NewReader(data))
-		if err == nil {
-			return img, nil
-		}
-		return nil, fmt.Errorf("data size mismatch: got %d, expected %d", len(data), expectedSize)
-	}
-
-	// Create image based on color space
-	switch {
-	case pdfImage.Cs == "DeviceGray" || comp == 1:
-		// Grayscale image
-		img := image.NewGray(image.Rect(0, 0, width, height))
-		if bpc == 8 {
-			copy(img.Pix, data[:width*height])
-		} else if bpc == 1 {
-			// 1-bit image (black and white)
-			for y := 0; y < height; y++ {
-				for x := 0; x < width; x++ {
-					byteIdx := (y*width + x) / 8
-					bitIdx := 7 - ((y*width + x) % 8)
-					if byteIdx < len(data) {
-						bit := (data[byteIdx] >> bitIdx) & 1
-						if bit == 0 {
-							img.SetGray(x, y, color.Gray{0}) // black
-						} else {
-							img.SetGray(x, y, color.Gray{255}) // white
-						}
-					}
-				}
-			}
-		}
-		return img, nil
+	vkn, err := p.ExtractVKNFromPDFBytes(data)
 
-	case pdfImage.Cs == "DeviceRGB" || comp == 3:
-		// RGB image
-		img := image.NewRGBA(image.Rect(0, 0, width, height))
-		for y := 0; y < height; y++ {
-			for x := 0; x < width; x++ {
-				idx := (y*width + x) * 3
-				if idx+2 < len(data) {
-					img.SetRGBA(x, y, color.RGBA{data[idx], data[idx+1], data[idx+2], 255})
-				}
-			}
-		}
-		return img, nil
-
-	case pdfImage.Cs == "DeviceCMYK" || comp == 4:
-		// CMYK image - convert to RGBA
-		img := image.NewRGBA(image.Rect(0, 0, width, height))
-		for y := 0; y < height; y++ {
-			for x := 0; x < width; x++ {
-				idx := (y*width + x) * 4
-				if idx+3 < len(data) {
-					c, m, yk, k := data[idx], data[idx+1], data[idx+2], data[idx+3]
-					// Convert CMYK to RGB
-					r := 255 - min(255, int(c)+int(k))
-					g := 255 - min(255, int(m)+int(k))
-					b := 255 - min(255, int(yk)+int(k))
-					img.SetRGBA(x, y, color.RGBA{uint8(r), uint8(g), uint8(b), 255})
-				}
-			}
-		}
-		return img, nil
+	return vkn, err
+}
 
-	default:
-		// Try standard decode as last resort
-		img, _, err := image.Decode(bytes.NewReader(data))
-		if err != nil {
-			return nil, fmt.Errorf("unsupported color space: %s with %d components", pdfImage.Cs, comp)
-		}
-		return img, nil
+// extractAllPDFImages extracts all images embedded in a PDF using the configured backend
+func (p *OCRParser) extractAllPDFImages(pdfData []byte) ([]image.Image, error) {
+	backend := p.Parser.backend
+	if backend == nil {
+		backend = &pdfcpuBackend{debug: p.debug}
 	}
+	return backend.ExtractImages(pdfData)
 }
 
 // upscaleImage upscales an image by the given factor using nearest-neighbor interpolation
@@ -299,66 +248,178 @@ func (p *OCRParser) upscaleImage(img image.Image, factor int) image.Image {
 // ExtractVKNFromPDFReaderWithImage extracts VKN from a PDF reader by extracting embedded images
 // Uses pdfcpu for image extraction (pure Go, no external dependencies)
 func (p *OCRParser) ExtractVKNFromPDFReaderWithImage(reader io.Reader) (string, error) {
-	// Read all data first
-	data, err := io.ReadAll(reader)
+	// Read all data first, up to the parser's size cap
+	data, err := p.readPDF(reader)
 	if err != nil {
-		return "", fmt.Errorf("failed to read PDF data: %w", err)
+		return "", err
 	}
 
 	// Extract all embedded images using pdfcpu
 	images, err := p.extractAllPDFImages(data)
-	if err != nil {
-		return "", fmt.Errorf("failed to extract images from PDF: %w", err)
+	vkn, _, err := p.extractVKNFromImages(images, err)
+	return vkn, err
+}
+
+// extractVKNFromImages scans images already extracted from a PDF for the VKN barcode,
+// reporting whether the payload's check digit was verified. extractErr is the error
+// returned by the image extraction, if any.
+func (p *OCRParser) extractVKNFromImages(images []image.Image, extractErr error) (string, bool, error) {
+	if extractErr != nil {
+		return "", false, fmt.Errorf("failed to extract images from PDF: %w", extractErr)
 	}
 
 	if len(images) == 0 {
-		return "", fmt.Errorf("no images found in PDF")
+		return "", false, fmt.Errorf("no images found in PDF: %w", ErrNoBarcodePresent)
 	}
 
 	if p.debug {
 		fmt.Printf("Found %d embedded images in PDF\n", len(images))
 	}
 
+	return p.withScanDeadline().scanImagesForVKN(images)
+}
+
+// scanImagesForVKN tries each image in order and returns the first valid VKN found in a
+// barcode, and whether its payload's check digit was verified. With an expected VKN set,
+// scanning continues past other VKNs until the expected one is found.
+func (p *OCRParser) scanImagesForVKN(images []image.Image) (string, bool, error) {
+	first, firstCheckDigit := "", false
+
+	var debugDir string
+	if p.debug {
+		dir, err := p.newDebugImageDir()
+		if err != nil {
+			return "", false, err
+		}
+		debugDir = dir
+	}
+
 	// Try each image for barcode scanning
 	for i, img := range images {
-		if p.debug {
-			fmt.Printf("Scanning image %d: %dx%d\n", i+1, img.Bounds().Dx(), img.Bounds().Dy())
-			_ = saveImage(img, fmt.Sprintf("debug_image_%d.png", i+1))
+		if p.budgetExceeded() {
+			break
 		}
-
-		// Try Code128 barcode scan (VKN barcode is Code128)
-		if vkn, err := p.scanCode128Barcode(img); err == nil && vkn != "" {
-			if p.debug {
-				fmt.Printf("Successfully extracted VKN from image %d: %s\n", i+1, vkn)
-			}
-			return vkn, nil
+		vkn, checkDigit := p.scanImageForVKN(i, img, debugDir)
+		if vkn == "" {
+			continue
 		}
-
-		// Try general barcode scan
-		if vkn, err := p.scanBarcode(img); err == nil && vkn != "" {
-			if p.debug {
-				fmt.Printf("Successfully extracted VKN from image %d: %s\n", i+1, vkn)
-			}
-			return vkn, nil
+		if p.expectedVKN == "" || vkn == p.expectedVKN {
+			return vkn, checkDigit, nil
+		}
+		if first == "" {
+			first, firstCheckDigit = vkn, checkDigit
 		}
+	}
 
-		// Try upscaling if the image is small
-		if img.Bounds().Dx() < 500 || img.Bounds().Dy() < 100 {
-			upscaled := p.upscaleImage(img, 4)
-			if p.debug {
-				fmt.Printf("Upscaled image %d to: %dx%d\n", i+1, upscaled.Bounds().Dx(), upscaled.Bounds().Dy())
-				_ = saveImage(upscaled, fmt.Sprintf("debug_image_%d_upscaled.png", i+1))
+	if p.thorough {
+		// Second pass: upscale every image, including large ones the regular pass skipped
+		for i, img := range images {
+			if p.budgetExceeded() {
+				break
 			}
-			if vkn, err := p.scanCode128Barcode(upscaled); err == nil && vkn != "" {
-				return vkn, nil
+			if img.Bounds().Dx() < 500 || img.Bounds().Dy() < 100 {
+				continue
 			}
-			if vkn, err := p.scanBarcode(upscaled); err == nil && vkn != "" {
-				return vkn, nil
+			upscaled := p.upscaleImage(img, 2)
+			if vkn, checkDigit, err := p.scanCode128Barcode(upscaled); err == nil && vkn != "" {
+				if p.debug {
+					fmt.Printf("Successfully extracted VKN from upscaled image %d: %s\n", i+1, vkn)
+				}
+				if p.expectedVKN == "" || vkn == p.expectedVKN {
+					return vkn, checkDigit, nil
+				}
+				if first == "" {
+					first, firstCheckDigit = vkn, checkDigit
+				}
 			}
 		}
 	}
 
-	return "", fmt.Errorf("could not extract VKN from PDF images")
+	if first != "" {
+		p.checkExpectedVKN(first)
+		return first, firstCheckDigit, nil
+	}
+
+	if p.budgetExceeded() {
+		return "", false, fmt.Errorf("could not extract VKN from PDF images: %w", p.budgetError())
+	}
+	return "", false, fmt.Errorf("could not extract VKN from PDF images: %w", barcodeFailure(images))
+}
+
+// barcodeFailure explains a failed barcode scan: ErrBarcodeUnreadable if any image has a
+// barcode-like region, ErrNoBarcodePresent otherwise
+func barcodeFailure(images []image.Image) error {
+	for _, img := range images {
+		if _, ok := findBarcodeRegion(img); ok {
+			return ErrBarcodeUnreadable
+		}
+	}
+	return ErrNoBarcodePresent
+}
+
+// ocrVKNFromImages reads the VKN from the printed digits of the first image that yields
+// one, for documents whose barcode could not be decoded
+func (p *OCRParser) ocrVKNFromImages(images []image.Image) string {
+	p = p.withScanDeadline()
+	for _, img := range images {
+		if p.budgetExceeded() {
+			break
+		}
+		if vkn, err := p.ExtractVKNFromImageData(img); err == nil && vkn != "" {
+			return vkn
+		}
+	}
+	return ""
+}
+
+// scanImageForVKN runs the barcode scans on the i-th image and returns the VKN, or "",
+// and whether the payload's check digit was verified. Debug images are written to debugDir.
+func (p *OCRParser) scanImageForVKN(i int, img image.Image, debugDir string) (string, bool) {
+	if p.debug {
+		fmt.Printf("Scanning image %d: %dx%d\n", i+1, img.Bounds().Dx(), img.Bounds().Dy())
+		_ = saveImage(img, filepath.Join(debugDir, fmt.Sprintf("debug_image_%d.png", i+1)))
+	}
+
+	// Try the analytically detected barcode region first
+	if vkn, checkDigit, err := p.scanBarcodeRegion(img); err == nil && vkn != "" {
+		if p.debug {
+			fmt.Printf("Successfully extracted VKN from barcode region of image %d: %s\n", i+1, vkn)
+		}
+		return vkn, checkDigit
+	}
+
+	// Try Code128 barcode scan (VKN barcode is Code128)
+	if vkn, checkDigit, err := p.scanCode128Barcode(img); err == nil && vkn != "" {
+		if p.debug {
+			fmt.Printf("Successfully extracted VKN from image %d: %s\n", i+1, vkn)
+		}
+		return vkn, checkDigit
+	}
+
+	// Try general barcode scan
+	if vkn, checkDigit, err := p.scanBarcode(img); err == nil && vkn != "" {
+		if p.debug {
+			fmt.Printf("Successfully extracted VKN from image %d: %s\n", i+1, vkn)
+		}
+		return vkn, checkDigit
+	}
+
+	// Try upscaling if the image is small
+	if img.Bounds().Dx() < 500 || img.Bounds().Dy() < 100 {
+		upscaled := p.upscaleImage(img, 4)
+		if p.debug {
+			fmt.Printf("Upscaled image %d to: %dx%d\n", i+1, upscaled.Bounds().Dx(), upscaled.Bounds().Dy())
+			_ = saveImage(upscaled, filepath.Join(debugDir, fmt.Sprintf("debug_image_%d_upscaled.png", i+1)))
+		}
+		if vkn, checkDigit, err := p.scanCode128Barcode(upscaled); err == nil && vkn != "" {
+			return vkn, checkDigit
+		}
+		if vkn, checkDigit, err := p.scanBarcode(upscaled); err == nil && vkn != "" {
+			return vkn, checkDigit
+		}
+	}
+
+	return "", false
 }
 
 // ExtractVKNFromPDFBytes extracts VKN from PDF bytes by extracting embedded images
@@ -449,33 +510,153 @@ func (p *OCRParser) ExtractVKNFromImageBytes(imgData []byte) (string, error) {
 	return p.ExtractVKNFromImageData(img)
 }
 
+// VKNFailureReason explains why no VKN could be extracted from an image
+type VKNFailureReason string
+
+const (
+	// VKNFailureTooFewDigits means fewer than 10 digits were recognized
+	VKNFailureTooFewDigits VKNFailureReason = "too_few_digits"
+
+	// VKNFailureNoValidPattern means enough digits were recognized but none formed a VKN
+	VKNFailureNoValidPattern VKNFailureReason = "no_valid_pattern"
+
+	// VKNFailureLowConfidence means a VKN was recognized but rejected by the confidence floor
+	VKNFailureLowConfidence VKNFailureReason = "low_confidence"
+
+	// VKNFailureBudgetExceeded means the scan budget ran out before digits were read
+	VKNFailureBudgetExceeded VKNFailureReason = "budget_exceeded"
+)
+
+// ExtractVKNResult is the detailed outcome of extracting a VKN from an image.
+// It is populated even when extraction fails so that failures can be audited.
+type ExtractVKNResult struct {
+	// VKN is the extracted tax ID, empty on failure
+	VKN string `json:"vkn,omitempty"`
+
+	// Source is where the VKN came from: "barcode" or "ocr"
+	Source string `json:"source,omitempty"`
+
+	// RecognizedDigits is the full digit stream recognized by the classifier, in reading order
+	RecognizedDigits string `json:"recognized_digits"`
+
+	// Confidence is the classifier confidence of the least confident VKN digit for OCR
+	// results, and 1 for barcode results
+	Confidence float64 `json:"confidence,omitempty"`
+
+	// CheckDigitVerified is set when the barcode payload carried the VKN's check digit
+	// apart from its first nine digits and it matched. Payloads with a mismatching check
+	// digit are rejected.
+	CheckDigitVerified bool `json:"check_digit_verified,omitempty"`
+
+	// Reason explains why no VKN was found, empty on success
+	Reason VKNFailureReason `json:"reason,omitempty"`
+
+	// ExpectedVKNMismatch is set when a VKN was found but differs from the one set with
+	// OCRParser.SetExpectedVKN
+	ExpectedVKNMismatch bool `json:"expected_vkn_mismatch,omitempty"`
+
+	// DebugDir is the directory this extraction wrote its debug images to, when debug
+	// output is enabled with OCRParser.SetOCRDebug
+	DebugDir string `json:"debug_dir,omitempty"`
+}
+
 // ExtractVKNFromImageData extracts VKN from an image.Image
 func (p *OCRParser) ExtractVKNFromImageData(img image.Image) (string, error) {
+	result, err := p.ExtractVKNFromImageDataResult(img)
+	if err != nil {
+		return "", err
+	}
+	return result.VKN, nil
+}
+
+// ExtractVKNFromImageDataResult extracts VKN from an image.Image and returns the full
+// recognized digit stream along with the reason for a failure. The result is non-nil
+// whenever the image could be processed, even if an error is returned.
+func (p *OCRParser) ExtractVKNFromImageDataResult(img image.Image) (*ExtractVKNResult, error) {
+	p = p.withScanDeadline()
+	result := &ExtractVKNResult{}
+
 	// Step 0: Try barcode scanning first (most reliable)
-	if vkn, err := p.scanBarcode(img); err == nil && vkn != "" {
+	if vkn, checkDigit, err := p.scanImageBarcode(img); err == nil && vkn != "" {
 		if p.debug {
 			fmt.Printf("Found VKN from barcode: %s\n", vkn)
 		}
-		return vkn, nil
+		result.VKN = vkn
+		result.Source = "barcode"
+		result.Confidence = 1
+		result.CheckDigitVerified = checkDigit
+		result.ExpectedVKNMismatch = p.checkExpectedVKN(vkn)
+		return result, nil
+	}
+	if p.budgetExceeded() {
+		result.Reason = VKNFailureBudgetExceeded
+		return result, p.budgetError()
+	}
+
+	// With an OCR engine installed, a checksum-valid VKN in the text it reads is taken
+	// before falling back to the digit classifier
+	if textRecognizer != nil {
+		if text, err := textRecognizer(img); err == nil {
+			if vkn := findTextVKN(text); vkn != "" {
+				if p.debug {
+					fmt.Printf("Found VKN from text recognizer: %s\n", vkn)
+				}
+				result.RecognizedDigits = vkn
+				return p.ocrVKNResult(result, vkn, nil)
+			}
+		} else if p.debug {
+			fmt.Printf("Text recognizer failed: %v\n", err)
+		}
+	}
+
+	var debugDir string
+	if p.debug {
+		dir, err := p.newDebugImageDir()
+		if err != nil {
+			return nil, err
+		}
+		debugDir = dir
+		result.DebugDir = dir
 	}
 
 	// Step 1: Convert to grayscale
 	grayImg := toGrayscale(img)
 
 	if p.debug {
-		err := saveImage(grayImg, "debug_01_grayscale.png")
+		err := saveImage(grayImg, filepath.Join(debugDir, "debug_01_grayscale.png"))
 		if err != nil {
-			return "", err
+			return nil, err
+		}
+	}
+
+	// The OCR-B templates match only the digits GİB prints, so they read just the VKN text
+	// area; the whole image below is left to the generic classifier
+	if p.digitFont == DigitFontOCRB {
+		if area, ok := cropVKNTextArea(grayImg); ok {
+			if p.debug {
+				fmt.Printf("Reading the VKN text area at %v with OCR-B templates\n", area.Bounds())
+			}
+			areaResult, err := p.readVKNDigits(&ExtractVKNResult{DebugDir: result.DebugDir}, area, sharedOCRBMatcher(), debugDir, "debug_vkn_area_")
+			if err == nil || errors.Is(err, ErrLowConfidence) {
+				return areaResult, err
+			}
 		}
 	}
 
+	return p.readVKNDigits(result, grayImg, p.classifier, debugDir, "debug_")
+}
+
+// readVKNDigits finds the digits of grayImg, classifies them with recognizer and picks
+// the VKN among them into result. Debug images are written to debugDir with the file
+// names starting with debugPrefix.
+func (p *OCRParser) readVKNDigits(result *ExtractVKNResult, grayImg *image.Gray, recognizer digitRecognizer, debugDir, debugPrefix string) (*ExtractVKNResult, error) {
 	// Step 2: Binarize with adaptive threshold
 	binaryImg := adaptiveBinarize(grayImg, 15, 10)
 
 	if p.debug {
-		err := saveImage(binaryImg, "debug_02_binary.png")
+		err := saveImage(binaryImg, filepath.Join(debugDir, debugPrefix+"02_binary.png"))
 		if err != nil {
-			return "", err
+			return nil, err
 		}
 	}
 
@@ -487,7 +668,7 @@ func (p *OCRParser) ExtractVKNFromImageData(img image.Image) (string, error) {
 	}
 
 	// Step 4: Filter regions that look like digits
-	digitRegions := filterDigitRegions(regions, binaryImg.Bounds())
+	digitRegions := filterDigitRegions(regions, p.regionFilter)
 
 	if p.debug {
 		fmt.Printf("Filtered to %d potential digits\n", len(digitRegions))
@@ -498,88 +679,165 @@ func (p *OCRParser) ExtractVKNFromImageData(img image.Image) (string, error) {
 
 	// Step 6: Recognize each digit
 	var allDigits strings.Builder
+	var confidences []float64
+	var distributions [][10]float64
 	for i, region := range sortedRegions {
 		// Extract and normalize digit image
 		digitImg := extractDigitImage(binaryImg, region)
 
 		// Classify the digit
-		digit, confidence := p.classifier.Classify(digitImg)
+		digit, confidence := recognizer.Classify(digitImg)
 
 		if p.debug {
 			fmt.Printf("Region %d at (%d,%d): digit=%d, confidence=%.2f\n",
 				i, region.Min.X, region.Min.Y, digit, confidence)
-			err := saveImage(digitImg, fmt.Sprintf("debug_digit_%02d.png", i))
+			err := saveImage(digitImg, filepath.Join(debugDir, fmt.Sprintf("%sdigit_%02d.png", debugPrefix, i)))
 			if err != nil {
-				return "", err
+				return nil, err
 			}
 		}
 
 		if confidence >= 0.3 {
 			allDigits.WriteByte(byte('0' + digit))
+			confidences = append(confidences, confidence)
+			if p.alternateDigits || p.expectedVKN != "" {
+				distributions = append(distributions, recognizer.ClassifyAll(digitImg))
+			}
 		}
 	}
 
 	// Step 7: Find VKN pattern (10 consecutive digits starting with non-zero)
 	digitStr := allDigits.String()
+	result.RecognizedDigits = digitStr
 	if p.debug {
 		fmt.Printf("All recognized digits: %s\n", digitStr)
 	}
 
-	re := regexp.MustCompile(`([1-9]\d{9})`)
-	if match := re.FindString(digitStr); match != "" {
-		return match, nil
+	// A reading consistent with the expected VKN, allowing the runner-up at each digit,
+	// verifies it without further disambiguation
+	if start := findExpectedVKN(distributions, p.expectedVKN); start >= 0 {
+		if p.debug {
+			fmt.Printf("Recognized digits match the expected VKN %s\n", p.expectedVKN)
+		}
+		result.VKN = p.expectedVKN
+		result.Source = "ocr"
+		result.Confidence = minConfidence(confidences[start : start+10])
+		return result, nil
+	}
+
+	loc := vknCandidateRe.FindStringIndex(digitStr)
+	match := ""
+	if loc != nil {
+		match = digitStr[loc[0]:loc[1]]
+	}
+	if p.alternateDigits && (match == "" || !IsValidVKNChecksum(match)) {
+		if alt, start := searchAlternateVKN(distributions); alt != "" {
+			if p.debug {
+				fmt.Printf("Greedy VKN %q failed the checksum, using alternate %s\n", match, alt)
+			}
+			return p.ocrVKNResult(result, alt, confidences[start:start+10])
+		}
+	}
+	if match != "" {
+		return p.ocrVKNResult(result, match, confidences[loc[0]:loc[1]])
 	}
 
 	// Try to find partial matches
-	re2 := regexp.MustCompile(`(\d{10})`)
-	if match := re2.FindString(digitStr); match != "" {
-		return match, nil
+	if loc := vknPartialRe.FindStringIndex(digitStr); loc != nil {
+		return p.ocrVKNResult(result, digitStr[loc[0]:loc[1]], confidences[loc[0]:loc[1]])
 	}
 
-	return "", fmt.Errorf("no valid VKN found (recognized: %s)", digitStr)
+	if len(digitStr) < 10 {
+		result.Reason = VKNFailureTooFewDigits
+	} else {
+		result.Reason = VKNFailureNoValidPattern
+	}
+
+	return result, fmt.Errorf("no valid VKN found (recognized: %s, reason: %s)", digitStr, result.Reason)
 }
 
-// scanCode128Barcode attempts to decode a Code128 barcode specifically
-// The VKN barcode in Turkish tax plates is a Code128 barcode
-func (p *OCRParser) scanCode128Barcode(img image.Image) (string, error) {
-	// Try scanning with different image orientations
-	orientations := []int{0, 90, 180, 270}
+// ocrVKNResult completes result with a VKN read from digits, or rejects it with
+// ErrLowConfidence when its least confident digit is below the configured floor
+func (p *OCRParser) ocrVKNResult(result *ExtractVKNResult, vkn string, digitConfidences []float64) (*ExtractVKNResult, error) {
+	confidence := minConfidence(digitConfidences)
+	result.Confidence = confidence
 
-	for _, rotation := range orientations {
-		rotatedImg := img
-		if rotation > 0 {
-			rotatedImg = rotateImage(img, rotation)
+	if p.minVKNConfidence > 0 && confidence < p.minVKNConfidence {
+		if p.debug {
+			fmt.Printf("Rejecting VKN %s: confidence %.2f below %.2f\n", vkn, confidence, p.minVKNConfidence)
 		}
+		result.Reason = VKNFailureLowConfidence
+		return result, fmt.Errorf("%w: %.2f < %.2f", ErrLowConfidence, confidence, p.minVKNConfidence)
+	}
 
-		// Try with original image
-		if vkn, err := p.scanCode128Only(rotatedImg); err == nil && vkn != "" {
-			return vkn, nil
-		}
+	result.VKN = vkn
+	result.Source = "ocr"
+	result.ExpectedVKNMismatch = p.checkExpectedVKN(vkn)
+	return result, nil
+}
 
-		// Try with enhanced contrast
-		enhanced := p.enhanceBarcode(rotatedImg)
-		if vkn, err := p.scanCode128Only(enhanced); err == nil && vkn != "" {
-			return vkn, nil
+// findTextVKN returns the first checksum-valid VKN printed in recognized text, or ""
+func findTextVKN(text string) string {
+	for _, run := range digitRunRe.FindAllString(text, -1) {
+		if len(run) == 10 && isValidVKN(run) && IsValidVKNChecksum(run) {
+			return run
 		}
 	}
+	return ""
+}
 
-	return "", fmt.Errorf("no Code128 barcode found")
+// minConfidence returns the lowest of the digit confidences, or 1 if there are none
+func minConfidence(digitConfidences []float64) float64 {
+	confidence := 1.0
+	for _, c := range digitConfidences {
+		confidence = math.Min(confidence, c)
+	}
+	return confidence
+}
+
+// scanCode128Barcode attempts to decode a Code128 barcode specifically
+// The VKN barcode in Turkish tax plates is a Code128 barcode
+func (p *OCRParser) scanCode128Barcode(img image.Image) (string, bool, error) {
+	if vkn, checkDigit := p.scanOrientations(img, (*OCRParser).scanCode128Orientation); vkn != "" {
+		return vkn, checkDigit, nil
+	}
+	if p.budgetExceeded() {
+		return "", false, p.budgetError()
+	}
+	return "", false, fmt.Errorf("no Code128 barcode found")
+}
+
+// scanCode128Orientation scans one orientation for a Code128 barcode, as is and with
+// enhanced contrast
+func (p *OCRParser) scanCode128Orientation(img image.Image) (string, bool, error) {
+	if vkn, checkDigit, err := p.scanCode128Only(img); err == nil && vkn != "" {
+		return vkn, checkDigit, nil
+	}
+	return p.scanCode128Only(p.enhanceBarcode(img))
 }
 
 // scanCode128Only scans image using only Code128 reader
-func (p *OCRParser) scanCode128Only(img image.Image) (string, error) {
+func (p *OCRParser) scanCode128Only(img image.Image) (string, bool, error) {
+	if p.budgetExceeded() {
+		return "", false, p.budgetError()
+	}
 	// Convert image to BinaryBitmap for gozxing
 	bmp, err := gozxing.NewBinaryBitmapFromImage(img)
 	if err != nil {
-		return "", fmt.Errorf("failed to create bitmap: %w", err)
+		return "", false, fmt.Errorf("failed to create bitmap: %w", err)
 	}
 
 	// Use Code128 reader specifically
 	reader := oned.NewCode128Reader()
 
-	result, err := reader.Decode(bmp, nil)
+	var hints map[gozxing.DecodeHintType]interface{}
+	if p.thorough {
+		hints = map[gozxing.DecodeHintType]interface{}{gozxing.DecodeHintType_TRY_HARDER: true}
+	}
+
+	result, err := reader.Decode(bmp, hints)
 	if err != nil {
-		return "", fmt.Errorf("Code128 decode failed: %w", err)
+		return "", false, fmt.Errorf("Code128 decode failed: %w", err)
 	}
 
 	text := result.GetText()
@@ -588,8 +846,12 @@ func (p *OCRParser) scanCode128Only(img image.Image) (string, error) {
 	}
 
 	// Extract VKN from the barcode text
-	if vkn := p.extractVKNFromBarcodeText(text); vkn != "" {
-		return vkn, nil
+	vkn, checkDigit, err := p.extractVKNFromBarcodeText(text)
+	if err != nil {
+		return "", false, err
+	}
+	if vkn != "" {
+		return vkn, checkDigit, nil
 	}
 
 	// If the text itself is a 10-digit number starting with non-zero, use it
@@ -602,11 +864,11 @@ func (p *OCRParser) scanCode128Only(img image.Image) (string, error) {
 			}
 		}
 		if allDigits {
-			return text, nil
+			return text, false, nil
 		}
 	}
 
-	return "", fmt.Errorf("no VKN found in barcode text: %s", text)
+	return "", false, fmt.Errorf("no VKN found in barcode text: %s", text)
 }
 
 // enhanceBarcode enhances the barcode image for better reading
@@ -632,33 +894,100 @@ func (p *OCRParser) enhanceBarcode(img image.Image) image.Image {
 	return enhanced
 }
 
-// scanBarcode attempts to decode a barcode from the image
-func (p *OCRParser) scanBarcode(img image.Image) (string, error) {
-	// Try scanning with different image orientations
-	// Sometimes barcodes need to be rotated for proper detection
-	orientations := []int{0, 90, 180, 270}
+// scanBarcode attempts to decode a barcode from the image with every reader, in the four
+// orientations at once
+func (p *OCRParser) scanBarcode(img image.Image) (string, bool, error) {
+	if vkn, checkDigit := p.scanOrientations(img, (*OCRParser).scanBarcodeOrientation); vkn != "" {
+		return vkn, checkDigit, nil
+	}
+	if p.budgetExceeded() {
+		return "", false, p.budgetError()
+	}
+	return "", false, fmt.Errorf("no barcode found")
+}
 
-	for _, rotation := range orientations {
-		rotatedImg := img
-		if rotation > 0 {
-			rotatedImg = rotateImage(img, rotation)
-		}
+// barcodeOrientations are the rotations, in degrees, a barcode is looked for in
+var barcodeOrientations = [...]int{0, 90, 180, 270}
+
+// scanOrientations runs scan on img in each of barcodeOrientations concurrently; each
+// attempt is expensive. The result is the lowest rotation whose VKN passes the checksum,
+// returned as soon as every lower rotation has finished, or else the lowest rotation that
+// found a VKN at all, so the result doesn't depend on which attempt finishes first. The
+// attempts still running when the result is known are cancelled at their next decode.
+func (p *OCRParser) scanOrientations(img image.Image, scan func(p *OCRParser, img image.Image) (string, bool, error)) (string, bool) {
+	if p.budgetExceeded() {
+		return "", false
+	}
+	parent := p.ctx
+	if parent == nil {
+		parent = context.Background()
+	}
+	ctx, cancel := context.WithCancel(parent)
+	defer cancel()
+
+	// The attempts share a copy carrying the cancellation; they only read it
+	scanner := *p
+	scanner.ctx = ctx
+
+	type decoded struct {
+		index      int
+		vkn        string
+		checkDigit bool
+	}
+	// Buffered so that attempts finishing after the result is known never block
+	results := make(chan decoded, len(barcodeOrientations))
+	for i, rotation := range barcodeOrientations {
+		go func() {
+			d := decoded{index: i}
+			defer func() { results <- d }()
+			// Rotating a large image is itself costly
+			if scanner.budgetExceeded() {
+				return
+			}
+			rotatedImg := img
+			if rotation > 0 {
+				rotatedImg = rotateImage(img, rotation)
+			}
+			if vkn, checkDigit, err := scan(&scanner, rotatedImg); err == nil {
+				d.vkn, d.checkDigit = vkn, checkDigit
+			}
+		}()
+	}
 
-		vkn, err := p.scanBarcodeOrientation(rotatedImg)
-		if err == nil && vkn != "" {
-			return vkn, nil
+	var found [len(barcodeOrientations)]*decoded
+	var done [len(barcodeOrientations)]bool
+	for range barcodeOrientations {
+		d := <-results
+		done[d.index] = true
+		if d.vkn != "" {
+			found[d.index] = &d
+		}
+		for i := range done {
+			if !done[i] {
+				break
+			}
+			if f := found[i]; f != nil && IsValidVKNChecksum(f.vkn) {
+				return f.vkn, f.checkDigit
+			}
 		}
 	}
-
-	return "", fmt.Errorf("no barcode found")
+	for _, f := range found {
+		if f != nil {
+			return f.vkn, f.checkDigit
+		}
+	}
+	return "", false
 }
 
 // scanBarcodeOrientation scans barcode in a specific orientation
-func (p *OCRParser) scanBarcodeOrientation(img image.Image) (string, error) {
+func (p *OCRParser) scanBarcodeOrientation(img image.Image) (string, bool, error) {
+	if p.budgetExceeded() {
+		return "", false, p.budgetError()
+	}
 	// Convert image to BinaryBitmap for gozxing
 	bmp, err := gozxing.NewBinaryBitmapFromImage(img)
 	if err != nil {
-		return "", fmt.Errorf("failed to create bitmap: %w", err)
+		return "", false, fmt.Errorf("failed to create bitmap: %w", err)
 	}
 
 	// First try MultiFormatReader which tries all formats
@@ -670,8 +999,12 @@ func (p *OCRParser) scanBarcodeOrientation(img image.Image) (string, error) {
 		if p.debug {
 			fmt.Printf("Reader decoded: %s\n", text)
 		}
-		if vkn := p.extractVKNFromBarcodeText(text); vkn != "" {
-			return vkn, nil
+		vkn, checkDigit, err := p.extractVKNFromBarcodeText(text)
+		if err != nil {
+			return "", false, err
+		}
+		if vkn != "" {
+			return vkn, checkDigit, nil
 		}
 	}
 
@@ -690,17 +1023,23 @@ func (p *OCRParser) scanBarcodeOrientation(img image.Image) (string, error) {
 	var allDecodedTexts []string
 
 	for _, reader := range readers {
+		if p.budgetExceeded() {
+			return "", false, p.budgetError()
+		}
 		result, err := reader.Decode(bmp, nil)
 		if err == nil {
 			text := result.GetText()
 			if p.debug {
 				fmt.Printf("Barcode decoded with %T: %s\n", reader, text)
 			}
-			allDecodedTexts = append(allDecodedTexts, text)
-
-			if vkn := p.extractVKNFromBarcodeText(text); vkn != "" {
-				return vkn, nil
+			vkn, checkDigit, err := p.extractVKNFromBarcodeText(text)
+			if err != nil {
+				return "", false, err
+			}
+			if vkn != "" {
+				return vkn, checkDigit, nil
 			}
+			allDecodedTexts = append(allDecodedTexts, text)
 		}
 	}
 
@@ -716,36 +1055,119 @@ func (p *OCRParser) scanBarcodeOrientation(img image.Image) (string, error) {
 		digitStr := digits.String()
 		if len(digitStr) >= 10 {
 			// Try to find VKN pattern
-			re := regexp.MustCompile(`([1-9]\d{9})`)
-			if match := re.FindString(digitStr); match != "" {
-				return match, nil
+			if match := vknCandidateRe.FindString(digitStr); match != "" {
+				return match, false, nil
 			}
 		}
 	}
 
-	return "", fmt.Errorf("no barcode found")
+	return "", false, fmt.Errorf("no barcode found")
 }
 
-// extractVKNFromBarcodeText extracts VKN from barcode decoded text
-func (p *OCRParser) extractVKNFromBarcodeText(text string) string {
-	// Check if it's a valid VKN (10 digits starting with non-zero)
-	re := regexp.MustCompile(`([1-9]\d{9})`)
-	matches := re.FindAllString(text, -1)
-	for _, match := range matches {
-		if isValidVKN(match) {
+// vknCandidateRe matches a 10-digit VKN candidate (no leading zero)
+var vknCandidateRe = regexp.MustCompile(`([1-9]\d{9})`)
+
+// vknPartialRe matches any ten digits of the recognized digit stream, the last resort
+// when no candidate without a leading zero is found; the stream is all digits, so unlike
+// the text patterns it must not require a word boundary
+var vknPartialRe = regexp.MustCompile(`(\d{10})`)
+
+// aimSymbologyIDRe matches AIM symbology identifiers such as "]C1" that some decoders
+// prefix to Code128 text
+var aimSymbologyIDRe = regexp.MustCompile(`\][A-Za-z][0-9]`)
+
+// cleanBarcodeText removes what Code128 decoders leave around the data: AIM symbology
+// identifiers, FNC1 (decoded as GS, 0x1D), FNC1-FNC4 written as U+00F1-U+00F4 by some
+// encoders, and other control characters. They become spaces so that the digit runs on
+// either side stay apart.
+func cleanBarcodeText(text string) string {
+	text = aimSymbologyIDRe.ReplaceAllString(text, " ")
+	return strings.Map(func(r rune) rune {
+		if r < 0x20 || r == 0x7f || r == unicode.ReplacementChar || (r >= 0xf1 && r <= 0xf4) {
+			return ' '
+		}
+		return r
+	}, text)
+}
+
+// vknCheckDigitRe matches the one payload layout with a check digit of its own: the first
+// nine digits of the VKN, a separator, and the VKN's check digit (its tenth digit under
+// GİB's algorithm), e.g. "482719305-6"
+var vknCheckDigitRe = regexp.MustCompile(`(?:^|\D)([1-9]\d{8})[-/](\d)(?:\D|$)`)
+
+// barcodeCheckDigit looks for a check digit set apart from the VKN in the cleaned barcode
+// text. It returns the VKN and whether the digit is the one GİB's algorithm gives for the
+// first nine, or "" if the payload has no such layout.
+func barcodeCheckDigit(text string) (vkn string, ok bool) {
+	m := vknCheckDigitRe.FindStringSubmatch(text)
+	if m == nil {
+		return "", false
+	}
+	vkn = m[1] + m[2]
+	return vkn, IsValidVKNChecksum(vkn)
+}
+
+// extractVKNFromBarcodeText extracts VKN from barcode decoded text. A check digit written
+// apart from the VKN is verified: checkDigit is set when it matches, and a mismatch
+// fails with ErrBarcodeCheckDigit so that a misread bar is not taken for the VKN.
+// Otherwise a digit run that is exactly a checksum-valid VKN is preferred, then a
+// checksum-valid VKN inside a longer run (e.g. behind a GS1 application identifier), then
+// any plausible 10-digit run.
+func (p *OCRParser) extractVKNFromBarcodeText(text string) (vkn string, checkDigit bool, err error) {
+	text = cleanBarcodeText(text)
+	if vkn, ok := barcodeCheckDigit(text); vkn != "" {
+		if !ok {
 			if p.debug {
-				fmt.Printf("Valid VKN found in barcode: %s\n", match)
+				fmt.Printf("Rejecting barcode %q: check digit does not match %s\n", text, vkn[:9])
 			}
-			return match
+			return "", false, fmt.Errorf("%w: %s", ErrBarcodeCheckDigit, vkn)
 		}
+		return vkn, true, nil
 	}
+	runs := digitRunRe.FindAllString(text, -1)
 
-	// If no valid VKN found via validation, still try to find 10-digit match
-	if match := re.FindString(text); match != "" {
-		return match
+	var found string
+	for _, run := range runs {
+		if len(run) == 10 && isValidVKN(run) && IsValidVKNChecksum(run) {
+			found = run
+			break
+		}
+	}
+	if found == "" {
+		for _, run := range runs {
+			if len(run) <= 10 {
+				continue
+			}
+			for i := 0; i+10 <= len(run); i++ {
+				if window := run[i : i+10]; isValidVKN(window) && IsValidVKNChecksum(window) {
+					found = window
+					break
+				}
+			}
+			if found != "" {
+				break
+			}
+		}
+	}
+	if found != "" {
+		if p.debug {
+			fmt.Printf("Valid VKN found in barcode: %s\n", found)
+		}
+		return found, false, nil
 	}
 
-	return ""
+	// Without a checksum-valid candidate, take the first plausible 10-digit match
+	matches := vknCandidateRe.FindAllString(text, -1)
+	for _, match := range matches {
+		if isValidVKN(match) {
+			return match, false, nil
+		}
+	}
+	if match := vknCandidateRe.FindString(text); match != "" {
+		return match, false, nil
+	}
+
+	return "", false, nil
 }
 
 // rotateImage rotates an image by the specified degrees (90, 180, 270)
@@ -783,7 +1205,8 @@ func rotateImage(img image.Image, degrees int) image.Image {
 }
 
 // isValidVKN validates a Turkish Tax Identification Number (Vergi Kimlik Numarası)
-// This performs basic structural validation
+// This performs basic structural validation, cheap enough to pre-filter candidate windows
+// before the full IsValidVKNChecksum
 func isValidVKN(vkn string) bool {
 	if len(vkn) != 10 {
 		return false
@@ -805,9 +1228,137 @@ func isValidVKN(vkn string) bool {
 		return false
 	}
 
+	// Runs such as 1111111111 or 9876543210 are placeholders or noise, not issued numbers
+	if isSequential(vkn) {
+		return false
+	}
+
+	return true
+}
+
+// isSequential reports whether the digits of s are all the same or step up or down by
+// one throughout, without wrapping around (so 1234567890 is not sequential)
+func isSequential(s string) bool {
+	if len(s) < 2 {
+		return false
+	}
+	step := int(s[1]) - int(s[0])
+	if step < -1 || step > 1 {
+		return false
+	}
+	for i := 2; i < len(s); i++ {
+		if int(s[i])-int(s[i-1]) != step {
+			return false
+		}
+	}
 	return true
 }
 
+// IsValidVKNChecksum reports whether vkn is a 10-digit Vergi Kimlik Numarası whose last
+// digit is the check digit computed with the GİB algorithm. It is the authoritative check
+// for VKNs from any source; unlike the parser's structural pre-filter it does not reject
+// numbers that merely look like dates or digit runs.
+func IsValidVKNChecksum(vkn string) bool {
+	if len(vkn) != 10 {
+		return false
+	}
+	for _, ch := range vkn {
+		if ch < '0' || ch > '9' {
+			return false
+		}
+	}
+
+	sum := 0
+	for i := 0; i < 9; i++ {
+		tmp := (int(vkn[i]-'0') + 9 - i) % 10
+		v := (tmp * (1 << (9 - i))) % 9
+		if tmp != 0 && v == 0 {
+			v = 9
+		}
+		sum += v
+	}
+
+	return int(vkn[9]-'0') == (10-sum%10)%10
+}
+
+// searchAlternateVKN looks for the most likely checksum-valid VKN in a recognized digit
+// sequence, allowing the first or second choice at each position. distributions holds the
+// ClassifyAll output for each recognized digit, in reading order. It returns the VKN and
+// the index of its first digit, or "" and -1 if there is none.
+func searchAlternateVKN(distributions [][10]float64) (string, int) {
+	best, bestStart, bestScore := "", -1, -1.0
+
+	for start := 0; start+10 <= len(distributions); start++ {
+		var choices [10][2]int
+		for i := 0; i < 10; i++ {
+			choices[i] = topTwoDigits(distributions[start+i])
+		}
+
+		// 2^10 combinations per window
+		for mask := 0; mask < 1<<10; mask++ {
+			// A VKN never starts with 0; skip before scoring the rest of the window
+			if choices[0][mask&1] == 0 {
+				continue
+			}
+			var candidate [10]byte
+			score := 0.0
+			for i := 0; i < 10; i++ {
+				digit := choices[i][(mask>>i)&1]
+				candidate[i] = byte('0' + digit)
+				score += distributions[start+i][digit]
+			}
+			if score <= bestScore {
+				continue
+			}
+			// The structural checks reject most windows before the checksum is computed
+			if vkn := string(candidate[:]); isValidVKN(vkn) && IsValidVKNChecksum(vkn) {
+				best, bestStart, bestScore = vkn, start, score
+			}
+		}
+	}
+
+	return best, bestStart
+}
+
+// findExpectedVKN returns the index of the first digit of a 10-digit window in which each
+// position's first or second choice spells expected, or -1 if there is none
+func findExpectedVKN(distributions [][10]float64, expected string) int {
+	if len(expected) != 10 {
+		return -1
+	}
+
+	for start := 0; start+10 <= len(distributions); start++ {
+		matched := true
+		for i := 0; i < 10 && matched; i++ {
+			choices := topTwoDigits(distributions[start+i])
+			digit := int(expected[i] - '0')
+			matched = choices[0] == digit || choices[1] == digit
+		}
+		if matched {
+			return start
+		}
+	}
+
+	return -1
+}
+
+// topTwoDigits returns the two highest-scoring digits of a distribution
+func topTwoDigits(dist [10]float64) [2]int {
+	first, second := 0, 1
+	if dist[second] > dist[first] {
+		first, second = second, first
+	}
+	for digit := 2; digit < 10; digit++ {
+		switch {
+		case dist[digit] > dist[first]:
+			first, second = digit, first
+		case dist[digit] > dist[second]:
+			second = digit
+		}
+	}
+	return [2]int{first, second}
+}
+
 // ============================================================================
 // Digit Classifier - Pure Go Implementation
 // ============================================================================
@@ -816,6 +1367,30 @@ func isValidVKN(vkn string) bool {
 type DigitClassifier struct {
 	// Pre-computed feature weights for each digit (0-9)
 	weights [10]DigitFeatureWeights
+
+	// Normalization used for the horizontal crossings feature
+	crossingNorm CrossingNormalization
+}
+
+// CrossingNormalization controls how the average number of horizontal stroke
+// crossings is mapped onto the 0-1 feature range
+type CrossingNormalization struct {
+	// Min is the average crossing count mapped to 0 (a single stroke crosses twice)
+	Min float64
+	// Max is the average crossing count mapped to 1 (three strokes crossed on one line)
+	Max float64
+	// Samples is the number of scan lines taken across the middle half of the glyph
+	Samples int
+}
+
+// DefaultCrossingNormalization returns the normalization the built-in weights are tuned for.
+// Real digits produce between 2 and 6 crossings per scan line, so that range is spread over 0-1.
+func DefaultCrossingNormalization() CrossingNormalization {
+	return CrossingNormalization{
+		Min:     2,
+		Max:     6,
+		Samples: 16,
+	}
 }
 
 // DigitFeatureWeights contains weights for matching a specific digit
@@ -832,12 +1407,21 @@ type DigitFeatureWeights struct {
 	crossings          float64
 }
 
+// sharedDigitClassifier returns the classifier shared by every OCRParser. The trained
+// model is built once; OCRParser never changes it and Classify only reads it, so it is
+// safe to share across parsers and goroutines.
+var sharedDigitClassifier = sync.OnceValue(NewDigitClassifier)
+
 // NewDigitClassifier creates a classifier with pre-trained weights
 func NewDigitClassifier() *DigitClassifier {
-	c := &DigitClassifier{}
+	c := &DigitClassifier{
+		crossingNorm: DefaultCrossingNormalization(),
+	}
 
 	// These weights are derived from MNIST digit characteristics
 	// Each digit has distinctive features
+	// Crossing weights use DefaultCrossingNormalization: 0.0 = two crossings
+	// (one stroke), 0.5 = four crossings (two strokes, e.g. both sides of a loop)
 
 	// 0: Round, symmetric, hole in center, wide
 	c.weights[0] = DigitFeatureWeights{
@@ -845,7 +1429,7 @@ func NewDigitClassifier() *DigitClassifier {
 		topHeavy: 0.5, bottomHeavy: 0.5,
 		leftHeavy: 0.5, rightHeavy: 0.5,
 		centerDensity: 0.3, aspectRatio: 0.7,
-		holeCount: 1.0, crossings: 0.4,
+		holeCount: 1.0, crossings: 0.5,
 	}
 
 	// 1: Narrow, tall, mostly in center/right, no holes
@@ -854,7 +1438,7 @@ func NewDigitClassifier() *DigitClassifier {
 		topHeavy: 0.5, bottomHeavy: 0.5,
 		leftHeavy: 0.3, rightHeavy: 0.6,
 		centerDensity: 0.7, aspectRatio: 0.3,
-		holeCount: 0.0, crossings: 0.2,
+		holeCount: 0.0, crossings: 0.0,
 	}
 
 	// 2: Top curve, diagonal, bottom horizontal
@@ -863,7 +1447,7 @@ func NewDigitClassifier() *DigitClassifier {
 		topHeavy: 0.6, bottomHeavy: 0.5,
 		leftHeavy: 0.4, rightHeavy: 0.5,
 		centerDensity: 0.4, aspectRatio: 0.6,
-		holeCount: 0.0, crossings: 0.5,
+		holeCount: 0.0, crossings: 0.1,
 	}
 
 	// 3: Right side heavy, two bumps
@@ -872,7 +1456,7 @@ func NewDigitClassifier() *DigitClassifier {
 		topHeavy: 0.5, bottomHeavy: 0.5,
 		leftHeavy: 0.3, rightHeavy: 0.7,
 		centerDensity: 0.4, aspectRatio: 0.6,
-		holeCount: 0.0, crossings: 0.6,
+		holeCount: 0.0, crossings: 0.15,
 	}
 
 	// 4: Vertical line on right, horizontal in middle
@@ -881,7 +1465,7 @@ func NewDigitClassifier() *DigitClassifier {
 		topHeavy: 0.6, bottomHeavy: 0.4,
 		leftHeavy: 0.4, rightHeavy: 0.6,
 		centerDensity: 0.5, aspectRatio: 0.6,
-		holeCount: 0.0, crossings: 0.5,
+		holeCount: 0.0, crossings: 0.25,
 	}
 
 	// 5: Top horizontal, middle, bottom curve
@@ -890,7 +1474,7 @@ func NewDigitClassifier() *DigitClassifier {
 		topHeavy: 0.55, bottomHeavy: 0.45,
 		leftHeavy: 0.5, rightHeavy: 0.5,
 		centerDensity: 0.45, aspectRatio: 0.6,
-		holeCount: 0.0, crossings: 0.5,
+		holeCount: 0.0, crossings: 0.1,
 	}
 
 	// 6: Top curve/tail, bottom loop with hole
@@ -899,7 +1483,7 @@ func NewDigitClassifier() *DigitClassifier {
 		topHeavy: 0.4, bottomHeavy: 0.6,
 		leftHeavy: 0.55, rightHeavy: 0.45,
 		centerDensity: 0.5, aspectRatio: 0.6,
-		holeCount: 0.8, crossings: 0.5,
+		holeCount: 0.8, crossings: 0.25,
 	}
 
 	// 7: Top horizontal, diagonal down
@@ -908,7 +1492,7 @@ func NewDigitClassifier() *DigitClassifier {
 		topHeavy: 0.7, bottomHeavy: 0.3,
 		leftHeavy: 0.4, rightHeavy: 0.6,
 		centerDensity: 0.35, aspectRatio: 0.6,
-		holeCount: 0.0, crossings: 0.3,
+		holeCount: 0.0, crossings: 0.0,
 	}
 
 	// 8: Two stacked loops, very symmetric
@@ -917,7 +1501,7 @@ func NewDigitClassifier() *DigitClassifier {
 		topHeavy: 0.5, bottomHeavy: 0.5,
 		leftHeavy: 0.5, rightHeavy: 0.5,
 		centerDensity: 0.4, aspectRatio: 0.65,
-		holeCount: 1.0, crossings: 0.6,
+		holeCount: 1.0, crossings: 0.4,
 	}
 
 	// 9: Top loop with hole, bottom tail
@@ -926,21 +1510,26 @@ func NewDigitClassifier() *DigitClassifier {
 		topHeavy: 0.6, bottomHeavy: 0.4,
 		leftHeavy: 0.45, rightHeavy: 0.55,
 		centerDensity: 0.5, aspectRatio: 0.6,
-		holeCount: 0.8, crossings: 0.5,
+		holeCount: 0.8, crossings: 0.25,
 	}
 
 	return c
 }
 
+// SetCrossingNormalization overrides how crossing counts are normalized.
+// The built-in weights assume DefaultCrossingNormalization, so retune them when changing it.
+func (c *DigitClassifier) SetCrossingNormalization(norm CrossingNormalization) {
+	c.crossingNorm = norm
+}
+
 // Classify returns the most likely digit and confidence
 func (c *DigitClassifier) Classify(img *image.Gray) (int, float64) {
-	features := extractFeatures(img)
+	scores := c.scores(img)
 
 	bestDigit := 0
 	bestScore := -1.0
 
-	for digit := 0; digit < 10; digit++ {
-		score := c.matchScore(features, c.weights[digit])
+	for digit, score := range scores {
 		if score > bestScore {
 			bestScore = score
 			bestDigit = digit
@@ -959,6 +1548,43 @@ func (c *DigitClassifier) Classify(img *image.Gray) (int, float64) {
 	return bestDigit, confidence
 }
 
+// ClassifyAll returns the match score of every digit, normalized to sum to 1.
+// Unlike Classify it keeps the runner-up digits, so callers can run their own
+// candidate search over ambiguous positions.
+func (c *DigitClassifier) ClassifyAll(img *image.Gray) [10]float64 {
+	scores := c.scores(img)
+
+	total := 0.0
+	for i, score := range scores {
+		if score < 0 {
+			scores[i] = 0
+		}
+		total += scores[i]
+	}
+	if total == 0 {
+		for i := range scores {
+			scores[i] = 0.1
+		}
+		return scores
+	}
+
+	for i := range scores {
+		scores[i] /= total
+	}
+	return scores
+}
+
+// scores returns the raw match score of every digit
+func (c *DigitClassifier) scores(img *image.Gray) [10]float64 {
+	features := extractFeatures(img, c.crossingNorm)
+
+	var scores [10]float64
+	for digit := 0; digit < 10; digit++ {
+		scores[digit] = c.matchScore(features, c.weights[digit])
+	}
+	return scores
+}
+
 func (c *DigitClassifier) matchScore(f DigitFeatures, w DigitFeatureWeights) float64 {
 	score := 0.0
 
@@ -991,7 +1617,7 @@ type DigitFeatures struct {
 	crossings          float64
 }
 
-func extractFeatures(img *image.Gray) DigitFeatures {
+func extractFeatures(img *image.Gray, norm CrossingNormalization) DigitFeatures {
 	bounds := img.Bounds()
 	width, height := bounds.Dx(), bounds.Dy()
 
@@ -1060,7 +1686,7 @@ func extractFeatures(img *image.Gray) DigitFeatures {
 	}
 
 	// Horizontal crossings (how many times we cross black when scanning horizontally)
-	f.crossings = calculateCrossings(img)
+	f.crossings = calculateCrossings(img, norm)
 
 	return f
 }
@@ -1179,18 +1805,27 @@ func countHoles(img *image.Gray) int {
 	return holes
 }
 
-func calculateCrossings(img *image.Gray) float64 {
+func calculateCrossings(img *image.Gray, norm CrossingNormalization) float64 {
 	bounds := img.Bounds()
 	width, height := bounds.Dx(), bounds.Dy()
 
+	samples := norm.Samples
+	if samples <= 0 {
+		samples = DefaultCrossingNormalization().Samples
+	}
+
 	totalCrossings := 0
 	lines := 0
 
-	// Sample horizontal lines
-	for y := height / 4; y < 3*height/4; y += height / 8 {
-		if y >= height {
+	// Sample horizontal lines evenly across the middle half of the glyph
+	startY, endY := height/4, 3*height/4
+	lastY := -1
+	for i := 0; i < samples; i++ {
+		y := startY + i*(endY-startY)/samples
+		if y >= height || y == lastY {
 			continue
 		}
+		lastY = y
 		crossings := 0
 		inForeground := false
 		for x := 0; x < width; x++ {
@@ -1209,8 +1844,12 @@ func calculateCrossings(img *image.Gray) float64 {
 	}
 
 	avg := float64(totalCrossings) / float64(lines)
-	// Normalize: 2 crossings (one stroke) = 0.2, 4 crossings = 0.4, etc.
-	return math.Min(avg/10.0, 1.0)
+	span := norm.Max - norm.Min
+	if span <= 0 {
+		return 0
+	}
+	// Spread the observed range over 0-1: Min crossings = 0, Max crossings = 1
+	return math.Max(0, math.Min((avg-norm.Min)/span, 1.0))
 }
 
 // ============================================================================
@@ -1335,33 +1974,74 @@ func floodFillRegion(img *image.Gray, visited [][]bool, startX, startY int, boun
 	return image.Rect(bounds.Min.X+minX, bounds.Min.Y+minY, bounds.Min.X+maxX+1, bounds.Min.Y+maxY+1)
 }
 
-func filterDigitRegions(regions []image.Rectangle, imgBounds image.Rectangle) []image.Rectangle {
-	var filtered []image.Rectangle
-	imgHeight := imgBounds.Dy()
-	imgWidth := imgBounds.Dx()
+// DigitRegionFilter bounds the connected regions that are read as digits. Apart from a
+// small noise floor, sizes are relative to the median region height, so the same filter
+// works on a low resolution scan and on a high DPI crop of the digits.
+type DigitRegionFilter struct {
+	// MinAspect and MaxAspect bound a region's width divided by its height
+	MinAspect float64
+	MaxAspect float64
+
+	// MinWidth and MinHeight are the noise floor in pixels
+	MinWidth  int
+	MinHeight int
+
+	// MinHeightRatio and MaxHeightRatio bound a region's height relative to the median
+	// height of the regions passing the checks above; 0 disables the bound
+	MinHeightRatio float64
+	MaxHeightRatio float64
+}
+
+// DefaultDigitRegionFilter returns the filter used by default: digits are between 0.15
+// and 1.5 times as wide as tall and between half and twice the median height
+func DefaultDigitRegionFilter() DigitRegionFilter {
+	return DigitRegionFilter{
+		MinAspect:      0.15,
+		MaxAspect:      1.5,
+		MinWidth:       2,
+		MinHeight:      6,
+		MinHeightRatio: 0.5,
+		MaxHeightRatio: 2,
+	}
+}
 
+// filterDigitRegions keeps the regions that look like digits under f
+func filterDigitRegions(regions []image.Rectangle, f DigitRegionFilter) []image.Rectangle {
+	var candidates []image.Rectangle
 	for _, r := range regions {
 		w, h := r.Dx(), r.Dy()
+		if w < f.MinWidth || h < f.MinHeight || h == 0 {
+			continue
+		}
 		aspectRatio := float64(w) / float64(h)
-
-		// Digits typically have aspect ratio between 0.2 and 1.2
-		if aspectRatio < 0.15 || aspectRatio > 1.5 {
+		if aspectRatio < f.MinAspect || aspectRatio > f.MaxAspect {
 			continue
 		}
+		candidates = append(candidates, r)
+	}
+	if len(candidates) == 0 {
+		return nil
+	}
 
-		// Not too small
-		if w < 5 || h < 8 {
+	// Digits of one line share a height; specks and frames stand out against the median
+	heights := make([]int, len(candidates))
+	for i, r := range candidates {
+		heights[i] = r.Dy()
+	}
+	sort.Ints(heights)
+	median := float64(heights[len(heights)/2])
+
+	var filtered []image.Rectangle
+	for _, r := range candidates {
+		h := float64(r.Dy())
+		if f.MinHeightRatio > 0 && h < f.MinHeightRatio*median {
 			continue
 		}
-
-		// Not too large (more than 1/3 of image)
-		if w > imgWidth/3 || h > imgHeight/2 {
+		if f.MaxHeightRatio > 0 && h > f.MaxHeightRatio*median {
 			continue
 		}
-
 		filtered = append(filtered, r)
 	}
-
 	return filtered
 }
 
@@ -0,0 +1,849 @@
+package vergilevhasi
+
+import (
+	"bytes"
+	"errors"
+	"image"
+	"image/color"
+	"image/png"
+	"os"
+	"path/filepath"
+	"reflect"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/makiuchi-d/gozxing"
+	"github.com/makiuchi-d/gozxing/oned"
+)
+
+// newWhiteGray creates a white grayscale canvas for drawing synthetic glyphs
+func newWhiteGray(width, height int) *image.Gray {
+	img := image.NewGray(image.Rect(0, 0, width, height))
+	for i := range img.Pix {
+		img.Pix[i] = 255
+	}
+	return img
+}
+
+// fillRect paints a black rectangle onto the canvas
+func fillRect(img *image.Gray, r image.Rectangle) {
+	for y := r.Min.Y; y < r.Max.Y; y++ {
+		for x := r.Min.X; x < r.Max.X; x++ {
+			img.SetGray(x, y, color.Gray{0})
+		}
+	}
+}
+
+// drawRing paints a rectangular loop with the given stroke width
+func drawRing(img *image.Gray, r image.Rectangle, stroke int) {
+	fillRect(img, image.Rect(r.Min.X, r.Min.Y, r.Max.X, r.Min.Y+stroke))
+	fillRect(img, image.Rect(r.Min.X, r.Max.Y-stroke, r.Max.X, r.Max.Y))
+	fillRect(img, image.Rect(r.Min.X, r.Min.Y, r.Min.X+stroke, r.Max.Y))
+	fillRect(img, image.Rect(r.Max.X-stroke, r.Min.Y, r.Max.X, r.Max.Y))
+}
+
+// drawOne draws a "1" as a single vertical stroke
+func drawOne(size int) *image.Gray {
+	img := newWhiteGray(size, size)
+	fillRect(img, image.Rect(size/2-2, 4, size/2+2, size-4))
+	return img
+}
+
+// drawEight draws an "8" as two stacked loops
+func drawEight(size int) *image.Gray {
+	img := newWhiteGray(size, size)
+	drawRing(img, image.Rect(size/4, 4, 3*size/4, size/2+2), 3)
+	drawRing(img, image.Rect(size/4, size/2-1, 3*size/4, size-4), 3)
+	return img
+}
+
+func TestCalculateCrossingsSeparatesDigits(t *testing.T) {
+	norm := DefaultCrossingNormalization()
+
+	one := calculateCrossings(drawOne(40), norm)
+	eight := calculateCrossings(drawEight(40), norm)
+
+	if one > 0.1 {
+		t.Errorf("crossings for 1 = %.2f, want close to 0", one)
+	}
+	if eight < 0.35 {
+		t.Errorf("crossings for 8 = %.2f, want at least 0.35", eight)
+	}
+	if eight-one < 0.3 {
+		t.Errorf("crossings not separated: 1 = %.2f, 8 = %.2f", one, eight)
+	}
+}
+
+func TestCalculateCrossingsCustomNormalization(t *testing.T) {
+	// With Max equal to the crossings of a loop, the 8 saturates the feature
+	norm := CrossingNormalization{Min: 2, Max: 4, Samples: 32}
+
+	if got := calculateCrossings(drawEight(40), norm); got < 0.8 {
+		t.Errorf("crossings for 8 = %.2f, want at least 0.8", got)
+	}
+	if got := calculateCrossings(drawOne(40), norm); got != 0 {
+		t.Errorf("crossings for 1 = %.2f, want 0", got)
+	}
+}
+
+func TestExtractVKNFromImageDataResultReportsDigitsOnFailure(t *testing.T) {
+	parser, err := NewOCRParser()
+	if err != nil {
+		t.Fatalf("NewOCRParser() error = %v", err)
+	}
+
+	// Four well separated strokes are recognized as digits, but far too few for a VKN
+	img := newWhiteGray(200, 60)
+	for i := 0; i < 4; i++ {
+		x := 20 + i*45
+		fillRect(img, image.Rect(x, 15, x+6, 45))
+	}
+
+	result, err := parser.ExtractVKNFromImageDataResult(img)
+	if err == nil {
+		t.Fatal("ExtractVKNFromImageDataResult() error = nil, want failure")
+	}
+	if result == nil {
+		t.Fatal("ExtractVKNFromImageDataResult() returned nil result on failure")
+	}
+	if len(result.RecognizedDigits) != 4 {
+		t.Errorf("RecognizedDigits = %q, want 4 digits", result.RecognizedDigits)
+	}
+	if result.Reason != VKNFailureTooFewDigits {
+		t.Errorf("Reason = %q, want %q", result.Reason, VKNFailureTooFewDigits)
+	}
+	if result.VKN != "" {
+		t.Errorf("VKN = %q, want empty", result.VKN)
+	}
+}
+
+// drawCode128 renders contents as a Code 128 barcode with a white quiet zone
+func drawCode128(t testing.TB, contents string) *image.Gray {
+	t.Helper()
+
+	matrix, err := oned.NewCode128Writer().Encode(contents, gozxing.BarcodeFormat_CODE_128, 300, 80, nil)
+	if err != nil {
+		t.Fatalf("failed to encode barcode: %v", err)
+	}
+
+	img := newWhiteGray(matrix.GetWidth(), matrix.GetHeight())
+	for y := 0; y < matrix.GetHeight(); y++ {
+		for x := 0; x < matrix.GetWidth(); x++ {
+			if matrix.Get(x, y) {
+				img.SetGray(x, y, color.Gray{0})
+			}
+		}
+	}
+	return img
+}
+
+func TestScanBarcodeRotations(t *testing.T) {
+	parser, err := NewOCRParser()
+	if err != nil {
+		t.Fatalf("NewOCRParser() error = %v", err)
+	}
+
+	barcode := drawCode128(t, "1234567890")
+	for _, rotation := range []int{0, 90, 180, 270} {
+		img := image.Image(barcode)
+		if rotation > 0 {
+			img = rotateImage(barcode, rotation)
+		}
+
+		vkn, _, err := parser.scanBarcode(img)
+		if err != nil {
+			t.Errorf("scanBarcode() at %d degrees error = %v", rotation, err)
+			continue
+		}
+		if vkn != "1234567890" {
+			t.Errorf("scanBarcode() at %d degrees = %q, want %q", rotation, vkn, "1234567890")
+		}
+	}
+}
+
+func TestScanBarcodeNoBarcode(t *testing.T) {
+	parser, err := NewOCRParser()
+	if err != nil {
+		t.Fatalf("NewOCRParser() error = %v", err)
+	}
+
+	if vkn, _, err := parser.scanBarcode(newWhiteGray(200, 80)); err == nil {
+		t.Errorf("scanBarcode() = %q, want error for blank image", vkn)
+	}
+}
+
+func TestScanOrientationsDeterministic(t *testing.T) {
+	parser, err := NewOCRParser()
+	if err != nil {
+		t.Fatalf("NewOCRParser() error = %v", err)
+	}
+
+	// A marker in the top-left corner tells which rotation an attempt is given
+	img := newWhiteGray(40, 20)
+	img.SetGray(0, 0, color.Gray{0})
+	rotationOf := func(rotated image.Image) int {
+		for _, rotation := range barcodeOrientations {
+			want := image.Image(img)
+			if rotation > 0 {
+				want = rotateImage(img, rotation)
+			}
+			if reflect.DeepEqual(want, rotated) {
+				return rotation
+			}
+		}
+		t.Errorf("attempt given an image of no rotation")
+		return -1
+	}
+
+	tests := []struct {
+		name    string
+		results map[int]string
+		want    string
+	}{
+		// 1234567891 fails the checksum, so the valid rotations win however fast it is
+		{"Lowest checksum-valid rotation", map[int]string{0: "1234567891", 90: "1234567890", 180: "4827193056"}, "1234567890"},
+		{"Lowest rotation without a valid one", map[int]string{90: "1111111111", 270: "2222222222"}, "1111111111"},
+		{"Nothing found", map[int]string{}, ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// Later rotations finish first on some runs and last on others
+			for run := 0; run < 20; run++ {
+				vkn, _ := parser.scanOrientations(img, func(_ *OCRParser, rotated image.Image) (string, bool, error) {
+					rotation := rotationOf(rotated)
+					if (rotation/90+run)%2 == 0 {
+						time.Sleep(time.Millisecond)
+					}
+					if vkn, ok := tt.results[rotation]; ok {
+						return vkn, false, nil
+					}
+					return "", false, errors.New("no barcode")
+				})
+				if vkn != tt.want {
+					t.Fatalf("run %d: scanOrientations() = %q, want %q", run, vkn, tt.want)
+				}
+			}
+		})
+	}
+}
+
+func TestScanOrientationsCancelsRemainingAttempts(t *testing.T) {
+	parser, err := NewOCRParser()
+	if err != nil {
+		t.Fatalf("NewOCRParser() error = %v", err)
+	}
+
+	// Rotation 0 finds a valid VKN once the others are running; they wait to be cancelled
+	img := image.Image(newWhiteGray(40, 20))
+	running := make(chan struct{}, len(barcodeOrientations))
+	cancelled := make(chan bool, len(barcodeOrientations))
+	vkn, _ := parser.scanOrientations(img, func(p *OCRParser, rotated image.Image) (string, bool, error) {
+		if rotated == img {
+			for range barcodeOrientations[1:] {
+				<-running
+			}
+			return "1234567890", false, nil
+		}
+		running <- struct{}{}
+		select {
+		case <-p.ctx.Done():
+			cancelled <- true
+		case <-time.After(5 * time.Second):
+			cancelled <- false
+		}
+		return "", false, p.budgetError()
+	})
+	if vkn != "1234567890" {
+		t.Fatalf("scanOrientations() = %q, want 1234567890", vkn)
+	}
+	for range barcodeOrientations[1:] {
+		if !<-cancelled {
+			t.Error("an attempt was not cancelled once the result was known")
+		}
+	}
+}
+
+func BenchmarkScanBarcode(b *testing.B) {
+	parser, err := NewOCRParser()
+	if err != nil {
+		b.Fatalf("NewOCRParser() error = %v", err)
+	}
+
+	// A vertical barcode, so the unrotated attempt fails and the rotations do the work
+	img := rotateImage(drawCode128(b, "1234567890"), 90)
+
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		if _, _, err := parser.scanBarcode(img); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+func TestNewOCRParserSharesClassifier(t *testing.T) {
+	first, err := NewOCRParser()
+	if err != nil {
+		t.Fatalf("NewOCRParser() error = %v", err)
+	}
+	second, err := NewOCRParser()
+	if err != nil {
+		t.Fatalf("NewOCRParser() error = %v", err)
+	}
+	if first.classifier != second.classifier {
+		t.Error("NewOCRParser() built a new classifier instead of reusing the shared one")
+	}
+}
+
+func BenchmarkNewOCRParser(b *testing.B) {
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		if _, err := NewOCRParser(); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+func TestScanImagesForVKNBarcodeInThirdImage(t *testing.T) {
+	// A logo-like block and a blank strip come before the barcode, as on reordered PDFs
+	logo := newWhiteGray(120, 120)
+	fillRect(logo, image.Rect(20, 20, 100, 100))
+	images := []image.Image{logo, newWhiteGray(600, 80), drawCode128(t, "1234567890")}
+
+	for _, thorough := range []bool{false, true} {
+		parser, err := NewOCRParser()
+		if err != nil {
+			t.Fatalf("NewOCRParser() error = %v", err)
+		}
+		parser.SetThoroughBarcodeScan(thorough)
+
+		vkn, _, err := parser.scanImagesForVKN(images)
+		if err != nil {
+			t.Errorf("scanImagesForVKN() thorough=%v error = %v", thorough, err)
+			continue
+		}
+		if vkn != "1234567890" {
+			t.Errorf("scanImagesForVKN() thorough=%v = %q, want %q", thorough, vkn, "1234567890")
+		}
+	}
+}
+
+func TestClassifyAllDistribution(t *testing.T) {
+	classifier := NewDigitClassifier()
+
+	for name, img := range map[string]*image.Gray{"one": drawOne(40), "eight": drawEight(40)} {
+		dist := classifier.ClassifyAll(img)
+
+		sum := 0.0
+		best := 0
+		for digit, p := range dist {
+			if p < 0 || p > 1 {
+				t.Errorf("%s: probability of %d = %.3f, want within [0, 1]", name, digit, p)
+			}
+			sum += p
+			if p > dist[best] {
+				best = digit
+			}
+		}
+		if sum < 0.999 || sum > 1.001 {
+			t.Errorf("%s: distribution sums to %.4f, want 1", name, sum)
+		}
+
+		if digit, _ := classifier.Classify(img); digit != best {
+			t.Errorf("%s: ClassifyAll best digit = %d, Classify = %d", name, best, digit)
+		}
+	}
+}
+
+func TestIsValidVKNChecksum(t *testing.T) {
+	tests := []struct {
+		vkn  string
+		want bool
+	}{
+		{"1234567890", true},
+		{"4827193056", true},
+		{"7351048263", true},
+		{"1234567891", false},
+		{"4827193956", false},
+		{"123456789", false},
+		{"12345a7890", false},
+		{"12345678901", false},
+		{" 1234567890", false},
+		{"", false},
+		// Valid for the checksum even though the parser's structural check rejects them:
+		// a leading zero, and a number that reads as the date 15.06.2024
+		{"0123456789", true},
+		{"1506202417", true},
+	}
+	for _, tt := range tests {
+		if got := IsValidVKNChecksum(tt.vkn); got != tt.want {
+			t.Errorf("IsValidVKNChecksum(%q) = %v, want %v", tt.vkn, got, tt.want)
+		}
+	}
+}
+
+// confidentDigit returns a distribution that strongly favours digit, with runnerUp second
+func confidentDigit(digit, runnerUp int, p float64) [10]float64 {
+	var dist [10]float64
+	rest := (1 - p - 0.2) / 8
+	for i := range dist {
+		dist[i] = rest
+	}
+	dist[digit] = p
+	dist[runnerUp] = 0.2
+	return dist
+}
+
+func TestSearchAlternateVKNRecoversChecksumValid(t *testing.T) {
+	// The 8th digit of 4827193056 is misread as 9, with the true 0 as runner-up
+	greedy := "4827193956"
+	var dists [][10]float64
+	for i, ch := range greedy {
+		runnerUp := (int(ch-'0') + 5) % 10
+		if i == 7 {
+			runnerUp = 0
+		}
+		dists = append(dists, confidentDigit(int(ch-'0'), runnerUp, 0.5))
+	}
+
+	if IsValidVKNChecksum(greedy) {
+		t.Fatalf("test setup: greedy reading %s must fail the checksum", greedy)
+	}
+
+	if got, start := searchAlternateVKN(dists); got != "4827193056" || start != 0 {
+		t.Errorf("searchAlternateVKN() = %q at %d, want %q at 0", got, start, "4827193056")
+	}
+
+	if got, _ := searchAlternateVKN(dists[:9]); got != "" {
+		t.Errorf("searchAlternateVKN() with 9 digits = %q, want empty", got)
+	}
+}
+
+func TestIsValidVKNRejectsSequential(t *testing.T) {
+	tests := []struct {
+		vkn  string
+		want bool
+	}{
+		{"1111111111", false},
+		{"9876543210", false},
+		{"2345678901", true},
+		{"1234567890", true},
+		{"4827193056", true},
+		{"0123456789", false},
+	}
+	for _, tt := range tests {
+		if got := isValidVKN(tt.vkn); got != tt.want {
+			t.Errorf("isValidVKN(%q) = %v, want %v", tt.vkn, got, tt.want)
+		}
+	}
+}
+
+func BenchmarkSearchAlternateVKN(b *testing.B) {
+	// A long noisy digit stream; every window is searched for the best checksum-valid reading
+	var dists [][10]float64
+	seed := uint32(1)
+	for len(dists) < 200 {
+		seed = seed*1664525 + 1013904223
+		digit := int(seed>>24) % 10
+		dists = append(dists, confidentDigit(digit, (digit+1+int(seed>>16)%9)%10, 0.5))
+	}
+
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		searchAlternateVKN(dists)
+	}
+}
+
+func TestFindExpectedVKNResolvesAmbiguousDigits(t *testing.T) {
+	// 4827193956 fails the checksum. Two checksum-valid readings use a runner-up digit:
+	// 4827193056 (8th digit) and 4827193950 (last digit, read with more confidence).
+	greedy := "4827193956"
+	var dists [][10]float64
+	for i, ch := range greedy {
+		runnerUp, confidence := (int(ch-'0')+5)%10, 0.5
+		switch i {
+		case 7:
+			runnerUp = 0
+		case 9:
+			runnerUp, confidence = 0, 0.7
+		}
+		dists = append(dists, confidentDigit(int(ch-'0'), runnerUp, confidence))
+	}
+
+	// Without a hint the more likely reading wins
+	if got, _ := searchAlternateVKN(dists); got != "4827193056" {
+		t.Fatalf("test setup: searchAlternateVKN() = %q, want %q", got, "4827193056")
+	}
+
+	// The hint selects the other reading the digits allow
+	if start := findExpectedVKN(dists, "4827193950"); start != 0 {
+		t.Errorf("findExpectedVKN() = %d, want 0", start)
+	}
+
+	// A VKN the digits cannot spell is not matched
+	if start := findExpectedVKN(dists, "1234567890"); start != -1 {
+		t.Errorf("findExpectedVKN() for an unrelated VKN = %d, want -1", start)
+	}
+}
+
+func TestExpectedVKN(t *testing.T) {
+	parser, err := NewOCRParser()
+	if err != nil {
+		t.Fatalf("NewOCRParser() error = %v", err)
+	}
+
+	// Ten strokes are read as a VKN of ones with low confidence
+	img := newWhiteGray(480, 60)
+	for i := 0; i < 10; i++ {
+		x := 20 + i*45
+		fillRect(img, image.Rect(x, 15, x+6, 45))
+	}
+	parser.SetMinVKNConfidence(0.99)
+
+	// A matching hint verifies the reading despite the confidence floor
+	parser.SetExpectedVKN("1111111111")
+	result, err := parser.ExtractVKNFromImageDataResult(img)
+	if err != nil || result.VKN != "1111111111" || result.ExpectedVKNMismatch {
+		t.Errorf("with a matching hint: result = %+v, err = %v, want the expected VKN", result, err)
+	}
+
+	// A barcode that contradicts the hint is still returned, flagged as a mismatch
+	parser.SetExpectedVKN("4827193950")
+	result, err = parser.ExtractVKNFromImageDataResult(drawCode128(t, "1234567890"))
+	if err != nil || result.VKN != "1234567890" || !result.ExpectedVKNMismatch {
+		t.Errorf("with a contradicting hint: result = %+v, err = %v, want the barcode VKN flagged as a mismatch", result, err)
+	}
+}
+
+func TestScanImagesForVKNPrefersExpected(t *testing.T) {
+	parser, err := NewOCRParser()
+	if err != nil {
+		t.Fatalf("NewOCRParser() error = %v", err)
+	}
+
+	images := []image.Image{drawCode128(t, "1234567890"), drawCode128(t, "4827193056")}
+
+	if vkn, _, err := parser.scanImagesForVKN(images); err != nil || vkn != "1234567890" {
+		t.Fatalf("scanImagesForVKN() without a hint = %q, %v, want the first barcode", vkn, err)
+	}
+
+	parser.SetExpectedVKN("4827193056")
+	if vkn, _, err := parser.scanImagesForVKN(images); err != nil || vkn != "4827193056" {
+		t.Errorf("scanImagesForVKN() with a hint = %q, %v, want the expected barcode", vkn, err)
+	}
+
+	parser.SetExpectedVKN("4827193950")
+	if vkn, _, err := parser.scanImagesForVKN(images); err != nil || vkn != "1234567890" {
+		t.Errorf("scanImagesForVKN() with an unmatched hint = %q, %v, want the first barcode", vkn, err)
+	}
+}
+
+func TestExtractVKNFromImageDataPartialMatch(t *testing.T) {
+	parser, err := NewOCRParser()
+	if err != nil {
+		t.Fatalf("NewOCRParser() error = %v", err)
+	}
+
+	// Twelve zeros: no candidate without a leading zero, but the first ten digits of the
+	// longer run are still the partial match
+	img := renderVKNPlate("000000000000")
+	parser.SetDigitFont(DigitFontOCRB)
+
+	result, err := parser.ExtractVKNFromImageDataResult(img)
+	if err != nil || result.VKN != "0000000000" || result.Source != "ocr" {
+		t.Errorf("ExtractVKNFromImageDataResult() = %+v, %v; want the partial match 0000000000", result, err)
+	}
+}
+
+func TestMinVKNConfidenceSuppressesLowConfidenceOCR(t *testing.T) {
+	parser, err := NewOCRParser()
+	if err != nil {
+		t.Fatalf("NewOCRParser() error = %v", err)
+	}
+
+	// Ten strokes are read as a VKN of ones; there is no barcode to fall back on
+	img := newWhiteGray(480, 60)
+	for i := 0; i < 10; i++ {
+		x := 20 + i*45
+		fillRect(img, image.Rect(x, 15, x+6, 45))
+	}
+
+	result, err := parser.ExtractVKNFromImageDataResult(img)
+	if err != nil {
+		t.Fatalf("ExtractVKNFromImageDataResult() without a floor error = %v", err)
+	}
+	if result.Source != "ocr" || result.Confidence <= 0 || result.Confidence >= 1 {
+		t.Fatalf("result = %+v, want an OCR VKN with a confidence in (0, 1)", result)
+	}
+	confidence := result.Confidence
+
+	parser.SetMinVKNConfidence(confidence + 0.01)
+	result, err = parser.ExtractVKNFromImageDataResult(img)
+	if !errors.Is(err, ErrLowConfidence) {
+		t.Fatalf("error = %v, want ErrLowConfidence", err)
+	}
+	if result.VKN != "" || result.Reason != VKNFailureLowConfidence {
+		t.Errorf("result = %+v, want no VKN and reason %q", result, VKNFailureLowConfidence)
+	}
+	if vkn, err := parser.ExtractVKNFromImageData(img); vkn != "" || !errors.Is(err, ErrLowConfidence) {
+		t.Errorf("ExtractVKNFromImageData() = %q, %v, want ErrLowConfidence", vkn, err)
+	}
+
+	parser.SetMinVKNConfidence(confidence)
+	if vkn, err := parser.ExtractVKNFromImageData(img); err != nil || vkn == "" {
+		t.Errorf("ExtractVKNFromImageData() at the floor = %q, %v, want the VKN", vkn, err)
+	}
+}
+
+func TestMinVKNConfidenceKeepsBarcode(t *testing.T) {
+	parser, err := NewOCRParser()
+	if err != nil {
+		t.Fatalf("NewOCRParser() error = %v", err)
+	}
+	parser.SetMinVKNConfidence(0.99)
+
+	vkn, err := parser.ExtractVKNFromImageData(drawCode128(t, "1234567890"))
+	if err != nil || vkn != "1234567890" {
+		t.Errorf("ExtractVKNFromImageData() = %q, %v, want the barcode VKN", vkn, err)
+	}
+}
+
+func TestExtractVKNFromBarcodeTextControlCharacters(t *testing.T) {
+	parser, err := NewOCRParser()
+	if err != nil {
+		t.Fatalf("NewOCRParser() error = %v", err)
+	}
+
+	tests := []struct {
+		name string
+		text string
+		want string
+	}{
+		{"Plain", "4827193056", "4827193056"},
+		{"FNC1 and AIM identifier", "]C1\x1d4827193056\x1d", "4827193056"},
+		{"FNC1 between VKN and trailing digits", "4827193056\x1d17250101", "4827193056"},
+		{"Encoder FNC characters", "ñ4827193056ô", "4827193056"},
+		{"Control bytes and replacement characters", "\x02VKN�4827193056\x03\x7f", "4827193056"},
+		// A GS1 application identifier merged into the run: the leading window "2148271930"
+		// looks plausible but fails the checksum
+		{"Identifier prefix in the same run", "214827193056", "4827193056"},
+		{"Checksum-valid run preferred over an earlier plausible one", "1234567891 4827193056", "4827193056"},
+		{"No checksum-valid run falls back to the first plausible one", "]C01234567891", "1234567891"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got, _, _ := parser.extractVKNFromBarcodeText(tt.text); got != tt.want {
+				t.Errorf("extractVKNFromBarcodeText(%q) = %q, want %q", tt.text, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestExtractVKNFromBarcodeTextCheckDigit(t *testing.T) {
+	parser, err := NewOCRParser()
+	if err != nil {
+		t.Fatalf("NewOCRParser() error = %v", err)
+	}
+
+	tests := []struct {
+		name           string
+		text           string
+		wantVKN        string
+		wantCheckDigit bool
+		wantErr        error
+	}{
+		{"Check digit after a hyphen", "482719305-6", "4827193056", true, nil},
+		{"Check digit after a slash", "VKN 482719305/6", "4827193056", true, nil},
+		{"Tampered check digit", "482719305-7", "", false, ErrBarcodeCheckDigit},
+		{"No check digit", "4827193056", "4827193056", false, nil},
+		// Other layouts carry no check digit of a known format and take the windowed match
+		{"Digit appended to a VKN", "48271930561", "4827193056", false, nil},
+		{"Digit after a full VKN", "4827193056-1", "4827193056", false, nil},
+		{"TCKN is not a VKN with a check digit", "10000000146", "1000000014", false, nil},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			vkn, checkDigit, err := parser.extractVKNFromBarcodeText(tt.text)
+			if vkn != tt.wantVKN || checkDigit != tt.wantCheckDigit || !errors.Is(err, tt.wantErr) {
+				t.Errorf("extractVKNFromBarcodeText(%q) = %q, %v, %v; want %q, %v, %v",
+					tt.text, vkn, checkDigit, err, tt.wantVKN, tt.wantCheckDigit, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestExtractVKNFromImageDataResultCheckDigit(t *testing.T) {
+	parser, err := NewOCRParser()
+	if err != nil {
+		t.Fatalf("NewOCRParser() error = %v", err)
+	}
+
+	result, err := parser.ExtractVKNFromImageDataResult(drawCode128(t, "482719305-6"))
+	if err != nil || result.VKN != "4827193056" || result.Source != "barcode" || !result.CheckDigitVerified {
+		t.Errorf("correct payload: result = %+v, %v; want the barcode VKN with a verified check digit", result, err)
+	}
+
+	// A misread check digit must not yield the barcode VKN
+	result, _ = parser.ExtractVKNFromImageDataResult(drawCode128(t, "482719305-7"))
+	if result != nil && result.Source == "barcode" {
+		t.Errorf("tampered payload: result = %+v, want the barcode rejected", result)
+	}
+}
+
+func TestParseReportsVKNCheckDigit(t *testing.T) {
+	text := "Adı Soyadı: Ali Örnek\nVergi Dairesi: Örnek VD\n"
+	for _, tt := range []struct {
+		payload string
+		want    bool
+	}{
+		{"482719305-6", true},
+		{"4827193056", false},
+	} {
+		parser := NewParser()
+		parser.SetBackend(&fakeBackend{
+			pages:  []PageText{{Number: 1, Text: text}},
+			images: []image.Image{drawCode128(t, tt.payload)},
+		})
+
+		vl, err := parser.Parse(bytes.NewReader(nil))
+		if err != nil {
+			t.Fatalf("Parse() with payload %q error = %v", tt.payload, err)
+		}
+		if vl.VergiKimlikNo != "4827193056" || vl.VKNCheckDigitVerified != tt.want {
+			t.Errorf("Parse() with payload %q = VKN %q, check digit verified %v; want 4827193056, %v",
+				tt.payload, vl.VergiKimlikNo, vl.VKNCheckDigitVerified, tt.want)
+		}
+	}
+}
+
+func TestFilterDigitRegionsResolutions(t *testing.T) {
+	// The same line of ten digits, a speck and a frame, scanned at 1x and 12x
+	digitsAt := func(scale int) (digits, regions []image.Rectangle) {
+		for i := 0; i < 10; i++ {
+			x := (2 + i*6) * scale
+			width := 4 * scale
+			if i == 3 {
+				width = scale + 1 // a narrow "1"
+			}
+			digits = append(digits, image.Rect(x, 4*scale, x+width, 11*scale))
+		}
+		speck := image.Rect(70*scale, 5*scale, 70*scale+scale+1, 5*scale+scale+1)
+		frame := image.Rect(0, 0, 64*scale, 15*scale)
+		return digits, append(append(regions, digits...), speck, frame)
+	}
+
+	for _, scale := range []int{1, 12} {
+		digits, regions := digitsAt(scale)
+		got := filterDigitRegions(regions, DefaultDigitRegionFilter())
+		if !reflect.DeepEqual(got, digits) {
+			t.Errorf("scale %d: filterDigitRegions() = %v, want the %d digits %v", scale, got, len(digits), digits)
+		}
+	}
+
+	// At high DPI a tight crop of two digits makes each wider than a third of the image
+	crop := []image.Rectangle{image.Rect(10, 10, 70, 130), image.Rect(80, 10, 140, 130)}
+	if got := filterDigitRegions(crop, DefaultDigitRegionFilter()); len(got) != 2 {
+		t.Errorf("filterDigitRegions() on a tight crop = %v, want both digits", got)
+	}
+}
+
+func TestSetDigitRegionFilter(t *testing.T) {
+	parser, err := NewOCRParser()
+	if err != nil {
+		t.Fatalf("NewOCRParser() error = %v", err)
+	}
+	if parser.regionFilter != DefaultDigitRegionFilter() {
+		t.Errorf("regionFilter = %+v, want the default", parser.regionFilter)
+	}
+
+	filter := DefaultDigitRegionFilter()
+	filter.MaxAspect = 0.5
+	parser.SetDigitRegionFilter(filter)
+
+	wide := image.Rect(0, 0, 30, 30)
+	narrow := image.Rect(40, 0, 50, 30)
+	if got := filterDigitRegions([]image.Rectangle{wide, narrow}, parser.regionFilter); !reflect.DeepEqual(got, []image.Rectangle{narrow}) {
+		t.Errorf("filterDigitRegions() = %v, want only %v", got, narrow)
+	}
+}
+
+func TestScanImagesForVKNBarcodeStatus(t *testing.T) {
+	parser, err := NewOCRParser()
+	if err != nil {
+		t.Fatalf("NewOCRParser() error = %v", err)
+	}
+
+	// A barcode with a strip of its bars wiped out still looks like one, but fails to decode
+	damaged := drawCode128(t, "1234567890")
+	bounds := damaged.Bounds()
+	for y := bounds.Min.Y; y < bounds.Max.Y; y++ {
+		for x := bounds.Dx()/2 - 6; x < bounds.Dx()/2+6; x++ {
+			damaged.SetGray(x, y, color.Gray{255})
+		}
+	}
+
+	tests := []struct {
+		name   string
+		images []image.Image
+		want   error
+	}{
+		{"Text-only page", []image.Image{newWhiteGray(400, 200)}, ErrNoBarcodePresent},
+		{"No images", nil, ErrNoBarcodePresent},
+		{"Damaged barcode", []image.Image{newWhiteGray(400, 200), damaged}, ErrBarcodeUnreadable},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			vkn, _, err := parser.extractVKNFromImages(tt.images, nil)
+			if vkn != "" || !errors.Is(err, tt.want) {
+				t.Errorf("extractVKNFromImages() = %q, %v, want %v", vkn, err, tt.want)
+			}
+		})
+	}
+}
+
+func TestDebugImagesConcurrent(t *testing.T) {
+	parser, err := NewOCRParser()
+	if err != nil {
+		t.Fatalf("NewOCRParser() error = %v", err)
+	}
+	parser.SetOCRDebug(true)
+	parser.SetDebugDir(t.TempDir())
+
+	img := newWhiteGray(120, 40)
+	fillRect(img, image.Rect(20, 8, 24, 32))
+
+	var wg sync.WaitGroup
+	results := make([]*ExtractVKNResult, 2)
+	for i := range results {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			results[i], _ = parser.ExtractVKNFromImageDataResult(img)
+		}()
+	}
+	wg.Wait()
+
+	if results[0] == nil || results[1] == nil {
+		t.Fatalf("ExtractVKNFromImageDataResult() results = %+v, want both non-nil", results)
+	}
+	if results[0].DebugDir == "" || results[0].DebugDir == results[1].DebugDir {
+		t.Fatalf("DebugDir = %q and %q, want two distinct directories", results[0].DebugDir, results[1].DebugDir)
+	}
+	for _, result := range results {
+		for _, name := range []string{"debug_01_grayscale.png", "debug_02_binary.png"} {
+			f, err := os.Open(filepath.Join(result.DebugDir, name))
+			if err != nil {
+				t.Errorf("debug image missing: %v", err)
+				continue
+			}
+			if _, err := png.Decode(f); err != nil {
+				t.Errorf("%s in %s is not a valid PNG: %v", name, result.DebugDir, err)
+			}
+			f.Close()
+		}
+	}
+}
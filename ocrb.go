@@ -0,0 +1,442 @@
+package vergilevhasi
+
+import (
+	"image"
+	"math"
+	"sync"
+)
+
+// DigitFont selects how printed digits are recognized when the VKN is read from the
+// digits of an image rather than its barcode
+type DigitFont string
+
+const (
+	// DigitFontGeneric uses the feature classifier, which makes no assumption about the
+	// font, on the whole image. This is the default.
+	DigitFontGeneric DigitFont = "generic"
+
+	// DigitFontOCRB first reads the VKN text area, the line printed under the barcode,
+	// by matching its digits against templates of OCR-B, the font GİB prints numbers in.
+	// The whole image is still read with the generic classifier when that area is missing
+	// or yields no VKN.
+	DigitFontOCRB DigitFont = "ocr-b"
+)
+
+// SetDigitFont selects the digit recognizer of the printed VKN fallback:
+// DigitFontGeneric (the default) or DigitFontOCRB
+func (p *OCRParser) SetDigitFont(font DigitFont) {
+	p.digitFont = font
+}
+
+// digitRecognizer classifies a single digit image; DigitClassifier and ocrbMatcher
+// implement it
+type digitRecognizer interface {
+	Classify(img *image.Gray) (int, float64)
+	ClassifyAll(img *image.Gray) [10]float64
+}
+
+// vknAreaPadding is the white margin, in pixels, around the VKN text area crop, so that
+// digits at its edge stay separate components
+const vknAreaPadding = 4
+
+// cropVKNTextArea returns the VKN text area of a plate image: the human-readable line
+// under a horizontal barcode, as wide as the barcode region and as tall as its bars. The
+// bars are the rows around the middle of the region matching its middle row. It reports
+// false when the image has no such barcode.
+func cropVKNTextArea(img *image.Gray) (*image.Gray, bool) {
+	region, ok := findBarcodeRegion(img)
+	if !ok || region.Dx() <= region.Dy() {
+		return nil, false
+	}
+
+	dark := func(x, y int) bool { return img.GrayAt(x, y).Y < 128 }
+	mid := (region.Min.Y + region.Max.Y) / 2
+	matchesMid := func(y int) bool {
+		same := 0
+		for x := region.Min.X; x < region.Max.X; x++ {
+			if dark(x, y) == dark(x, mid) {
+				same++
+			}
+		}
+		return 10*same >= 9*region.Dx()
+	}
+	top, bottom := mid, mid+1
+	for top > region.Min.Y && matchesMid(top-1) {
+		top--
+	}
+	for bottom < region.Max.Y && matchesMid(bottom) {
+		bottom++
+	}
+
+	area := image.Rect(region.Min.X, bottom, region.Max.X, bottom+(bottom-top)).Intersect(img.Bounds())
+	if area.Empty() {
+		return nil, false
+	}
+	crop := image.NewGray(image.Rect(0, 0, area.Dx()+2*vknAreaPadding, area.Dy()+2*vknAreaPadding))
+	for i := range crop.Pix {
+		crop.Pix[i] = 255
+	}
+	for y := area.Min.Y; y < area.Max.Y; y++ {
+		for x := area.Min.X; x < area.Max.X; x++ {
+			crop.SetGray(x-area.Min.X+vknAreaPadding, y-area.Min.Y+vknAreaPadding, img.GrayAt(x, y))
+		}
+	}
+	return crop, true
+}
+
+// ocrbDigitRows are the OCR-B digits 0-9 on a 9x13 grid with two-cell strokes
+var ocrbDigitRows = [10][]string{
+	{
+		"..#####..",
+		".##...##.",
+		"##.....##",
+		"##.....##",
+		"##.....##",
+		"##.....##",
+		"##.....##",
+		"##.....##",
+		"##.....##",
+		"##.....##",
+		"##.....##",
+		".##...##.",
+		"..#####..",
+	},
+	{
+		"....##...",
+		"...###...",
+		"..####...",
+		".##.##...",
+		"##..##...",
+		"....##...",
+		"....##...",
+		"....##...",
+		"....##...",
+		"....##...",
+		"....##...",
+		"....##...",
+		"....##...",
+	},
+	{
+		"..#####..",
+		".##...##.",
+		"##.....##",
+		".......##",
+		".......##",
+		"......##.",
+		".....##..",
+		"....##...",
+		"...##....",
+		"..##.....",
+		".##......",
+		"##.......",
+		"#########",
+	},
+	{
+		"..#####..",
+		".##...##.",
+		"##.....##",
+		".......##",
+		".......##",
+		"......##.",
+		"...####..",
+		"......##.",
+		".......##",
+		".......##",
+		"##.....##",
+		".##...##.",
+		"..#####..",
+	},
+	{
+		".....##..",
+		"....###..",
+		"...####..",
+		"...#.##..",
+		"..##.##..",
+		"..#..##..",
+		".##..##..",
+		"##...##..",
+		"#########",
+		".....##..",
+		".....##..",
+		".....##..",
+		".....##..",
+	},
+	{
+		"########.",
+		"##.......",
+		"##.......",
+		"##.......",
+		"#######..",
+		"......##.",
+		".......##",
+		".......##",
+		".......##",
+		".......##",
+		"##.....##",
+		".##...##.",
+		"..#####..",
+	},
+	{
+		".....##..",
+		"....##...",
+		"...##....",
+		"..##.....",
+		".##......",
+		".######..",
+		"##....##.",
+		"##.....##",
+		"##.....##",
+		"##.....##",
+		"##.....##",
+		".##...##.",
+		"..#####..",
+	},
+	{
+		"#########",
+		".......##",
+		"......##.",
+		"......##.",
+		".....##..",
+		".....##..",
+		"....##...",
+		"....##...",
+		"...##....",
+		"...##....",
+		"..##.....",
+		"..##.....",
+		"..##.....",
+	},
+	{
+		"..#####..",
+		".##...##.",
+		"##.....##",
+		"##.....##",
+		".##...##.",
+		"..#####..",
+		".##...##.",
+		"##.....##",
+		"##.....##",
+		"##.....##",
+		"##.....##",
+		".##...##.",
+		"..#####..",
+	},
+	{
+		"..#####..",
+		".##...##.",
+		"##.....##",
+		"##.....##",
+		"##.....##",
+		"##.....##",
+		".##....##",
+		"..######.",
+		"......##.",
+		".....##..",
+		"....##...",
+		"...##....",
+		"..##.....",
+	},
+}
+
+// ocrbSampleScale is how many sample cells a template cell spans when a digit image is
+// compared with it; finer sampling lets the comparison tolerate strokes of other widths
+const ocrbSampleScale = 2
+
+// ocrbTemplate is a digit template trimmed to its ink, sampled ocrbSampleScale times finer
+type ocrbTemplate struct {
+	cells  glyphBitmap
+	aspect float64
+}
+
+// ocrbMatcher recognizes OCR-B digits by comparing them with the templates
+type ocrbMatcher struct {
+	templates [10]ocrbTemplate
+}
+
+// sharedOCRBMatcher returns the matcher shared by every OCRParser; it is read-only
+var sharedOCRBMatcher = sync.OnceValue(newOCRBMatcher)
+
+// newOCRBMatcher builds the templates from ocrbDigitRows
+func newOCRBMatcher() *ocrbMatcher {
+	m := &ocrbMatcher{}
+	for digit, rows := range ocrbDigitRows {
+		trimmed := trimBitmapRows(rows)
+		w, h := len(trimmed[0]), len(trimmed)
+		cells := make(glyphBitmap, h*ocrbSampleScale)
+		for y := range cells {
+			cells[y] = make([]bool, w*ocrbSampleScale)
+			for x := range cells[y] {
+				cells[y][x] = trimmed[y/ocrbSampleScale][x/ocrbSampleScale]
+			}
+		}
+		m.templates[digit] = ocrbTemplate{cells: cells, aspect: float64(w) / float64(h)}
+	}
+	return m
+}
+
+// trimBitmapRows converts rows of '#' and '.' into a bitmap cropped to its ink
+func trimBitmapRows(rows []string) glyphBitmap {
+	minR, maxR, minC, maxC := len(rows), -1, math.MaxInt, -1
+	for r, row := range rows {
+		for c, ch := range row {
+			if ch == '#' {
+				minR, maxR = min(minR, r), max(maxR, r)
+				minC, maxC = min(minC, c), max(maxC, c)
+			}
+		}
+	}
+
+	bm := make(glyphBitmap, 0, maxR-minR+1)
+	for r := minR; r <= maxR; r++ {
+		line := make([]bool, 0, maxC-minC+1)
+		for c := minC; c <= maxC; c++ {
+			line = append(line, rows[r][c] == '#')
+		}
+		bm = append(bm, line)
+	}
+	return bm
+}
+
+// Classify returns the best matching digit and its match score as the confidence
+func (m *ocrbMatcher) Classify(img *image.Gray) (int, float64) {
+	scores := m.scores(img)
+	best := 0
+	for digit, score := range scores {
+		if score > scores[best] {
+			best = digit
+		}
+	}
+	return best, scores[best]
+}
+
+// ClassifyAll returns the match score of every digit, normalized to sum to 1
+func (m *ocrbMatcher) ClassifyAll(img *image.Gray) [10]float64 {
+	scores := m.scores(img)
+	total := 0.0
+	for _, score := range scores {
+		total += score
+	}
+	for i := range scores {
+		if total == 0 {
+			scores[i] = 0.1
+		} else {
+			scores[i] /= total
+		}
+	}
+	return scores
+}
+
+// scores returns the match score of every digit, from 0 to 1. The ink of img is sampled
+// onto each template's grid; the score is the share of ink of each lying within one cell
+// of ink of the other, scaled down when the aspect ratios differ.
+func (m *ocrbMatcher) scores(img *image.Gray) [10]float64 {
+	var scores [10]float64
+	box, ok := inkBounds(img)
+	if !ok {
+		return scores
+	}
+	aspect := float64(box.Dx()) / float64(box.Dy())
+
+	for digit, tmpl := range m.templates {
+		sampled := sampleInk(img, box, len(tmpl.cells[0]), len(tmpl.cells))
+		precision := coveredInk(sampled, tmpl.cells)
+		recall := coveredInk(tmpl.cells, sampled)
+		if precision+recall == 0 {
+			continue
+		}
+		f1 := 2 * precision * recall / (precision + recall)
+		scores[digit] = f1 * math.Min(aspect, tmpl.aspect) / math.Max(aspect, tmpl.aspect)
+	}
+	return scores
+}
+
+// inkBounds returns the bounding box of the ink of img. Dark pixels with fewer than two
+// dark neighbours are left out, so scattered specks don't stretch the box.
+func inkBounds(img *image.Gray) (image.Rectangle, bool) {
+	b := img.Bounds()
+	dark := func(x, y int) bool {
+		return image.Pt(x, y).In(b) && img.GrayAt(x, y).Y < 128
+	}
+
+	box := image.Rectangle{}
+	found := false
+	for y := b.Min.Y; y < b.Max.Y; y++ {
+		for x := b.Min.X; x < b.Max.X; x++ {
+			if !dark(x, y) {
+				continue
+			}
+			neighbours := 0
+			for dy := -1; dy <= 1; dy++ {
+				for dx := -1; dx <= 1; dx++ {
+					if (dx != 0 || dy != 0) && dark(x+dx, y+dy) {
+						neighbours++
+					}
+				}
+			}
+			if neighbours < 2 {
+				continue
+			}
+			pixel := image.Rect(x, y, x+1, y+1)
+			if !found {
+				box, found = pixel, true
+			} else {
+				box = box.Union(pixel)
+			}
+		}
+	}
+	return box, found
+}
+
+// sampleInk divides box into cols x rows cells and marks the cells that are at least
+// half dark. Cells smaller than a pixel take the pixel they fall on.
+func sampleInk(img *image.Gray, box image.Rectangle, cols, rows int) glyphBitmap {
+	bm := make(glyphBitmap, rows)
+	for r := range bm {
+		bm[r] = make([]bool, cols)
+		y0 := box.Min.Y + r*box.Dy()/rows
+		y1 := max(box.Min.Y+(r+1)*box.Dy()/rows, y0+1)
+		for c := range bm[r] {
+			x0 := box.Min.X + c*box.Dx()/cols
+			x1 := max(box.Min.X+(c+1)*box.Dx()/cols, x0+1)
+			dark, total := 0, 0
+			for y := y0; y < y1; y++ {
+				for x := x0; x < x1; x++ {
+					if img.GrayAt(x, y).Y < 128 {
+						dark++
+					}
+					total++
+				}
+			}
+			bm[r][c] = 2*dark >= total
+		}
+	}
+	return bm
+}
+
+// coveredInk returns the share of the ink cells of a that have an ink cell of b at most
+// one cell away; both bitmaps have the same size
+func coveredInk(a, b glyphBitmap) float64 {
+	ink, covered := 0, 0
+	for y, row := range a {
+		for x, set := range row {
+			if !set {
+				continue
+			}
+			ink++
+		near:
+			for dy := -1; dy <= 1; dy++ {
+				for dx := -1; dx <= 1; dx++ {
+					yy, xx := y+dy, x+dx
+					if yy >= 0 && yy < len(b) && xx >= 0 && xx < len(b[yy]) && b[yy][xx] {
+						covered++
+						break near
+					}
+				}
+			}
+		}
+	}
+	if ink == 0 {
+		return 0
+	}
+	return float64(covered) / float64(ink)
+}
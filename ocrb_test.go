@@ -0,0 +1,222 @@
+package vergilevhasi
+
+import (
+	"image"
+	"image/color"
+	"math"
+	"math/rand"
+	"testing"
+)
+
+// ocrbPoint is a point of a glyph outline, in units of a 6x10 glyph box
+type ocrbPoint struct{ x, y float64 }
+
+// ocrbArc returns the points of an elliptical arc from one angle to another, in degrees
+// clockwise from the right
+func ocrbArc(cx, cy, rx, ry, from, to float64) []ocrbPoint {
+	const steps = 24
+	pts := make([]ocrbPoint, 0, steps+1)
+	for i := 0; i <= steps; i++ {
+		a := (from + (to-from)*float64(i)/steps) * math.Pi / 180
+		pts = append(pts, ocrbPoint{cx + rx*math.Cos(a), cy + ry*math.Sin(a)})
+	}
+	return pts
+}
+
+// ocrbGlyphStrokes are the OCR-B digits traced as stroke center lines. They are drawn from
+// the shapes of the font, independently of the matcher's ocrbDigitRows bitmaps, so the
+// tests don't read back the templates they match against.
+var ocrbGlyphStrokes = [10][][]ocrbPoint{
+	{ocrbArc(3, 5, 2.5, 4.6, 0, 360)},
+	{{{3.6, 0.3}, {3.6, 9.7}}, {{3.6, 0.3}, {1.2, 2.6}}},
+	{append(ocrbArc(3, 2.8, 2.4, 2.5, 190, 360), ocrbPoint{5.2, 3.6}, ocrbPoint{0.6, 9.7}, ocrbPoint{5.6, 9.7})},
+	{ocrbArc(3, 2.6, 2.3, 2.3, 200, 450), ocrbArc(3, 7.2, 2.6, 2.5, -90, 160)},
+	{{{4.2, 0.3}, {0.4, 6.8}, {5.8, 6.8}}, {{4.2, 3.5}, {4.2, 9.7}}},
+	{{{5.2, 0.3}, {1.0, 0.3}, {0.8, 4.5}}, append([]ocrbPoint{{0.8, 4.5}}, ocrbArc(3, 6.7, 2.6, 3.0, -130, 150)...)},
+	{{{4.6, 0.3}, {0.9, 6.2}}, ocrbArc(3, 7.1, 2.5, 2.6, 0, 360)},
+	{{{0.6, 0.3}, {5.4, 0.3}, {2.0, 9.7}}},
+	{ocrbArc(3, 2.5, 2.1, 2.2, 0, 360), ocrbArc(3, 7.2, 2.6, 2.5, 0, 360)},
+	{ocrbArc(3, 2.9, 2.5, 2.6, 0, 360), {{5.1, 3.8}, {1.4, 9.7}}},
+}
+
+// drawOCRBGlyph renders an OCR-B digit onto img with its top-left corner at (x0, y0),
+// scale pixels per glyph unit (the glyph is 10 units tall) and strokes stroke units wide
+func drawOCRBGlyph(img *image.Gray, digit, x0, y0 int, scale, stroke float64) {
+	for y := 0; y <= int(10*scale)+1; y++ {
+		for x := 0; x <= int(6*scale)+1; x++ {
+			p := ocrbPoint{float64(x) / scale, float64(y) / scale}
+			for _, line := range ocrbGlyphStrokes[digit] {
+				for i := 1; i < len(line); i++ {
+					if segmentDistance(p, line[i-1], line[i]) <= stroke/2 {
+						img.SetGray(x0+x, y0+y, color.Gray{0})
+					}
+				}
+			}
+		}
+	}
+}
+
+// segmentDistance returns the distance from p to the segment from a to b
+func segmentDistance(p, a, b ocrbPoint) float64 {
+	dx, dy := b.x-a.x, b.y-a.y
+	t := 0.0
+	if l := dx*dx + dy*dy; l > 0 {
+		t = math.Max(0, math.Min(1, ((p.x-a.x)*dx+(p.y-a.y)*dy)/l))
+	}
+	return math.Hypot(p.x-a.x-t*dx, p.y-a.y-t*dy)
+}
+
+// renderVKNPlate draws barcode bars that don't decode, as on a damaged print, with digits
+// printed in OCR-B under them like the human-readable VKN line of a plate
+func renderVKNPlate(digits string) *image.Gray {
+	img := newWhiteGray(len(digits)*32+80, 200)
+	rng := rand.New(rand.NewSource(7))
+	for x := 20; x < img.Bounds().Dx()-20; {
+		bar := 2 + rng.Intn(6)
+		fillRect(img, image.Rect(x, 20, x+bar, 90))
+		x += bar + 2 + rng.Intn(6)
+	}
+	for i, d := range digits {
+		drawOCRBGlyph(img, int(d-'0'), 40+i*32, 104, 4, 0.9)
+	}
+	return img
+}
+
+func TestOCRBMatcherAccuracy(t *testing.T) {
+	matcher := newOCRBMatcher()
+	generic := NewDigitClassifier()
+	rng := rand.New(rand.NewSource(1))
+
+	total, ocrbCorrect, genericCorrect := 0, 0, 0
+	for _, scale := range []float64{2, 2.5, 3, 4, 5, 7} {
+		for _, stroke := range []float64{0.7, 0.9, 1.2} {
+			for _, noise := range []float64{0, 0.03} {
+				for digit := 0; digit < 10; digit++ {
+					w, h := int(6*scale)+12, int(10*scale)+12
+					img := newWhiteGray(w, h)
+					drawOCRBGlyph(img, digit, 5, 5, scale, stroke)
+					// Flip pixels inside the glyph box, like scanner speckle
+					for y := 5; y < h-6; y++ {
+						for x := 5; x < w-6; x++ {
+							if rng.Float64() < noise {
+								img.Pix[y*img.Stride+x] ^= 255
+							}
+						}
+					}
+
+					total++
+					if got, confidence := matcher.Classify(img); got == digit {
+						ocrbCorrect++
+					} else {
+						t.Logf("scale %v stroke %v noise %v: digit %d read as %d (%.2f)", scale, stroke, noise, digit, got, confidence)
+					}
+					// The generic classifier gets the same digit cut to its ink, as the
+					// pipeline cuts each component
+					if box, ok := inkBounds(img); ok {
+						if got, _ := generic.Classify(extractDigitImage(img, box)); got == digit {
+							genericCorrect++
+						}
+					}
+				}
+			}
+		}
+	}
+
+	ocrb, gen := float64(ocrbCorrect)/float64(total), float64(genericCorrect)/float64(total)
+	t.Logf("accuracy on %d rendered OCR-B digits: ocr-b %.3f, generic %.3f", total, ocrb, gen)
+	if ocrb < 0.95 {
+		t.Errorf("OCR-B accuracy = %.3f (%d/%d), want at least 0.95", ocrb, ocrbCorrect, total)
+	}
+	if ocrb <= gen {
+		t.Errorf("OCR-B accuracy %.3f is no better than generic %.3f on the same digits", ocrb, gen)
+	}
+}
+
+func TestOCRBMatcherClassifyAll(t *testing.T) {
+	img := newWhiteGray(40, 50)
+	drawOCRBGlyph(img, 7, 4, 4, 3.5, 0.9)
+
+	dist := newOCRBMatcher().ClassifyAll(img)
+	sum := 0.0
+	for _, p := range dist {
+		sum += p
+	}
+	if sum < 0.999 || sum > 1.001 {
+		t.Errorf("distribution sums to %.4f, want 1", sum)
+	}
+	if best := topTwoDigits(dist)[0]; best != 7 {
+		t.Errorf("best digit = %d, want 7", best)
+	}
+
+	if got := newOCRBMatcher().ClassifyAll(newWhiteGray(10, 10)); got[0] != 0.1 {
+		t.Errorf("ClassifyAll(blank) = %v, want uniform", got)
+	}
+}
+
+func TestCropVKNTextArea(t *testing.T) {
+	img := renderVKNPlate("4827193056")
+
+	area, ok := cropVKNTextArea(img)
+	if !ok {
+		t.Fatal("cropVKNTextArea() found no text area")
+	}
+	// The crop holds the digit line and none of the bars
+	digits := 0
+	for _, r := range filterDigitRegions(findConnectedComponents(adaptiveBinarize(area, 15, 10)), DefaultDigitRegionFilter()) {
+		if r.Dy() > 60 {
+			t.Errorf("region %v in the text area is as tall as a bar", r)
+		}
+		digits++
+	}
+	if digits != 10 {
+		t.Errorf("text area has %d digit regions, want 10", digits)
+	}
+
+	if _, ok := cropVKNTextArea(newWhiteGray(400, 200)); ok {
+		t.Error("cropVKNTextArea() found a text area without a barcode")
+	}
+}
+
+func TestExtractVKNOCRBDigits(t *testing.T) {
+	const vkn = "4827193056"
+	img := renderVKNPlate(vkn)
+
+	parser, err := NewOCRParser()
+	if err != nil {
+		t.Fatal(err)
+	}
+	generic, err := parser.ExtractVKNFromImageDataResult(img)
+	t.Logf("generic classifier: %+v, %v", generic, err)
+
+	parser.SetDigitFont(DigitFontOCRB)
+	result, err := parser.ExtractVKNFromImageDataResult(img)
+	if err != nil {
+		t.Fatalf("ExtractVKNFromImageDataResult() error = %v (recognized %q)", err, result.RecognizedDigits)
+	}
+	if result.VKN != vkn || result.Source != "ocr" {
+		t.Errorf("VKN = %q from %q, want %q from ocr", result.VKN, result.Source, vkn)
+	}
+	if generic != nil && generic.VKN == vkn {
+		t.Errorf("generic classifier also read %s; the input doesn't tell the fonts apart", vkn)
+	}
+}
+
+func TestExtractVKNOCRBWithoutTextArea(t *testing.T) {
+	// Digits without a barcode have no VKN text area; the OCR-B mode reads them like the
+	// generic one
+	img := newWhiteGray(400, 90)
+	for i, d := range "4827193056" {
+		drawOCRBGlyph(img, int(d-'0'), 20+i*36, 20, 4, 0.9)
+	}
+
+	parser, err := NewOCRParser()
+	if err != nil {
+		t.Fatal(err)
+	}
+	generic, genericErr := parser.ExtractVKNFromImageDataResult(img)
+	parser.SetDigitFont(DigitFontOCRB)
+	ocrb, ocrbErr := parser.ExtractVKNFromImageDataResult(img)
+	if generic.RecognizedDigits != ocrb.RecognizedDigits || generic.VKN != ocrb.VKN || (genericErr == nil) != (ocrbErr == nil) {
+		t.Errorf("OCR-B mode = %+v, %v; want the generic result %+v, %v", ocrb, ocrbErr, generic, genericErr)
+	}
+}
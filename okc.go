@@ -0,0 +1,60 @@
+package vergilevhasi
+
+import (
+	"regexp"
+	"slices"
+	"strings"
+)
+
+// maxOkcLines bounds the lines read after the cash register section heading
+const maxOkcLines = 20
+
+// okcSerialRe matches a cash register serial number: a brand prefix of two or three
+// letters followed by eight to ten digits, e.g. "JH 20012345" or "FT0012345678"
+var okcSerialRe = regexp.MustCompile(`\b([A-Z]{2,3})\s?(\d{8,10})\b`)
+
+// isOkcHeading reports whether line opens the cash register (ödeme kaydedici cihaz)
+// section
+func isOkcHeading(line string) bool {
+	folded := foldTurkish(line)
+	return strings.Contains(folded, "odeme kaydedici cihaz") || strings.HasPrefix(folded, "okc")
+}
+
+// extractOkcSeriNolari returns the serial numbers of the cash registers (ÖKC) listed in
+// the "Ödeme Kaydedici Cihaz" section, either on the heading line or on the lines below
+// it up to the next blank line or section. Serials are returned without the space
+// some plates print after the prefix, and duplicates are dropped.
+func extractOkcSeriNolari(text string) []string {
+	lines := strings.Split(text, "\n")
+	for i, line := range lines {
+		if !isOkcHeading(line) {
+			continue
+		}
+
+		var serials []string
+		add := func(s string) {
+			for _, m := range okcSerialRe.FindAllStringSubmatch(s, -1) {
+				serial := m[1] + m[2]
+				if !slices.Contains(serials, serial) {
+					serials = append(serials, serial)
+				}
+			}
+		}
+		add(line)
+
+		for j, next := range lines[i+1:] {
+			trimmed := strings.TrimSpace(next)
+			if trimmed == "" || j >= maxOkcLines {
+				break
+			}
+			folded := foldTurkish(trimmed)
+			if !okcSerialRe.MatchString(trimmed) &&
+				(strings.Contains(folded, "vergi") || strings.Contains(folded, "faaliyet") || strings.Contains(folded, "matrah")) {
+				break
+			}
+			add(trimmed)
+		}
+		return serials
+	}
+	return nil
+}
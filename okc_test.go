@@ -0,0 +1,44 @@
+package vergilevhasi
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestParseContentOkcSeriNolari(t *testing.T) {
+	tests := []struct {
+		name string
+		text string
+	}{
+		{
+			"Serials below the heading",
+			"VERGİ LEVHASI\nMÜKELLEFİN\nALİ ÖRNEK\nÖRNEK MAH. TEST CAD. NO:1 ÇANKAYA/ANKARA\n" +
+				"YILLIK GELİR VERGİSİ\nÇANKAYA\n10000000146\nÖDEME KAYDEDİCİ CİHAZLAR\n1- JH 20012345\n2- FT20098765\n",
+		},
+		{
+			"Serials on the heading line",
+			"VERGİ LEVHASI\nMÜKELLEFİN\nALİ ÖRNEK\nÖRNEK MAH. TEST CAD. NO:1 ÇANKAYA/ANKARA\n" +
+				"YILLIK GELİR VERGİSİ\nÇANKAYA\n10000000146\nÖdeme Kaydedici Cihaz Seri No: JH 20012345, FT 20098765\n",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			vl := &VergiLevhasi{}
+			NewParser().parseContent(vl, tt.text)
+
+			if want := []string{"JH20012345", "FT20098765"}; !reflect.DeepEqual(vl.OkcSeriNolari, want) {
+				t.Errorf("OkcSeriNolari = %q, want %q", vl.OkcSeriNolari, want)
+			}
+			if vl.TCKimlikNo != "10000000146" {
+				t.Errorf("TCKimlikNo = %q, want 10000000146", vl.TCKimlikNo)
+			}
+		})
+	}
+}
+
+func TestExtractOkcSeriNolariWithoutSection(t *testing.T) {
+	if got := extractOkcSeriNolari("MÜKELLEFİN\nALİ ÖRNEK\nJH 20012345\n"); got != nil {
+		t.Errorf("extractOkcSeriNolari() = %q, want nil", got)
+	}
+}
@@ -0,0 +1,97 @@
+package vergilevhasi
+
+import (
+	"regexp"
+	"strings"
+)
+
+// maxOrtaklar bounds the partner lines read after the partner label
+const maxOrtaklar = 20
+
+var (
+	// ortakNumberRe matches list numbering before a partner name, e.g. "1- " or "2) "
+	ortakNumberRe = regexp.MustCompile(`^\d{1,2}\s*[-.)]\s*`)
+
+	// ortakIDRe matches a TCKN or VKN printed after a partner name
+	ortakIDRe = regexp.MustCompile(`\s*[-–(]?\s*\d{10,11}\s*\)?$`)
+)
+
+// isAdiOrtaklik reports whether the document is issued for an ordinary partnership
+// (adi ortaklık), which is taxed through its partners and lists them on the plate
+func isAdiOrtaklik(text string) bool {
+	folded := foldTurkish(text)
+	return strings.Contains(folded, "adi ortaklik") || strings.Contains(folded, "adi ortakligi")
+}
+
+// extractOrtaklar returns the partner names listed under an "ORTAKLAR" label, either
+// separated by commas on the label line or one per following line. Numbering and
+// identification numbers printed next to the names are dropped.
+func extractOrtaklar(text string) []string {
+	lines := strings.Split(text, "\n")
+	for i, line := range lines {
+		rest, ok := cutOrtakLabel(strings.TrimSpace(line))
+		if !ok {
+			continue
+		}
+
+		var ortaklar []string
+		for _, name := range strings.FieldsFunc(rest, func(r rune) bool { return r == ',' || r == ';' }) {
+			if name = cleanOrtakName(name); name != "" {
+				ortaklar = append(ortaklar, name)
+			}
+		}
+		if len(ortaklar) > 0 {
+			return ortaklar
+		}
+
+		// One partner per line, up to the next label or section
+		for _, next := range lines[i+1:] {
+			trimmed := strings.TrimSpace(next)
+			if trimmed == "" || len(ortaklar) >= maxOrtaklar {
+				break
+			}
+			if digitLineRe.MatchString(trimmed) {
+				// The partner's TCKN on a line of its own
+				continue
+			}
+			folded := foldTurkish(trimmed)
+			if strings.ContainsAny(trimmed, ":：") || strings.Contains(folded, "vergi") ||
+				strings.Contains(folded, "faaliyet") || strings.Contains(folded, "matrah") ||
+				dateLineRe.MatchString(trimmed) {
+				break
+			}
+			if name := cleanOrtakName(trimmed); name != "" {
+				ortaklar = append(ortaklar, name)
+			}
+		}
+		return ortaklar
+	}
+	return nil
+}
+
+// cutOrtakLabel returns the rest of line after a partner label such as "ORTAKLAR:" or
+// "Ortakların Adı Soyadı", and whether the line starts with one
+func cutOrtakLabel(line string) (string, bool) {
+	words := strings.Fields(line)
+	if len(words) == 0 {
+		return "", false
+	}
+	switch strings.TrimRight(foldTurkish(words[0]), ":：") {
+	case "ortaklar", "ortaklari", "ortaklarin":
+	default:
+		return "", false
+	}
+
+	rest := words[1:]
+	if len(rest) >= 2 && strings.HasPrefix(foldTurkish(rest[0]), "adi") && strings.HasPrefix(foldTurkish(rest[1]), "soyadi") {
+		rest = rest[2:]
+	}
+	return strings.TrimSpace(strings.TrimLeft(strings.Join(rest, " "), ":：")), true
+}
+
+// cleanOrtakName trims list numbering and identification numbers from a partner name
+func cleanOrtakName(name string) string {
+	name = ortakNumberRe.ReplaceAllString(strings.TrimSpace(name), "")
+	name = ortakIDRe.ReplaceAllString(name, "")
+	return strings.TrimSpace(name)
+}
@@ -0,0 +1,50 @@
+package vergilevhasi
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestParseContentAdiOrtaklik(t *testing.T) {
+	tests := []struct {
+		name string
+		text string
+	}{
+		{
+			"Partners on separate lines",
+			"VERGİ LEVHASI\nMÜKELLEFİN\nÖRNEK YAPI ADİ ORTAKLIĞI\nÖRNEK MAH. TEST CAD. NO:1 ÇANKAYA/ANKARA\n" +
+				"YILLIK GELİR VERGİSİ\nÇANKAYA\n1234567890\nORTAKLAR\nALİ ÖRNEK\nAYŞE ÖRNEK\n",
+		},
+		{
+			"Partners on the label line",
+			"VERGİ LEVHASI\nMÜKELLEFİN\nÖRNEK YAPI ADİ ORTAKLIĞI\nÖRNEK MAH. TEST CAD. NO:1 ÇANKAYA/ANKARA\n" +
+				"YILLIK GELİR VERGİSİ\nÇANKAYA\n1234567890\nOrtaklar: 1- ALİ ÖRNEK (10000000146), 2- AYŞE ÖRNEK\n",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			vl := &VergiLevhasi{}
+			NewParser().parseContent(vl, tt.text)
+
+			if want := []string{"ALİ ÖRNEK", "AYŞE ÖRNEK"}; !reflect.DeepEqual(vl.Ortaklar, want) {
+				t.Errorf("Ortaklar = %q, want %q", vl.Ortaklar, want)
+			}
+			if vl.TicaretUnvani != "ÖRNEK YAPI ADİ ORTAKLIĞI" {
+				t.Errorf("TicaretUnvani = %q, want the partnership name", vl.TicaretUnvani)
+			}
+			if vl.AdiSoyadi != "" {
+				t.Errorf("AdiSoyadi = %q, want empty", vl.AdiSoyadi)
+			}
+			if vl.MukellefTuru != MukellefTuruAdiOrtaklik {
+				t.Errorf("MukellefTuru = %q, want %q", vl.MukellefTuru, MukellefTuruAdiOrtaklik)
+			}
+		})
+	}
+}
+
+func TestExtractOrtaklarWithoutLabel(t *testing.T) {
+	if got := extractOrtaklar("MÜKELLEFİN\nALİ ÖRNEK\n"); got != nil {
+		t.Errorf("extractOrtaklar() = %q, want nil", got)
+	}
+}
@@ -1,20 +1,22 @@
 package vergilevhasi
 
 import (
-	"bytes"
+	"context"
+	"errors"
 	"fmt"
 	"io"
 	"log"
 	"os"
 	"regexp"
+	"slices"
+	"sort"
 	"strconv"
 	"strings"
 	"time"
+	"unicode"
 	"unicode/utf16"
+	"unicode/utf8"
 
-	"github.com/pdfcpu/pdfcpu/pkg/api"
-	"github.com/pdfcpu/pdfcpu/pkg/pdfcpu"
-	"github.com/pdfcpu/pdfcpu/pkg/pdfcpu/model"
 	"golang.org/x/text/encoding/charmap"
 )
 
@@ -22,13 +24,155 @@ import (
 type Parser struct {
 	// Options for parsing
 	debug bool
+
+	// tcknAsVergiNo flags individuals whose TCKN is their tax identifier
+	tcknAsVergiNo bool
+
+	// Fuzzy matching of tax types and tax office names
+	fuzzyMaxDistance int
+	taxTypeRefs      []string
+	taxOfficeRefs    []string
+
+	// fields selects which fields are extracted
+	fields FieldSet
+
+	// repairMojibake fixes mis-decoded Turkish letters in free-text fields
+	repairMojibake bool
+
+	// normalizeUnvan cleans up whitespace, punctuation and legal-form suffixes in TicaretUnvani
+	normalizeUnvan bool
+
+	// cleanupFields collapses whitespace and trims stray punctuation in free-text fields
+	cleanupFields bool
+
+	// backend extracts text and images; nil means the default pdfcpu backend
+	backend PDFBackend
+
+	// maxRegexInput skips whole-text regex passes on longer inputs; 0 disables the guard
+	maxRegexInput int
+
+	// activityCodeLengths lists the accepted activity code lengths in digits
+	activityCodeLengths []int
+
+	// activitySimilarity is the least similarity a longer description of a repeated
+	// activity code needs to replace the one kept; 0 always replaces
+	activitySimilarity float64
+
+	// fieldPages records the source page of each extracted field
+	fieldPages bool
+
+	// taxTypeChecks are the tax type keywords, in checking order; nil means the defaults
+	taxTypeChecks []taxTypeCheck
+
+	// addressMarkers identify address lines; nil means DefaultAddressMarkers
+	addressMarkers []string
+
+	// addressDetector, if set, replaces addressMarkers
+	addressDetector func(line string) bool
+
+	// maxPages stops text and image extraction after this many pages; 0 disables the cap
+	maxPages int
+
+	// maxPDFSize is the largest PDF, in bytes, that is read into memory; 0 disables the cap
+	maxPDFSize int64
+
+	// collectMatches records every pattern match into matches for debugging
+	collectMatches bool
+	matches        []PatternMatch
+
+	// extractHook, if set, is called before each field-specific extraction pass runs
+	extractHook func(FieldSet)
+
+	// postProcessors run on every result before it is returned, in registration order
+	postProcessors []func(*VergiLevhasi)
 }
 
 // NewParser creates a new Parser instance
 func NewParser() *Parser {
 	return &Parser{
-		debug: false,
+		debug:            false,
+		fuzzyMaxDistance: DefaultFuzzyMaxDistance,
+		taxTypeRefs:      DefaultTaxTypeReferences(),
+		fields:           AllFields,
+		repairMojibake:   true,
+		normalizeUnvan:   true,
+		cleanupFields:    true,
+		maxRegexInput:    DefaultMaxRegexInputLength,
+		maxPages:         DefaultMaxPages,
+		maxPDFSize:       DefaultMaxPDFSize,
+
+		activityCodeLengths: DefaultActivityCodeLengths(),
+	}
+}
+
+// ErrPDFTooLarge is returned when a PDF is larger than the cap set with Parser.SetMaxPDFSize
+var ErrPDFTooLarge = errors.New("PDF exceeds the maximum size")
+
+// DefaultMaxPDFSize is the default cap on the size of a PDF read into memory. Tax plates
+// are well under a megabyte.
+const DefaultMaxPDFSize = 50 << 20
+
+// SetMaxPDFSize sets the largest PDF, in bytes, that Parse, PeekText and
+// CandidateIdentifiers read. A larger stream is not read past the cap and fails with
+// ErrPDFTooLarge. Zero or a negative value disables the cap.
+func (p *Parser) SetMaxPDFSize(bytes int64) {
+	p.maxPDFSize = bytes
+}
+
+// readPDF reads a whole PDF into memory, enforcing the size cap
+func (p *Parser) readPDF(reader io.Reader) ([]byte, error) {
+	if p.maxPDFSize <= 0 {
+		data, err := io.ReadAll(reader)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read PDF data: %w", err)
+		}
+		return data, nil
+	}
+
+	// Read one byte past the cap to tell a PDF of exactly the cap from a larger one
+	data, err := io.ReadAll(io.LimitReader(reader, p.maxPDFSize+1))
+	if err != nil {
+		return nil, fmt.Errorf("failed to read PDF data: %w", err)
 	}
+	if int64(len(data)) > p.maxPDFSize {
+		return nil, fmt.Errorf("%w of %d bytes", ErrPDFTooLarge, p.maxPDFSize)
+	}
+	return data, nil
+}
+
+// DefaultMaxPages is the default number of pages processed per document. Tax plates are
+// one or two pages; the cap keeps a huge PDF from tying up a server.
+const DefaultMaxPages = 100
+
+// SetMaxPages sets how many pages Parse processes. Pages beyond the cap are ignored and
+// the result carries a warning that processing was truncated. Zero or a negative value
+// disables the cap.
+func (p *Parser) SetMaxPages(n int) {
+	p.maxPages = n
+}
+
+// DefaultMaxRegexInputLength is the default text length above which whole-text regex
+// passes are skipped. Real plates are a few kilobytes of text.
+const DefaultMaxRegexInputLength = 256 * 1024
+
+// SetMaxRegexInputLength sets the text length (in bytes) above which the whole-text
+// regex passes (single-line GİB layout and single-line activity lists) are skipped.
+// Go regexes run in linear time, but those patterns rescan the whole text per match and
+// get slow on very large or garbled inputs. Line-based passes are unaffected.
+// Zero or a negative value disables the guard.
+func (p *Parser) SetMaxRegexInputLength(n int) {
+	p.maxRegexInput = n
+}
+
+// allowsWholeTextRegex reports whether text is short enough for whole-text regex passes
+func (p *Parser) allowsWholeTextRegex(text string) bool {
+	if p.maxRegexInput <= 0 || len(text) <= p.maxRegexInput {
+		return true
+	}
+	if p.debug {
+		log.Printf("Skipping whole-text regex pass: %d bytes exceeds limit of %d", len(text), p.maxRegexInput)
+	}
+	return false
 }
 
 // SetDebug enables or disables debug mode
@@ -36,8 +180,20 @@ func (p *Parser) SetDebug(debug bool) {
 	p.debug = debug
 }
 
+// SetTCKNAsVergiNo enables flagging individual taxpayers that use their TCKN as the tax identifier.
+// When enabled and an individual plate has a valid TCKN but no VKN, UsesTCKNAsVergiNo is set and
+// TCKimlikNo is the authoritative identifier. VergiKimlikNo is left empty; no VKN is ever invented.
+func (p *Parser) SetTCKNAsVergiNo(enabled bool) {
+	p.tcknAsVergiNo = enabled
+}
+
 // ParseFile parses a tax plate PDF file and returns structured data
 func (p *Parser) ParseFile(filepath string) (*VergiLevhasi, error) {
+	return p.parseFile(context.Background(), filepath)
+}
+
+// parseFile parses a tax plate PDF file, giving up with ctx's error once ctx is done
+func (p *Parser) parseFile(ctx context.Context, filepath string) (*VergiLevhasi, error) {
 	file, err := os.Open(filepath)
 	if err != nil {
 		return nil, fmt.Errorf("failed to open file: %w", err)
@@ -49,73 +205,112 @@ func (p *Parser) ParseFile(filepath string) (*VergiLevhasi, error) {
 		}
 	}(file)
 
-	return p.Parse(file)
+	data, err := p.readPDF(file)
+	if err != nil {
+		return nil, err
+	}
+	return p.parseData(ctx, data, nil)
 }
 
 // Parse parses a tax plate PDF from an io.ReadSeeker and returns structured data
 func (p *Parser) Parse(reader io.ReadSeeker) (*VergiLevhasi, error) {
 	// Read all content into a buffer
-	data, err := io.ReadAll(reader)
+	data, err := p.readPDF(reader)
 	if err != nil {
-		return nil, fmt.Errorf("failed to read PDF data: %w", err)
+		return nil, err
 	}
 
-	// Create a reader from the data
-	rs := bytes.NewReader(data)
-
-	// Create pdfcpu configuration
-	conf := model.NewDefaultConfiguration()
+	return p.parseData(context.Background(), data, nil)
+}
 
-	// Read, validate and optimize the PDF safely using pdfcpu
-	ctx, err := api.ReadValidateAndOptimize(rs, conf)
+// parseData parses a PDF read into memory. onImageVKN, if set, is called with the VKN read
+// from the images as soon as it is found, before the text is parsed. Once ctx is done the
+// parse stops at the next step, abandoning the barcode scan, and fails with ctx's error.
+func (p *Parser) parseData(ctx context.Context, data []byte, onImageVKN func(vkn string)) (*VergiLevhasi, error) {
+	// Extract text from all pages, and the images for the barcode from the same read.
+	// The barcode only carries the VKN, so there is nothing to scan for when it is not requested.
+	doc, err := p.readDocument(data, p.fields.Has(FieldVergiKimlikNo))
 	if err != nil {
-		return nil, fmt.Errorf("failed to read and validate PDF: %w", err)
+		return nil, err
 	}
+	return p.parseDocument(ctx, doc, onImageVKN)
+}
 
-	// Extract text from all pages using pdfcpu's ExtractPageContent
-	var rawText strings.Builder
-	for pageNr := 1; pageNr <= ctx.PageCount; pageNr++ {
-		contentReader, err := pdfcpu.ExtractPageContent(ctx, pageNr)
-		if err != nil {
-			continue
-		}
-		if contentReader == nil {
-			continue
-		}
+// parseDocument parses a document already read by readDocument, which must have read the
+// images if the VKN is requested
+func (p *Parser) parseDocument(ctx context.Context, doc *pdfDocument, onImageVKN func(vkn string)) (*VergiLevhasi, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	wantVKN := p.fields.Has(FieldVergiKimlikNo)
 
-		contentBytes, err := io.ReadAll(contentReader)
-		if err != nil {
-			continue
-		}
+	var warnings []string
+	if doc.truncatedPages > 0 {
+		warning := fmt.Sprintf("processing truncated to the first %d of %d pages", p.maxPages, doc.truncatedPages)
+		log.Printf("Warning: %s", warning)
+		warnings = append(warnings, warning)
+	}
 
-		// Parse the PDF content stream to extract text
-		pageText := extractTextFromPDFContent(string(contentBytes))
-		rawText.WriteString(pageText)
+	var rawText strings.Builder
+	var layoutRows []textRow
+	for _, page := range doc.pages {
+		rawText.WriteString(page.Text)
 		rawText.WriteString("\n")
+
+		// Keep the visual layout for table-style plates
+		layoutRows = append(layoutRows, page.rows...)
 	}
 
 	// Combine extraction methods
 	combinedText := rawText.String()
-	ocrParser, err := NewOCRParser()
-	if err != nil {
-		log.Printf("Warning: Could not create OCR parser: %v", err)
-	} else {
-		defer func(ocrParser *OCRParser) {
-			err := ocrParser.Close()
-			if err != nil {
-				log.Printf("Warning: Could not close OCR parser: %v", err)
+
+	// A scanned plate has no text layer; only the VKN can be recovered, from the images
+	imageOnly := strings.TrimSpace(combinedText) == ""
+	if imageOnly {
+		warning := "no text layer found; the PDF is image-only and only the VKN is read, from its images"
+		log.Printf("Warning: %s", warning)
+		warnings = append(warnings, warning)
+	}
+
+	imageVKN, checkDigit := "", false
+	if wantVKN {
+		ocrParser, err := NewOCRParser()
+		if err != nil {
+			log.Printf("Warning: Could not create OCR parser: %v", err)
+		} else {
+			defer func(ocrParser *OCRParser) {
+				err := ocrParser.Close()
+				if err != nil {
+					log.Printf("Warning: Could not close OCR parser: %v", err)
+				}
+			}(ocrParser)
+			ocrParser.SetOCRDebug(p.debug)
+			ocrParser.ctx = ctx
+			vkn, verified, err := ocrParser.extractVKNFromImages(doc.images, doc.imagesErr)
+			if vkn == "" && imageOnly && doc.imagesErr == nil {
+				// Without a text layer there is no other source, so fall back to reading the digits
+				vkn = ocrParser.ocrVKNFromImages(doc.images)
+				if vkn != "" {
+					err = nil
+				}
+			}
+			if err == nil && vkn != "" {
+				imageVKN, checkDigit = vkn, verified
+				combinedText += "\nVKN: " + vkn + "\n"
+				fmt.Printf("VKN extracted via OCR: %s\n\n", vkn)
+				if onImageVKN != nil {
+					onImageVKN(vkn)
+				}
+			} else if err != nil {
+				log.Printf("OCR extraction failed: %v", err)
 			}
-		}(ocrParser)
-		ocrParser.SetOCRDebug(p.debug)
-		vkn, err := ocrParser.ExtractVKNFromPDFWithImage(data)
-		if err == nil && vkn != "" {
-			combinedText += "\nVKN: " + vkn + "\n"
-			fmt.Printf("VKN extracted via OCR: %s\n\n", vkn)
-		} else if err != nil {
-			log.Printf("OCR extraction failed: %v", err)
 		}
 	}
 
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
 	if p.debug {
 		fmt.Println("Extracted Text:")
 		fmt.Println(combinedText)
@@ -123,69 +318,160 @@ func (p *Parser) Parse(reader io.ReadSeeker) (*VergiLevhasi, error) {
 
 	// Parse the extracted text
 	vergiLevhasi := &VergiLevhasi{
-		RawText: combinedText,
+		Warnings: warnings,
+		RawText:  combinedText,
+	}
+
+	p.parseText(vergiLevhasi, combinedText, layoutRows)
+	vergiLevhasi.ImzaVar = doc.signature.signed
+	vergiLevhasi.ImzalayanAd = doc.signature.signer
+	// The text may carry a VKN of its own that takes precedence over the barcode
+	vergiLevhasi.VKNCheckDigitVerified = checkDigit && vergiLevhasi.VergiKimlikNo == imageVKN
+
+	if imageOnly {
+		vergiLevhasi.IsImageOnly = true
+		vergiLevhasi.RequiresOCR = p.fields
+		if vergiLevhasi.VergiKimlikNo != "" {
+			vergiLevhasi.RequiresOCR &^= FieldVergiKimlikNo
+		}
 	}
 
-	p.parseContent(vergiLevhasi, combinedText)
+	if p.fieldPages {
+		vergiLevhasi.FieldPages = attributeFieldPages(vergiLevhasi, doc.pages)
+	}
 
+	p.runPostProcessors(vergiLevhasi)
 	return vergiLevhasi, nil
 }
 
-// extractTextFromPDFContent parses PDF content stream operators to extract text
-func extractTextFromPDFContent(content string) string {
-	var result strings.Builder
+// parseText parses the page text and layout rows of a plate into vl and cleans up the
+// extracted values
+func (p *Parser) parseText(vl *VergiLevhasi, text string, rows []textRow) {
+	p.parseContent(vl, text)
+	p.parseTableLayout(vl, rows)
+	if p.wants(FieldIsYeriAdresi) {
+		vl.IsYeriAdresleri = p.extractIsYeriAdresleri(text, vl.IsYeriAdresi)
+	}
+	p.repairMojibakeFields(vl)
+	p.normalizeUnvanField(vl)
+
+	// The sector group follows from the primary activity
+	if len(vl.FaaliyetKodlari) > 0 {
+		vl.SektorGrubu = naceSektorGrubu(vl.FaaliyetKodlari[0].Kod)
+	}
+
+	// Snap a slightly garbled tax office name to its reference entry
+	if office, ok := snapToReference(vl.VergiDairesi, p.taxOfficeRefs, p.fuzzyMaxDistance); ok {
+		vl.VergiDairesi = office
+	}
+
+	p.cleanupFieldValues(vl)
+}
+
+// PeekText returns up to maxChars characters of page text for previews.
+// It never runs OCR or field parsing. Pages are extracted one at a time and extraction
+// stops once maxChars is reached; backends that cannot extract single pages read them all.
+func (p *Parser) PeekText(reader io.ReadSeeker, maxChars int) (string, error) {
+	if maxChars <= 0 {
+		return "", fmt.Errorf("maxChars must be positive, got %d", maxChars)
+	}
+
+	data, err := p.readPDF(reader)
+	if err != nil {
+		return "", err
+	}
 
-	// PDF text is encoded between BT (begin text) and ET (end text)
-	// Text showing operators include: Tj, TJ, ', "
-	// We look for text in parentheses (literal strings) or angle brackets (hex strings)
-
-	// Extract text from parenthesized strings using a parser that handles escapes
-	extractedStrings := extractPDFStrings(content)
-	for _, s := range extractedStrings {
-		text := decodePDFString(s)
-		result.WriteString(text)
-		result.WriteString("\n")
-	}
-
-	// Pattern for hex strings
-	hexRe := regexp.MustCompile(`<([0-9A-Fa-f]+)>`)
-	hexMatches := hexRe.FindAllStringSubmatch(content, -1)
-	for _, match := range hexMatches {
-		if len(match) > 1 {
-			text := decodeHexString(match[1])
-			if text != "" {
-				result.WriteString(text)
-				result.WriteString("\nYILLIK GELİR VERGİSİ")
+	var preview strings.Builder
+	chars := 0
+	addPage := func(page PageText) bool {
+		preview.WriteString(page.Text)
+		preview.WriteString("\n")
+		chars += utf8.RuneCountInString(page.Text) + 1
+		return chars < maxChars
+	}
+
+	backend := p.pdfBackend()
+	if pb, ok := backend.(pageTextBackend); ok {
+		if err := pb.extractTextPages(data, addPage); err != nil {
+			return "", err
+		}
+	} else {
+		pages, err := backend.ExtractText(data)
+		if err != nil {
+			return "", err
+		}
+		for _, page := range pages {
+			if !addPage(page) {
+				break
 			}
 		}
 	}
 
-	return result.String()
+	return truncateRunes(preview.String(), maxChars), nil
 }
 
-// extractPDFStrings extracts strings enclosed in parentheses, handling escaped parens
-func extractPDFStrings(content string) []string {
-	var results []string
-	i := 0
-	for i < len(content) {
-		if content[i] == '(' {
-			// Find matching closing parenthesis, handling escapes and nested parens
-			str, endIdx := extractPDFString(content, i)
-			if endIdx > i {
-				results = append(results, str)
-				i = endIdx
-			} else {
-				i++
-			}
-		} else {
-			i++
+// MukellefinBlock returns the raw taxpayer block of a plate, one line per printed line:
+// the text between the "MÜKELLEFİN" label and the next section (tax type, date or
+// identification number), usually the name followed by the address. It is the input the
+// name and address extraction works from, for callers who parse the block themselves.
+// The result is empty when the plate has no such label.
+func (p *Parser) MukellefinBlock(reader io.ReadSeeker) (string, error) {
+	data, err := p.readPDF(reader)
+	if err != nil {
+		return "", err
+	}
+
+	pages, err := p.pdfBackend().ExtractText(data)
+	if err != nil {
+		return "", err
+	}
+
+	var text strings.Builder
+	for _, page := range pages {
+		text.WriteString(page.Text)
+		text.WriteString("\n")
+	}
+	return strings.Join(mukellefinBlock(strings.Split(normalizeLineEndings(text.String()), "\n")), "\n"), nil
+}
+
+// truncateRunes shortens s to at most n characters without splitting multi-byte runes
+func truncateRunes(s string, n int) string {
+	if n <= 0 {
+		return ""
+	}
+	count := 0
+	for i := range s {
+		if count == n {
+			return s[:i]
 		}
+		count++
+	}
+	return s
+}
+
+// pageTextFromContent extracts the text of a page, one text line per line in content
+// stream order. When the page's fonts have ToUnicode CMaps the text is decoded per font,
+// otherwise the byte-level heuristics are used.
+// fragments may be passed if already extracted with the same fonts.
+func pageTextFromContent(content string, fonts fontCMaps, fragments []textFragment) string {
+	if fragments == nil {
+		fragments = extractTextFragmentsWithFonts(content, fonts)
 	}
-	return results
+	return fragmentsText(fragments)
+}
+
+// extractTextFromPDFContent returns the text shown by a page content stream (Tj, TJ, ' and "),
+// one text line per line in document order. Literal and hex strings are decoded in the
+// same pass, and the pieces of a TJ array or of consecutive show operators on the same
+// line are joined into one line.
+func extractTextFromPDFContent(content string) string {
+	return fragmentsText(extractTextFragments(content))
 }
 
 // extractPDFString extracts a single parenthesized string starting at position start
-// Returns the string content (without outer parens) and the index after the closing paren
+// Returns the string content (without outer parens) and the index after the closing paren.
+// The content is returned raw, with its escape sequences intact: escapes are only used
+// here to skip escaped parens, and are resolved once, by unescapePDFString.
 func extractPDFString(content string, start int) (string, int) {
 	if start >= len(content) || content[start] != '(' {
 		return "", start
@@ -198,7 +484,7 @@ func extractPDFString(content string, start int) (string, int) {
 	for i < len(content) {
 		ch := content[i]
 		if ch == '\\' && i+1 < len(content) {
-			// Escaped character - include both backslash and next char in result
+			// Escaped character - keep the escape as written for unescapePDFString
 			result.WriteByte(ch)
 			result.WriteByte(content[i+1])
 			i += 2
@@ -225,6 +511,20 @@ func extractPDFString(content string, start int) (string, int) {
 
 // decodePDFString decodes escape sequences in PDF literal strings
 func decodePDFString(s string) string {
+	// Try to convert from Windows-1254 (Turkish) to UTF-8 if needed
+	decoded := unescapePDFString(s)
+	if containsReplacementChars(decoded) || containsHighBytes(decoded) {
+		if converted, err := convertWindows1254ToUTF8(decoded); err == nil {
+			return converted
+		}
+	}
+	return decoded
+}
+
+// unescapePDFString resolves escape sequences in a PDF literal string and returns the raw bytes.
+// s is the raw string content returned by extractPDFString. A backslash before a character
+// without an escape meaning is dropped, leaving the character itself.
+func unescapePDFString(s string) string {
 	var result strings.Builder
 	i := 0
 	for i < len(s) {
@@ -246,6 +546,13 @@ func decodePDFString(s string) string {
 				result.WriteRune(')')
 			case '\\':
 				result.WriteRune('\\')
+			case '\n':
+				// Line continuation: a backslash before an end-of-line removes the line break
+			case '\r':
+				// Line continuation with CR or CRLF
+				if i+2 < len(s) && s[i+2] == '\n' {
+					i++
+				}
 			default:
 				// Octal escape sequence
 				if s[i+1] >= '0' && s[i+1] <= '7' {
@@ -255,29 +562,28 @@ func decodePDFString(s string) string {
 						octal += string(s[j])
 						j++
 					}
+					// Octal escapes denote single bytes; high-order overflow is ignored per the PDF spec.
+					// Writing a byte keeps them consistent with the Windows-1254 conversion below.
 					if val, err := strconv.ParseInt(octal, 8, 32); err == nil {
-						result.WriteRune(rune(val))
+						result.WriteByte(byte(val))
 					}
-					i = j - 1
+					i = j
+					continue
 				} else {
+					// Not an escape, e.g. "\%": the backslash is ignored
 					result.WriteByte(s[i+1])
 				}
 			}
 			i += 2
+		} else if s[i] == '\\' {
+			// A dangling backslash at the end of the string escapes nothing
+			i++
 		} else {
 			result.WriteByte(s[i])
 			i++
 		}
 	}
-
-	// Try to convert from Windows-1254 (Turkish) to UTF-8 if needed
-	decoded := result.String()
-	if containsReplacementChars(decoded) || containsHighBytes(decoded) {
-		if converted, err := convertWindows1254ToUTF8(decoded); err == nil {
-			return converted
-		}
-	}
-	return decoded
+	return result.String()
 }
 
 // containsReplacementChars checks if string contains Unicode replacement characters
@@ -307,20 +613,7 @@ func convertWindows1254ToUTF8(s string) (string, error) {
 
 // decodeHexString decodes hex-encoded strings, including Turkish and other Unicode characters
 func decodeHexString(hex string) string {
-	// Pad with 0 if odd length
-	if len(hex)%2 != 0 {
-		hex += "0"
-	}
-
-	// Convert hex to bytes first
-	byteData := make([]byte, len(hex)/2)
-	for i := 0; i+1 < len(hex); i += 2 {
-		val, err := strconv.ParseInt(hex[i:i+2], 16, 32)
-		if err != nil {
-			continue
-		}
-		byteData[i/2] = byte(val)
-	}
+	byteData := hexToBytes(hex)
 
 	// Check for UTF-16BE BOM (FEFF) or detect UTF-16BE encoding
 	if len(byteData) >= 2 && byteData[0] == 0xFE && byteData[1] == 0xFF {
@@ -352,6 +645,23 @@ func decodeHexString(hex string) string {
 	return decoded
 }
 
+// hexToBytes converts the body of a PDF hex string to bytes, padding an odd length with 0
+func hexToBytes(hex string) []byte {
+	if len(hex)%2 != 0 {
+		hex += "0"
+	}
+
+	byteData := make([]byte, len(hex)/2)
+	for i := 0; i+1 < len(hex); i += 2 {
+		val, err := strconv.ParseInt(hex[i:i+2], 16, 32)
+		if err != nil {
+			continue
+		}
+		byteData[i/2] = byte(val)
+	}
+	return byteData
+}
+
 // isLikelyUTF16BE checks if bytes look like UTF-16BE encoded text
 func isLikelyUTF16BE(data []byte) bool {
 	if len(data) < 4 || len(data)%2 != 0 {
@@ -395,6 +705,36 @@ func decodeUTF16BE(data []byte) string {
 
 // parseContent extracts structured data from the raw text
 func (p *Parser) parseContent(vl *VergiLevhasi, text string) {
+	if p.collectMatches {
+		p.matches = nil
+	}
+
+	// Line-anchored patterns and the line scans expect "\n" line ends, so Windows
+	// ("\r\n") and classic Mac ("\r") line ends are converted first
+	text = normalizeLineEndings(text)
+
+	// Numeric patterns only match ASCII digits, so full-width and other Unicode digits
+	// of re-typeset documents are folded first
+	text = normalizeDigits(text)
+
+	// The generation timestamp is removed before any other date is looked at,
+	// so the footer date can never be taken for the business start date
+	generated, text := p.extractGenerationTimestamp(text)
+	if p.wants(FieldOlusturulmaTarihi) {
+		vl.OlusturulmaTarihi = generated
+	}
+
+	// Fields filled as a side effect of shared passes are cleared if not requested
+	defer p.applyFieldMask(vl)
+
+	// Activity certificates share fields with the tax plate but use a different label set
+	if isFaaliyetBelgesi(text) {
+		vl.DocumentType = DocumentTypeFaaliyetBelgesi
+		p.parseFaaliyetBelgesi(vl, text)
+		return
+	}
+	vl.DocumentType = DocumentTypeVergiLevhasi
+
 	// Parse using position-based extraction for the GIB PDF format
 	lines := strings.Split(text, "\n")
 
@@ -409,71 +749,85 @@ func (p *Parser) parseContent(vl *VergiLevhasi, text string) {
 		return false
 	}
 
-	// Try line-based parsing first for GIB PDF format
-	p.parseLineBasedFormat(vl, lines, containsAny)
+	if isInteraktifVergiDairesi(text) {
+		// The interactive tax office export pairs its own labels with values; the
+		// position-based GIB passes below would misread its ordering
+		p.parseInteraktif(vl, lines)
+	} else {
+		// Try line-based parsing first for GIB PDF format
+		p.parseLineBasedFormat(vl, lines, containsAny)
 
-	// Try GIB single-line format parsing first
-	// GIB PDFs often have all data in a single line with labels mixed with values
-	p.parseGIBFormat(vl, text, containsAny)
+		// Try GIB single-line format parsing first
+		// GIB PDFs often have all data in a single line with labels mixed with values
+		p.parseGIBFormat(vl, text, containsAny)
+	}
 
 	// Try traditional format only if GIB format didn't find the values (with colons)
 	// Extract Adı Soyadı (Full Name) - traditional format with colon
 	if vl.AdiSoyadi == "" {
-		vl.AdiSoyadi = p.extractField(text, []string{
-			`(?i)adı\s*soyadı\s*[:：]\s*(.+?)(?:\n|$)`,
-			`(?i)ad[ıi]\s*soyad[ıi]\s*[:：]\s*(.+?)(?:\n|$)`,
-		})
+		vl.AdiSoyadi = p.extractField(text, labelAdiSoyadiPatterns)
 	}
 
 	// Extract Ticaret Ünvanı - traditional format
 	if vl.TicaretUnvani == "" {
-		vl.TicaretUnvani = p.extractField(text, []string{
-			`(?i)ticaret\s*ünvanı\s*[:：]\s*(.+?)(?:\n|$)`,
-			`(?i)ticaret\s+ünvan[ıi]\s*[:：]\s*(.+?)(?:\n|$)`,
-		})
+		vl.TicaretUnvani = p.extractField(text, labelTicaretUnvaniPatterns)
 	}
 
 	// Extract İş Yeri Adresi - traditional format
 	if vl.IsYeriAdresi == "" {
-		vl.IsYeriAdresi = p.extractField(text, []string{
-			`(?i)iş\s*yeri\s*adresi\s*[:：]\s*(.+?)(?:\n|$)`,
-			`(?i)[iİ]ş\s*[yY]eri\s*[aA]dresi\s*[:：]\s*(.+?)(?:\n|$)`,
-		})
+		vl.IsYeriAdresi = p.extractField(text, labelIsYeriAdresiPatterns)
 	}
 
 	// Extract Vergi Dairesi - traditional format
 	if vl.VergiDairesi == "" {
-		vl.VergiDairesi = p.extractField(text, []string{
-			`(?i)vergi\s*dairesi\s*[:：]\s*(.+?)(?:\n|$)`,
-		})
+		vl.VergiDairesi = p.extractField(text, labelVergiDairesiPatterns)
 	}
 
 	// Extract Vergi Kimlik No - traditional format
 	if vl.VergiKimlikNo == "" {
-		vl.VergiKimlikNo = p.extractField(text, []string{
-			`(?i)vergi\s*kimlik\s*no\s*[:：]\s*(\d{10})`,
-			`(?i)v\.?k\.?n\.?\s*[:：]\s*(\d{10})`,
-		})
+		vl.VergiKimlikNo = p.extractField(text, labelVKNPatterns)
+	}
+	if vl.VergiKimlikNo == "" && p.wants(FieldVergiKimlikNo) {
+		vl.VergiKimlikNo = p.extractGroupedVKN(text)
 	}
 
 	// Extract TC Kimlik No - traditional format
-	if vl.TCKimlikNo == "" {
-		vl.TCKimlikNo = p.extractField(text, []string{
-			`(?i)t\.?c\.?\s*kimlik\s*no\s*[:：]\s*(\d{11})`,
-			`(?i)tckn\s*[:：]\s*(\d{11})`,
-			`(?i)tc\s*k[iİ]ml[iİ]k\s*no\s*[:：]?\s*(\d{11})`,
-			`(?i)t\.c\.\s*k[iİ]ml[iİ]k\s*no\s*[:：]?\s*(\d{11})`,
-		})
+	if vl.TCKimlikNo == "" && p.wants(FieldTCKimlikNo) {
+		vl.TCKimlikNo = p.extractField(text, labelTCKNPatterns)
+	}
+
+	// Extract Uyruk and Pasaport No - foreign individuals
+	if vl.Uyruk == "" && p.wants(FieldUyruk) {
+		vl.Uyruk = p.extractField(text, labelUyrukPatterns)
+	}
+	if vl.PasaportNo == "" && p.wants(FieldPasaportNo) {
+		vl.PasaportNo = strings.ToUpper(p.extractField(text, labelPasaportNoPatterns))
+	}
+
+	// Extract Kayıt No - associations and foundations
+	if vl.KayitNo == "" && p.wants(FieldKayitNo) {
+		vl.KayitNo = p.extractField(text, labelKayitNoPatterns)
+	}
+
+	// Extract a potential VKN and a foreign tax number - foreign entities with limited
+	// tax liability. A potential VKN replaces whatever number was found without its label.
+	if p.wants(FieldVergiKimlikNo) {
+		if vkn := p.extractField(text, labelPotansiyelVKNPatterns); vkn != "" {
+			vl.VergiKimlikNo = vkn
+			vl.IsPotansiyelVKN = true
+		}
+	}
+	if vl.YabanciVergiNo == "" && p.wants(FieldYabanciVergiNo) {
+		vl.YabanciVergiNo = strings.ToUpper(p.extractField(text, labelYabanciVergiNoPatterns))
 	}
 
 	// Extract İşe Başlama Tarihi - traditional format
-	dateStr := p.extractField(text, []string{
-		`(?i)işe\s*başlama\s*tarihi\s*[:：]\s*(\d{2}[./-]\d{2}[./-]\d{4})`,
-		`(?i)[iİ]şe\s*[bB]aşlama\s*[tT]arihi\s*[:：]\s*(\d{2}[./-]\d{2}[./-]\d{4})`,
-	})
-	if dateStr != "" {
-		if date, err := p.parseDate(dateStr); err == nil {
-			vl.IseBaslamaTarihi = &date
+	if p.wants(FieldIseBaslamaTarihi) {
+		dateStr := p.extractField(text, labelIseBaslamaPatterns)
+		if dateStr != "" {
+			if date, err := p.parseDate(dateStr); err == nil {
+				vl.IseBaslamaTarihi = &date
+			}
 		}
 	}
 
@@ -515,12 +869,12 @@ func (p *Parser) parseContent(vl *VergiLevhasi, text string) {
 	}
 
 	// Extract İş Yeri Adresi - GIB format: look for address patterns
-	if vl.IsYeriAdresi == "" {
+	if vl.IsYeriAdresi == "" && p.wants(FieldIsYeriAdresi) {
 		for _, line := range lines {
 			trimmedLine := strings.TrimSpace(line)
 			// Address usually contains street/district markers (with proper suffixes)
 			// Be more specific to avoid matching "TC Kimlik No:" type lines
-			hasAddressMarker := containsAny(line, "MAH.", "MAH ", "CAD.", "CAD ", "SOK.", "SOK ", "KAPI NO", "İÇ KAPI", "IC KAPI", "YOLU CAD")
+			hasAddressMarker := p.isAddressLine(line)
 			// Also check for "NO:" but only if preceded by address-related words
 			if !hasAddressMarker && strings.Contains(strings.ToUpper(line), "NO:") {
 				// Check if this looks like an address (has building/apartment indicators)
@@ -552,24 +906,30 @@ func (p *Parser) parseContent(vl *VergiLevhasi, text string) {
 	// (between tax type line and date/TCKN line)
 
 	// Extract Vergi Kimlik No - GIB format: look for 10-digit tax ID
-	if vl.VergiKimlikNo == "" {
-		vl.VergiKimlikNo = p.extractField(text, []string{
-			`(?m)^(\d{10})$`,
-			`\b(\d{10})\b`,
-		})
+	if vl.VergiKimlikNo == "" && p.wants(FieldVergiKimlikNo) {
+		vl.VergiKimlikNo = p.extractField(text, bareVKNPatterns)
 	}
 
-	// Extract TC Kimlik No - GIB format: look for 11-digit Turkish ID
-	if vl.TCKimlikNo == "" {
-		vl.TCKimlikNo = p.extractField(text, []string{
-			`(?m)^(\d{11})$`,
-			`\b(\d{11})\b`,
-		})
+	// Extract Şube Kodu - branch plates print a branch code by label or appended to the VKN.
+	// The VKN patterns above only ever capture the 10-digit base, never base+branch.
+	if vl.SubeKodu == "" && p.wants(FieldSubeKodu) {
+		vl.SubeKodu = p.extractField(text, subeKoduPatterns)
+	}
+
+	// Extract İş Yeri Türü - whether the address is the head office or a branch
+	if vl.IsYeriTuru == IsYeriTuruBilinmiyor && p.wants(FieldIsYeriTuru) {
+		vl.IsYeriTuru = p.extractIsYeriTuru(text, lines, vl.IsYeriAdresi)
+	}
+
+	// Extract TC Kimlik No - GIB format: look for 11-digit Turkish ID.
+	// Foreign individuals identified by passport may have no TCKN, so any other
+	// 11-digit number on their plate is not taken for one.
+	if vl.TCKimlikNo == "" && vl.PasaportNo == "" && p.wants(FieldTCKimlikNo) {
+		vl.TCKimlikNo = p.extractField(text, bareTCKNPatterns)
 	}
 
 	// Extract İşe Başlama Tarihi - GIB format: look for date patterns (DD.MM.YYYY)
-	if vl.IseBaslamaTarihi == nil {
-		dateRe := regexp.MustCompile(`(\d{2}\.\d{2}\.\d{4})`)
+	if vl.IseBaslamaTarihi == nil && p.wants(FieldIseBaslamaTarihi) {
 		dateMatches := dateRe.FindAllString(text, -1)
 		if len(dateMatches) > 0 {
 			// Use the first date found (usually the İşe Başlama Tarihi)
@@ -609,18 +969,44 @@ func (p *Parser) parseContent(vl *VergiLevhasi, text string) {
 		}
 	}
 
-	// Extract Vergi Türü (Tax Types)
-	vl.VergiTuru = p.extractTaxTypes(text)
+	// Extract Vergi Türü (Tax Types). They also decide below whether the name is a person's
+	// or a company's, so they are extracted whenever a name field is requested.
+	if p.wants(FieldVergiTuru) || p.fields&(FieldAdiSoyadi|FieldTicaretUnvani) != 0 {
+		vl.VergiTuru = p.extractTaxTypes(text)
+	}
+
+	// Extract Gelir Unsuru (Income Element). Rental income also decides below that the
+	// taxpayer is an individual, so it is looked for even when the field is not requested.
+	gelirUnsuru := extractGelirUnsuru(text)
+	if p.wants(FieldGelirUnsuru) {
+		vl.GelirUnsuru = gelirUnsuru
+	}
 
 	// Extract Faaliyet Kodları (Activity Codes)
-	vl.FaaliyetKodlari = p.extractActivities(text)
+	if p.wants(FieldFaaliyetKodlari) {
+		vl.FaaliyetKodlari = p.extractActivities(text)
+	}
+
+	// Basit usul taxpayers pay no tax on a declared base, so there are no tax bases to
+	// extract; amounts on their plates are something else
+	vergiUsulu := extractVergiUsulu(text)
+	if p.wants(FieldVergiUsulu) {
+		vl.VergiUsulu = vergiUsulu
+	}
 
 	// Extract Geçmiş Matrahlar (Historical Tax Bases)
-	vl.GecmisMatra = p.extractTaxBases(text)
+	if vergiUsulu != VergiUsuluBasit && p.wants(FieldGecmisMatrahlar) {
+		vl.GecmisMatra = p.extractTaxBases(text)
+	}
+
+	// Retail taxpayers may list their registered cash registers
+	if p.wants(FieldOkcSeriNolari) {
+		vl.OkcSeriNolari = extractOkcSeriNolari(text)
+	}
 
 	// Handle "Yeni işe başlama" (new business) case
 	// In this case, there's no matrah data - the year shown is the registration year
-	if containsAny(text, "Yeni işe başlama", "Yeni ise baslama") {
+	if len(vl.GecmisMatra) > 0 && containsAny(text, "Yeni işe başlama", "Yeni ise baslama") {
 		// Clear matrah data that might have been incorrectly parsed
 		// (e.g., activity code numbers being mistaken for amounts)
 		var validMatrahlar []Matrah
@@ -634,13 +1020,32 @@ func (p *Parser) parseContent(vl *VergiLevhasi, text string) {
 		vl.GecmisMatra = validMatrahlar
 	}
 
-	isKurumsal := false
+	// Ordinary partnerships list their partners; the name on the plate is the partnership's
+	adiOrtaklik := isAdiOrtaklik(text)
+	if adiOrtaklik && p.wants(FieldOrtaklar) {
+		vl.Ortaklar = extractOrtaklar(text)
+	}
+
+	// Associations and foundations are legal entities even when they pay no corporate tax
+	orgType := organizationType(vl.TicaretUnvani, vl.AdiSoyadi)
+	kurumlarVergisi := false
 	for _, vt := range vl.VergiTuru {
 		if strings.Contains(strings.ToLower(vt), "kurumlar") {
-			isKurumsal = true
+			kurumlarVergisi = true
 			break
 		}
 	}
+	isKurumsal := orgType != "" || kurumlarVergisi
+
+	// A plate that states the taxpayer is a natural or a legal person settles it, whatever
+	// the name and the tax types suggest
+	kisiTuru := extractKisiTuru(text)
+	switch kisiTuru {
+	case kisiTuruTuzel:
+		isKurumsal = true
+	case kisiTuruGercek:
+		isKurumsal, orgType, adiOrtaklik = false, "", false
+	}
 
 	if isKurumsal {
 		// Kurumsal: AdiSoyadi'ndaki değer aslında TicaretUnvani olmalı
@@ -653,6 +1058,20 @@ func (p *Parser) parseContent(vl *VergiLevhasi, text string) {
 		if vl.TicaretUnvani != "" {
 			vl.AdiSoyadi = ""
 		}
+
+		vl.MukellefTuru = MukellefTuruKurumsal
+		if orgType != "" {
+			vl.MukellefTuru = orgType
+		}
+	} else if adiOrtaklik {
+		// The partnership name is a trade name, never a person's; partners are in Ortaklar
+		if vl.TicaretUnvani == "" {
+			vl.TicaretUnvani = vl.AdiSoyadi
+		}
+		vl.AdiSoyadi = ""
+		if vl.TicaretUnvani != "" || len(vl.Ortaklar) > 0 {
+			vl.MukellefTuru = MukellefTuruAdiOrtaklik
+		}
 	} else {
 		// Bireysel: TicaretUnvani boş olmalı (bireysel mükellefin ticaret unvanı yok)
 		// AdiSoyadi zaten doğru yerde
@@ -661,20 +1080,131 @@ func (p *Parser) parseContent(vl *VergiLevhasi, text string) {
 			vl.AdiSoyadi = vl.TicaretUnvani
 			vl.TicaretUnvani = ""
 		}
+
+		// Rental income (GMSİ) is declared by individuals, who have no trade name; a value
+		// picked up next to the name is a repeat of it or the income label itself
+		if gelirUnsuru == gelirUnsuruGMSI {
+			vl.TicaretUnvani = ""
+		}
+
+		// Sole proprietors are often registered under their TCKN alone
+		if p.tcknAsVergiNo && vl.VergiKimlikNo == "" && isValidTCKN(vl.TCKimlikNo) {
+			vl.UsesTCKNAsVergiNo = true
+		}
+
+		if vl.AdiSoyadi != "" || kisiTuru == kisiTuruGercek {
+			vl.MukellefTuru = MukellefTuruBireysel
+		}
+	}
+
+	// The classification above is heuristic; borderline cases are flagged for review
+	vl.MukellefTuruGuveni = mukellefTuruGuveni(vl, kisiTuru, kurumlarVergisi)
+	if warning := mukellefTuruWarning(vl); warning != "" {
+		vl.Warnings = append(vl.Warnings, warning)
 	}
 }
 
-// parseLineBasedFormat parses the GIB PDF using line-based logic
-// This handles the specific structure where:
-// - Lines 13-14 contain "FAALİYET KOD VE ADLARI" or "ANA FAALİYET KODU VE ADI"
-// - Line 15 contains "MÜKELLEFİN"
-// - Lines 16-17 contain company/person name (may be 1 or 2 lines)
-// - Next lines contain address (may be 1 or 2 lines)
-// - Then comes tax type (e.g., "KURUMLAR VERGİSİ" or "YILLIK GELİR VERGİSİ")
-// - Then comes tax office (Vergi Dairesi)
-func (p *Parser) parseLineBasedFormat(vl *VergiLevhasi, lines []string, containsAny func(string, ...string) bool) {
-	// Turkish city names for detecting second address line
-	turkishCities := []string{
+// Stated taxpayer kinds returned by extractKisiTuru
+const (
+	kisiTuruGercek = "gercek"
+	kisiTuruTuzel  = "tuzel"
+)
+
+// kisiTuruRe matches a stated taxpayer kind, "Gerçek Kişi" or "Tüzel Kişi", in text folded
+// with foldTurkish: after a "Mükellefiyet Türü" style label, or alone on its line
+var kisiTuruRe = regexp.MustCompile(`(?m)(?:(?:mukellefiyet|mukellef|kisi)\s*turu\s*[:：]?\s*|^\s*)(gercek|tuzel)\s*kisi(?:si)?\s*$`)
+
+// extractKisiTuru returns kisiTuruGercek or kisiTuruTuzel when the text states whether the
+// taxpayer is a natural or a legal person, or "" when it does not or states both
+func extractKisiTuru(text string) string {
+	var lines []string
+	for _, line := range strings.Split(text, "\n") {
+		lines = append(lines, foldTurkish(line))
+	}
+
+	found := ""
+	for _, m := range kisiTuruRe.FindAllStringSubmatch(strings.Join(lines, "\n"), -1) {
+		if found != "" && found != m[1] {
+			return ""
+		}
+		found = m[1]
+	}
+	return found
+}
+
+// organizationType recognizes associations (dernek) and foundations (vakıf) by their name,
+// e.g. "ÖRNEK KÜLTÜR DERNEĞİ" or "ÖRNEK EĞİTİM VAKFI". It returns "" for other names.
+func organizationType(names ...string) MukellefTuru {
+	for _, name := range names {
+		upper := strings.ToUpperSpecial(unicode.TurkishCase, name)
+		for _, word := range strings.FieldsFunc(upper, func(r rune) bool { return !unicode.IsLetter(r) }) {
+			switch word {
+			case "DERNEĞİ", "DERNEGI", "DERNEK":
+				return MukellefTuruDernek
+			case "VAKFI", "VAKIF", "VAKFİ":
+				return MukellefTuruVakif
+			}
+		}
+	}
+	return ""
+}
+
+// mukellefinLabelRe captures what follows the MÜKELLEFİN label on its line; the Ü is
+// often lost or mangled by the PDF encoding ("MKELLEFIN")
+var mukellefinLabelRe = regexp.MustCompile(`(?i)M\S{0,2}KELLEF\S*\s*[:：]?\s*(.*)`)
+
+// findMukellefinLine returns the index of the "MÜKELLEFİN" label line, or -1
+func findMukellefinLine(lines []string) int {
+	for i, line := range lines {
+		upper := strings.ToUpper(strings.TrimSpace(line))
+		// Check for MÜKELLEFİN or MKELLEFIN (without Ü due to encoding issues)
+		if strings.Contains(upper, "MKELLEF") || strings.Contains(upper, "MÜKELLEFİN") {
+			return i
+		}
+	}
+	return -1
+}
+
+// mukellefinBlock returns the lines of the taxpayer block: anything printed after the
+// "MÜKELLEFİN" label on its own line, then every line up to the next section boundary,
+// which is the tax type, a date or an identification number
+func mukellefinBlock(lines []string) []string {
+	idx := findMukellefinLine(lines)
+	if idx == -1 {
+		return nil
+	}
+
+	var block []string
+	if m := mukellefinLabelRe.FindStringSubmatch(strings.TrimSpace(lines[idx])); len(m) > 1 && strings.TrimSpace(m[1]) != "" {
+		block = append(block, strings.TrimSpace(m[1]))
+	}
+	for _, line := range lines[idx+1:] {
+		trimmed := strings.TrimSpace(line)
+		if trimmed == "" {
+			continue
+		}
+		upper := strings.ToUpperSpecial(unicode.TurkishCase, trimmed)
+		if strings.Contains(upper, "VERGİSİ") || strings.Contains(upper, "VERGISI") ||
+			strings.Contains(upper, "KİMLİK NO") || strings.Contains(upper, "KIMLIK NO") ||
+			dateLineRe.MatchString(trimmed) || digitLineRe.MatchString(trimmed) {
+			break
+		}
+		block = append(block, trimmed)
+	}
+	return block
+}
+
+// parseLineBasedFormat parses the GIB PDF using line-based logic
+// This handles the specific structure where:
+// - Lines 13-14 contain "FAALİYET KOD VE ADLARI" or "ANA FAALİYET KODU VE ADI"
+// - Line 15 contains "MÜKELLEFİN"
+// - Lines 16-17 contain company/person name (may be 1 or 2 lines)
+// - Next lines contain address (may be 1 or 2 lines)
+// - Then comes tax type (e.g., "KURUMLAR VERGİSİ" or "YILLIK GELİR VERGİSİ")
+// - Then comes tax office (Vergi Dairesi)
+func (p *Parser) parseLineBasedFormat(vl *VergiLevhasi, lines []string, containsAny func(string, ...string) bool) {
+	// Turkish city names for detecting second address line
+	turkishCities := []string{
 		"ADANA", "ADIYAMAN", "AFYONKARAHİSAR", "AĞRI", "AMASYA", "ANKARA", "ANTALYA", "ARTVİN",
 		"AYDIN", "BALIKESİR", "BİLECİK", "BİNGÖL", "BİTLİS", "BOLU", "BURDUR", "BURSA",
 		"ÇANAKKALE", "ÇANKIRI", "ÇORUM", "DENİZLİ", "DİYARBAKIR", "EDİRNE", "ELAZIĞ", "ERZİNCAN",
@@ -687,33 +1217,23 @@ func (p *Parser) parseLineBasedFormat(vl *VergiLevhasi, lines []string, contains
 		"ARDAHAN", "IĞDIR", "YALOVA", "KARABÜK", "KİLİS", "OSMANİYE", "DÜZCE",
 	}
 
-	// Find "MÜKELLEFİN" line index
-	mukellefinIdx := -1
-	for i, line := range lines {
-		trimmed := strings.TrimSpace(line)
-		// Check for MÜKELLEFİN or MKELLEFIN (without Ü due to encoding issues)
-		if strings.Contains(strings.ToUpper(trimmed), "MKELLEF") || strings.Contains(strings.ToUpper(trimmed), "MÜKELLEFİN") {
-			mukellefinIdx = i
-			break
-		}
+	mukellefinIdx := findMukellefinLine(lines)
+	if mukellefinIdx == -1 {
+		return
 	}
 
-	if mukellefinIdx == -1 || mukellefinIdx+1 >= len(lines) {
-		return
+	// Some layouts print the name on the label line itself ("MÜKELLEFİN ACME LTD ŞTİ")
+	var sameLineName string
+	if m := mukellefinLabelRe.FindStringSubmatch(strings.TrimSpace(lines[mukellefinIdx])); len(m) > 1 {
+		sameLineName = strings.TrimSpace(m[1])
 	}
 
-	// Address markers to check if a line is an address line
-	addressMarkers := []string{"MAH.", "MAH ", "CAD.", "CAD ", "SOK.", "SOK ", "SK.", "SK ", "NO:", "KAPI", "BULVARI", "BULV."}
-	isAddressLine := func(line string) bool {
-		upperLine := strings.ToUpper(line)
-		for _, marker := range addressMarkers {
-			if strings.Contains(upperLine, marker) {
-				return true
-			}
-		}
-		return false
+	if sameLineName == "" && mukellefinIdx+1 >= len(lines) {
+		return
 	}
 
+	isAddressLine := p.isAddressLine
+
 	// Check if line contains a Turkish city name (for second address line detection)
 	containsCityName := func(line string) bool {
 		upperLine := strings.ToUpper(line)
@@ -732,14 +1252,19 @@ func (p *Parser) parseLineBasedFormat(vl *VergiLevhasi, lines []string, contains
 	var nameLines []string
 	var addressStartIdx int
 
+	if sameLineName != "" && !isAddressLine(sameLineName) {
+		nameLines = append(nameLines, sameLineName)
+	}
+
 	for i := nameStartIdx; i < len(lines) && i < nameStartIdx+3; i++ {
 		trimmed := strings.TrimSpace(lines[i])
 		if trimmed == "" {
 			continue
 		}
 
-		// If this line looks like an address, stop collecting name lines
-		if isAddressLine(trimmed) {
+		// The name ends at the first address line, or at the tax type when no address
+		// line was recognized
+		if isAddressLine(trimmed) || containsAny(trimmed, "VERGİSİ", "VERGISI") {
 			addressStartIdx = i
 			break
 		}
@@ -752,9 +1277,10 @@ func (p *Parser) parseLineBasedFormat(vl *VergiLevhasi, lines []string, contains
 	// Join name lines to form full company/person name
 	if len(nameLines) > 0 {
 		fullName := strings.Join(nameLines, " ")
+		vl.HamUnvan = fullName
 
 		// Determine if this is a company or individual
-		isCompany := containsAny(fullName, "ŞİRKET", "SIRKET", "LİMİTED", "LIMITED", "A.Ş", "A.S.",
+		isCompany := containsAny(fullName, "ŞİRKET", "SIRKET", "LİMİTED", "LIMITED", "LTD", "ŞTİ", "A.Ş", "A.S.",
 			"DERNEĞİ", "DERNEGI", "İKTİSADİ", "IKTISADI", "SANAYİ", "SANAYI", "TİCARET", "TICARET")
 
 		if isCompany {
@@ -798,6 +1324,28 @@ func (p *Parser) parseLineBasedFormat(vl *VergiLevhasi, lines []string, contains
 		vl.IsYeriAdresi = strings.Join(addressLines, " ")
 	}
 
+	// Streams emitted out of visual order can put the address where the name is expected,
+	// so the name comes out empty or address-like. The block between the label and the
+	// tax type is cross-checked for a clean company name.
+	block := mukellefinBlock(lines)
+	if len(block) > 6 {
+		block = block[:6]
+	}
+	looksLikeAddress := func(line string) bool {
+		return isAddressLine(line) || containsCityName(line)
+	}
+	name := vl.TicaretUnvani
+	if name == "" {
+		name = vl.AdiSoyadi
+	}
+	if name, address, ok := disambiguateNameAndAddress(name, block, looksLikeAddress); ok {
+		vl.TicaretUnvani, vl.AdiSoyadi = name, ""
+		vl.HamUnvan = name
+		if address != "" {
+			vl.IsYeriAdresi = address
+		}
+	}
+
 	// Find Vergi Türü line index if not already found
 	if vergiTuruIdx == 0 {
 		for i := addressStartIdx; i < len(lines); i++ {
@@ -813,10 +1361,6 @@ func (p *Parser) parseLineBasedFormat(vl *VergiLevhasi, lines []string, contains
 	// For corporations: between "X VERGİSİ" and date (DD.MM.YYYY)
 	// For individuals: between "X VERGİSİ" and 11-digit TCKN
 	if vergiTuruIdx > 0 && vergiTuruIdx+1 < len(lines) {
-		dateRe := regexp.MustCompile(`^\d{2}\.\d{2}\.\d{4}$`)
-		tcknRe := regexp.MustCompile(`^\d{11}$`)
-		digitOnlyRe := regexp.MustCompile(`^\d+$`)
-
 		for i := vergiTuruIdx + 1; i < len(lines) && i < vergiTuruIdx+5; i++ {
 			trimmed := strings.TrimSpace(lines[i])
 			if trimmed == "" {
@@ -824,12 +1368,12 @@ func (p *Parser) parseLineBasedFormat(vl *VergiLevhasi, lines []string, contains
 			}
 
 			// If we hit a date or TCKN, we've passed the vergi dairesi
-			if dateRe.MatchString(trimmed) || tcknRe.MatchString(trimmed) {
+			if dateLineRe.MatchString(trimmed) || tcknLineRe.MatchString(trimmed) {
 				break
 			}
 
 			// Skip if this is a number pattern (could be VKN or other ID)
-			if digitOnlyRe.MatchString(trimmed) {
+			if digitLineRe.MatchString(trimmed) {
 				continue
 			}
 
@@ -847,17 +1391,201 @@ func (p *Parser) parseLineBasedFormat(vl *VergiLevhasi, lines []string, contains
 	}
 }
 
+// disambiguateNameAndAddress checks a name taken from the MÜKELLEFİN block against the
+// block's lines. If the name is empty or contains address markers while a line of the
+// block is a clean company name (a legal-form suffix and no address markers), that line
+// is returned as the name along with the address-like lines of the rest of the block.
+func disambiguateNameAndAddress(name string, block []string, looksLikeAddress func(string) bool) (string, string, bool) {
+	if name != "" && !looksLikeAddress(name) {
+		return "", "", false
+	}
+
+	company := -1
+	for i, line := range block {
+		if hasLegalFormSuffix(line) && !looksLikeAddress(line) {
+			company = i
+			break
+		}
+	}
+	if company < 0 || block[company] == name {
+		return "", "", false
+	}
+
+	var address []string
+	for i, line := range block {
+		if i != company && looksLikeAddress(line) {
+			address = append(address, line)
+		}
+	}
+	return block[company], strings.Join(address, " "), true
+}
+
+// Regexes shared by the parsing passes, compiled once
+var (
+	dateRe       = regexp.MustCompile(`(\d{2}\.\d{2}\.\d{4})`)
+	dateLineRe   = regexp.MustCompile(`^\d{2}\.\d{2}\.\d{4}$`)
+	tcknLineRe   = regexp.MustCompile(`^\d{11}$`)
+	digitLineRe  = regexp.MustCompile(`^\d+$`)
+	gibNameRe    = regexp.MustCompile(`MÜKELLEFİN\s+(.+?)\s+[A-ZÇĞİÖŞÜ]+\s+MAH`)
+	gibAddressRe = regexp.MustCompile(`([A-ZÇĞİÖŞÜ]+\s+MAH\.?\s+.+?(?:İSTANBUL|ISTANBUL|ANKARA|İZMİR|IZMIR|BURSA|ANTALYA|KONYA))`)
+
+	gibTaxOfficeRe       = regexp.MustCompile(`(?:YILLIK\s+GELİR\s+VERGİSİ|GELİR\s+VERGİSİ|KURUMLAR\s+VERGİSİ)\s+([A-ZÇĞİÖŞÜ]+)\s+\d{11}`)
+	singleLineActivityRe = regexp.MustCompile(`\b(\d+)\s*[-–]\s*([A-ZÇĞİÖŞÜa-zçğıöşü\s]+?)(?:\s+TAKVİM|\s+TAKVIM|\s+BEYAN|\s+\d{4})`)
+	activityLineRe       = regexp.MustCompile(`\b(\d+)\s*[-–]\s*(.+)`)
+	activityYearSuffixRe = regexp.MustCompile(`\s+\d{4}\s*[A-Za-z]*$`)
+	trailingAmountRe     = regexp.MustCompile(`^[ \t]*(\d{1,3}(?:[.,]\d{3})+(?:[.,]\d{2})?)(?:[ \t]*(?:TL|₺))?`)
+	taxBaseRe            = regexp.MustCompile(`(?m)(\d{4})\s+(?:yılı\s+)?(\d{1,3}(?:[.,]\d{3})+(?:[.,]\d{2})?)\s*(?:TL|₺)?`)
+)
+
+// Pattern lists for extractField, tried in order
+var (
+	// Traditional "Label: value" format
+	labelAdiSoyadiPatterns = mustCompileAll(
+		`(?i)adı\s*soyadı\s*[:：]\s*(.+?)(?:\n|$)`,
+		`(?i)ad[ıi]\s*soyad[ıi]\s*[:：]\s*(.+?)(?:\n|$)`,
+	)
+	labelTicaretUnvaniPatterns = mustCompileAll(
+		`(?i)ticaret\s*ünvanı\s*[:：]\s*(.+?)(?:\n|$)`,
+		`(?i)ticaret\s+ünvan[ıi]\s*[:：]\s*(.+?)(?:\n|$)`,
+		`(?i)trade\s*name\s*[:：]\s*(.+?)(?:\n|$)`,
+	)
+	labelIsYeriAdresiPatterns = mustCompileAll(
+		`(?i)iş\s*yeri\s*adresi\s*(?:\(\s*(?:merkez|[şs]ube)\s*\)\s*)?[:：]\s*(.+?)(?:\n|$)`,
+		`(?i)[iİ]ş\s*[yY]eri\s*[aA]dresi\s*(?:\(\s*(?:merkez|[şs]ube)\s*\)\s*)?[:：]\s*(.+?)(?:\n|$)`,
+		`(?i)business\s*address\s*[:：]\s*(.+?)(?:\n|$)`,
+	)
+	// Addresses listed besides the business address
+	labelEkAdresPatterns = mustCompileAll(
+		`(?i)(?:fatura|faaliyet|i[şs]letme|ikinci|ek|di[ğg]er)\s*(?:i[şs]\s*yeri\s*)?adres[i]?\s*[:：]\s*(.+?)(?:\n|$)`,
+		`(?i)(?:billing|operating|secondary)\s*address\s*[:：]\s*(.+?)(?:\n|$)`,
+	)
+	labelVergiDairesiPatterns = mustCompileAll(
+		`(?i)vergi\s*dairesi\s*[:：]\s*(.+?)(?:\n|$)`,
+		`(?i)tax\s*office\s*[:：]\s*(.+?)(?:\n|$)`,
+	)
+	labelVKNPatterns = mustCompileAll(
+		`(?i)vergi\s*kimlik\s*no\s*[:：]\s*(\d{10})`,
+		`(?i)v\.?k\.?n\.?\s*[:：]\s*(\d{10})`,
+		`(?i)tax\s*id(?:entification)?\s*(?:no|number)?\s*[:：]\s*(\d{10})`,
+	)
+	// VKN printed in digit groups for readability, e.g. "122 215 3986" or "1.222.153.986"
+	labelGroupedVKNPatterns = mustCompileAll(
+		`(?i)vergi\s*kimlik\s*no\s*[:：]?\s*(\d{1,4}(?:[ .]\d{1,4})+)`,
+		`(?i)v\.?k\.?n\.?\s*[:：]?\s*(\d{1,4}(?:[ .]\d{1,4})+)`,
+		`(?i)tax\s*id(?:entification)?\s*(?:no|number)?\s*[:：]?\s*(\d{1,4}(?:[ .]\d{1,4})+)`,
+	)
+	labelTCKNPatterns = mustCompileAll(
+		`(?i)t\.?c\.?\s*kimlik\s*no\s*[:：]\s*(\d{11})`,
+		`(?i)tckn\s*[:：]\s*(\d{11})`,
+		`(?i)tc\s*k[iİ]ml[iİ]k\s*no\s*[:：]?\s*(\d{11})`,
+		`(?i)t\.c\.\s*k[iİ]ml[iİ]k\s*no\s*[:：]?\s*(\d{11})`,
+	)
+	labelUyrukPatterns = mustCompileAll(
+		`(?i)uyru[kğg]u?\s*[:：]\s*(.+?)(?:\n|$)`,
+	)
+	labelPasaportNoPatterns = mustCompileAll(
+		`(?i)pasaport\s*(?:no|numaras[ıi])\s*[:：]?\s*([A-Z0-9]{5,15})\b`,
+	)
+	labelKayitNoPatterns = mustCompileAll(
+		`(?i)(?:kurum|dernek|vak[ıiİ]f)\s*(?:kay[ıiİ]t|k[üu]t[üu]k)\s*(?:no|numaras[ıi])\s*[:：]?\s*(\d+(?:[./-]\d+)*)`,
+	)
+	labelPotansiyelVKNPatterns = mustCompileAll(
+		`(?i)(?:potansiyel|ge[çc]ici)\s*(?:vergi\s*kimlik\s*(?:no|numaras[ıi])|v\.?k\.?n\.?)\s*[:：]?\s*(\d{10})\b`,
+	)
+	labelYabanciVergiNoPatterns = mustCompileAll(
+		`(?i)yabanc[ıi]\s*vergi\s*(?:no|numaras[ıi])\s*[:：]?\s*([A-Z0-9](?:[A-Z0-9./-]*[A-Z0-9])?)\b`,
+		`(?i)foreign\s*tax\s*(?:no|number|id)\s*[:：]?\s*([A-Z0-9](?:[A-Z0-9./-]*[A-Z0-9])?)\b`,
+	)
+	labelIseBaslamaPatterns = mustCompileAll(
+		`(?i)işe\s*başlama\s*tarihi\s*[:：]\s*(\d{2}[./-]\d{2}[./-]\d{4})`,
+		`(?i)[iİ]şe\s*[bB]aşlama\s*[tT]arihi\s*[:：]\s*(\d{2}[./-]\d{2}[./-]\d{4})`,
+		`(?i)(?:business\s*)?start\s*date\s*[:：]\s*(\d{2}[./-]\d{2}[./-]\d{4})`,
+	)
+
+	// GIB format: unlabelled identifiers
+	bareVKNPatterns = mustCompileAll(
+		`(?m)^(\d{10})$`,
+		`\b(\d{10})\b`,
+	)
+
+	// Branch code by label or appended to the VKN
+	subeKoduPatterns = mustCompileAll(
+		`(?i)[şs]ube\s*kodu\s*[:：]?\s*(\d{1,6})\b`,
+		`(?i)[şs]ube\s*no\s*[:：]?\s*(\d{1,6})\b`,
+		`(?i)vergi\s*kimlik\s*no\s*[:：]?\s*\d{10}\s*[-/]\s*(\d{1,4})\b`,
+		`(?i)vergi\s*kimlik\s*no\s*[:：]?\s*\d{10}(\d{3})\b`,
+	)
+
+	bareTCKNPatterns = mustCompileAll(
+		`(?m)^(\d{11})$`,
+		`\b(\d{11})\b`,
+	)
+)
+
+// mustCompileAll compiles each pattern with mustCompileLabel, panicking on an invalid one
+// like regexp.MustCompile
+func mustCompileAll(patterns ...string) []*regexp.Regexp {
+	res := make([]*regexp.Regexp, len(patterns))
+	for i, pattern := range patterns {
+		res[i] = mustCompileLabel(pattern)
+	}
+	return res
+}
+
 // extractField extracts a field using multiple regex patterns
-func (p *Parser) extractField(text string, patterns []string) string {
-	for _, pattern := range patterns {
-		re := regexp.MustCompile(pattern)
+func (p *Parser) extractField(text string, patterns []*regexp.Regexp) string {
+	value := ""
+	for _, re := range patterns {
+		if value != "" {
+			// The later patterns only run to record what they would have matched
+			p.recordMatches(re, text, -1)
+			continue
+		}
 		if matches := re.FindStringSubmatch(text); len(matches) > 1 {
-			return strings.TrimSpace(matches[1])
+			value = strings.TrimSpace(matches[1])
+			p.recordMatches(re, text, 0)
+			if !p.collectMatches {
+				break
+			}
+			continue
+		}
+		p.recordMatches(re, text, -1)
+	}
+	return value
+}
+
+// extractGroupedVKN finds a VKN printed in digit groups next to its label. The groups
+// are joined until they make ten digits, so a number printed after the VKN on the same
+// line is not taken in; the result must pass the VKN checksum.
+func (p *Parser) extractGroupedVKN(text string) string {
+	for _, re := range labelGroupedVKNPatterns {
+		for _, loc := range re.FindAllStringSubmatchIndex(text, -1) {
+			vkn := ""
+			for _, group := range strings.FieldsFunc(text[loc[2]:loc[3]], func(r rune) bool { return r == ' ' || r == '.' }) {
+				if len(vkn) >= 10 {
+					break
+				}
+				vkn += group
+			}
+			accepted := len(vkn) == 10 && IsValidVKNChecksum(vkn)
+			p.recordMatch(re, text, loc, accepted)
+			if accepted {
+				return vkn
+			}
 		}
 	}
 	return ""
 }
 
+// selectedIf returns the selected argument of recordMatches for a first match that
+// was used if used is set
+func selectedIf(used bool) int {
+	if used {
+		return 0
+	}
+	return -1
+}
+
 // parseGIBFormat parses the GIB (Revenue Administration) PDF format
 // This format often has all content in a single line with labels and values mixed
 func (p *Parser) parseGIBFormat(vl *VergiLevhasi, text string, containsAny func(string, ...string) bool) {
@@ -869,22 +1597,25 @@ func (p *Parser) parseGIBFormat(vl *VergiLevhasi, text string, containsAny func(
 	if !containsAny(text, "VERGİ LEVHASI", "VERGI LEVHASI", "GİB", "GIB") {
 		return
 	}
+	if !p.allowsWholeTextRegex(text) {
+		return
+	}
 
 	// Extract name - look for "MÜKELLEFİN" followed by name until "MAH" (mahalle)
 	// Pattern: MÜKELLEFİN [NAME] [DISTRICT] MAH...
 	// Only set if not already set by line-based parsing
 	if vl.AdiSoyadi == "" && vl.TicaretUnvani == "" {
-		nameRe := regexp.MustCompile(`MÜKELLEFİN\s+(.+?)\s+[A-ZÇĞİÖŞÜ]+\s+MAH`)
-		if matches := nameRe.FindStringSubmatch(text); len(matches) > 1 {
+		if matches := gibNameRe.FindStringSubmatch(text); len(matches) > 1 {
 			name := strings.TrimSpace(matches[1])
 			if len(name) > 3 {
 				vl.AdiSoyadi = name
+				vl.HamUnvan = name
 			}
 		}
+		p.recordMatches(gibNameRe, text, selectedIf(vl.AdiSoyadi != ""))
 	}
 
-	addrRe := regexp.MustCompile(`([A-ZÇĞİÖŞÜ]+\s+MAH\.?\s+.+?(?:İSTANBUL|ISTANBUL|ANKARA|İZMİR|IZMIR|BURSA|ANTALYA|KONYA))`)
-	if matches := addrRe.FindStringSubmatch(text); len(matches) > 1 {
+	if matches := gibAddressRe.FindStringSubmatch(text); len(matches) > 1 {
 		addr := strings.TrimSpace(matches[1])
 		// Remove trailing tax type if captured
 		if idx := strings.Index(addr, " YILLIK"); idx > 0 {
@@ -896,39 +1627,131 @@ func (p *Parser) parseGIBFormat(vl *VergiLevhasi, text string, containsAny func(
 		if len(addr) > 20 {
 			vl.IsYeriAdresi = addr
 		}
+		p.recordMatches(gibAddressRe, text, selectedIf(len(addr) > 20))
 	}
 
 	// Extract Vergi Dairesi - between tax type and 11-digit TCKN
 	// Pattern: YILLIK GELİR VERGİSİ [TAX_OFFICE] [11_DIGIT_TCKN]
 	// Only set if not already set by line-based parsing
 	if vl.VergiDairesi == "" {
-		taxOfficeRe := regexp.MustCompile(`(?:YILLIK\s+GELİR\s+VERGİSİ|GELİR\s+VERGİSİ|KURUMLAR\s+VERGİSİ)\s+([A-ZÇĞİÖŞÜ]+)\s+\d{11}`)
-		if matches := taxOfficeRe.FindStringSubmatch(text); len(matches) > 1 {
+		if matches := gibTaxOfficeRe.FindStringSubmatch(text); len(matches) > 1 {
 			vl.VergiDairesi = strings.TrimSpace(matches[1])
 		}
+		p.recordMatches(gibTaxOfficeRe, text, 0)
 	}
 
 	// Extract VKN (10-digit) - not applicable for bireysel, they have 11-digit TCKN
 	// VKN is for kurumsal only
 
 	// Extract date - look for DD.MM.YYYY pattern
-	dateRe := regexp.MustCompile(`(\d{2}\.\d{2}\.\d{4})`)
 	if matches := dateRe.FindStringSubmatch(text); len(matches) > 1 {
-		if date, err := p.parseDate(matches[1]); err == nil {
+		date, err := p.parseDate(matches[1])
+		if err == nil {
 			vl.IseBaslamaTarihi = &date
 		}
+		p.recordMatches(dateRe, text, selectedIf(err == nil))
 	}
 
-	// Extract activity code and name - look for 6-digit code followed by dash and description
-	activityRe := regexp.MustCompile(`(\d{6})\s*[-–]\s*([A-ZÇĞİÖŞÜa-zçğıöşü\s]+?)(?:\s+TAKVİM|\s+TAKVIM|\s+BEYAN|\s+\d{4})`)
-	if matches := activityRe.FindStringSubmatch(text); len(matches) > 2 {
-		vl.FaaliyetKodlari = []Faaliyet{{
-			Kod: strings.TrimSpace(matches[1]),
-			Ad:  strings.TrimSpace(matches[2]),
-		}}
+	// Extract activity code and name - look for a code followed by dash and description
+	if !p.wants(FieldFaaliyetKodlari) {
+		return
+	}
+	if activities := p.extractSingleLineActivities(text, make(map[string]int)); len(activities) > 0 {
+		vl.FaaliyetKodlari = activities[:1]
 	}
 }
 
+// generationTimestampRe matches the document's print/generation timestamp: a labelled
+// "Oluşturulma Tarihi" date, or any date followed by a clock time (DD.MM.YYYY HH:MM[:SS]).
+// Business start dates are never printed with a time of day.
+var generationTimestampRe = mustCompileLabel(`(?i)(?:olu[şs]turulma\s*tar[iİ]h[iİ]\s*[:：]?\s*(\d{2}[./-]\d{2}[./-]\d{4})(?:\s+(\d{2}:\d{2}(?::\d{2})?))?)|(?:(\d{2}[./-]\d{2}[./-]\d{4})\s+(\d{2}:\d{2}(?::\d{2})?))`)
+
+var (
+	isYeriTuruLabelRe  = mustCompileLabel(`(?i)[iİ]ş\s*yeri\s*t[üu]r[üu]\s*[:：]\s*(merkez|[şs]ube)`)
+	isYeriAdresiTuruRe = mustCompileLabel(`(?i)[iİ]ş\s*yeri\s*adres[iİ]\s*\(\s*(merkez|[şs]ube)\s*\)`)
+	isYeriAdresiLineRe = mustCompileLabel(`(?i)[iİ]ş\s*yeri\s*adres`)
+	isYeriTuruLineRe   = mustCompileLabel(`(?i)^(merkez|[şs]ube)(?:\s+adres[iİ])?$`)
+)
+
+// extractIsYeriTuru tells a head office address from a branch address. It reads an
+// "İş Yeri Türü" label, a "(Merkez)"/"(Şube)" note on the address label, or a MERKEZ/ŞUBE
+// line next to the address; "MERKEZ" elsewhere is usually a district name and is ignored.
+// A branch code on the plate also marks a branch.
+func (p *Parser) extractIsYeriTuru(text string, lines []string, address string) IsYeriTuru {
+	for _, re := range []*regexp.Regexp{isYeriTuruLabelRe, isYeriAdresiTuruRe} {
+		if m := re.FindStringSubmatch(text); len(m) > 1 {
+			return parseIsYeriTuru(m[1])
+		}
+	}
+
+	for i, line := range lines {
+		trimmed := strings.TrimSpace(line)
+		if !isYeriAdresiLineRe.MatchString(trimmed) && (address == "" || trimmed != address) {
+			continue
+		}
+		for j := max(0, i-2); j < len(lines) && j <= i+2; j++ {
+			if m := isYeriTuruLineRe.FindStringSubmatch(strings.TrimSpace(lines[j])); len(m) > 1 {
+				return parseIsYeriTuru(m[1])
+			}
+		}
+	}
+
+	if p.extractField(text, subeKoduPatterns) != "" {
+		return IsYeriTuruSube
+	}
+	return IsYeriTuruBilinmiyor
+}
+
+// parseIsYeriTuru maps a MERKEZ or ŞUBE keyword to its IsYeriTuru
+func parseIsYeriTuru(keyword string) IsYeriTuru {
+	if strings.EqualFold(keyword, "merkez") {
+		return IsYeriTuruMerkez
+	}
+	return IsYeriTuruSube
+}
+
+// extractGenerationTimestamp returns the document generation timestamp, if any, and the
+// text with all generation timestamps blanked out so later date heuristics skip them
+func (p *Parser) extractGenerationTimestamp(text string) (*time.Time, string) {
+	var generated *time.Time
+
+	used := -1
+	for i, m := range generationTimestampRe.FindAllStringSubmatch(text, -1) {
+		if generated != nil {
+			break
+		}
+		dateStr, clock := m[1], m[2]
+		if dateStr == "" {
+			dateStr, clock = m[3], m[4]
+		}
+
+		date, err := p.parseDate(dateStr)
+		if err != nil {
+			continue
+		}
+		if clock != "" {
+			layout := "15:04"
+			if len(clock) > 5 {
+				layout = "15:04:05"
+			}
+			t, err := time.Parse(layout, clock)
+			if err != nil {
+				continue
+			}
+			date = time.Date(date.Year(), date.Month(), date.Day(), t.Hour(), t.Minute(), t.Second(), 0, time.UTC)
+		}
+		generated = &date
+		used = i
+	}
+	p.recordMatches(generationTimestampRe, text, used)
+
+	cleaned := generationTimestampRe.ReplaceAllStringFunc(text, func(match string) string {
+		return strings.Repeat(" ", len(match))
+	})
+
+	return generated, cleaned
+}
+
 // parseDate parses a date string in Turkish format
 func (p *Parser) parseDate(dateStr string) (time.Time, error) {
 	// Try multiple date formats
@@ -949,6 +1772,60 @@ func (p *Parser) parseDate(dateStr string) (time.Time, error) {
 	return time.Time{}, fmt.Errorf("failed to parse date: %s", dateStr)
 }
 
+// taxTypeCheck maps a lowercase keyword found in the text to a tax type's display name
+type taxTypeCheck struct {
+	pattern     string
+	displayName string
+}
+
+// defaultTaxTypeChecks are the built-in tax types, more specific keywords first so that
+// "Yıllık Gelir Vergisi" is checked before "Gelir Vergisi"
+var defaultTaxTypeChecks = []taxTypeCheck{
+	{"yıllık gelir vergisi", "Yıllık Gelir Vergisi"},
+	{"yillik gelir vergisi", "Yıllık Gelir Vergisi"},
+	{"kurumlar vergisi", "Kurumlar Vergisi"},
+	{"katma değer vergisi", "Katma Değer Vergisi"},
+	{"katma deger vergisi", "Katma Değer Vergisi"},
+	{"geçici vergi", "Geçici Vergi"},
+	{"gecici vergi", "Geçici Vergi"},
+	{"damga vergisi", "Damga Vergisi"},
+	{"muhtasar", "Muhtasar"},
+	{"stopaj", "Stopaj"},
+	{"bağ-kur", "Bağ-Kur"},
+	{"bag-kur", "Bağ-Kur"},
+	{"sgk", "SGK"},
+	{"kdv", "KDV"},
+	// "Gelir Vergisi" checked last - only if Yıllık not found
+	{"gelir vergisi", "Gelir Vergisi"},
+}
+
+// AddTaxType registers a tax type keyword, matched case-insensitively anywhere in the text,
+// and the name reported in VergiTuru when it is found, e.g. AddTaxType("ötv", "ÖTV").
+// The keyword is checked before any registered keyword it contains, so a more specific
+// keyword such as "gelir vergisi stopajı" wins over "gelir vergisi". Empty arguments are
+// ignored.
+func (p *Parser) AddTaxType(pattern, displayName string) {
+	pattern = strings.ToLower(strings.TrimSpace(pattern))
+	displayName = strings.TrimSpace(displayName)
+	if pattern == "" || displayName == "" {
+		return
+	}
+
+	checks := p.taxTypeChecks
+	if checks == nil {
+		checks = defaultTaxTypeChecks
+	}
+	// Insert before the first less specific keyword, copying so the defaults are never shared
+	at := len(checks)
+	for i, check := range checks {
+		if strings.Contains(pattern, check.pattern) {
+			at = i
+			break
+		}
+	}
+	p.taxTypeChecks = slices.Insert(slices.Clone(checks), at, taxTypeCheck{pattern, displayName})
+}
+
 // extractTaxTypes extracts tax types from the text
 func (p *Parser) extractTaxTypes(text string) []string {
 	var types []string
@@ -956,59 +1833,143 @@ func (p *Parser) extractTaxTypes(text string) []string {
 	textLower := strings.ToLower(text)
 	seen := make(map[string]bool)
 
-	// Check for specific tax types in order (more specific first)
-	// This ensures "Yıllık Gelir Vergisi" is checked before "Gelir Vergisi"
-	taxTypeChecks := []struct {
-		pattern     string
-		displayName string
-	}{
-		{"yıllık gelir vergisi", "Yıllık Gelir Vergisi"},
-		{"yillik gelir vergisi", "Yıllık Gelir Vergisi"},
-		{"kurumlar vergisi", "Kurumlar Vergisi"},
-		{"katma değer vergisi", "Katma Değer Vergisi"},
-		{"katma deger vergisi", "Katma Değer Vergisi"},
-		{"geçici vergi", "Geçici Vergi"},
-		{"gecici vergi", "Geçici Vergi"},
-		{"damga vergisi", "Damga Vergisi"},
-		{"muhtasar", "Muhtasar"},
-		{"stopaj", "Stopaj"},
-		{"bağ-kur", "Bağ-Kur"},
-		{"bag-kur", "Bağ-Kur"},
-		{"sgk", "SGK"},
-		{"kdv", "KDV"},
-		// "Gelir Vergisi" checked last - only if Yıllık not found
-		{"gelir vergisi", "Gelir Vergisi"},
+	taxTypeChecks := p.taxTypeChecks
+	if taxTypeChecks == nil {
+		taxTypeChecks = defaultTaxTypeChecks
 	}
 
+	// Text matched by a keyword is blanked, so a less specific keyword only matches
+	// where it occurs on its own ("stopaj" not inside "gelir vergisi stopajı")
 	for _, check := range taxTypeChecks {
-		if strings.Contains(textLower, check.pattern) && !seen[check.displayName] {
-			// For "Gelir Vergisi", skip if "Yıllık Gelir Vergisi" is already added
-			if check.displayName == "Gelir Vergisi" && seen["Yıllık Gelir Vergisi"] {
+		if !strings.Contains(textLower, check.pattern) {
+			continue
+		}
+		textLower = strings.ReplaceAll(textLower, check.pattern, strings.Repeat(" ", len(check.pattern)))
+		if seen[check.displayName] {
+			continue
+		}
+		// For "Gelir Vergisi", skip if "Yıllık Gelir Vergisi" is already added
+		if check.displayName == "Gelir Vergisi" && seen["Yıllık Gelir Vergisi"] {
+			continue
+		}
+		seen[check.displayName] = true
+		types = append(types, check.displayName)
+	}
+
+	// Snap near-match lines (OCR or layout errors such as "KURUMLAP VERGISI") to known tax types
+	for _, line := range strings.Split(text, "\n") {
+		candidates := []string{line}
+		if idx := strings.LastIndex(line, ":"); idx >= 0 {
+			candidates = append(candidates, line[idx+1:])
+		}
+		for _, candidate := range candidates {
+			name, ok := snapToReference(candidate, p.taxTypeRefs, p.fuzzyMaxDistance)
+			if !ok || seen[name] {
+				continue
+			}
+			if name == "Gelir Vergisi" && seen["Yıllık Gelir Vergisi"] {
 				continue
 			}
-			seen[check.displayName] = true
-			types = append(types, check.displayName)
+			seen[name] = true
+			types = append(types, name)
 		}
 	}
 
 	return types
 }
 
+// gelirUnsuruGMSI is the income element of individuals declaring rental income
+// (Gayrimenkul Sermaye İradı, GMSİ)
+const gelirUnsuruGMSI = "Gayrimenkul Sermaye İradı"
+
+// gelirUnsurlari maps income element keywords (diacritics folded) to their display names.
+// "Serbest meslek" is checked first since it is the most specific; rental income comes
+// last, so a plate listing it next to a business income reports the business. Keywords
+// marked word only match a whole word, e.g. the abbreviation "GMSİ".
+var gelirUnsurlari = []struct {
+	keyword     string
+	displayName string
+	word        bool
+}{
+	{"serbest meslek kazanci", "Serbest Meslek Kazancı", false},
+	{"ticari kazanc", "Ticari Kazanç", false},
+	{"zirai kazanc", "Zirai Kazanç", false},
+	{"gayrimenkul sermaye iradi", gelirUnsuruGMSI, false},
+	{"gmsi", gelirUnsuruGMSI, true},
+}
+
+// extractGelirUnsuru returns the income element stated on an individual's plate, if any
+func extractGelirUnsuru(text string) string {
+	folded := foldTurkish(text)
+	var words []string
+	for _, g := range gelirUnsurlari {
+		if !g.word {
+			if strings.Contains(folded, g.keyword) {
+				return g.displayName
+			}
+			continue
+		}
+		if words == nil {
+			words = strings.FieldsFunc(folded, func(r rune) bool { return !unicode.IsLetter(r) && !unicode.IsDigit(r) })
+		}
+		if slices.Contains(words, g.keyword) {
+			return g.displayName
+		}
+	}
+	return ""
+}
+
+// extractVergiUsulu returns the taxation regime stated on the plate, if any
+func extractVergiUsulu(text string) VergiUsulu {
+	folded := foldTurkish(text)
+	switch {
+	case strings.Contains(folded, "basit usul"):
+		return VergiUsuluBasit
+	case strings.Contains(folded, "gercek usul"):
+		return VergiUsuluGercek
+	}
+	return VergiUsuluBilinmiyor
+}
+
+// DefaultActivityCodeLengths returns the activity code lengths accepted by default:
+// 6-digit NACE codes on current plates and 4- or 5-digit codes on older ones
+func DefaultActivityCodeLengths() []int {
+	return []int{4, 5, 6}
+}
+
+// SetActivityCodeLengths sets the accepted activity code lengths in digits, for both the
+// line-based and the single-line extraction. Codes are kept as printed: a 4-digit code
+// is not zero-padded to 6 digits. Calling it without lengths restores the default.
+func (p *Parser) SetActivityCodeLengths(lengths ...int) {
+	if len(lengths) == 0 {
+		lengths = DefaultActivityCodeLengths()
+	}
+	p.activityCodeLengths = lengths
+}
+
+// acceptsActivityCode reports whether code has one of the accepted activity code lengths
+func (p *Parser) acceptsActivityCode(code string) bool {
+	for _, n := range p.activityCodeLengths {
+		if len(code) == n {
+			return true
+		}
+	}
+	return false
+}
+
 // extractActivities extracts activity codes and names
 func (p *Parser) extractActivities(text string) []Faaliyet {
 	var activities []Faaliyet
-	seen := make(map[string]bool)
+	seen := make(map[string]int)
 
 	// Split by lines and process each line
 	lines := strings.Split(text, "\n")
 
-	// Pattern for activity codes (usually 4-6 digits followed by description)
+	// Pattern for activity codes (a code of an accepted length followed by description)
 	// We process line by line for better control
-	lineRe := regexp.MustCompile(`(\d{4,6})\s*[-–]\s*(.+)`)
-
 	for _, line := range lines {
-		matches := lineRe.FindStringSubmatch(line)
-		if len(matches) > 2 {
+		matches := activityLineRe.FindStringSubmatch(line)
+		if len(matches) > 2 && p.acceptsActivityCode(matches[1]) {
 			kod := strings.TrimSpace(matches[1])
 			ad := strings.TrimSpace(matches[2])
 
@@ -1021,39 +1982,77 @@ func (p *Parser) extractActivities(text string) []Faaliyet {
 			}
 
 			// Remove year patterns at the end (e.g., "2024 Ma")
-			yearRe := regexp.MustCompile(`\s+\d{4}\s*[A-Za-z]*$`)
-			ad = yearRe.ReplaceAllString(ad, "")
+			ad = activityYearSuffixRe.ReplaceAllString(ad, "")
 			ad = strings.TrimSpace(ad)
 
-			if !seen[kod] && len(ad) > 3 {
-				seen[kod] = true
-				activities = append(activities, Faaliyet{
-					Kod: kod,
-					Ad:  ad,
-				})
+			if len(ad) > 3 {
+				activities, _ = p.mergeActivity(activities, seen, Faaliyet{Kod: kod, Ad: ad})
 			}
 		}
 	}
 
 	// Also try to find activities in single-line format (GIB PDFs)
-	if len(activities) == 0 {
-		re := regexp.MustCompile(`(\d{6})\s*[-–]\s*([A-ZÇĞİÖŞÜa-zçğıöşü\s]+?)(?:\s+TAKVİM|\s+TAKVIM|\s+BEYAN|\s+\d{4})`)
-		matches := re.FindAllStringSubmatch(text, -1)
-		for _, match := range matches {
-			if len(match) > 2 {
-				kod := strings.TrimSpace(match[1])
-				ad := strings.TrimSpace(match[2])
-				if !seen[kod] && len(ad) > 3 {
-					seen[kod] = true
-					activities = append(activities, Faaliyet{
-						Kod: kod,
-						Ad:  ad,
-					})
-				}
-			}
-		}
+	if len(activities) == 0 && p.allowsWholeTextRegex(text) {
+		activities = p.extractSingleLineActivities(text, seen)
+	}
+
+	return activities
+}
+
+// SetActivitySimilarityThreshold sets how similar, from 0 to 1, the descriptions of a
+// repeated activity code must be for the longer one to replace the one kept. Similarity
+// compares the shorter description with the start of the longer one, so a truncated
+// description scores 1. A repeat below the threshold is taken to be a misread of a
+// neighbouring line and the first description is kept. The default, 0, always keeps the
+// longer description.
+func (p *Parser) SetActivitySimilarityThreshold(threshold float64) {
+	p.activitySimilarity = min(max(threshold, 0), 1)
+}
+
+// mergeActivity appends f to activities, or, if its code is already in seen, keeps the
+// longer of the two descriptions: a repeat of a code may be truncated or partially read,
+// and the first one seen is not necessarily the complete one. seen maps codes to their
+// index in activities. It reports whether f's description was kept.
+func (p *Parser) mergeActivity(activities []Faaliyet, seen map[string]int, f Faaliyet) ([]Faaliyet, bool) {
+	i, ok := seen[f.Kod]
+	if !ok {
+		seen[f.Kod] = len(activities)
+		return append(activities, f), true
+	}
+	if utf8.RuneCountInString(f.Ad) > utf8.RuneCountInString(activities[i].Ad) &&
+		descriptionSimilarity(activities[i].Ad, f.Ad) >= p.activitySimilarity {
+		activities[i].Ad = f.Ad
+		return activities, true
+	}
+	return activities, false
+}
+
+// descriptionSimilarity compares short with the start of long, ignoring case and Turkish
+// diacritics, and returns 1 minus their edit distance per rune of short
+func descriptionSimilarity(short, long string) float64 {
+	s, l := []rune(foldTurkish(short)), []rune(foldTurkish(long))
+	if len(s) == 0 {
+		return 1
+	}
+	if len(l) > len(s) {
+		l = l[:len(s)]
 	}
+	return 1 - float64(levenshtein(string(s), string(l)))/float64(len(s))
+}
 
+// extractSingleLineActivities finds activities in text where the whole plate is on one
+// line, as in GIB PDFs; repeated codes are merged with mergeActivity
+func (p *Parser) extractSingleLineActivities(text string, seen map[string]int) []Faaliyet {
+	var activities []Faaliyet
+	for _, loc := range singleLineActivityRe.FindAllStringSubmatchIndex(text, -1) {
+		kod := strings.TrimSpace(text[loc[2]:loc[3]])
+		ad := strings.TrimSpace(text[loc[4]:loc[5]])
+		accepted := p.acceptsActivityCode(kod) && len(ad) > 3
+		if accepted {
+			activities, accepted = p.mergeActivity(activities, seen, Faaliyet{Kod: kod, Ad: ad})
+		}
+		p.recordMatch(singleLineActivityRe, text, loc, accepted)
+	}
 	return activities
 }
 
@@ -1069,39 +2068,307 @@ func (p *Parser) extractTaxBases(text string) []Matrah {
 		return matrahlar
 	}
 
+	text = expandScaledAmounts(normalizeKurusSeparators(text))
+
 	// Pattern for year and amount - must be a realistic tax amount (at least 4 digits)
 	// This prevents matching activity codes (621000) or small numbers
 	// Matches: "2020 100.000,00" or "2020 yılı 100.000,00 TL"
-	re := regexp.MustCompile(`(?m)(\d{4})\s+(?:yılı\s+)?(\d{1,3}(?:[.,]\d{3})+(?:[.,]\d{2})?)\s*(?:TL|₺)?`)
-	matches := re.FindAllStringSubmatch(text, -1)
+	matches := taxBaseRe.FindAllStringSubmatchIndex(text, -1)
+
+	// Accrued tax amounts by year, attached to the tax base of the same year below
+	type taxAmount struct {
+		amount float64
+		kurus  int64
+	}
+	taxes := make(map[int]taxAmount)
+	var taxYears []int
+	addTax := func(year int, amount float64, kurus int64) {
+		if _, ok := taxes[year]; !ok {
+			taxes[year] = taxAmount{amount, kurus}
+			taxYears = append(taxYears, year)
+		}
+	}
 
-	for _, match := range matches {
-		if len(match) > 2 {
-			year, err := strconv.Atoi(match[1])
-			if err != nil || year < 2000 || year > 2100 {
-				continue
+	for _, loc := range matches {
+		year, err := strconv.Atoi(text[loc[2]:loc[3]])
+		amount, kurus, ok := parseTaxBaseAmount(text[loc[4]:loc[5]])
+		ok = ok && err == nil && year >= 2000 && year <= 2100
+		p.recordMatch(taxBaseRe, text, loc, ok)
+		if !ok {
+			continue
+		}
+
+		// The label next to the amount, or the column header above it, tells a declared
+		// tax base from the tax accrued on it; a row under a header with both columns
+		// carries one amount per column
+		firstIsTax, twoColumns := taxBaseColumns(text, loc[0])
+		if twoColumns {
+			lineEnd := len(text)
+			if i := strings.IndexByte(text[loc[1]:], '\n'); i >= 0 {
+				lineEnd = loc[1] + i
 			}
+			if m := trailingAmountRe.FindStringSubmatch(text[loc[1]:lineEnd]); m != nil {
+				if second, secondKurus, ok := parseTaxBaseAmount(m[1]); ok {
+					if !firstIsTax {
+						addTax(year, second, secondKurus)
+					} else {
+						matrahlar = append(matrahlar, Matrah{Yil: year, Tutar: second, TutarKurus: secondKurus})
+					}
+				}
+			}
+		}
+		if firstIsTax {
+			addTax(year, amount, kurus)
+			continue
+		}
 
-			// Parse amount
-			amountStr := strings.ReplaceAll(match[2], ".", "")
-			amountStr = strings.ReplaceAll(amountStr, ",", ".")
-			amount, err := strconv.ParseFloat(amountStr, 64)
-			if err != nil {
-				continue
+		matrahlar = append(matrahlar, Matrah{
+			Yil:        year,
+			Tutar:      amount,
+			TutarKurus: kurus,
+		})
+	}
+
+	for _, year := range taxYears {
+		tax := taxes[year]
+		found := false
+		for i := range matrahlar {
+			if matrahlar[i].Yil == year {
+				matrahlar[i].Vergi, matrahlar[i].VergiKurus = tax.amount, tax.kurus
+				found = true
+				break
 			}
+		}
+		if !found {
+			matrahlar = append(matrahlar, Matrah{Yil: year, Vergi: tax.amount, VergiKurus: tax.kurus})
+		}
+	}
 
-			// Skip unrealistically small amounts (likely parsing errors)
-			// Real tax bases are typically at least 1000 TL
-			if amount < 1000 {
-				continue
+	return normalizeMatrahlar(matrahlar)
+}
+
+// parseTaxBaseAmount parses a printed amount such as "1.234.567,89", rejecting
+// unrealistically small amounts (likely parsing errors); real tax bases are typically at
+// least 1000 TL
+func parseTaxBaseAmount(s string) (float64, int64, bool) {
+	amountStr := strings.ReplaceAll(s, ".", "")
+	amountStr = strings.ReplaceAll(amountStr, ",", ".")
+	amount, err := strconv.ParseFloat(amountStr, 64)
+	if err != nil || amount < 1000 {
+		return 0, 0, false
+	}
+	kurus, err := parseKurus(amountStr)
+	if err != nil {
+		return 0, 0, false
+	}
+	return amount, kurus, true
+}
+
+// taxAmountLabels mark an amount as the tax accrued for the year rather than the
+// declared tax base
+var taxAmountLabels = []string{"TAHAKKUK", "HESAPLANAN VERGİ", "HESAPLANAN VERGI", "ÖDENEN VERGİ", "ODENEN VERGI", "VERGİ TUTARI", "VERGI TUTARI"}
+
+// taxBaseColumns classifies the amounts of the tax base row starting at pos by the label
+// on the row itself or, failing that, the nearest line above with a label. firstIsTax
+// reports that the first amount is an accrued tax; twoColumns reports that the label line
+// has both a tax base and a tax column, so a second amount on the row is the other one.
+func taxBaseColumns(text string, pos int) (firstIsTax, twoColumns bool) {
+	lineStart := strings.LastIndexByte(text[:pos], '\n') + 1
+	lineEnd := len(text)
+	if i := strings.IndexByte(text[pos:], '\n'); i >= 0 {
+		lineEnd = pos + i
+	}
+
+	for line := text[lineStart:lineEnd]; ; {
+		upper := strings.ToUpperSpecial(unicode.TurkishCase, line)
+		matrahAt := strings.Index(upper, "MATRAH")
+		taxAt := -1
+		for _, label := range taxAmountLabels {
+			if i := strings.Index(upper, label); i >= 0 && (taxAt < 0 || i < taxAt) {
+				taxAt = i
 			}
+		}
+		switch {
+		case taxAt >= 0 && matrahAt >= 0:
+			return taxAt < matrahAt, true
+		case taxAt >= 0:
+			return true, false
+		case matrahAt >= 0:
+			return false, false
+		}
 
-			matrahlar = append(matrahlar, Matrah{
-				Yil:   year,
-				Tutar: amount,
-			})
+		if lineStart == 0 {
+			return false, false
 		}
+		lineEnd = lineStart - 1
+		lineStart = strings.LastIndexByte(text[:lineEnd], '\n') + 1
+		line = text[lineStart:lineEnd]
 	}
+}
+
+// parseKurus converts a decimal amount such as "1234567.89" to kuruş without going
+// through float64, so the result is exact
+func parseKurus(amount string) (int64, error) {
+	whole, frac, _ := strings.Cut(amount, ".")
+	if len(frac) > 2 {
+		return 0, fmt.Errorf("too many decimal places in amount: %s", amount)
+	}
+	for len(frac) < 2 {
+		frac += "0"
+	}
+	return strconv.ParseInt(whole+frac, 10, 64)
+}
 
-	return matrahlar
+// normalizeMatrahlar sorts tax bases by year and drops repeated (year, type, period)
+// entries, keeping the first one found in the text
+func normalizeMatrahlar(matrahlar []Matrah) []Matrah {
+	type matrahKey struct {
+		yil        int
+		tur, donem string
+	}
+	seen := make(map[matrahKey]bool)
+
+	unique := matrahlar[:0]
+	for _, m := range matrahlar {
+		key := matrahKey{m.Yil, m.Tur, m.Donem}
+		if seen[key] {
+			continue
+		}
+		seen[key] = true
+		unique = append(unique, m)
+	}
+
+	sort.SliceStable(unique, func(i, j int) bool {
+		return unique[i].Yil < unique[j].Yil
+	})
+	return unique
+}
+
+// kurusSeparatorRe matches an amount whose kuruş is rendered as a separate token,
+// e.g. "450.000 00" or "450.000·00", capturing the character after the kuruş
+var kurusSeparatorRe = regexp.MustCompile(`(\d{1,3}(?:\.\d{3})+)(?: *[·•∙⋅] *| +)(\d{2})($|[^\d.,])`)
+
+// scaledAmountRe matches an amount followed by a multiplier word, e.g. "1,5 Milyon" or
+// "450 Bin", capturing the number and the word
+var scaledAmountRe = mustCompileLabel(`(?i)\b(\d{1,3}(?:\.\d{3})*(?:,\d+)?)\s*(b[iİ]n|m[iİ]lyon|m[iİ]lyar)\b`)
+
+// amountMultipliers maps the folded multiplier words to their value
+var amountMultipliers = map[string]int64{
+	"bin":    1_000,
+	"milyon": 1_000_000,
+	"milyar": 1_000_000_000,
+}
+
+// expandScaledAmounts rewrites amounts written with a multiplier word into the printed
+// form, e.g. "1,5 Milyon" into "1.500.000,00", so they parse like any other amount
+func expandScaledAmounts(text string) string {
+	return scaledAmountRe.ReplaceAllStringFunc(text, func(m string) string {
+		sub := scaledAmountRe.FindStringSubmatch(m)
+		multiplier := amountMultipliers[foldTurkish(sub[2])] * 100
+		whole, frac, _ := strings.Cut(strings.ReplaceAll(sub[1], ".", ""), ",")
+		w, err := strconv.ParseInt(whole, 10, 64)
+		if err != nil {
+			return m
+		}
+		kurus := w * multiplier
+		// The fraction scales exactly as long as the multiplier covers its digits
+		if frac != "" {
+			f, err := strconv.ParseInt(frac, 10, 64)
+			scale := int64(1)
+			for range frac {
+				scale *= 10
+			}
+			if err != nil || multiplier%scale != 0 {
+				return m
+			}
+			kurus += f * (multiplier / scale)
+		}
+		return formatKurus(kurus)
+	})
+}
+
+// formatKurus formats an amount in kuruş the way plates print it, e.g. "1.500.000,00"
+func formatKurus(kurus int64) string {
+	whole := strconv.FormatInt(kurus/100, 10)
+	var b strings.Builder
+	for i, ch := range whole {
+		if i > 0 && (len(whole)-i)%3 == 0 {
+			b.WriteByte('.')
+		}
+		b.WriteRune(ch)
+	}
+	fmt.Fprintf(&b, ",%02d", kurus%100)
+	return b.String()
+}
+
+// lineEndingReplacer converts "\r\n" and lone "\r" line ends to "\n"
+var lineEndingReplacer = strings.NewReplacer("\r\n", "\n", "\r", "\n")
+
+// normalizeLineEndings converts Windows and classic Mac line ends to "\n"
+func normalizeLineEndings(s string) string {
+	if !strings.Contains(s, "\r") {
+		return s
+	}
+	return lineEndingReplacer.Replace(s)
+}
+
+// normalizeDigits replaces Unicode decimal digits (full-width "０-９", Arabic-Indic "٠-٩"
+// and the other Nd forms) with ASCII 0-9
+func normalizeDigits(s string) string {
+	ascii := true
+	for i := 0; i < len(s); i++ {
+		if s[i] >= utf8.RuneSelf {
+			ascii = false
+			break
+		}
+	}
+	if ascii {
+		return s
+	}
+
+	return strings.Map(func(r rune) rune {
+		if r < utf8.RuneSelf || !unicode.IsDigit(r) {
+			return r
+		}
+		// Unicode decimal digits come in consecutive runs of ten starting at zero
+		start := r
+		for unicode.IsDigit(start - 1) {
+			start--
+		}
+		return '0' + (r-start)%10
+	}, s)
+}
+
+// normalizeKurusSeparators rewrites unusual kuruş separators into the standard comma
+// decimal ("450.000,00") and turns non-breaking spaces into plain spaces
+func normalizeKurusSeparators(text string) string {
+	text = strings.NewReplacer("\u00a0", " ", "\u202f", " ").Replace(text)
+	return kurusSeparatorRe.ReplaceAllString(text, "$1,$2$3")
+}
+
+// isValidTCKN validates a Turkish ID number (TC Kimlik No) using its checksum digits
+func isValidTCKN(tckn string) bool {
+	if len(tckn) != 11 || tckn[0] == '0' {
+		return false
+	}
+
+	var d [11]int
+	for i := 0; i < 11; i++ {
+		if tckn[i] < '0' || tckn[i] > '9' {
+			return false
+		}
+		d[i] = int(tckn[i] - '0')
+	}
+
+	oddSum := d[0] + d[2] + d[4] + d[6] + d[8]
+	evenSum := d[1] + d[3] + d[5] + d[7]
+	if ((oddSum*7-evenSum)%10+10)%10 != d[9] {
+		return false
+	}
+
+	total := 0
+	for i := 0; i < 10; i++ {
+		total += d[i]
+	}
+	return total%10 == d[10]
 }
@@ -1,9 +1,15 @@
 package vergilevhasi
 
 import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"reflect"
 	"strings"
 	"testing"
 	"time"
+	"unicode/utf8"
 )
 
 func TestNewParser(t *testing.T) {
@@ -56,7 +62,7 @@ func TestExtractField(t *testing.T) {
 
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			got := parser.extractField(tt.text, tt.patterns)
+			got := parser.extractField(tt.text, mustCompileAll(tt.patterns...))
 			if got != tt.want {
 				t.Errorf("extractField() = %v, want %v", got, tt.want)
 			}
@@ -188,6 +194,81 @@ func TestExtractActivities(t *testing.T) {
 	}
 }
 
+func TestExtractActivitiesKeepsLongestDescription(t *testing.T) {
+	parser := NewParser()
+
+	tests := []struct {
+		name string
+		text string
+	}{
+		{"truncated first", "471101 - BAKKAL VE MARKETLERDE\n5610 - Lokanta hizmetleri\n471101 - BAKKAL VE MARKETLERDE YAPILAN PERAKENDE TİCARET\n"},
+		{"truncated last", "471101 - BAKKAL VE MARKETLERDE YAPILAN PERAKENDE TİCARET\n5610 - Lokanta hizmetleri\n471101 - BAKKAL VE MARKET\n"},
+	}
+	const want = "BAKKAL VE MARKETLERDE YAPILAN PERAKENDE TİCARET"
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := parser.extractActivities(tt.text)
+			if len(got) != 2 || got[0].Kod != "471101" || got[1].Kod != "5610" {
+				t.Fatalf("extractActivities() = %+v, want 471101 and 5610 once each, in order", got)
+			}
+			if got[0].Ad != want {
+				t.Errorf("Ad = %q, want %q", got[0].Ad, want)
+			}
+		})
+	}
+
+	// GIB PDFs with the whole plate on one line
+	text := "471101 - BAKKAL VE MARKET TAKVİM 5610 - LOKANTA HİZMETLERİ TAKVİM 471101 - " + want + " TAKVİM"
+	got := parser.extractSingleLineActivities(text, make(map[string]int))
+	if len(got) != 2 || got[0].Ad != want {
+		t.Errorf("extractSingleLineActivities() = %+v, want 2 activities keeping %q", got, want)
+	}
+}
+
+func TestActivitySimilarityThreshold(t *testing.T) {
+	const full = "BAKKAL VE MARKETLERDE YAPILAN PERAKENDE TİCARET"
+	tests := []struct {
+		name      string
+		threshold float64
+		repeat    string
+		want      string
+	}{
+		{"default keeps longer", 0, "ELEKTRİK TESİSATI VE BENZERİ İŞLERİN YAPILMASI VE ONARIMI", "ELEKTRİK TESİSATI VE BENZERİ İŞLERİN YAPILMASI VE ONARIMI"},
+		{"dissimilar repeat ignored", 0.8, "ELEKTRİK TESİSATI VE BENZERİ İŞLERİN YAPILMASI VE ONARIMI", "BAKKAL VE MARKET"},
+		{"truncation replaced", 1, full, full},
+		{"misread repeat replaced", 0.8, "BAKKAL VE MARKFTLERDE YAPILAN PERAKENDE TİCARET", "BAKKAL VE MARKFTLERDE YAPILAN PERAKENDE TİCARET"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			parser := NewParser()
+			parser.SetActivitySimilarityThreshold(tt.threshold)
+			got := parser.extractActivities("471101 - BAKKAL VE MARKET\n471101 - " + tt.repeat + "\n")
+			if len(got) != 1 || got[0].Ad != tt.want {
+				t.Errorf("extractActivities() = %+v, want 471101 with %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestDescriptionSimilarity(t *testing.T) {
+	tests := []struct {
+		short, long string
+		want        float64
+	}{
+		{"BAKKAL VE MARKET", "BAKKAL VE MARKETLERDE YAPILAN PERAKENDE TİCARET", 1},
+		{"BAKKAL VE MARKET", "BAKKAL VE MARKETLERDE", 1},
+		{"BAKKAL", "BAKKAI VE MARKET", 5.0 / 6},
+		{"", "BAKKAL", 1},
+	}
+	for _, tt := range tests {
+		if got := descriptionSimilarity(tt.short, tt.long); got != tt.want {
+			t.Errorf("descriptionSimilarity(%q, %q) = %v, want %v", tt.short, tt.long, got, tt.want)
+		}
+	}
+}
+
 func TestExtractTaxBases(t *testing.T) {
 	parser := NewParser()
 
@@ -288,4 +369,1545 @@ func TestParseContent(t *testing.T) {
 	if len(vl.GecmisMatra) < 1 {
 		t.Errorf("GecmisMatra length = %v, want at least 1", len(vl.GecmisMatra))
 	}
+
+	if vl.DocumentType != DocumentTypeVergiLevhasi {
+		t.Errorf("DocumentType = %v, want %v", vl.DocumentType, DocumentTypeVergiLevhasi)
+	}
+}
+
+func TestTruncateRunes(t *testing.T) {
+	tests := []struct {
+		name string
+		text string
+		n    int
+		want string
+	}{
+		{name: "Shorter than limit", text: "VERGİ", n: 10, want: "VERGİ"},
+		{name: "Truncated", text: "VERGİ LEVHASI", n: 5, want: "VERGİ"},
+		{name: "Multi-byte boundary", text: "ŞİŞLİ", n: 2, want: "Şİ"},
+		{name: "Zero", text: "VERGİ", n: 0, want: ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := truncateRunes(tt.text, tt.n); got != tt.want {
+				t.Errorf("truncateRunes() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestPeekTextInvalidInput(t *testing.T) {
+	parser := NewParser()
+
+	if _, err := parser.PeekText(strings.NewReader("%PDF-1.4"), 0); err == nil {
+		t.Error("PeekText() with maxChars 0 should return an error")
+	}
+	if _, err := parser.PeekText(strings.NewReader("not a pdf"), 100); err == nil {
+		t.Error("PeekText() with invalid PDF should return an error")
+	}
+}
+
+// pagedFakeBackend serves the pages of a fakeBackend one at a time and counts them
+type pagedFakeBackend struct {
+	fakeBackend
+	pagesRead int
+}
+
+func (f *pagedFakeBackend) extractTextPages(data []byte, yield func(PageText) bool) error {
+	for _, page := range f.pages {
+		f.pagesRead++
+		if !yield(page) {
+			break
+		}
+	}
+	return f.err
+}
+
+func TestPeekTextTruncatesToMaxChars(t *testing.T) {
+	pages := []PageText{
+		{Number: 1, Text: "MÜKELLEFİN ADI SOYADI"},
+		{Number: 2, Text: "VERGİ DAİRESİ"},
+		{Number: 3, Text: "VERGİ KİMLİK NO"},
+	}
+
+	backend := &pagedFakeBackend{fakeBackend: fakeBackend{pages: pages}}
+	parser := NewParser()
+	parser.SetBackend(backend)
+	text, err := parser.PeekText(bytes.NewReader(nil), 25)
+	if err != nil {
+		t.Fatalf("PeekText() error = %v", err)
+	}
+	if want := "MÜKELLEFİN ADI SOYADI\nVER"; text != want {
+		t.Errorf("PeekText() = %q, want %q", text, want)
+	}
+	// The third page is never extracted
+	if backend.pagesRead != 2 || backend.calls != 0 {
+		t.Errorf("read %d pages one at a time and all pages %d times, want 2 and 0", backend.pagesRead, backend.calls)
+	}
+
+	// A backend without single-page extraction reads every page, and the text is cut the same
+	whole := &fakeBackend{pages: pages}
+	parser.SetBackend(whole)
+	if got, err := parser.PeekText(bytes.NewReader(nil), 25); err != nil || got != text || whole.calls != 1 {
+		t.Errorf("PeekText() without single-page extraction = %q, %v after %d reads; want %q", got, err, whole.calls, text)
+	}
+}
+
+func TestParseContentFaaliyetBelgesi(t *testing.T) {
+	parser := NewParser()
+
+	// Synthetic activity certificate with clearly fictional data
+	text := `GELİR İDARESİ BAŞKANLIĞI
+FAALİYET BELGESİ
+Vergi Kimlik Numarası : 1234567890
+Unvanı : ÖRNEK TEKNOLOJİ LİMİTED ŞİRKETİ
+Adresi : ÖRNEK MAH. TEST CAD. NO:1 ÇANKAYA/ANKARA
+Bağlı Olduğu Vergi Dairesi : ÖRNEK VERGİ DAİRESİ
+İşe Başlama Tarihi : 15.06.2020
+Faaliyet Kodu : 620100 - BİLGİSAYAR PROGRAMLAMA FAALİYETLERİ
+Kurumlar Vergisi
+`
+
+	vl := &VergiLevhasi{}
+	parser.parseContent(vl, text)
+
+	if vl.DocumentType != DocumentTypeFaaliyetBelgesi {
+		t.Errorf("DocumentType = %q, want %q", vl.DocumentType, DocumentTypeFaaliyetBelgesi)
+	}
+	if vl.VergiKimlikNo != "1234567890" {
+		t.Errorf("VergiKimlikNo = %q, want '1234567890'", vl.VergiKimlikNo)
+	}
+	if vl.TicaretUnvani != "ÖRNEK TEKNOLOJİ LİMİTED ŞİRKETİ" {
+		t.Errorf("TicaretUnvani = %q, want 'ÖRNEK TEKNOLOJİ LİMİTED ŞİRKETİ'", vl.TicaretUnvani)
+	}
+	if vl.VergiDairesi != "ÖRNEK VERGİ DAİRESİ" {
+		t.Errorf("VergiDairesi = %q, want 'ÖRNEK VERGİ DAİRESİ'", vl.VergiDairesi)
+	}
+	if !strings.Contains(vl.IsYeriAdresi, "ÖRNEK MAH.") {
+		t.Errorf("IsYeriAdresi = %q, want to contain 'ÖRNEK MAH.'", vl.IsYeriAdresi)
+	}
+	if vl.IseBaslamaTarihi == nil {
+		t.Error("IseBaslamaTarihi is nil")
+	}
+	if len(vl.FaaliyetKodlari) != 1 || vl.FaaliyetKodlari[0].Kod != "620100" {
+		t.Errorf("FaaliyetKodlari = %v, want one activity with code 620100", vl.FaaliyetKodlari)
+	}
+}
+
+func TestParseContentFaaliyetBelgesiIndividual(t *testing.T) {
+	parser := NewParser()
+
+	text := `FAALİYET BELGESİ
+Adı Soyadı : ALİ ÖRNEK
+T.C. Kimlik Numarası : 11111111110
+Vergi Kimlik Numarası : 1234567890
+`
+
+	vl := &VergiLevhasi{}
+	parser.parseContent(vl, text)
+
+	if vl.AdiSoyadi != "ALİ ÖRNEK" {
+		t.Errorf("AdiSoyadi = %q, want 'ALİ ÖRNEK'", vl.AdiSoyadi)
+	}
+	if vl.TCKimlikNo != "11111111110" {
+		t.Errorf("TCKimlikNo = %q, want '11111111110'", vl.TCKimlikNo)
+	}
+	if vl.VergiKimlikNo != "1234567890" {
+		t.Errorf("VergiKimlikNo = %q, want '1234567890'", vl.VergiKimlikNo)
+	}
+}
+
+func TestIsValidTCKN(t *testing.T) {
+	tests := []struct {
+		tckn string
+		want bool
+	}{
+		{"11111111110", true},
+		{"10000000146", true},
+		{"11111111111", false},
+		{"01111111110", false},
+		{"1111111111", false},
+		{"1111111111a", false},
+	}
+
+	for _, tt := range tests {
+		if got := isValidTCKN(tt.tckn); got != tt.want {
+			t.Errorf("isValidTCKN(%q) = %v, want %v", tt.tckn, got, tt.want)
+		}
+	}
+}
+
+func TestSetTCKNAsVergiNo(t *testing.T) {
+	// Individual plate with a TCKN but no VKN (clearly fictional data)
+	text := `
+	Adı Soyadı: Ali Örnek
+	TC Kimlik No: 11111111110
+	Vergi Dairesi: Örnek VD
+	Yıllık Gelir Vergisi
+	`
+
+	parser := NewParser()
+	parser.SetTCKNAsVergiNo(true)
+
+	vl := &VergiLevhasi{}
+	parser.parseContent(vl, text)
+
+	if !vl.UsesTCKNAsVergiNo {
+		t.Error("UsesTCKNAsVergiNo = false, want true")
+	}
+	if vl.VergiKimlikNo != "" {
+		t.Errorf("VergiKimlikNo = %q, want empty (no VKN must be invented)", vl.VergiKimlikNo)
+	}
+	if vl.TCKimlikNo != "11111111110" {
+		t.Errorf("TCKimlikNo = %q, want '11111111110'", vl.TCKimlikNo)
+	}
+
+	// Disabled by default
+	vl = &VergiLevhasi{}
+	NewParser().parseContent(vl, text)
+	if vl.UsesTCKNAsVergiNo {
+		t.Error("UsesTCKNAsVergiNo = true with option disabled, want false")
+	}
+
+	// Not set for corporate taxpayers
+	vl = &VergiLevhasi{}
+	parser.parseContent(vl, text+"Kurumlar Vergisi\n")
+	if vl.UsesTCKNAsVergiNo {
+		t.Error("UsesTCKNAsVergiNo = true for a corporate plate, want false")
+	}
+}
+
+func FuzzDecodePDFString(f *testing.F) {
+	seeds := []string{
+		`Ali \(Örnek\)`,
+		`\101\102C`,
+		`\7`,
+		`\0`,
+		`trailing\`,
+		`\\\\`,
+		`(nested (parens))`,
+		"\\\r\n",
+		"\xdd\xde\xd0",
+	}
+	for _, s := range seeds {
+		f.Add(s)
+	}
+
+	f.Fuzz(func(t *testing.T, s string) {
+		decoded := decodePDFString(s)
+		if !utf8.ValidString(decoded) {
+			t.Errorf("decodePDFString(%q) returned invalid UTF-8: %q", s, decoded)
+		}
+
+		// The same input wrapped as a literal string must survive the extractor too
+		content := "(" + s + ") Tj"
+		str, end := extractPDFString(content, 0)
+		if end < 0 || end > len(content) {
+			t.Fatalf("extractPDFString(%q) returned out-of-range end %d", content, end)
+		}
+		decodePDFString(str)
+		tokenizeContent(s)
+	})
+}
+
+func FuzzDecodeHexString(f *testing.F) {
+	seeds := []string{
+		"FEFF00410042",
+		"00560045005200470130",
+		"56455247DD",
+		"ABC",
+		"",
+		"F",
+		"FEFF0",
+		"zz",
+		"D800",
+	}
+	for _, s := range seeds {
+		f.Add(s)
+	}
+
+	f.Fuzz(func(t *testing.T, hex string) {
+		decoded := decodeHexString(hex)
+		if !utf8.ValidString(decoded) {
+			t.Errorf("decodeHexString(%q) returned invalid UTF-8: %q", hex, decoded)
+		}
+		decodeUTF16BE([]byte(hex))
+	})
+}
+
+func TestDecodePDFString(t *testing.T) {
+	tests := []struct {
+		name string
+		in   string
+		want string
+	}{
+		{name: "Plain", in: "VERGI", want: "VERGI"},
+		{name: "Escaped parens", in: `Ali \(Ornek\)`, want: "Ali (Ornek)"},
+		{name: "Octal followed by text", in: `\101BC`, want: "ABC"},
+		{name: "Short octal", in: `\60\61x`, want: "01x"},
+		{name: "Truncated octal at end", in: `A\7`, want: "A\a"},
+		{name: "Octal Windows-1254", in: `VERG\335`, want: "VERGİ"},
+		{name: "Octal overflow", in: `\501`, want: "A"},
+		{name: "Dangling backslash", in: `trailing\`, want: "trailing"},
+		{name: "Line continuation LF", in: "VERGI \\\nLEVHASI", want: "VERGI LEVHASI"},
+		{name: "Line continuation CRLF", in: "VERGI \\\r\nLEVHASI", want: "VERGI LEVHASI"},
+		{name: "Line continuation CR", in: "VERGI \\\rLEVHASI", want: "VERGI LEVHASI"},
+		{name: "Continuation after octal", in: "VERG\\335\\\n DA\\335RES\\335", want: "VERGİ DAİRESİ"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := decodePDFString(tt.in); got != tt.want {
+				t.Errorf("decodePDFString(%q) = %q, want %q", tt.in, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestExtractPDFStringEscapes(t *testing.T) {
+	tests := []struct {
+		name    string
+		content string
+		raw     string
+		want    string
+	}{
+		{name: "Unknown escape", content: `(a\zb) Tj`, raw: `a\zb`, want: "azb"},
+		{name: "Escaped percent", content: `(50\% off) Tj`, raw: `50\% off`, want: "50% off"},
+		{name: "Escaped parens", content: `(Ali \(Ornek\)) Tj`, raw: `Ali \(Ornek\)`, want: "Ali (Ornek)"},
+		{name: "Escaped backslash before paren", content: `(a\\) Tj`, raw: `a\\`, want: `a\`},
+		{name: "Escaped backslash before escape", content: `(\\n) Tj`, raw: `\\n`, want: `\n`},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			raw, end := extractPDFString(tt.content, 0)
+			if raw != tt.raw || end != len(tt.content)-len(" Tj") {
+				t.Fatalf("extractPDFString(%q) = %q, %d; want %q, %d", tt.content, raw, end, tt.raw, len(tt.content)-len(" Tj"))
+			}
+			if got := decodePDFString(raw); got != tt.want {
+				t.Errorf("decodePDFString(%q) = %q, want %q", raw, got, tt.want)
+			}
+			// The content stream path resolves each escape exactly once as well
+			if got := extractTextFromPDFContent("BT " + tt.content + " ET"); got != tt.want+"\n" {
+				t.Errorf("extractTextFromPDFContent(%q) = %q, want %q", tt.content, got, tt.want+"\n")
+			}
+		})
+	}
+}
+
+func TestExtractTextFromPDFContentLineContinuation(t *testing.T) {
+	// A long literal string split over two lines with a backslash-CRLF continuation
+	content := "BT (ORNEK TEKNOLOJ\\\r\nI LIMITED) Tj ET"
+
+	if got := extractTextFromPDFContent(content); got != "ORNEK TEKNOLOJI LIMITED\n" {
+		t.Errorf("extractTextFromPDFContent(%q) = %q, want %q", content, got, "ORNEK TEKNOLOJI LIMITED\n")
+	}
+}
+
+func TestDecodeHexStringMalformed(t *testing.T) {
+	tests := []struct {
+		name string
+		in   string
+		want string
+	}{
+		{name: "Odd length", in: "414", want: "A@"},
+		{name: "BOM only", in: "FEFF", want: ""},
+		{name: "Truncated UTF-16 after BOM", in: "FEFF004", want: "@"},
+		{name: "Empty", in: "", want: ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := decodeHexString(tt.in); got != tt.want {
+				t.Errorf("decodeHexString(%q) = %q, want %q", tt.in, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestExtractSubeKodu(t *testing.T) {
+	parser := NewParser()
+
+	tests := []struct {
+		name     string
+		text     string
+		wantVKN  string
+		wantSube string
+	}{
+		{
+			name:     "Branch code label",
+			text:     "Vergi Kimlik No: 1234567890\nŞube Kodu: 002\n",
+			wantVKN:  "1234567890",
+			wantSube: "002",
+		},
+		{
+			name:     "Branch code appended with dash",
+			text:     "Vergi Kimlik No: 1234567890-001\n",
+			wantVKN:  "1234567890",
+			wantSube: "001",
+		},
+		{
+			name:     "Branch code concatenated",
+			text:     "Vergi Kimlik No: 1234567890003\n",
+			wantVKN:  "1234567890",
+			wantSube: "003",
+		},
+		{
+			name:     "GIB format without labels",
+			text:     "VERGİ LEVHASI\n1234567890-004\nŞUBE NO 004\n",
+			wantVKN:  "1234567890",
+			wantSube: "004",
+		},
+		{
+			name:     "Head office",
+			text:     "Vergi Kimlik No: 1234567890\n",
+			wantVKN:  "1234567890",
+			wantSube: "",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			vl := &VergiLevhasi{}
+			parser.parseContent(vl, tt.text)
+			if vl.VergiKimlikNo != tt.wantVKN {
+				t.Errorf("VergiKimlikNo = %q, want %q", vl.VergiKimlikNo, tt.wantVKN)
+			}
+			if vl.SubeKodu != tt.wantSube {
+				t.Errorf("SubeKodu = %q, want %q", vl.SubeKodu, tt.wantSube)
+			}
+		})
+	}
+}
+
+func TestParseContentGenerationTimestamp(t *testing.T) {
+	parser := NewParser()
+
+	tests := []struct {
+		name      string
+		text      string
+		generated time.Time
+	}{
+		{
+			name: "Footer datetime before start date",
+			text: `15.03.2024 14:32
+ALI ORNEK
+ORNEK MAH. TEST CAD. NO:1
+01.01.2020
+YILLIK GELİR VERGİSİ`,
+			generated: time.Date(2024, 3, 15, 14, 32, 0, 0, time.UTC),
+		},
+		{
+			name: "Labelled timestamp with seconds",
+			text: `Oluşturulma Tarihi: 15.03.2024 14:32:05
+ALI ORNEK
+01.01.2020`,
+			generated: time.Date(2024, 3, 15, 14, 32, 5, 0, time.UTC),
+		},
+		{
+			name: "Labelled date without time",
+			text: `OLUŞTURULMA TARİHİ 15.03.2024
+ALI ORNEK
+01.01.2020`,
+			generated: time.Date(2024, 3, 15, 0, 0, 0, 0, time.UTC),
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			vl := &VergiLevhasi{}
+			parser.parseContent(vl, tt.text)
+
+			if vl.OlusturulmaTarihi == nil {
+				t.Fatal("OlusturulmaTarihi is nil")
+			}
+			if !vl.OlusturulmaTarihi.Equal(tt.generated) {
+				t.Errorf("OlusturulmaTarihi = %v, want %v", vl.OlusturulmaTarihi, tt.generated)
+			}
+
+			want := time.Date(2020, 1, 1, 0, 0, 0, 0, time.UTC)
+			if vl.IseBaslamaTarihi == nil || !vl.IseBaslamaTarihi.Equal(want) {
+				t.Errorf("IseBaslamaTarihi = %v, want %v", vl.IseBaslamaTarihi, want)
+			}
+		})
+	}
+}
+
+func TestExtractGelirUnsuru(t *testing.T) {
+	tests := []struct {
+		name string
+		text string
+		want string
+	}{
+		{"Ticari kazanç", "YILLIK GELİR VERGİSİ\nTİCARİ KAZANÇ\nÖRNEK VD", "Ticari Kazanç"},
+		{"Serbest meslek kazancı", "Gelir Unsuru: Serbest Meslek Kazancı", "Serbest Meslek Kazancı"},
+		{"Zirai kazanç", "ZIRAI KAZANC", "Zirai Kazanç"},
+		{"ASCII folded", "SERBEST MESLEK KAZANCI", "Serbest Meslek Kazancı"},
+		{"Rental income", "Gelir Unsuru: Gayrimenkul Sermaye İradı", "Gayrimenkul Sermaye İradı"},
+		{"Rental income abbreviated", "YILLIK GELİR VERGİSİ (GMSİ)", "Gayrimenkul Sermaye İradı"},
+		{"Business income wins over rental income", "TİCARİ KAZANÇ\nGMSİ", "Ticari Kazanç"},
+		{"Abbreviation inside a word", "ÖRNEKGMSİLER", ""},
+		{"None", "KURUMLAR VERGİSİ", ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := extractGelirUnsuru(tt.text); got != tt.want {
+				t.Errorf("extractGelirUnsuru() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestParseContentGelirUnsuru(t *testing.T) {
+	parser := NewParser()
+
+	vl := &VergiLevhasi{}
+	parser.parseContent(vl, "Adı Soyadı: Ali Örnek\nYILLIK GELİR VERGİSİ\nSERBEST MESLEK KAZANCI\n")
+
+	if vl.GelirUnsuru != "Serbest Meslek Kazancı" {
+		t.Errorf("GelirUnsuru = %q, want %q", vl.GelirUnsuru, "Serbest Meslek Kazancı")
+	}
+}
+
+func TestParseContentGMSI(t *testing.T) {
+	text := "VERGİ LEVHASI\n" +
+		"Adı Soyadı: AYŞE ÖRNEK\n" +
+		"Ticaret Ünvanı: AYŞE ÖRNEK GMSİ\n" +
+		"İş Yeri Adresi: ÖRNEK MAH. TEST SOK. NO:2 ÇANKAYA/ANKARA\n" +
+		"Vergi Dairesi: ÇANKAYA\n" +
+		"TC Kimlik No: 10000000146\n" +
+		"YILLIK GELİR VERGİSİ\n" +
+		"Gelir Unsuru: GMSİ (Gayrimenkul Sermaye İradı)\n"
+
+	vl := &VergiLevhasi{}
+	NewParser().parseContent(vl, text)
+
+	if vl.GelirUnsuru != "Gayrimenkul Sermaye İradı" {
+		t.Errorf("GelirUnsuru = %q, want %q", vl.GelirUnsuru, "Gayrimenkul Sermaye İradı")
+	}
+	if vl.AdiSoyadi != "AYŞE ÖRNEK" || vl.TicaretUnvani != "" {
+		t.Errorf("AdiSoyadi = %q, TicaretUnvani = %q; want the name and no trade name", vl.AdiSoyadi, vl.TicaretUnvani)
+	}
+	if vl.MukellefTuru != MukellefTuruBireysel {
+		t.Errorf("MukellefTuru = %q, want %q", vl.MukellefTuru, MukellefTuruBireysel)
+	}
+}
+
+func TestExtractTaxBasesKurusSeparators(t *testing.T) {
+	parser := NewParser()
+
+	tests := []struct {
+		name string
+		text string
+		want []Matrah
+	}{
+		{"Space separated", "2020 450.000 00", []Matrah{{Yil: 2020, Tutar: 450000, TutarKurus: 45000000}}},
+		{"Space separated kuruş", "2020 450.000 75 TL", []Matrah{{Yil: 2020, Tutar: 450000.75, TutarKurus: 45000075}}},
+		{"Middle dot", "2021 1.250.000·50", []Matrah{{Yil: 2021, Tutar: 1250000.50, TutarKurus: 125000050}}},
+		{"Middle dot with spaces", "2021 1.250.000 · 50 TL", []Matrah{{Yil: 2021, Tutar: 1250000.50, TutarKurus: 125000050}}},
+		{"Non-breaking spaces", "2022\u00a0300.000\u00a000", []Matrah{{Yil: 2022, Tutar: 300000, TutarKurus: 30000000}}},
+		{"Next row not taken as kuruş", "2020 450.000\n2021 500.000,00", []Matrah{{Yil: 2020, Tutar: 450000, TutarKurus: 45000000}, {Yil: 2021, Tutar: 500000, TutarKurus: 50000000}}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := parser.extractTaxBases(tt.text)
+			if len(got) != len(tt.want) {
+				t.Fatalf("extractTaxBases() = %+v, want %+v", got, tt.want)
+			}
+			for i := range tt.want {
+				if got[i] != tt.want[i] {
+					t.Errorf("matrah %d = %+v, want %+v", i, got[i], tt.want[i])
+				}
+			}
+		})
+	}
+}
+
+func TestExtractTaxBasesScaledAmounts(t *testing.T) {
+	parser := NewParser()
+
+	tests := []struct {
+		name string
+		text string
+		want Matrah
+	}{
+		{"Milyon with decimals", "2022 1,5 Milyon TL", Matrah{Yil: 2022, Tutar: 1500000, TutarKurus: 150000000}},
+		{"Bin", "2023 450 Bin", Matrah{Yil: 2023, Tutar: 450000, TutarKurus: 45000000}},
+		{"Uppercase Turkish", "2021 2,25 MİLYON TL", Matrah{Yil: 2021, Tutar: 2250000, TutarKurus: 225000000}},
+		{"Milyar", "2020 1,2 Milyar TL", Matrah{Yil: 2020, Tutar: 1200000000, TutarKurus: 120000000000}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := parser.extractTaxBases(tt.text)
+			if len(got) != 1 || got[0] != tt.want {
+				t.Errorf("extractTaxBases(%q) = %+v, want %+v", tt.text, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestSetMaxRegexInputLength(t *testing.T) {
+	// Single-line GİB layout: only the whole-text pass finds the tax office
+	text := "VERGİ LEVHASI YILLIK GELİR VERGİSİ ÇANKAYA 12345678901 " + strings.Repeat("X", 1000)
+
+	parser := NewParser()
+	vl := &VergiLevhasi{}
+	parser.parseGIBFormat(vl, text, containsAnyFold)
+	if vl.VergiDairesi != "ÇANKAYA" {
+		t.Fatalf("VergiDairesi = %q, want %q with the default limit", vl.VergiDairesi, "ÇANKAYA")
+	}
+
+	parser.SetMaxRegexInputLength(500)
+	vl = &VergiLevhasi{}
+	parser.parseGIBFormat(vl, text, containsAnyFold)
+	if vl.VergiDairesi != "" {
+		t.Errorf("VergiDairesi = %q, want the whole-text pass skipped above the limit", vl.VergiDairesi)
+	}
+
+	parser.SetMaxRegexInputLength(0)
+	vl = &VergiLevhasi{}
+	parser.parseGIBFormat(vl, text, containsAnyFold)
+	if vl.VergiDairesi != "ÇANKAYA" {
+		t.Errorf("VergiDairesi = %q, want %q with the guard disabled", vl.VergiDairesi, "ÇANKAYA")
+	}
+}
+
+// containsAnyFold mirrors the case-insensitive containsAny helper of parseContent
+func containsAnyFold(s string, substrs ...string) bool {
+	lower := strings.ToLower(s)
+	for _, sub := range substrs {
+		if strings.Contains(lower, strings.ToLower(sub)) {
+			return true
+		}
+	}
+	return false
+}
+
+// BenchmarkParseContent reports allocations per document; the regexes are compiled
+// once per process, so they do not show up here
+func BenchmarkParseContent(b *testing.B) {
+	parser := NewParser()
+	text := "Adı Soyadı: Ali Örnek\nVergi Dairesi: Çankaya\nVergi Kimlik No: 1234567890\n" +
+		"YILLIK GELİR VERGİSİ\n620100 - BİLGİSAYAR PROGRAMLAMA FAALİYETLERİ\n" +
+		"İşe Başlama Tarihi: 01.02.2020\n2022 450.000,00\n2023 500.000,00\n"
+
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		parser.parseContent(&VergiLevhasi{}, text)
+	}
+}
+
+// BenchmarkParseBatch compares extracting the labelled fields of a batch of documents
+// with the package-level compiled patterns against compiling them for every document,
+// as the parser used to
+func BenchmarkParseBatch(b *testing.B) {
+	batch := make([]string, 50)
+	for i := range batch {
+		batch[i] = fmt.Sprintf("Adı Soyadı: Ali Örnek %d\nVergi Dairesi: Çankaya\nVergi Kimlik No: %010d\n"+
+			"İşe Başlama Tarihi: 01.02.2020\n2022 450.000,00\n", i, 1234567890+i)
+	}
+	sources := [][]string{
+		{`(?i)adı\s*soyadı\s*[:：]\s*(.+?)(?:\n|$)`, `(?i)ad[ıi]\s*soyad[ıi]\s*[:：]\s*(.+?)(?:\n|$)`},
+		{`(?i)vergi\s*dairesi\s*[:：]\s*(.+?)(?:\n|$)`},
+		{`(?i)vergi\s*kimlik\s*no\s*[:：]\s*(\d{10})`, `(?i)v\.?k\.?n\.?\s*[:：]\s*(\d{10})`},
+	}
+	parser := NewParser()
+
+	b.Run("precompiled", func(b *testing.B) {
+		b.ReportAllocs()
+		for i := 0; i < b.N; i++ {
+			for _, text := range batch {
+				parser.extractField(text, labelAdiSoyadiPatterns)
+				parser.extractField(text, labelVergiDairesiPatterns)
+				parser.extractField(text, labelVKNPatterns)
+			}
+		}
+	})
+
+	b.Run("compile-per-document", func(b *testing.B) {
+		b.ReportAllocs()
+		for i := 0; i < b.N; i++ {
+			for _, text := range batch {
+				for _, patterns := range sources {
+					parser.extractField(text, mustCompileAll(patterns...))
+				}
+			}
+		}
+	})
+
+	b.Run("parseContent", func(b *testing.B) {
+		b.ReportAllocs()
+		for i := 0; i < b.N; i++ {
+			for _, text := range batch {
+				parser.parseContent(&VergiLevhasi{}, text)
+			}
+		}
+	})
+}
+
+func TestParseLineBasedFormatSameLineName(t *testing.T) {
+	parser := NewParser()
+
+	tests := []struct {
+		name        string
+		lines       []string
+		wantAdi     string
+		wantUnvan   string
+		wantAddress string
+	}{
+		{
+			name:        "Company on the label line",
+			lines:       []string{"VERGİ LEVHASI", "MÜKELLEFİN ACME LTD ŞTİ", "ÖRNEK MAH. TEST CAD. NO:1", "KURUMLAR VERGİSİ"},
+			wantUnvan:   "ACME LTD ŞTİ",
+			wantAddress: "ÖRNEK MAH. TEST CAD. NO:1",
+		},
+		{
+			name:        "Name continues on the next line",
+			lines:       []string{"MÜKELLEFİN: ACME BİLİŞİM", "LİMİTED ŞİRKETİ", "ÖRNEK MAH. TEST CAD. NO:1", "KURUMLAR VERGİSİ"},
+			wantUnvan:   "ACME BİLİŞİM LİMİTED ŞİRKETİ",
+			wantAddress: "ÖRNEK MAH. TEST CAD. NO:1",
+		},
+		{
+			name:        "Mangled label",
+			lines:       []string{"MKELLEFIN ALİ ÖRNEK", "ÖRNEK MAH. TEST SOK. NO:2", "YILLIK GELİR VERGİSİ"},
+			wantAdi:     "ALİ ÖRNEK",
+			wantAddress: "ÖRNEK MAH. TEST SOK. NO:2",
+		},
+		{
+			name:        "Name on the following line",
+			lines:       []string{"MÜKELLEFİN", "ALİ ÖRNEK", "ÖRNEK MAH. TEST SOK. NO:2", "YILLIK GELİR VERGİSİ"},
+			wantAdi:     "ALİ ÖRNEK",
+			wantAddress: "ÖRNEK MAH. TEST SOK. NO:2",
+		},
+		{
+			name:    "Label is the last line",
+			lines:   []string{"VERGİ LEVHASI", "MÜKELLEFİN ALİ ÖRNEK"},
+			wantAdi: "ALİ ÖRNEK",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			vl := &VergiLevhasi{}
+			parser.parseLineBasedFormat(vl, tt.lines, containsAnyFold)
+
+			if vl.AdiSoyadi != tt.wantAdi {
+				t.Errorf("AdiSoyadi = %q, want %q", vl.AdiSoyadi, tt.wantAdi)
+			}
+			if vl.TicaretUnvani != tt.wantUnvan {
+				t.Errorf("TicaretUnvani = %q, want %q", vl.TicaretUnvani, tt.wantUnvan)
+			}
+			if vl.IsYeriAdresi != tt.wantAddress {
+				t.Errorf("IsYeriAdresi = %q, want %q", vl.IsYeriAdresi, tt.wantAddress)
+			}
+		})
+	}
+}
+
+func TestActivityCodeLengthsBothPathsAgree(t *testing.T) {
+	tests := []struct {
+		name    string
+		lengths []int
+		code    string
+		want    bool
+	}{
+		{"4-digit code", nil, "4711", true},
+		{"6-digit code", nil, "471101", true},
+		{"7-digit number", nil, "4711012", false},
+		{"4-digit code with 6 only", []int{6}, "4711", false},
+		{"6-digit code with 6 only", []int{6}, "471101", true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			parser := NewParser()
+			parser.SetActivityCodeLengths(tt.lengths...)
+
+			lineBased := parser.extractActivities(tt.code + " - GIDA SATIŞI\n")
+
+			vl := &VergiLevhasi{}
+			parser.parseGIBFormat(vl, "VERGİ LEVHASI "+tt.code+" - GIDA SATIŞI TAKVİM YILI", containsAnyFold)
+			singleLine := vl.FaaliyetKodlari
+
+			for path, got := range map[string][]Faaliyet{"line-based": lineBased, "single-line": singleLine} {
+				if !tt.want {
+					if len(got) != 0 {
+						t.Errorf("%s: got %+v, want no activities", path, got)
+					}
+					continue
+				}
+				// Codes are kept as printed, without zero padding
+				if len(got) != 1 || got[0].Kod != tt.code || got[0].Ad != "GIDA SATIŞI" {
+					t.Errorf("%s: got %+v, want [{%s GIDA SATIŞI}]", path, got, tt.code)
+				}
+			}
+		})
+	}
+}
+
+func TestCodesKeepLeadingZeros(t *testing.T) {
+	want := Faaliyet{Kod: "012345", Ad: "TAHIL YETİŞTİRİCİLİĞİ"}
+	parser := NewParser()
+
+	lineBased := &VergiLevhasi{}
+	parser.parseContent(lineBased, "VERGİ LEVHASI\nMÜKELLEFİN\nALİ ÖRNEK\n"+
+		"YILLIK GELİR VERGİSİ\nÇANKAYA\n1234567890\nŞube Kodu: 007\n012345 - TAHIL YETİŞTİRİCİLİĞİ\n")
+
+	singleLine := &VergiLevhasi{}
+	parser.parseGIBFormat(singleLine, "VERGİ LEVHASI 012345 - TAHIL YETİŞTİRİCİLİĞİ TAKVİM YILI", containsAnyFold)
+
+	parser.SetBackend(&fakeBackend{pages: []PageText{{Number: 1, Text: syntheticPlateText(&VergiLevhasi{
+		AdiSoyadi:       "ALİ ÖRNEK",
+		VergiKimlikNo:   "1234567890",
+		VergiTuru:       []string{"Gelir Vergisi"},
+		FaaliyetKodlari: []Faaliyet{want},
+	})}}})
+	parsed, err := parser.Parse(bytes.NewReader(nil))
+	if err != nil {
+		t.Fatalf("Parse() error = %v", err)
+	}
+
+	for path, vl := range map[string]*VergiLevhasi{"line-based": lineBased, "single-line": singleLine, "Parse": parsed} {
+		if len(vl.FaaliyetKodlari) != 1 || vl.FaaliyetKodlari[0] != want {
+			t.Errorf("%s: FaaliyetKodlari = %+v, want [%+v]", path, vl.FaaliyetKodlari, want)
+		}
+	}
+	if lineBased.SubeKodu != "007" {
+		t.Errorf("SubeKodu = %q, want 007", lineBased.SubeKodu)
+	}
+
+	data, err := json.Marshal(lineBased)
+	if err != nil {
+		t.Fatalf("json.Marshal() error = %v", err)
+	}
+	var decoded VergiLevhasi
+	if err := json.Unmarshal(data, &decoded); err != nil {
+		t.Fatalf("json.Unmarshal() error = %v", err)
+	}
+	if !decoded.Equal(lineBased) {
+		t.Errorf("JSON round trip = %+v, want %+v", decoded, lineBased)
+	}
+	if !bytes.Contains(data, []byte(`"kod":"012345"`)) || !bytes.Contains(data, []byte(`"sube_kodu":"007"`)) {
+		t.Errorf("JSON = %s, want the codes as strings with their leading zeros", data)
+	}
+}
+
+func TestParseContentBilingualLabels(t *testing.T) {
+	text := "VERGİ LEVHASI / TAX PLATE\n" +
+		"Ticaret Ünvanı / Trade Name: ÖRNEK YAZILIM LİMİTED ŞİRKETİ\n" +
+		"İş Yeri Adresi / Business Address: ÖRNEK MAH. TEST CAD. NO:1 ÇANKAYA/ANKARA\n" +
+		"Vergi Dairesi / Tax Office: ÇANKAYA\n" +
+		"Vergi Kimlik No / Tax ID No: 1234567890\n" +
+		"İşe Başlama Tarihi / Start Date: 15.01.2020\n" +
+		"Vergi Türü / Tax Type: KURUMLAR VERGİSİ\n"
+
+	vl := &VergiLevhasi{}
+	NewParser().parseContent(vl, text)
+
+	if vl.TicaretUnvani != "ÖRNEK YAZILIM LİMİTED ŞİRKETİ" {
+		t.Errorf("TicaretUnvani = %q, want %q", vl.TicaretUnvani, "ÖRNEK YAZILIM LİMİTED ŞİRKETİ")
+	}
+	if vl.IsYeriAdresi != "ÖRNEK MAH. TEST CAD. NO:1 ÇANKAYA/ANKARA" {
+		t.Errorf("IsYeriAdresi = %q, want %q", vl.IsYeriAdresi, "ÖRNEK MAH. TEST CAD. NO:1 ÇANKAYA/ANKARA")
+	}
+	if vl.VergiDairesi != "ÇANKAYA" {
+		t.Errorf("VergiDairesi = %q, want %q", vl.VergiDairesi, "ÇANKAYA")
+	}
+	if vl.VergiKimlikNo != "1234567890" {
+		t.Errorf("VergiKimlikNo = %q, want %q", vl.VergiKimlikNo, "1234567890")
+	}
+	if want := time.Date(2020, 1, 15, 0, 0, 0, 0, time.UTC); vl.IseBaslamaTarihi == nil || !vl.IseBaslamaTarihi.Equal(want) {
+		t.Errorf("IseBaslamaTarihi = %v, want %v", vl.IseBaslamaTarihi, want)
+	}
+}
+
+func TestParseContentGroupedVKN(t *testing.T) {
+	tests := []struct {
+		name string
+		line string
+		want string
+	}{
+		{"spaced", "Vergi Kimlik No: 123 456 7890", "1234567890"},
+		{"dotted", "Vergi Kimlik No: 1.234.567.890", "1234567890"},
+		{"abbreviated label", "VKN 482 719 3056", "4827193056"},
+		{"number after the VKN", "Vergi Kimlik No: 1234 567 890 2024", "1234567890"},
+		{"bad checksum", "Vergi Kimlik No: 123 456 7891", ""},
+		{"too few digits", "Vergi Kimlik No: 123 456 789", ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			vl := &VergiLevhasi{}
+			NewParser().parseContent(vl, "VERGİ LEVHASI\nAdı Soyadı: ALİ ÖRNEK\n"+tt.line+"\nVergi Dairesi: ÇANKAYA\n")
+			if vl.VergiKimlikNo != tt.want {
+				t.Errorf("VergiKimlikNo = %q, want %q", vl.VergiKimlikNo, tt.want)
+			}
+		})
+	}
+}
+
+func TestParseContentForeignIndividual(t *testing.T) {
+	parser := NewParser()
+
+	text := "Adı Soyadı: John Example\nUyruğu: ALMANYA\nPasaport No: C01X00T47\n" +
+		"Vergi Kimlik No: 1234567890\nİşe Başlama Tarihi: 01.02.2020\nKayıt No: 12345678901\n"
+
+	vl := &VergiLevhasi{}
+	parser.parseContent(vl, text)
+
+	if vl.Uyruk != "ALMANYA" {
+		t.Errorf("Uyruk = %q, want %q", vl.Uyruk, "ALMANYA")
+	}
+	if vl.PasaportNo != "C01X00T47" {
+		t.Errorf("PasaportNo = %q, want %q", vl.PasaportNo, "C01X00T47")
+	}
+	if vl.TCKimlikNo != "" {
+		t.Errorf("TCKimlikNo = %q, want empty for a passport holder", vl.TCKimlikNo)
+	}
+	if vl.VergiKimlikNo != "1234567890" || vl.AdiSoyadi != "John Example" {
+		t.Errorf("Parse() = %+v, want the other labelled fields", vl)
+	}
+}
+
+func TestParseContentForeignCompany(t *testing.T) {
+	parser := NewParser()
+
+	text := "VERGİ LEVHASI\nTicaret Ünvanı: EXAMPLE TRADING GMBH\nVergi Dairesi: BOĞAZİÇİ KURUMLAR\n" +
+		"Potansiyel Vergi Kimlik No: 4827193056\nYabancı Vergi No: de-123/456789\nKURUMLAR VERGİSİ\n"
+
+	vl := &VergiLevhasi{}
+	parser.parseContent(vl, text)
+
+	if vl.VergiKimlikNo != "4827193056" || !vl.IsPotansiyelVKN {
+		t.Errorf("VergiKimlikNo = %q, IsPotansiyelVKN = %v; want 4827193056 flagged as potential", vl.VergiKimlikNo, vl.IsPotansiyelVKN)
+	}
+	if vl.YabanciVergiNo != "DE-123/456789" {
+		t.Errorf("YabanciVergiNo = %q, want %q", vl.YabanciVergiNo, "DE-123/456789")
+	}
+	if vl.TicaretUnvani != "EXAMPLE TRADING GMBH" || vl.MukellefTuru != MukellefTuruKurumsal {
+		t.Errorf("TicaretUnvani = %q, MukellefTuru = %q; want a company", vl.TicaretUnvani, vl.MukellefTuru)
+	}
+
+	// A regular VKN is not flagged
+	vl = &VergiLevhasi{}
+	parser.parseContent(vl, "Ticaret Ünvanı: ÖRNEK A.Ş.\nVergi Kimlik No: 4827193056\n")
+	if vl.IsPotansiyelVKN || vl.YabanciVergiNo != "" {
+		t.Errorf("IsPotansiyelVKN = %v, YabanciVergiNo = %q; want neither for a domestic company", vl.IsPotansiyelVKN, vl.YabanciVergiNo)
+	}
+}
+
+func TestParseContentIsYeriTuru(t *testing.T) {
+	parser := NewParser()
+
+	tests := []struct {
+		name        string
+		text        string
+		want        IsYeriTuru
+		wantAddress string
+	}{
+		{
+			name: "Merkez by label",
+			text: "Ticaret Ünvanı: ÖRNEK A.Ş.\nİş Yeri Türü: Merkez\nİş Yeri Adresi: ÖRNEK MAH. TEST CAD. NO:1 ÇANKAYA/ANKARA\n",
+			want: IsYeriTuruMerkez,
+		},
+		{
+			name:        "Şube noted on the address label",
+			text:        "Ticaret Ünvanı: ÖRNEK A.Ş.\nİş Yeri Adresi (Şube): ÖRNEK MAH. TEST CAD. NO:5 KADIKÖY/İSTANBUL\n",
+			want:        IsYeriTuruSube,
+			wantAddress: "ÖRNEK MAH. TEST CAD. NO:5 KADIKÖY/İSTANBUL",
+		},
+		{
+			name: "Keyword line next to the address",
+			text: "Ticaret Ünvanı: ÖRNEK A.Ş.\nİş Yeri Adresi: ÖRNEK MAH. TEST CAD. NO:1 ÇANKAYA/ANKARA\nMERKEZ\n",
+			want: IsYeriTuruMerkez,
+		},
+		{
+			name: "Branch code",
+			text: "Ticaret Ünvanı: ÖRNEK A.Ş.\nVergi Kimlik No: 1234567890\nŞube Kodu: 002\n",
+			want: IsYeriTuruSube,
+		},
+		{
+			name: "District named Merkez",
+			text: "Ticaret Ünvanı: ÖRNEK A.Ş.\nİş Yeri Adresi: MERKEZ MAH. TEST CAD. NO:1 ÇORUM MERKEZ/ÇORUM\n",
+			want: IsYeriTuruBilinmiyor,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			vl := &VergiLevhasi{}
+			parser.parseContent(vl, tt.text)
+			if vl.IsYeriTuru != tt.want {
+				t.Errorf("IsYeriTuru = %q, want %q", vl.IsYeriTuru, tt.want)
+			}
+			if tt.wantAddress != "" && vl.IsYeriAdresi != tt.wantAddress {
+				t.Errorf("IsYeriAdresi = %q, want %q", vl.IsYeriAdresi, tt.wantAddress)
+			}
+		})
+	}
+}
+
+func TestParseContentAssociation(t *testing.T) {
+	parser := NewParser()
+
+	tests := []struct {
+		name     string
+		text     string
+		wantName string
+		wantNo   string
+		wantTuru MukellefTuru
+	}{
+		{
+			name: "Association",
+			text: "Adı Soyadı: ÖRNEK KÜLTÜR VE DAYANIŞMA DERNEĞİ\nDernek Kütük No: 06-123-456\n" +
+				"Vergi Kimlik No: 1234567890\nVergi Dairesi: Örnek VD\nİşe Başlama Tarihi: 01.02.2020\n",
+			wantName: "ÖRNEK KÜLTÜR VE DAYANIŞMA DERNEĞİ",
+			wantNo:   "06-123-456",
+			wantTuru: MukellefTuruDernek,
+		},
+		{
+			name:     "Foundation",
+			text:     "Ticaret Ünvanı: ÖRNEK EĞİTİM VAKFI\nVakıf Kayıt No: 1234\nVergi Kimlik No: 1234567890\n",
+			wantName: "ÖRNEK EĞİTİM VAKFI",
+			wantNo:   "1234",
+			wantTuru: MukellefTuruVakif,
+		},
+		{
+			name:     "Company",
+			text:     "Ticaret Ünvanı: ÖRNEK TEKNOLOJİ LİMİTED ŞİRKETİ\nKurumlar Vergisi\nVergi Kimlik No: 1234567890\n",
+			wantName: "ÖRNEK TEKNOLOJİ LİMİTED ŞİRKETİ",
+			wantTuru: MukellefTuruKurumsal,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			vl := &VergiLevhasi{}
+			parser.parseContent(vl, tt.text)
+
+			if vl.TicaretUnvani != tt.wantName || vl.AdiSoyadi != "" {
+				t.Errorf("TicaretUnvani/AdiSoyadi = %q/%q, want %q and no personal name", vl.TicaretUnvani, vl.AdiSoyadi, tt.wantName)
+			}
+			if vl.KayitNo != tt.wantNo {
+				t.Errorf("KayitNo = %q, want %q", vl.KayitNo, tt.wantNo)
+			}
+			if vl.MukellefTuru != tt.wantTuru || !vl.MukellefTuru.IsKurumsal() {
+				t.Errorf("MukellefTuru = %q, want %q", vl.MukellefTuru, tt.wantTuru)
+			}
+			if vl.VergiKimlikNo != "1234567890" {
+				t.Errorf("VergiKimlikNo = %q, want %q", vl.VergiKimlikNo, "1234567890")
+			}
+		})
+	}
+}
+
+func TestParseContentLineEndings(t *testing.T) {
+	date := time.Date(2020, 2, 1, 0, 0, 0, 0, time.UTC)
+	fixtures := map[string]string{
+		"Labelled": syntheticPlateText(&VergiLevhasi{
+			AdiSoyadi:        "ALİ ÖRNEK",
+			IsYeriAdresi:     "ÖRNEK MAH. TEST CAD. NO:1 ÇANKAYA/ANKARA",
+			VergiDairesi:     "ÇANKAYA",
+			VergiKimlikNo:    "1234567890",
+			IseBaslamaTarihi: &date,
+			VergiTuru:        []string{"Gelir Vergisi"},
+			GecmisMatra:      []Matrah{{Yil: 2022, Tutar: 450000}},
+		}),
+		"Line-based": "VERGİ LEVHASI\nMÜKELLEFİN\nALİ ÖRNEK\nÖRNEK MAH. TEST CAD. NO:1 ÇANKAYA/ANKARA\n" +
+			"YILLIK GELİR VERGİSİ\nÇANKAYA\n1234567890\n01.02.2020\n",
+	}
+
+	for name, lf := range fixtures {
+		t.Run(name, func(t *testing.T) {
+			parser := NewParser()
+			want := &VergiLevhasi{}
+			parser.parseContent(want, lf)
+			if want.VergiKimlikNo != "1234567890" || want.AdiSoyadi != "ALİ ÖRNEK" {
+				t.Fatalf("test setup: LF text parsed to %+v", want)
+			}
+
+			for ending, text := range map[string]string{
+				"CRLF": strings.ReplaceAll(lf, "\n", "\r\n"),
+				"CR":   strings.ReplaceAll(lf, "\n", "\r"),
+			} {
+				got := &VergiLevhasi{}
+				parser.parseContent(got, text)
+				if !got.Equal(want) {
+					t.Errorf("%s text parsed to %+v, want %+v", ending, got, want)
+				}
+				if strings.ContainsRune(got.AdiSoyadi+got.IsYeriAdresi+got.VergiDairesi, '\r') {
+					t.Errorf("%s text left a carriage return in %+v", ending, got)
+				}
+			}
+		})
+	}
+}
+
+func TestParseContentStatedKisiTuru(t *testing.T) {
+	parser := NewParser()
+
+	tests := []struct {
+		name          string
+		text          string
+		wantTuru      MukellefTuru
+		wantAdiSoyadi string
+		wantUnvan     string
+	}{
+		{
+			// Vakıf is also a surname; the name alone would make this a foundation
+			name:          "Natural person named like a foundation",
+			text:          "Adı Soyadı: AHMET VAKIF\nMükellefiyet Türü: Gerçek Kişi\nVergi Kimlik No: 1234567890\n",
+			wantTuru:      MukellefTuruBireysel,
+			wantAdiSoyadi: "AHMET VAKIF",
+		},
+		{
+			// Without corporate tax or a legal-form suffix the name would be taken for a person's
+			name:      "Legal person without corporate tax",
+			text:      "Ticaret Ünvanı: ÖRNEK YAPI KOOPERATİFİ\nTÜZEL KİŞİ\nKATMA DEĞER VERGİSİ\nVergi Kimlik No: 1234567890\n",
+			wantTuru:  MukellefTuruKurumsal,
+			wantUnvan: "ÖRNEK YAPI KOOPERATİFİ",
+		},
+		{
+			// Contradicting statements are ignored and the heuristics decide
+			name:          "Both stated",
+			text:          "Ticaret Ünvanı: ÖRNEK YAPI KOOPERATİFİ\nGerçek Kişi\nTüzel Kişi\nVergi Kimlik No: 1234567890\n",
+			wantTuru:      MukellefTuruBireysel,
+			wantAdiSoyadi: "ÖRNEK YAPI KOOPERATİFİ",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			vl := &VergiLevhasi{}
+			parser.parseContent(vl, tt.text)
+
+			if vl.MukellefTuru != tt.wantTuru {
+				t.Errorf("MukellefTuru = %q, want %q", vl.MukellefTuru, tt.wantTuru)
+			}
+			if vl.AdiSoyadi != tt.wantAdiSoyadi || vl.TicaretUnvani != tt.wantUnvan {
+				t.Errorf("AdiSoyadi/TicaretUnvani = %q/%q, want %q/%q", vl.AdiSoyadi, vl.TicaretUnvani, tt.wantAdiSoyadi, tt.wantUnvan)
+			}
+		})
+	}
+}
+
+func TestExtractTaxBasesKurusExact(t *testing.T) {
+	parser := NewParser()
+
+	tests := []struct {
+		text string
+		want int64
+	}{
+		{"2020 1.000,29 TL", 100029},
+		{"2021 1.234.567,89 TL", 123456789},
+		{"2022 450.000 TL", 45000000},
+		// Beyond float64's 53-bit mantissa: Tutar is rounded, TutarKurus is not
+		{"2023 90.071.992.547.409,93 TL", 9007199254740993},
+	}
+
+	for _, tt := range tests {
+		got := parser.extractTaxBases(tt.text)
+		if len(got) != 1 || got[0].TutarKurus != tt.want {
+			t.Errorf("extractTaxBases(%q) = %+v, want TutarKurus %d", tt.text, got, tt.want)
+		}
+	}
+
+	// Summing in kuruş is exact where summing Tutar drifts
+	var rows strings.Builder
+	for year := 2000; year < 2100; year++ {
+		fmt.Fprintf(&rows, "%d 1.000,10 TL\n", year)
+	}
+	var total int64
+	for _, m := range parser.extractTaxBases(rows.String()) {
+		total += m.TutarKurus
+	}
+	if total != 100*100010 {
+		t.Errorf("sum of TutarKurus = %d, want %d", total, 100*100010)
+	}
+}
+
+func TestExtractTaxBasesChronologicalAndDeduplicated(t *testing.T) {
+	parser := NewParser()
+
+	text := "2023 500.000,00 TL\n2021 300.000,00 TL\n2022 400.000,00 TL\n2023 500.000,00 TL\n"
+	got := parser.extractTaxBases(text)
+
+	want := []Matrah{{Yil: 2021, Tutar: 300000, TutarKurus: 30000000}, {Yil: 2022, Tutar: 400000, TutarKurus: 40000000}, {Yil: 2023, Tutar: 500000, TutarKurus: 50000000}}
+	if len(got) != len(want) {
+		t.Fatalf("extractTaxBases() = %+v, want %+v", got, want)
+	}
+	for i := range want {
+		if got[i] != want[i] {
+			t.Errorf("matrah %d = %+v, want %+v", i, got[i], want[i])
+		}
+	}
+}
+
+func TestExtractTaxBasesSeparatesAccruedTax(t *testing.T) {
+	parser := NewParser()
+
+	want := []Matrah{
+		{Yil: 2022, Tutar: 1250000, TutarKurus: 125000000, Vergi: 250000, VergiKurus: 25000000},
+		{Yil: 2023, Tutar: 1480000.5, TutarKurus: 148000050, Vergi: 296000.1, VergiKurus: 29600010},
+	}
+
+	tests := []struct {
+		name string
+		text string
+		want []Matrah
+	}{
+		{
+			name: "Table with both columns",
+			text: "GEÇMİŞ YILLAR\nYIL  BEYAN EDİLEN MATRAH  TAHAKKUK EDEN VERGİ\n" +
+				"2022 1.250.000,00 TL 250.000,00 TL\n2023 1.480.000,50 TL 296.000,10 TL\n",
+			want: want,
+		},
+		{
+			name: "Tax column first",
+			text: "YIL  TAHAKKUK EDEN VERGİ  MATRAH\n" +
+				"2022 250.000,00 1.250.000,00\n2023 296.000,10 1.480.000,50\n",
+			want: want,
+		},
+		{
+			name: "Separate sections",
+			text: "Beyan Edilen Matrah\n2022 1.250.000,00 TL\n2023 1.480.000,50 TL\n" +
+				"Tahakkuk Eden Vergi\n2022 250.000,00 TL\n2023 296.000,10 TL\n",
+			want: want,
+		},
+		{
+			name: "Tax without a tax base",
+			text: "Tahakkuk Eden Vergi\n2023 296.000,10 TL 1.000,00 TL\n",
+			want: []Matrah{{Yil: 2023, Vergi: 296000.1, VergiKurus: 29600010}},
+		},
+		{
+			name: "Unlabelled second amount is ignored",
+			text: "2023 1.480.000,50 TL 296.000,10 TL\n",
+			want: []Matrah{{Yil: 2023, Tutar: 1480000.5, TutarKurus: 148000050}},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := parser.extractTaxBases(tt.text); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("extractTaxBases() = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestNormalizeMatrahlarKeepsDistinctTypes(t *testing.T) {
+	got := normalizeMatrahlar([]Matrah{
+		{Yil: 2022, Tur: "KDV", Donem: "01", Tutar: 1000},
+		{Yil: 2021, Tur: "GV", Tutar: 5000},
+		{Yil: 2022, Tur: "KDV", Donem: "02", Tutar: 2000},
+		{Yil: 2022, Tur: "KDV", Donem: "01", Tutar: 1500},
+	})
+
+	want := []Matrah{
+		{Yil: 2021, Tur: "GV", Tutar: 5000},
+		{Yil: 2022, Tur: "KDV", Donem: "01", Tutar: 1000},
+		{Yil: 2022, Tur: "KDV", Donem: "02", Tutar: 2000},
+	}
+	if len(got) != len(want) {
+		t.Fatalf("normalizeMatrahlar() = %+v, want %+v", got, want)
+	}
+	for i := range want {
+		if got[i] != want[i] {
+			t.Errorf("matrah %d = %+v, want %+v", i, got[i], want[i])
+		}
+	}
+}
+
+func TestNormalizeDigits(t *testing.T) {
+	tests := []struct {
+		in   string
+		want string
+	}{
+		{"VKN 1234567890", "VKN 1234567890"},
+		{"ＶＫＮ １２３４５６７８９０", "ＶＫＮ 1234567890"},
+		{"٠١.٠٢.٢٠٢٠", "01.02.2020"},
+		{"۱۲۳ ०१२ 𝟗𝟘", "123 012 90"},
+		{"ÇANKAYA ²³ ①", "ÇANKAYA ²³ ①"},
+	}
+	for _, tt := range tests {
+		if got := normalizeDigits(tt.in); got != tt.want {
+			t.Errorf("normalizeDigits(%q) = %q, want %q", tt.in, got, tt.want)
+		}
+	}
+}
+
+func TestParseContentFullWidthDigits(t *testing.T) {
+	text := "Adı Soyadı: Ali Örnek\n" +
+		"Vergi Dairesi: Örnek VD\n" +
+		"Vergi Kimlik No: １２３４５６７８９０\n" +
+		"TC Kimlik No: １０００００００１４６\n" +
+		"İşe Başlama Tarihi: ٠١.٠٢.٢٠٢٠\n" +
+		"GELİR VERGİSİ\n" +
+		"２０２３ １.２３４,５６ TL\n"
+
+	vl := &VergiLevhasi{}
+	NewParser().parseContent(vl, text)
+
+	if vl.VergiKimlikNo != "1234567890" {
+		t.Errorf("VergiKimlikNo = %q, want %q", vl.VergiKimlikNo, "1234567890")
+	}
+	if vl.TCKimlikNo != "10000000146" {
+		t.Errorf("TCKimlikNo = %q, want %q", vl.TCKimlikNo, "10000000146")
+	}
+	if vl.IseBaslamaTarihi == nil || vl.IseBaslamaTarihi.Format("02.01.2006") != "01.02.2020" {
+		t.Errorf("IseBaslamaTarihi = %v, want 01.02.2020", vl.IseBaslamaTarihi)
+	}
+	want := []Matrah{{Yil: 2023, Tutar: 1234.56, TutarKurus: 123456}}
+	if !reflect.DeepEqual(vl.GecmisMatra, want) {
+		t.Errorf("GecmisMatra = %+v, want %+v", vl.GecmisMatra, want)
+	}
+}
+
+func TestParseContentNameAddressOutOfOrder(t *testing.T) {
+	tests := []struct {
+		name        string
+		block       string
+		wantUnvan   string
+		wantAddress string
+	}{
+		{
+			name:        "Address emitted before the name",
+			block:       "KIZILAY MAH. ATATÜRK BULVARI NO:5 ÇANKAYA/ANKARA\nÖRNEK PLAZA İŞLETMECİLİĞİ ANONİM ŞİRKETİ\n",
+			wantUnvan:   "ÖRNEK PLAZA İŞLETMECİLİĞİ ANONİM ŞİRKETİ",
+			wantAddress: "KIZILAY MAH. ATATÜRK BULVARI NO:5 ÇANKAYA/ANKARA",
+		},
+		{
+			name:        "Address without street markers taken for the name",
+			block:       "ÖRNEK PLAZA K:3 ÇANKAYA/ANKARA\nÖRNEK PLAZA YATIRIM LTD. ŞTİ.\n",
+			wantUnvan:   "ÖRNEK PLAZA YATIRIM LTD. ŞTİ.",
+			wantAddress: "ÖRNEK PLAZA K:3 ÇANKAYA/ANKARA",
+		},
+		{
+			name:        "Visual order is left alone",
+			block:       "ÖRNEK PLAZA İŞLETMECİLİĞİ ANONİM ŞİRKETİ\nKIZILAY MAH. ATATÜRK BULVARI NO:5 ÇANKAYA/ANKARA\n",
+			wantUnvan:   "ÖRNEK PLAZA İŞLETMECİLİĞİ ANONİM ŞİRKETİ",
+			wantAddress: "KIZILAY MAH. ATATÜRK BULVARI NO:5 ÇANKAYA/ANKARA",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			text := "MÜKELLEFİN\n" + tt.block + "KURUMLAR VERGİSİ\nÇANKAYA\n01.02.2020\n"
+
+			vl := &VergiLevhasi{}
+			NewParser().parseContent(vl, text)
+
+			if vl.TicaretUnvani != tt.wantUnvan || vl.AdiSoyadi != "" {
+				t.Errorf("TicaretUnvani = %q, AdiSoyadi = %q; want %q", vl.TicaretUnvani, vl.AdiSoyadi, tt.wantUnvan)
+			}
+			if vl.IsYeriAdresi != tt.wantAddress {
+				t.Errorf("IsYeriAdresi = %q, want %q", vl.IsYeriAdresi, tt.wantAddress)
+			}
+			if vl.VergiDairesi != "ÇANKAYA" {
+				t.Errorf("VergiDairesi = %q, want %q", vl.VergiDairesi, "ÇANKAYA")
+			}
+		})
+	}
+}
+
+func TestAddTaxType(t *testing.T) {
+	parser := NewParser()
+	parser.AddTaxType("ötv", "ÖTV")
+	parser.AddTaxType("  BSMV ", "BSMV")
+	parser.AddTaxType("gelir vergisi stopajı", "Gelir Vergisi Stopajı")
+	parser.AddTaxType("", "Boş")
+
+	tests := []struct {
+		name string
+		text string
+		want []string
+	}{
+		{"Custom keyword", "Mükellefiyet: Gelir Vergisi ve ötv", []string{"Gelir Vergisi", "ÖTV"}},
+		{"Keyword is lowercased", "Vergi türü: bsmv", []string{"BSMV"}},
+		{"More specific keyword wins", "Gelir Vergisi Stopajı", []string{"Gelir Vergisi Stopajı"}},
+		{"Less specific keyword on its own", "Gelir Vergisi Stopajı ve Stopaj", []string{"Gelir Vergisi Stopajı", "Stopaj"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := parser.extractTaxTypes(tt.text); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("extractTaxTypes(%q) = %q, want %q", tt.text, got, tt.want)
+			}
+		})
+	}
+
+	// Registering on one parser leaves the defaults of others untouched
+	if got := NewParser().extractTaxTypes("ötv"); len(got) != 0 {
+		t.Errorf("extractTaxTypes() on a new parser = %q, want none", got)
+	}
+}
+
+// endlessReader is a seekable stream that never ends, counting the bytes read from it
+type endlessReader struct {
+	read int64
+}
+
+func (r *endlessReader) Read(p []byte) (int, error) {
+	for i := range p {
+		p[i] = '0'
+	}
+	r.read += int64(len(p))
+	return len(p), nil
+}
+
+func (r *endlessReader) Seek(offset int64, whence int) (int64, error) {
+	return 0, nil
+}
+
+func TestSetMaxPDFSize(t *testing.T) {
+	parser := NewParser()
+	parser.SetBackend(&fakeBackend{pages: []PageText{{Number: 1, Text: "Adı Soyadı: Ali Örnek\n"}}})
+	parser.SetMaxPDFSize(1024)
+
+	stream := &endlessReader{}
+	if _, err := parser.Parse(stream); !errors.Is(err, ErrPDFTooLarge) {
+		t.Errorf("Parse() of an endless stream error = %v, want ErrPDFTooLarge", err)
+	}
+	if stream.read > 64*1024 {
+		t.Errorf("Parse() read %d bytes of an endless stream, want it to stop near the cap", stream.read)
+	}
+	if _, err := parser.PeekText(&endlessReader{}, 10); !errors.Is(err, ErrPDFTooLarge) {
+		t.Errorf("PeekText() error = %v, want ErrPDFTooLarge", err)
+	}
+	if _, _, err := parser.CandidateIdentifiers(&endlessReader{}); !errors.Is(err, ErrPDFTooLarge) {
+		t.Errorf("CandidateIdentifiers() error = %v, want ErrPDFTooLarge", err)
+	}
+
+	ocrParser, err := NewOCRParser()
+	if err != nil {
+		t.Fatalf("NewOCRParser() error = %v", err)
+	}
+	ocrParser.SetMaxPDFSize(1024)
+	stream = &endlessReader{}
+	if _, err := ocrParser.ExtractVKNFromPDFReaderWithImage(stream); !errors.Is(err, ErrPDFTooLarge) {
+		t.Errorf("ExtractVKNFromPDFReaderWithImage() error = %v, want ErrPDFTooLarge", err)
+	}
+	if stream.read > 64*1024 {
+		t.Errorf("ExtractVKNFromPDFReaderWithImage() read %d bytes of an endless stream, want it to stop near the cap", stream.read)
+	}
+
+	// A stream of exactly the cap is accepted
+	if _, err := parser.PeekText(bytes.NewReader(make([]byte, 1024)), 10); err != nil {
+		t.Errorf("PeekText() of a stream at the cap error = %v", err)
+	}
+
+	parser.SetMaxPDFSize(0)
+	if _, err := parser.PeekText(bytes.NewReader(make([]byte, 4096)), 10); err != nil {
+		t.Errorf("PeekText() with the cap disabled error = %v", err)
+	}
+}
+
+func TestMukellefinBlock(t *testing.T) {
+	tests := []struct {
+		name string
+		text string
+		want string
+	}{
+		{
+			name: "Name and address lines",
+			text: "VERGİ LEVHASI\nMÜKELLEFİN\nÖRNEK YAZILIM TİCARET\nLİMİTED ŞİRKETİ\n\nKIZILAY MAH. ATATÜRK BULVARI NO:5\nÇANKAYA/ANKARA\nKURUMLAR VERGİSİ\nÇANKAYA\n1234567890\n",
+			want: "ÖRNEK YAZILIM TİCARET\nLİMİTED ŞİRKETİ\nKIZILAY MAH. ATATÜRK BULVARI NO:5\nÇANKAYA/ANKARA",
+		},
+		{
+			name: "Name on the label line, block ends at an ID",
+			text: "MÜKELLEFİN: ALİ ÖRNEK\nÖRNEK MAH. TEST SOK. NO:2\n10000000146\nYILLIK GELİR VERGİSİ\n",
+			want: "ALİ ÖRNEK\nÖRNEK MAH. TEST SOK. NO:2",
+		},
+		{
+			name: "No label",
+			text: "Adı Soyadı: Ali Örnek\n",
+			want: "",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			parser := NewParser()
+			parser.SetBackend(&fakeBackend{pages: []PageText{{Number: 1, Text: tt.text}}})
+
+			got, err := parser.MukellefinBlock(bytes.NewReader(nil))
+			if err != nil {
+				t.Fatalf("MukellefinBlock() error = %v", err)
+			}
+			if got != tt.want {
+				t.Errorf("MukellefinBlock() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestParseContentHamUnvan(t *testing.T) {
+	tests := []struct {
+		name          string
+		text          string
+		wantAdiSoyadi string
+		wantUnvan     string
+	}{
+		{
+			// The trade-like name is moved to AdiSoyadi since the taxpayer pays income tax
+			name: "Company-like name of an individual",
+			text: "VERGİ LEVHASI\nMÜKELLEFİN\nALİ ÖRNEK TİCARET\nÖRNEK MAH. TEST CAD. NO:1 ÇANKAYA/ANKARA\n" +
+				"YILLIK GELİR VERGİSİ\nÇANKAYA\n10000000146\n",
+			wantAdiSoyadi: "ALİ ÖRNEK TİCARET",
+		},
+		{
+			// The person-like name is moved to TicaretUnvani by the corporate tax, and the
+			// name split over two lines is joined
+			name: "Person-like name of a company",
+			text: "VERGİ LEVHASI\nMÜKELLEFİN\nÖRNEK\nYAPI\nÖRNEK MAH. TEST CAD. NO:1 ÇANKAYA/ANKARA\n" +
+				"KURUMLAR VERGİSİ\nÇANKAYA\n1234567890\n",
+			wantUnvan: "ÖRNEK YAPI",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			vl := &VergiLevhasi{}
+			NewParser().parseContent(vl, tt.text)
+
+			if vl.AdiSoyadi != tt.wantAdiSoyadi || vl.TicaretUnvani != tt.wantUnvan {
+				t.Errorf("AdiSoyadi, TicaretUnvani = %q, %q, want %q, %q", vl.AdiSoyadi, vl.TicaretUnvani, tt.wantAdiSoyadi, tt.wantUnvan)
+			}
+			if want := tt.wantAdiSoyadi + tt.wantUnvan; vl.HamUnvan != want {
+				t.Errorf("HamUnvan = %q, want %q", vl.HamUnvan, want)
+			}
+		})
+	}
+}
+
+func TestParseContentVergiUsulu(t *testing.T) {
+	tests := []struct {
+		name       string
+		usul       string
+		want       VergiUsulu
+		wantMatrah bool
+	}{
+		{"Basit usul", "BASİT USUL\n", VergiUsuluBasit, false},
+		{"Gerçek usul", "GERÇEK USUL\n", VergiUsuluGercek, true},
+		{"Not stated", "", VergiUsuluBilinmiyor, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			text := "VERGİ LEVHASI\nMÜKELLEFİN\nALİ ÖRNEK\nÖRNEK MAH. TEST CAD. NO:1 ÇANKAYA/ANKARA\n" +
+				"YILLIK GELİR VERGİSİ\n" + tt.usul + "ÇANKAYA\n10000000146\n2022 450.000,00 TL\n"
+			vl := &VergiLevhasi{}
+			NewParser().parseContent(vl, text)
+
+			if vl.VergiUsulu != tt.want {
+				t.Errorf("VergiUsulu = %q, want %q", vl.VergiUsulu, tt.want)
+			}
+			if got := len(vl.GecmisMatra) > 0; got != tt.wantMatrah {
+				t.Errorf("GecmisMatra = %+v, want tax bases %v", vl.GecmisMatra, tt.wantMatrah)
+			}
+		})
+	}
 }
@@ -0,0 +1,18 @@
+package vergilevhasi
+
+// AddPostProcessor registers fn to run on every parse result before it is returned, after
+// the parser's own cleanup. Processors run in registration order, so each one sees the
+// changes of the ones before it. Use them for consumer-specific formatting such as
+// uppercasing names or trimming addresses.
+func (p *Parser) AddPostProcessor(fn func(*VergiLevhasi)) {
+	if fn != nil {
+		p.postProcessors = append(p.postProcessors, fn)
+	}
+}
+
+// runPostProcessors applies the registered post-processors to vl in order
+func (p *Parser) runPostProcessors(vl *VergiLevhasi) {
+	for _, fn := range p.postProcessors {
+		fn(vl)
+	}
+}
@@ -0,0 +1,40 @@
+package vergilevhasi
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+	"unicode"
+)
+
+func TestAddPostProcessor(t *testing.T) {
+	parser := NewParser()
+	parser.SetBackend(&fakeBackend{pages: []PageText{{Number: 1, Text: syntheticPlateText(&VergiLevhasi{
+		TicaretUnvani: "Örnek Yazılım Limited Şirketi",
+		VergiDairesi:  "ÖRNEK VERGİ DAİRESİ",
+		VergiKimlikNo: "1234567890",
+		VergiTuru:     []string{"Kurumlar Vergisi"},
+	})}}})
+
+	var order []string
+	parser.AddPostProcessor(func(vl *VergiLevhasi) {
+		order = append(order, "upper")
+		vl.TicaretUnvani = strings.ToUpperSpecial(unicode.TurkishCase, vl.TicaretUnvani)
+	})
+	parser.AddPostProcessor(nil)
+	parser.AddPostProcessor(func(vl *VergiLevhasi) {
+		// Runs second, so it sees the uppercased name
+		order = append(order, "suffix:"+vl.TicaretUnvani)
+	})
+
+	vl, err := parser.Parse(bytes.NewReader(nil))
+	if err != nil {
+		t.Fatalf("Parse() error = %v", err)
+	}
+	if want := "ÖRNEK YAZILIM LİMİTED ŞİRKETİ"; vl.TicaretUnvani != want {
+		t.Errorf("TicaretUnvani = %q, want %q", vl.TicaretUnvani, want)
+	}
+	if want := []string{"upper", "suffix:ÖRNEK YAZILIM LİMİTED ŞİRKETİ"}; strings.Join(order, "|") != strings.Join(want, "|") {
+		t.Errorf("post-processors ran as %q, want %q", order, want)
+	}
+}
@@ -0,0 +1,54 @@
+package vergilevhasi
+
+import (
+	"errors"
+	"fmt"
+	"time"
+)
+
+// DefaultScanBudget is the default time limit for one VKN extraction from images. The
+// rotations, crops, upscales and binarizations tried multiply, and on a large image
+// without a barcode trying them all takes many seconds before failing.
+const DefaultScanBudget = 15 * time.Second
+
+// ErrScanBudgetExceeded is returned when a VKN extraction from images gives up without a
+// VKN because its time budget, set with OCRParser.SetScanBudget, ran out
+var ErrScanBudgetExceeded = errors.New("scan budget exceeded")
+
+// SetScanBudget sets the time limit for one VKN extraction from images, covering every
+// image of a document and every barcode and digit attempt on them. Once it runs out the
+// remaining attempts are skipped and extraction fails with ErrScanBudgetExceeded. Zero or a
+// negative value removes the limit.
+func (p *OCRParser) SetScanBudget(budget time.Duration) {
+	p.scanBudget = budget
+}
+
+// withScanDeadline returns the parser to run one extraction with: a copy carrying the
+// deadline of the scan budget, so concurrent extractions keep their own deadlines. An
+// extraction nested in another one keeps the outer deadline.
+func (p *OCRParser) withScanDeadline() *OCRParser {
+	if p.scanBudget <= 0 || !p.deadline.IsZero() {
+		return p
+	}
+	scan := *p
+	scan.deadline = time.Now().Add(p.scanBudget)
+	return &scan
+}
+
+// budgetExceeded reports whether the current extraction has run out of time or has been
+// cancelled
+func (p *OCRParser) budgetExceeded() bool {
+	if p.ctx != nil && p.ctx.Err() != nil {
+		return true
+	}
+	return !p.deadline.IsZero() && time.Now().After(p.deadline)
+}
+
+// budgetError is the error returned when the scan budget runs out, or the cancellation
+// error when the extraction was cancelled
+func (p *OCRParser) budgetError() error {
+	if p.ctx != nil && p.ctx.Err() != nil {
+		return fmt.Errorf("scan cancelled: %w", p.ctx.Err())
+	}
+	return fmt.Errorf("%w: %v", ErrScanBudgetExceeded, p.scanBudget)
+}
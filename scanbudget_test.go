@@ -0,0 +1,82 @@
+package vergilevhasi
+
+import (
+	"errors"
+	"image"
+	"math/rand"
+	"testing"
+	"time"
+)
+
+// newNoiseGray returns a w×h image of random gray levels: no barcode, no digits, and
+// plenty of edges to keep every detector busy
+func newNoiseGray(w, h int) *image.Gray {
+	rng := rand.New(rand.NewSource(1))
+	img := image.NewGray(image.Rect(0, 0, w, h))
+	rng.Read(img.Pix)
+	return img
+}
+
+func TestScanBudgetBarcodelessImage(t *testing.T) {
+	parser, err := NewOCRParser()
+	if err != nil {
+		t.Fatalf("NewOCRParser() error = %v", err)
+	}
+	const budget = 20 * time.Millisecond
+	parser.SetScanBudget(budget)
+
+	img := newNoiseGray(1600, 1200)
+
+	start := time.Now()
+	result, err := parser.ExtractVKNFromImageDataResult(img)
+	elapsed := time.Since(start)
+
+	if !errors.Is(err, ErrScanBudgetExceeded) {
+		t.Fatalf("ExtractVKNFromImageDataResult() error = %v, want ErrScanBudgetExceeded", err)
+	}
+	if result == nil || result.Reason != VKNFailureBudgetExceeded {
+		t.Errorf("result = %+v, want reason %q", result, VKNFailureBudgetExceeded)
+	}
+	// A single decode attempt in progress when the budget runs out is allowed to finish
+	if elapsed > budget+2*time.Second {
+		t.Errorf("extraction took %v with a budget of %v", elapsed, budget)
+	}
+
+	start = time.Now()
+	_, _, err = parser.extractVKNFromImages([]image.Image{img, img, img}, nil)
+	if !errors.Is(err, ErrScanBudgetExceeded) {
+		t.Errorf("extractVKNFromImages() error = %v, want ErrScanBudgetExceeded", err)
+	}
+	if elapsed := time.Since(start); elapsed > budget+2*time.Second {
+		t.Errorf("extraction of 3 images took %v with a budget of %v", elapsed, budget)
+	}
+}
+
+func TestScanBudgetKeepsBarcodeResult(t *testing.T) {
+	parser, err := NewOCRParser()
+	if err != nil {
+		t.Fatalf("NewOCRParser() error = %v", err)
+	}
+	parser.SetScanBudget(time.Minute)
+
+	vkn, _, err := parser.extractVKNFromImages([]image.Image{drawCode128(t, "1234567890")}, nil)
+	if err != nil || vkn != "1234567890" {
+		t.Errorf("extractVKNFromImages() = %q, %v; want 1234567890", vkn, err)
+	}
+	if !parser.deadline.IsZero() {
+		t.Error("extraction set a deadline on the shared parser")
+	}
+}
+
+func BenchmarkExtractVKNBarcodelessImage(b *testing.B) {
+	parser, err := NewOCRParser()
+	if err != nil {
+		b.Fatalf("NewOCRParser() error = %v", err)
+	}
+	img := newNoiseGray(1600, 1200)
+
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		_, _ = parser.ExtractVKNFromImageDataResult(img)
+	}
+}
@@ -0,0 +1,157 @@
+package vergilevhasi
+
+import (
+	"context"
+	"io"
+	"sort"
+	"strings"
+	"unicode/utf8"
+)
+
+const (
+	// approxCharWidth estimates the width of a character, in points, for the horizontal
+	// extent of a text cell; the extraction does not track glyph widths
+	approxCharWidth = 5.0
+
+	// minPlateGap is the narrowest empty column, in points, taken as the divider between
+	// two plates printed side by side
+	minPlateGap = 40.0
+)
+
+// ParseAll parses a PDF that may carry more than one tax plate on a page, as in print
+// layouts that put two plates side by side on a landscape page. Each page is checked on
+// its own: a page that splits into two halves that each look like a plate gives one result
+// per half, and any other page that looks like a plate gives one result. The VKN barcode is
+// not read for such documents since it cannot be tied to a plate. A document with no split
+// page is parsed as by Parse and returned as a single result.
+func (p *Parser) ParseAll(reader io.ReadSeeker) ([]*VergiLevhasi, error) {
+	data, err := p.readPDF(reader)
+	if err != nil {
+		return nil, err
+	}
+
+	// The images are read up front so a document with no split page is not read again
+	doc, err := p.readDocument(data, p.fields.Has(FieldVergiKimlikNo))
+	if err != nil {
+		return nil, err
+	}
+	if plates := p.parsePages(doc.pages); plates != nil {
+		return plates, nil
+	}
+
+	vl, err := p.parseDocument(context.Background(), doc, nil)
+	if err != nil {
+		return nil, err
+	}
+	return []*VergiLevhasi{vl}, nil
+}
+
+// parsePages parses the plates of a document page by page, or returns nil if no page has
+// two plates side by side
+func (p *Parser) parsePages(pages []PageText) []*VergiLevhasi {
+	var plates []*VergiLevhasi
+	split := false
+	for _, page := range pages {
+		if halves := p.parseSideBySide(page.rows); halves != nil {
+			plates = append(plates, halves...)
+			split = true
+		} else if looksLikePlate(page.Text) {
+			plates = append(plates, p.parseRows(page.Text, page.rows))
+		}
+	}
+	if !split {
+		return nil
+	}
+	return plates
+}
+
+// parseSideBySide parses the left and right halves of side-by-side plates separately, or
+// returns nil if the rows are not two plates next to each other
+func (p *Parser) parseSideBySide(rows []textRow) []*VergiLevhasi {
+	left, right, ok := splitSideBySide(rows)
+	if !ok {
+		return nil
+	}
+	return []*VergiLevhasi{p.parseRows(rowsText(left), left), p.parseRows(rowsText(right), right)}
+}
+
+// parseRows parses the text and layout rows of a single plate
+func (p *Parser) parseRows(text string, rows []textRow) *VergiLevhasi {
+	vl := &VergiLevhasi{RawText: text}
+	p.parseText(vl, text, rows)
+	p.runPostProcessors(vl)
+	return vl
+}
+
+// splitSideBySide finds the divider between two plates printed side by side: the widest
+// column no text cell reaches into, found from the horizontal projection of the cells.
+// The split is only taken when the text on both sides looks like a tax plate, so the gap
+// between the label and value columns of a single plate is not mistaken for it.
+func splitSideBySide(rows []textRow) (left, right []textRow, ok bool) {
+	type span struct{ start, end float64 }
+	var spans []span
+	for _, row := range rows {
+		for _, cell := range row.Cells {
+			width := float64(utf8.RuneCountInString(strings.TrimSpace(cell.Text))) * approxCharWidth
+			spans = append(spans, span{cell.X, cell.X + width})
+		}
+	}
+	if len(spans) < 2 {
+		return nil, nil, false
+	}
+	sort.Slice(spans, func(i, j int) bool { return spans[i].start < spans[j].start })
+
+	// Widest gap in the union of the cell spans
+	divider, widest := 0.0, 0.0
+	reach := spans[0].end
+	for _, s := range spans[1:] {
+		if gap := s.start - reach; gap > widest {
+			divider, widest = reach+gap/2, gap
+		}
+		reach = max(reach, s.end)
+	}
+	if widest < minPlateGap {
+		return nil, nil, false
+	}
+
+	for _, row := range rows {
+		var l, r []textFragment
+		for _, cell := range row.Cells {
+			if cell.X < divider {
+				l = append(l, cell)
+			} else {
+				r = append(r, cell)
+			}
+		}
+		if len(l) > 0 {
+			left = append(left, textRow{Y: row.Y, Cells: l})
+		}
+		if len(r) > 0 {
+			right = append(right, textRow{Y: row.Y, Cells: r})
+		}
+	}
+	if !looksLikePlate(rowsText(left)) || !looksLikePlate(rowsText(right)) {
+		return nil, nil, false
+	}
+	return left, right, true
+}
+
+// looksLikePlate reports whether text carries the title or the identifier labels of a
+// tax plate
+func looksLikePlate(text string) bool {
+	folded := foldTurkish(text)
+	return strings.Contains(folded, "vergi levhasi") || strings.Contains(folded, "vergi kimlik")
+}
+
+// rowsText renders layout rows as page text, one cell per line, top to bottom and left
+// to right
+func rowsText(rows []textRow) string {
+	var b strings.Builder
+	for _, row := range rows {
+		for _, cell := range row.Cells {
+			b.WriteString(cell.Text)
+			b.WriteString("\n")
+		}
+	}
+	return b.String()
+}
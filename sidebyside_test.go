@@ -0,0 +1,146 @@
+package vergilevhasi
+
+import (
+	"bytes"
+	"fmt"
+	"strings"
+	"testing"
+)
+
+// plateContent renders a table-layout plate as a content stream with its label column
+// at x and its value column 150 points to the right
+func plateContent(x float64, fields [][2]string) string {
+	var b strings.Builder
+	b.WriteString("BT\n")
+	fmt.Fprintf(&b, "1 0 0 1 %g 760 Tm (VERGI LEVHASI) Tj\n", x)
+	for i, f := range fields {
+		y := 720 - 20*i
+		fmt.Fprintf(&b, "1 0 0 1 %g %d Tm (%s) Tj\n", x, y, f[0])
+		fmt.Fprintf(&b, "1 0 0 1 %g %d Tm (%s) Tj\n", x+150, y, f[1])
+	}
+	b.WriteString("ET\n")
+	return b.String()
+}
+
+func TestParseSideBySide(t *testing.T) {
+	left := plateContent(40, [][2]string{
+		{"TICARET UNVANI", "ORNEK TEKNOLOJI LIMITED SIRKETI"},
+		{"VERGI DAIRESI", "ORNEK VD"},
+		{"VERGI KIMLIK NO", "1234567890"},
+		{"VERGI TURU", "KURUMLAR VERGISI"},
+	})
+	right := plateContent(460, [][2]string{
+		{"ADI SOYADI", "ALI ORNEK"},
+		{"VERGI DAIRESI", "DIGER VD"},
+		{"VERGI KIMLIK NO", "4827193056"},
+		{"TC KIMLIK NO", "10000000146"},
+		{"VERGI TURU", "YILLIK GELIR VERGISI"},
+	})
+
+	// The right plate is drawn first and the rows of both plates are interleaved on the
+	// page, so the halves are only separated by position
+	rows := groupTextRows(extractTextFragments(right + left))
+	plates := NewParser().parseSideBySide(rows)
+	if len(plates) != 2 {
+		t.Fatalf("parseSideBySide() returned %d plates, want 2", len(plates))
+	}
+
+	company, person := plates[0], plates[1]
+	if company.TicaretUnvani != "ORNEK TEKNOLOJI LIMITED SIRKETI" || company.VergiKimlikNo != "1234567890" ||
+		company.VergiDairesi != "ORNEK VD" || company.AdiSoyadi != "" {
+		t.Errorf("left plate = %+v, want the company", company)
+	}
+	if person.AdiSoyadi != "ALI ORNEK" || person.VergiKimlikNo != "4827193056" || person.TCKimlikNo != "10000000146" ||
+		person.VergiDairesi != "DIGER VD" || person.TicaretUnvani != "" {
+		t.Errorf("right plate = %+v, want the individual", person)
+	}
+}
+
+func TestParseSideBySideSinglePlate(t *testing.T) {
+	// The gap between the label and value columns is not a plate divider
+	content := plateContent(40, [][2]string{
+		{"ADI SOYADI", "ALI ORNEK"},
+		{"VERGI KIMLIK NO", "4827193056"},
+	})
+	if plates := NewParser().parseSideBySide(groupTextRows(extractTextFragments(content))); plates != nil {
+		t.Errorf("parseSideBySide() = %d plates, want nil for a single plate", len(plates))
+	}
+}
+
+func TestParseAllSinglePlate(t *testing.T) {
+	backend := &fakeBackend{pages: []PageText{{Number: 1, Text: syntheticPlateText(&VergiLevhasi{
+		AdiSoyadi:     "ALİ ÖRNEK",
+		VergiDairesi:  "ÖRNEK VERGİ DAİRESİ",
+		VergiKimlikNo: "4827193056",
+		VergiTuru:     []string{"Gelir Vergisi"},
+	})}}}
+	parser := NewParser()
+	parser.SetBackend(backend)
+
+	plates, err := parser.ParseAll(bytes.NewReader(nil))
+	if err != nil {
+		t.Fatalf("ParseAll() error = %v", err)
+	}
+	if len(plates) != 1 || plates[0].AdiSoyadi != "ALİ ÖRNEK" {
+		t.Errorf("ParseAll() = %+v, want the single plate", plates)
+	}
+	// The document read for the split check is parsed as is
+	if backend.calls != 1 {
+		t.Errorf("ParseAll() extracted the text %d times, want 1", backend.calls)
+	}
+}
+
+// layoutPage renders content as a page with the positioned rows the pdfcpu backend extracts
+func layoutPage(number int, content string) PageText {
+	rows := groupTextRows(extractTextFragments(content))
+	return PageText{Number: number, Text: rowsText(rows), rows: rows}
+}
+
+func TestParseAllSplitsPerPage(t *testing.T) {
+	company := plateContent(40, [][2]string{
+		{"TICARET UNVANI", "ORNEK TEKNOLOJI LIMITED SIRKETI"},
+		{"VERGI KIMLIK NO", "1234567890"},
+	})
+	person := plateContent(460, [][2]string{
+		{"ADI SOYADI", "ALI ORNEK"},
+		{"VERGI KIMLIK NO", "4827193056"},
+	})
+	other := plateContent(460, [][2]string{
+		{"ADI SOYADI", "AYSE ORNEK"},
+		{"VERGI KIMLIK NO", "10000000146"},
+	})
+
+	parser := NewParser()
+	parser.SetBackend(&fakeBackend{pages: []PageText{
+		layoutPage(1, company+person),
+		layoutPage(2, other),
+	}})
+	plates, err := parser.ParseAll(bytes.NewReader(nil))
+	if err != nil {
+		t.Fatalf("ParseAll() error = %v", err)
+	}
+	if len(plates) != 3 {
+		t.Fatalf("ParseAll() returned %d plates, want 3", len(plates))
+	}
+	if plates[0].VergiKimlikNo != "1234567890" || plates[1].AdiSoyadi != "ALI ORNEK" || plates[2].AdiSoyadi != "AYSE ORNEK" {
+		t.Errorf("ParseAll() = %+v, %+v, %+v; want the pair of page 1 and the plate of page 2",
+			plates[0], plates[1], plates[2])
+	}
+}
+
+func TestParseAllNoSplitAcrossPages(t *testing.T) {
+	// Plates on separate pages at different positions would look side by side if the rows
+	// of both pages were pooled
+	left := plateContent(40, [][2]string{{"ADI SOYADI", "ALI ORNEK"}})
+	right := plateContent(460, [][2]string{{"ADI SOYADI", "AYSE ORNEK"}})
+
+	parser := NewParser()
+	parser.SetBackend(&fakeBackend{pages: []PageText{layoutPage(1, left), layoutPage(2, right)}})
+	plates, err := parser.ParseAll(bytes.NewReader(nil))
+	if err != nil {
+		t.Fatalf("ParseAll() error = %v", err)
+	}
+	if len(plates) != 1 {
+		t.Errorf("ParseAll() returned %d plates, want 1 for a document with no split page", len(plates))
+	}
+}
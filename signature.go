@@ -0,0 +1,131 @@
+package vergilevhasi
+
+import (
+	"crypto/x509"
+	"encoding/asn1"
+	"strings"
+
+	"github.com/pdfcpu/pdfcpu/pkg/pdfcpu/types"
+)
+
+// pdfSignature is the digital signature (e-imza) found in a PDF
+type pdfSignature struct {
+	signed bool
+
+	// signer is the signer's name, empty when the signature doesn't reveal it
+	signer string
+}
+
+// findSignature looks for a signed signature field in the catalog's AcroForm, or a
+// certification signature in its /Perms. The signer is the signature dictionary's /Name,
+// else the subject of the certificate embedded in its /Contents.
+func findSignature(r objectResolver, catalog types.Dict) pdfSignature {
+	f := newFormText(r)
+	var sigs []types.Dict
+	if acroForm := f.dict(catalog["AcroForm"]); acroForm != nil {
+		sigs = signatureValues(f, f.array(acroForm["Fields"]), false, 0)
+	}
+	if perms := f.dict(catalog["Perms"]); perms != nil {
+		if sig := f.dict(perms["DocMDP"]); sig != nil {
+			sigs = append(sigs, sig)
+		}
+	}
+	if len(sigs) == 0 {
+		return pdfSignature{}
+	}
+
+	sig := pdfSignature{signed: true}
+	for _, v := range sigs {
+		if name, ok := f.textString(v["Name"]); ok && strings.TrimSpace(name) != "" {
+			sig.signer = strings.TrimSpace(name)
+			return sig
+		}
+	}
+	for _, v := range sigs {
+		if contents, ok := f.deref(v["Contents"]).(types.HexLiteral); ok {
+			if name := pkcs7SignerName(hexToBytes(string(contents))); name != "" {
+				sig.signer = name
+				return sig
+			}
+		}
+	}
+	return sig
+}
+
+// signatureValues returns the signature dictionaries (/V) of the signed signature fields.
+// The field type /FT is inherited by kids; isSig tells whether the parent is a signature field.
+func signatureValues(f *formText, fields types.Array, isSig bool, depth int) []types.Dict {
+	if depth >= maxFieldDepth {
+		return nil
+	}
+	var sigs []types.Dict
+	for _, obj := range fields {
+		field := f.dict(obj)
+		if field == nil {
+			continue
+		}
+		fieldIsSig := isSig
+		if ft, ok := f.deref(field["FT"]).(types.Name); ok {
+			fieldIsSig = ft == "Sig"
+		}
+		if v := f.dict(field["V"]); fieldIsSig && v != nil {
+			sigs = append(sigs, v)
+		}
+		sigs = append(sigs, signatureValues(f, f.array(field["Kids"]), fieldIsSig, depth+1)...)
+	}
+	return sigs
+}
+
+// pkcs7ContentInfo and pkcs7SignedData are the parts of a CMS/PKCS#7 signature needed to
+// reach its certificates
+type pkcs7ContentInfo struct {
+	ContentType asn1.ObjectIdentifier
+	Content     asn1.RawValue `asn1:"explicit,optional,tag:0"`
+}
+
+type pkcs7SignedData struct {
+	Version          int
+	DigestAlgorithms asn1.RawValue
+	ContentInfo      asn1.RawValue
+	Certificates     asn1.RawValue `asn1:"optional,tag:0"`
+	CRLs             asn1.RawValue `asn1:"optional,tag:1"`
+	SignerInfos      asn1.RawValue
+}
+
+// pkcs7SignerName returns the subject common name of the signer's certificate in a
+// DER-encoded PKCS#7 signature, skipping CA certificates of the chain, or "". The
+// signature value of /Contents is zero-padded, so trailing bytes are ignored.
+func pkcs7SignerName(der []byte) string {
+	var info pkcs7ContentInfo
+	if _, err := asn1.Unmarshal(der, &info); err != nil {
+		return ""
+	}
+	var sd pkcs7SignedData
+	if _, err := asn1.Unmarshal(info.Content.Bytes, &sd); err != nil {
+		return ""
+	}
+
+	var fallback string
+	for rest := sd.Certificates.Bytes; len(rest) > 0; {
+		var raw asn1.RawValue
+		var err error
+		if rest, err = asn1.Unmarshal(rest, &raw); err != nil {
+			break
+		}
+		cert, err := x509.ParseCertificate(raw.FullBytes)
+		if err != nil {
+			continue
+		}
+		name := strings.TrimSpace(cert.Subject.CommonName)
+		if name == "" {
+			continue
+		}
+		if !cert.IsCA {
+			return name
+		}
+		if fallback == "" {
+			fallback = name
+		}
+	}
+	return fallback
+}
@@ -0,0 +1,140 @@
+package vergilevhasi
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/asn1"
+	"encoding/hex"
+	"math/big"
+	"testing"
+	"time"
+
+	"github.com/pdfcpu/pdfcpu/pkg/pdfcpu/types"
+)
+
+// selfSignedCert returns a DER certificate for commonName
+func selfSignedCert(t *testing.T, commonName string, isCA bool) []byte {
+	t.Helper()
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	tmpl := &x509.Certificate{
+		SerialNumber:          big.NewInt(1),
+		Subject:               pkix.Name{CommonName: commonName},
+		NotBefore:             time.Now().Add(-time.Hour),
+		NotAfter:              time.Now().Add(time.Hour),
+		BasicConstraintsValid: true,
+		IsCA:                  isCA,
+	}
+	der, err := x509.CreateCertificate(rand.Reader, tmpl, tmpl, &key.PublicKey, key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return der
+}
+
+// pkcs7Signature wraps certificates in a PKCS#7 SignedData the way a PDF signature's
+// /Contents carries them, zero padded like the reserved space in a signed PDF
+func pkcs7Signature(t *testing.T, certs ...[]byte) []byte {
+	t.Helper()
+	var certBytes []byte
+	for _, c := range certs {
+		certBytes = append(certBytes, c...)
+	}
+	sd, err := asn1.Marshal(pkcs7SignedData{
+		Version:          1,
+		DigestAlgorithms: asn1.RawValue{Tag: asn1.TagSet, Class: asn1.ClassUniversal, IsCompound: true},
+		ContentInfo:      asn1.RawValue{FullBytes: []byte{0x30, 0x0b, 0x06, 0x09, 0x2a, 0x86, 0x48, 0x86, 0xf7, 0x0d, 0x01, 0x07, 0x01}},
+		Certificates:     asn1.RawValue{Tag: 0, Class: asn1.ClassContextSpecific, IsCompound: true, Bytes: certBytes},
+		SignerInfos:      asn1.RawValue{Tag: asn1.TagSet, Class: asn1.ClassUniversal, IsCompound: true},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	der, err := asn1.Marshal(pkcs7ContentInfo{
+		ContentType: asn1.ObjectIdentifier{1, 2, 840, 113549, 1, 7, 2},
+		Content:     asn1.RawValue{Tag: 0, Class: asn1.ClassContextSpecific, IsCompound: true, Bytes: sd},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return append(der, make([]byte, 64)...)
+}
+
+func TestFindSignature(t *testing.T) {
+	contents := types.HexLiteral(hex.EncodeToString(pkcs7Signature(t,
+		selfSignedCert(t, "Kamu SM Nitelikli Elektronik Sertifika Hizmet Sağlayıcısı", true),
+		selfSignedCert(t, "ALİ ÖRNEK", false))))
+
+	tests := []struct {
+		name       string
+		objects    objectTable
+		catalog    types.Dict
+		wantSigned bool
+		wantSigner string
+	}{
+		{
+			name: "SignerName",
+			objects: objectTable{
+				1: types.Dict{"FT": types.Name("Sig"), "T": types.StringLiteral("Imza1"), "V": ref(2)},
+				2: types.Dict{"Type": types.Name("Sig"), "Name": types.HexLiteral("FEFF00D60052004E0045004B00200056004500520047013000200044004101300052004500530130")},
+			},
+			catalog:    types.Dict{"AcroForm": types.Dict{"Fields": types.Array{ref(1)}}},
+			wantSigned: true,
+			wantSigner: "ÖRNEK VERGİ DAİRESİ",
+		},
+		{
+			name: "SignerFromCertificate",
+			objects: objectTable{
+				1: types.Dict{"FT": types.Name("Sig"), "Kids": types.Array{ref(2)}},
+				2: types.Dict{"Subtype": types.Name("Widget"), "V": ref(3)},
+				3: types.Dict{"Type": types.Name("Sig"), "Contents": contents},
+			},
+			catalog:    types.Dict{"AcroForm": types.Dict{"Fields": types.Array{ref(1)}}},
+			wantSigned: true,
+			wantSigner: "ALİ ÖRNEK",
+		},
+		{
+			name: "CertificationSignature",
+			objects: objectTable{
+				1: types.Dict{"Type": types.Name("Sig"), "Contents": types.HexLiteral("00")},
+			},
+			catalog:    types.Dict{"Perms": types.Dict{"DocMDP": ref(1)}},
+			wantSigned: true,
+		},
+		{
+			name: "UnsignedField",
+			objects: objectTable{
+				1: types.Dict{"FT": types.Name("Sig"), "T": types.StringLiteral("Imza1")},
+				2: types.Dict{"FT": types.Name("Tx"), "V": types.StringLiteral("1234567890")},
+			},
+			catalog: types.Dict{"AcroForm": types.Dict{"Fields": types.Array{ref(1), ref(2)}}},
+		},
+		{
+			name:    "NoForm",
+			objects: objectTable{},
+			catalog: types.Dict{},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := findSignature(tt.objects, tt.catalog)
+			if got.signed != tt.wantSigned || got.signer != tt.wantSigner {
+				t.Errorf("findSignature() = %+v, want signed %v, signer %q", got, tt.wantSigned, tt.wantSigner)
+			}
+		})
+	}
+}
+
+func TestPKCS7SignerNameMalformed(t *testing.T) {
+	for _, der := range [][]byte{nil, {0x30, 0x03, 0x02}, pkcs7Signature(t)} {
+		if got := pkcs7SignerName(der); got != "" {
+			t.Errorf("pkcs7SignerName(%x) = %q, want \"\"", der, got)
+		}
+	}
+}
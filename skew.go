@@ -0,0 +1,142 @@
+package vergilevhasi
+
+import (
+	"fmt"
+	"image"
+	"image/color"
+	"math"
+)
+
+const (
+	// minSkewDegrees is the smallest barcode tilt worth correcting; decoders read bars
+	// tilted less than this without help
+	minSkewDegrees = 1.0
+
+	// skewBinsPerDegree is the resolution of the gradient orientation histogram
+	skewBinsPerDegree = 4
+
+	// minSkewGradient is the Sobel magnitude below which a pixel is not on a bar edge
+	minSkewGradient = 200
+)
+
+// estimateBarcodeSkew estimates how far the bars of a barcode are tilted from the nearest
+// cardinal orientation, in degrees within [-45, 45). The intensity gradient at the bar
+// edges points across the bars, so the peak of the magnitude-weighted histogram of
+// gradient orientations gives the direction across the bars. It returns false when the
+// image has too few edges for an estimate.
+func estimateBarcodeSkew(img image.Image) (float64, bool) {
+	gray := toGrayscale(img)
+	b := gray.Bounds()
+	at := func(x, y int) float64 { return float64(gray.GrayAt(x, y).Y) }
+
+	hist := make([]float64, 90*skewBinsPerDegree)
+	total := 0.0
+	for y := b.Min.Y + 1; y < b.Max.Y-1; y++ {
+		for x := b.Min.X + 1; x < b.Max.X-1; x++ {
+			gx := at(x+1, y-1) + 2*at(x+1, y) + at(x+1, y+1) - at(x-1, y-1) - 2*at(x-1, y) - at(x-1, y+1)
+			gy := at(x-1, y+1) + 2*at(x, y+1) + at(x+1, y+1) - at(x-1, y-1) - 2*at(x, y-1) - at(x+1, y-1)
+			mag := math.Hypot(gx, gy)
+			if mag < minSkewGradient {
+				continue
+			}
+			// Fold the orientation onto [-45, 45): edges of opposite polarity and bars
+			// of a vertical barcode give the same skew
+			angle := math.Mod(math.Atan2(gy, gx)*180/math.Pi+405, 90) - 45
+			bin := min(int((angle+45)*skewBinsPerDegree), len(hist)-1)
+			hist[bin] += mag
+			total += mag
+		}
+	}
+	if total == 0 {
+		return 0, false
+	}
+
+	peak := 0
+	for i, w := range hist {
+		if w > hist[peak] {
+			peak = i
+		}
+	}
+
+	// Refine the peak with the weighted mean of its neighbourhood, wrapping around the
+	// ends of the histogram
+	const reach = 2 * skewBinsPerDegree
+	sum, weight := 0.0, 0.0
+	for d := -reach; d <= reach; d++ {
+		w := hist[(peak+d+len(hist))%len(hist)]
+		sum += float64(d) * w
+		weight += w
+	}
+	angle := (float64(peak)+0.5+sum/weight)/skewBinsPerDegree - 45
+	if angle >= 45 {
+		angle -= 90
+	} else if angle < -45 {
+		angle += 90
+	}
+	return angle, true
+}
+
+// rotateImageAngle rotates img by degrees about its centre, turning a direction at angle
+// a (in image coordinates, y down) to a+degrees. The canvas grows to fit the rotated
+// image, uncovered pixels are white, and pixels are sampled bilinearly.
+func rotateImageAngle(img image.Image, degrees float64) *image.Gray {
+	gray := toGrayscale(img)
+	b := gray.Bounds()
+	rad := degrees * math.Pi / 180
+	sin, cos := math.Sin(rad), math.Cos(rad)
+
+	w, h := float64(b.Dx()), float64(b.Dy())
+	outW := int(math.Ceil(math.Abs(w*cos) + math.Abs(h*sin)))
+	outH := int(math.Ceil(math.Abs(w*sin) + math.Abs(h*cos)))
+	out := image.NewGray(image.Rect(0, 0, outW, outH))
+
+	sample := func(x, y int) float64 {
+		if x < b.Min.X || x >= b.Max.X || y < b.Min.Y || y >= b.Max.Y {
+			return 255
+		}
+		return float64(gray.GrayAt(x, y).Y)
+	}
+
+	cx, cy := float64(b.Min.X)+w/2, float64(b.Min.Y)+h/2
+	ocx, ocy := float64(outW)/2, float64(outH)/2
+	for y := 0; y < outH; y++ {
+		for x := 0; x < outW; x++ {
+			// Inverse rotation back into the source
+			dx, dy := float64(x)+0.5-ocx, float64(y)+0.5-ocy
+			sx := dx*cos + dy*sin + cx - 0.5
+			sy := -dx*sin + dy*cos + cy - 0.5
+
+			x0, y0 := int(math.Floor(sx)), int(math.Floor(sy))
+			fx, fy := sx-float64(x0), sy-float64(y0)
+			v := sample(x0, y0)*(1-fx)*(1-fy) + sample(x0+1, y0)*fx*(1-fy) +
+				sample(x0, y0+1)*(1-fx)*fy + sample(x0+1, y0+1)*fx*fy
+			out.SetGray(x, y, color.Gray{Y: uint8(math.Round(v))})
+		}
+	}
+	return out
+}
+
+// scanSkewedBarcode decodes a barcode tilted off the cardinal orientations. The tilt is
+// estimated on the detected region, which may cover only part of a tilted barcode, so
+// the area around the region is straightened and the barcode is located again in it.
+func (p *OCRParser) scanSkewedBarcode(img image.Image, region image.Rectangle) (string, bool, error) {
+	if p.budgetExceeded() {
+		return "", false, p.budgetError()
+	}
+	skew, ok := estimateBarcodeSkew(cropImage(img, region, 0))
+	if !ok || math.Abs(skew) < minSkewDegrees {
+		return "", false, fmt.Errorf("no barcode skew to correct")
+	}
+	if p.debug {
+		fmt.Printf("Barcode skew estimated at %.1f degrees\n", skew)
+	}
+
+	area := region.Inset(-max(region.Dx(), region.Dy())).Intersect(img.Bounds())
+	straight := rotateImageAngle(cropImage(img, area, 0), -skew)
+	if found, ok := findBarcodeRegion(straight); ok {
+		if vkn, checkDigit, err := p.scanCode128Barcode(cropImage(straight, found, p.quietZone)); err == nil && vkn != "" {
+			return vkn, checkDigit, nil
+		}
+	}
+	return p.scanCode128Barcode(straight)
+}
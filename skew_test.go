@@ -0,0 +1,73 @@
+package vergilevhasi
+
+import (
+	"image"
+	"image/color"
+	"image/draw"
+	"math"
+	"testing"
+
+	"github.com/makiuchi-d/gozxing"
+	"github.com/makiuchi-d/gozxing/oned"
+)
+
+// tiltedBarcodePage renders a low Code 128 barcode tilted by degrees on a white page, so
+// that no horizontal scan line crosses every bar
+func tiltedBarcodePage(t *testing.T, contents string, degrees float64) (page, barcode *image.Gray) {
+	t.Helper()
+
+	matrix, err := oned.NewCode128Writer().Encode(contents, gozxing.BarcodeFormat_CODE_128, 300, 32, nil)
+	if err != nil {
+		t.Fatalf("failed to encode barcode: %v", err)
+	}
+	flat := newWhiteGray(matrix.GetWidth(), matrix.GetHeight())
+	for y := 0; y < matrix.GetHeight(); y++ {
+		for x := 0; x < matrix.GetWidth(); x++ {
+			if matrix.Get(x, y) {
+				flat.SetGray(x, y, color.Gray{0})
+			}
+		}
+	}
+
+	barcode = rotateImageAngle(flat, degrees)
+	page = newWhiteGray(600, 300)
+	draw.Draw(page, barcode.Bounds().Add(image.Pt(100, 80)), barcode, image.Point{}, draw.Src)
+	return page, barcode
+}
+
+func TestEstimateBarcodeSkew(t *testing.T) {
+	for _, degrees := range []float64{0, 3, 7, -10, 90} {
+		_, barcode := tiltedBarcodePage(t, "1234567890", degrees)
+
+		// A vertical barcode is not skewed
+		want := math.Mod(degrees+45, 90) - 45
+		skew, ok := estimateBarcodeSkew(barcode)
+		if !ok || math.Abs(skew-want) > 1 {
+			t.Errorf("estimateBarcodeSkew() of a barcode tilted %v degrees = %.2f, %v; want %v", degrees, skew, ok, want)
+		}
+	}
+
+	if _, ok := estimateBarcodeSkew(newWhiteGray(100, 40)); ok {
+		t.Error("estimateBarcodeSkew() of a blank image succeeded, want false")
+	}
+}
+
+func TestScanImageBarcodeTilted(t *testing.T) {
+	parser, err := NewOCRParser()
+	if err != nil {
+		t.Fatalf("NewOCRParser() error = %v", err)
+	}
+
+	for _, degrees := range []float64{7, 10, -10} {
+		page, _ := tiltedBarcodePage(t, "1234567890", degrees)
+
+		// The cardinal rotations cannot straighten the bars
+		if vkn, _, err := parser.scanBarcode(page); err == nil {
+			t.Fatalf("test setup: scanBarcode() decoded %q at %v degrees without fine rotation", vkn, degrees)
+		}
+
+		if vkn, _, err := parser.scanImageBarcode(page); err != nil || vkn != "1234567890" {
+			t.Errorf("scanImageBarcode() at %v degrees = %q, %v; want %q", degrees, vkn, err, "1234567890")
+		}
+	}
+}
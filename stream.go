@@ -0,0 +1,111 @@
+package vergilevhasi
+
+import (
+	"context"
+	"io"
+	"reflect"
+	"strings"
+)
+
+// FieldEvent reports one extracted field, or the end of the parse, from ParseStream
+type FieldEvent struct {
+	// Field is the JSON name of the field, e.g. "vergi_kimlik_no"; empty on the final event
+	Field string
+
+	// Value holds the field with its VergiLevhasi type, e.g. a string for "adi_soyadi",
+	// []Faaliyet for "faaliyet_kodlari" or *time.Time for "ise_baslama_tarihi"
+	Value any
+
+	// Source is "image" for the VKN read from the barcode or digits before the text is
+	// parsed, and "text" for fields of the parsed result
+	Source string
+
+	// Done marks the final event, which carries either the complete Result or Err
+	Done   bool
+	Result *VergiLevhasi
+	Err    error
+}
+
+// ParseStream parses a tax plate PDF like Parse and reports its fields on the returned
+// channel, so a UI can render progressively. Only the VKN read from the barcode is
+// reported as it is found, before the text is parsed; the other fields are replayed from
+// the parsed result once the parse is done. The barcode VKN is reported again if the
+// text changes it; the last report wins. The last event has Done set and carries the
+// result or the error, and the channel is closed after it.
+//
+// Reading the PDF fails immediately with an error. Cancelling ctx stops the events and
+// closes the channel; the parse already running stops at its next step.
+func (p *Parser) ParseStream(ctx context.Context, reader io.ReadSeeker) (<-chan FieldEvent, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	data, err := p.readPDF(reader)
+	if err != nil {
+		return nil, err
+	}
+
+	events := make(chan FieldEvent)
+	go func() {
+		defer close(events)
+
+		// send gives up once ctx is done; the parse then fails with ctx's error and the
+		// events after it are not sent either
+		send := func(event FieldEvent) {
+			select {
+			case events <- event:
+			case <-ctx.Done():
+			}
+		}
+
+		imageVKN := ""
+		vl, err := p.parseData(ctx, data, func(vkn string) {
+			imageVKN = vkn
+			send(FieldEvent{Field: "vergi_kimlik_no", Value: vkn, Source: "image"})
+		})
+		if err != nil {
+			send(FieldEvent{Done: true, Err: err})
+			return
+		}
+
+		for _, event := range fieldEvents(vl) {
+			if event.Field == "vergi_kimlik_no" && event.Value == imageVKN {
+				continue
+			}
+			if ctx.Err() != nil {
+				return
+			}
+			send(event)
+		}
+		send(FieldEvent{Done: true, Result: vl})
+	}()
+
+	return events, nil
+}
+
+// streamDiagnostics are the JSON names of the VergiLevhasi fields that describe the parse
+// rather than the plate; ParseStream does not report them
+var streamDiagnostics = map[string]bool{
+	"field_pages":              true,
+	"warnings":                 true,
+	"is_image_only":            true,
+	"requires_ocr":             true,
+	"vkn_check_digit_verified": true,
+}
+
+// fieldEvents returns an event for each data field of vl that has a value, in the order
+// of the VergiLevhasi fields. The fields are found from their JSON tags, so new fields
+// are reported without changes here.
+func fieldEvents(vl *VergiLevhasi) []FieldEvent {
+	var events []FieldEvent
+	v := reflect.ValueOf(vl).Elem()
+	for i := range v.NumField() {
+		name, _, _ := strings.Cut(v.Type().Field(i).Tag.Get("json"), ",")
+		field := v.Field(i)
+		if name == "" || name == "-" || streamDiagnostics[name] || field.IsZero() ||
+			field.Kind() == reflect.Slice && field.Len() == 0 {
+			continue
+		}
+		events = append(events, FieldEvent{Field: name, Value: field.Interface(), Source: "text"})
+	}
+	return events
+}
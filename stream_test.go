@@ -0,0 +1,124 @@
+package vergilevhasi
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"image"
+	"testing"
+	"time"
+)
+
+func TestParseStream(t *testing.T) {
+	backend := &fakeBackend{
+		pages: []PageText{{Number: 1, Text: "Adı Soyadı: Ali Örnek\nVergi Dairesi: Örnek VD\n" +
+			"TC Kimlik No: 10000000146\nİşe Başlama Tarihi: 01.02.2020\nYILLIK GELİR VERGİSİ\n"}},
+		images: []image.Image{drawCode128(t, "1234567890")},
+	}
+	parser := NewParser()
+	parser.SetBackend(backend)
+
+	events, err := parser.ParseStream(context.Background(), bytes.NewReader([]byte("not a real pdf")))
+	if err != nil {
+		t.Fatalf("ParseStream() error = %v", err)
+	}
+
+	var got []FieldEvent
+	for event := range events {
+		got = append(got, event)
+	}
+	if len(got) == 0 {
+		t.Fatal("ParseStream() sent no events")
+	}
+
+	// The barcode VKN is known before the text is parsed
+	if first := got[0]; first.Field != "vergi_kimlik_no" || first.Value != "1234567890" || first.Source != "image" {
+		t.Errorf("first event = %+v, want the barcode VKN", first)
+	}
+
+	last := got[len(got)-1]
+	if !last.Done || last.Err != nil || last.Result == nil {
+		t.Fatalf("last event = %+v, want Done with a result", last)
+	}
+
+	fields := make(map[string]any)
+	for _, event := range got[:len(got)-1] {
+		if event.Done {
+			t.Errorf("event %+v before the last one is marked Done", event)
+		}
+		fields[event.Field] = event.Value
+	}
+	want := map[string]any{
+		"vergi_kimlik_no": "1234567890",
+		"adi_soyadi":      "Ali Örnek",
+		"vergi_dairesi":   "Örnek VD",
+		"tc_kimlik_no":    "10000000146",
+		"mukellef_turu":   MukellefTuruBireysel,
+	}
+	for field, value := range want {
+		if fields[field] != value {
+			t.Errorf("field %s = %v, want %v", field, fields[field], value)
+		}
+	}
+	if _, ok := fields["ise_baslama_tarihi"]; !ok {
+		t.Error("no ise_baslama_tarihi event")
+	}
+}
+
+func TestParseStreamError(t *testing.T) {
+	parser := NewParser()
+	parser.SetMaxPDFSize(4)
+	if _, err := parser.ParseStream(context.Background(), bytes.NewReader([]byte("not a real pdf"))); err == nil {
+		t.Error("ParseStream() of an oversized PDF succeeded, want an error")
+	}
+
+	parser = NewParser()
+	parser.SetBackend(&fakeBackend{err: errors.New("backend failure")})
+	events, err := parser.ParseStream(context.Background(), bytes.NewReader([]byte("not a real pdf")))
+	if err != nil {
+		t.Fatalf("ParseStream() error = %v", err)
+	}
+	var last FieldEvent
+	for event := range events {
+		last = event
+	}
+	if !last.Done || last.Err == nil {
+		t.Errorf("last event = %+v, want Done with the parse error", last)
+	}
+}
+
+func TestFieldEventsCoverDataFields(t *testing.T) {
+	start := time.Now()
+	full := &VergiLevhasi{
+		AdiSoyadi: "a", TicaretUnvani: "a", HamUnvan: "a", Ortaklar: []string{"a"}, IsYeriAdresi: "a", IsYeriAdresleri: []string{"a"},
+		VergiTuru: []string{"a"}, VergiUsulu: VergiUsuluBasit, GelirUnsuru: "a", FaaliyetKodlari: []Faaliyet{{Kod: "1"}}, SektorGrubu: "a",
+		VergiDairesi: "a", VergiKimlikNo: "a", SubeKodu: "a", IsYeriTuru: IsYeriTuruSube, TCKimlikNo: "a",
+		Uyruk: "a", PasaportNo: "a", KayitNo: "a", YabanciVergiNo: "a", IsPotansiyelVKN: true,
+		UsesTCKNAsVergiNo: true, IseBaslamaTarihi: &start, OlusturulmaTarihi: &start, OkcSeriNolari: []string{"a"},
+		ImzaVar: true, ImzalayanAd: "a",
+		GecmisMatra: []Matrah{{Yil: 1}}, DocumentType: DocumentTypeVergiLevhasi, MukellefTuru: MukellefTuruBireysel,
+		MukellefTuruGuveni: 1,
+		// Diagnostics are not reported
+		Warnings: []string{"a"}, IsImageOnly: true, VKNCheckDigitVerified: true, RawText: "a",
+	}
+
+	// Every data field, the same set ToMap has a key for, is reported with its own type
+	events := fieldEvents(full)
+	keys := full.ToMap()
+	if len(events) != len(keys) {
+		t.Errorf("fieldEvents() = %d events, want %d", len(events), len(keys))
+	}
+	for _, event := range events {
+		if _, ok := keys[event.Field]; !ok {
+			t.Errorf("fieldEvents() reports %q, not a data field", event.Field)
+		}
+		if event.Field == "ise_baslama_tarihi" && event.Value != &start {
+			t.Errorf("ise_baslama_tarihi = %v, want the *time.Time of the result", event.Value)
+		}
+	}
+
+	// Empty lists are not reported
+	if events := fieldEvents(&VergiLevhasi{VergiTuru: []string{}}); len(events) != 0 {
+		t.Errorf("fieldEvents() = %+v, want no events for an empty result", events)
+	}
+}
@@ -0,0 +1,27 @@
+module github.com/alparslanahmed/vergilevhasi-parser-go/tesseract
+
+go 1.24.12
+
+require (
+	github.com/alparslanahmed/vergilevhasi-parser-go v1.1.0
+	github.com/otiai10/gosseract/v2 v2.4.1
+	golang.org/x/image v0.32.0
+)
+
+require (
+	github.com/clipperhouse/uax29/v2 v2.2.0 // indirect
+	github.com/hhrutter/lzw v1.0.0 // indirect
+	github.com/hhrutter/pkcs7 v0.2.0 // indirect
+	github.com/hhrutter/tiff v1.0.2 // indirect
+	github.com/makiuchi-d/gozxing v0.1.1 // indirect
+	github.com/mattn/go-runewidth v0.0.19 // indirect
+	github.com/pdfcpu/pdfcpu v0.11.1 // indirect
+	github.com/pkg/errors v0.9.1 // indirect
+	golang.org/x/crypto v0.45.0 // indirect
+	golang.org/x/text v0.33.0 // indirect
+	golang.org/x/xerrors v0.0.0-20200804184101-5ec99f83aff1 // indirect
+	gopkg.in/yaml.v2 v2.4.0 // indirect
+)
+
+// Builds in this repository use the parser next to it, which has SetTextRecognizer
+replace github.com/alparslanahmed/vergilevhasi-parser-go => ../
@@ -0,0 +1,53 @@
+// Package tesseract reads tax plate images with Tesseract through gosseract. It is a
+// separate module so that the parser itself stays pure Go; importing it installs
+// Recognize as the parser's text recognizer:
+//
+//	import _ "github.com/alparslanahmed/vergilevhasi-parser-go/tesseract"
+//
+// gosseract binds the Tesseract C++ library with cgo, so building this package, and
+// running its tests, needs a C compiler and the libtesseract and leptonica development
+// headers (libtesseract-dev and libleptonica-dev on Debian and Ubuntu); without them the
+// package does not compile. Reading plates also needs the Turkish trained data
+// (tesseract-ocr-tur).
+package tesseract
+
+import (
+	"bytes"
+	"fmt"
+	"image"
+	"image/png"
+
+	vergilevhasi "github.com/alparslanahmed/vergilevhasi-parser-go"
+	"github.com/otiai10/gosseract/v2"
+)
+
+// Language is the trained data Tesseract reads plates with
+const Language = "tur"
+
+func init() {
+	vergilevhasi.SetTextRecognizer(Recognize)
+}
+
+// Recognize reads the text of an image with Tesseract. On any error the parser falls
+// back to its pure-Go recognizers.
+func Recognize(img image.Image) (string, error) {
+	var buf bytes.Buffer
+	if err := png.Encode(&buf, img); err != nil {
+		return "", fmt.Errorf("failed to encode image for tesseract: %w", err)
+	}
+
+	client := gosseract.NewClient()
+	defer client.Close()
+
+	if err := client.SetLanguage(Language); err != nil {
+		return "", fmt.Errorf("failed to set tesseract language: %w", err)
+	}
+	if err := client.SetImageFromBytes(buf.Bytes()); err != nil {
+		return "", fmt.Errorf("failed to load image into tesseract: %w", err)
+	}
+	text, err := client.Text()
+	if err != nil {
+		return "", fmt.Errorf("tesseract failed: %w", err)
+	}
+	return text, nil
+}
@@ -0,0 +1,56 @@
+package tesseract
+
+import (
+	"image"
+	"image/color"
+	"image/draw"
+	"strings"
+	"testing"
+
+	vergilevhasi "github.com/alparslanahmed/vergilevhasi-parser-go"
+	"golang.org/x/image/font"
+	"golang.org/x/image/font/basicfont"
+	"golang.org/x/image/math/fixed"
+)
+
+// renderLines draws lines of text in the basic 7x13 font, enlarged scale times
+func renderLines(lines []string, scale int) *image.Gray {
+	small := image.NewGray(image.Rect(0, 0, 300, 16*len(lines)+8))
+	draw.Draw(small, small.Bounds(), image.White, image.Point{}, draw.Src)
+	d := font.Drawer{Dst: small, Src: image.Black, Face: basicfont.Face7x13}
+	for i, line := range lines {
+		d.Dot = fixed.P(8, 16*(i+1))
+		d.DrawString(line)
+	}
+
+	b := small.Bounds()
+	img := image.NewGray(image.Rect(0, 0, b.Dx()*scale, b.Dy()*scale))
+	for y := 0; y < img.Bounds().Dy(); y++ {
+		for x := 0; x < img.Bounds().Dx(); x++ {
+			img.SetGray(x, y, color.Gray{small.GrayAt(x/scale, y/scale).Y})
+		}
+	}
+	return img
+}
+
+func TestRecognize(t *testing.T) {
+	img := renderLines([]string{"VERGI DAIRESI: CANKAYA", "VKN: 1234567890"}, 4)
+
+	text, err := Recognize(img)
+	if err != nil {
+		t.Fatalf("Recognize() error = %v", err)
+	}
+	if !strings.Contains(text, "CANKAYA") || !strings.Contains(text, "1234567890") {
+		t.Errorf("Recognize() = %q, want the tax office and the VKN", text)
+	}
+
+	// Importing the package installs the recognizer
+	parser, err := vergilevhasi.NewOCRParser()
+	if err != nil {
+		t.Fatalf("NewOCRParser() error = %v", err)
+	}
+	vkn, err := parser.ExtractVKNFromImageData(img)
+	if err != nil || vkn != "1234567890" {
+		t.Errorf("ExtractVKNFromImageData() = %q, %v, want 1234567890", vkn, err)
+	}
+}
@@ -0,0 +1,405 @@
+package vergilevhasi
+
+import (
+	"bytes"
+	"fmt"
+	"image"
+	"math"
+	"sort"
+	"strings"
+)
+
+// glyph is a 5x7 bitmap template for the lightweight text recognizer. Turkish letters
+// add an accent row above the body (İ, Ö, Ü, Ğ) or a cedilla row below it (Ç, Ş).
+type glyph struct {
+	r     rune
+	body  string // 7 rows of 5 columns, space separated; '#' is ink
+	above string // optional accent row, drawn two rows above the body
+	below string // optional row drawn directly under the body
+}
+
+// glyphSet covers the uppercase letters, digits and punctuation printed on tax plates
+var glyphSet = []glyph{
+	{r: 'A', body: ".###. #...# #...# ##### #...# #...# #...#"},
+	{r: 'B', body: "####. #...# #...# ####. #...# #...# ####."},
+	{r: 'C', body: ".###. #...# #.... #.... #.... #...# .###."},
+	{r: 'Ç', body: ".###. #...# #.... #.... #.... #...# .###.", below: "..#.."},
+	{r: 'D', body: "####. #...# #...# #...# #...# #...# ####."},
+	{r: 'E', body: "##### #.... #.... ####. #.... #.... #####"},
+	{r: 'F', body: "##### #.... #.... ####. #.... #.... #...."},
+	{r: 'G', body: ".###. #...# #.... #.### #...# #...# .###."},
+	{r: 'Ğ', body: ".###. #...# #.... #.### #...# #...# .###.", above: ".###."},
+	{r: 'H', body: "#...# #...# #...# ##### #...# #...# #...#"},
+	{r: 'I', body: ".###. ..#.. ..#.. ..#.. ..#.. ..#.. .###."},
+	{r: 'İ', body: ".###. ..#.. ..#.. ..#.. ..#.. ..#.. .###.", above: "..#.."},
+	{r: 'J', body: "..### ...#. ...#. ...#. ...#. #..#. .##.."},
+	{r: 'K', body: "#...# #..#. #.#.. ##... #.#.. #..#. #...#"},
+	{r: 'L', body: "#.... #.... #.... #.... #.... #.... #####"},
+	{r: 'M', body: "#...# ##.## #.#.# #.#.# #...# #...# #...#"},
+	{r: 'N', body: "#...# #...# ##..# #.#.# #..## #...# #...#"},
+	{r: 'O', body: ".###. #...# #...# #...# #...# #...# .###."},
+	{r: 'Ö', body: ".###. #...# #...# #...# #...# #...# .###.", above: ".#.#."},
+	{r: 'P', body: "####. #...# #...# ####. #.... #.... #...."},
+	{r: 'Q', body: ".###. #...# #...# #...# #.#.# #..#. .##.#"},
+	{r: 'R', body: "####. #...# #...# ####. #.#.. #..#. #...#"},
+	{r: 'S', body: ".#### #.... #.... .###. ....# ....# ####."},
+	{r: 'Ş', body: ".#### #.... #.... .###. ....# ....# ####.", below: "..#.."},
+	{r: 'T', body: "##### ..#.. ..#.. ..#.. ..#.. ..#.. ..#.."},
+	{r: 'U', body: "#...# #...# #...# #...# #...# #...# .###."},
+	{r: 'Ü', body: "#...# #...# #...# #...# #...# #...# .###.", above: ".#.#."},
+	{r: 'V', body: "#...# #...# #...# #...# #...# .#.#. ..#.."},
+	{r: 'W', body: "#...# #...# #...# #.#.# #.#.# #.#.# .#.#."},
+	{r: 'X', body: "#...# #...# .#.#. ..#.. .#.#. #...# #...#"},
+	{r: 'Y', body: "#...# #...# .#.#. ..#.. ..#.. ..#.. ..#.."},
+	{r: 'Z', body: "##### ....# ...#. ..#.. .#... #.... #####"},
+	{r: '0', body: ".###. #...# #..## #.#.# ##..# #...# .###."},
+	{r: '1', body: "..#.. .##.. ..#.. ..#.. ..#.. ..#.. .###."},
+	{r: '2', body: ".###. #...# ....# ...#. ..#.. .#... #####"},
+	{r: '3', body: "##### ...#. ..#.. ...#. ....# #...# .###."},
+	{r: '4', body: "...#. ..##. .#.#. #..#. ##### ...#. ...#."},
+	{r: '5', body: "##### #.... ####. ....# ....# #...# .###."},
+	{r: '6', body: "..##. .#... #.... ####. #...# #...# .###."},
+	{r: '7', body: "##### ....# ...#. ..#.. .#... .#... .#..."},
+	{r: '8', body: ".###. #...# #...# .###. #...# #...# .###."},
+	{r: '9', body: ".###. #...# #...# .#### ....# ...#. .##.."},
+	{r: '.', body: "..... ..... ..... ..... ..... .##.. .##.."},
+	{r: ',', body: "..... ..... ..... ..... .##.. ..#.. .#..."},
+	{r: ':', body: "..... .##.. .##.. ..... .##.. .##.. ....."},
+	{r: '-', body: "..... ..... ..... ##### ..... ..... ....."},
+	{r: '/', body: "....# ....# ...#. ..#.. .#... #.... #...."},
+}
+
+// glyphRows returns the full bitmap of a glyph: the accent rows (if any), the 7 body
+// rows and the row below (if any), together with the index of the first body row
+func (g glyph) glyphRows() ([]string, int) {
+	var rows []string
+	top := 0
+	if g.above != "" {
+		rows = append(rows, g.above, ".....")
+		top = 2
+	}
+	rows = append(rows, strings.Fields(g.body)...)
+	if g.below != "" {
+		rows = append(rows, g.below)
+	}
+	return rows, top
+}
+
+// glyphBitmap is a trimmed binary bitmap, indexed [row][col]
+type glyphBitmap [][]bool
+
+// trimmed returns the glyph's bitmap cropped to its ink
+func (g glyph) trimmed() glyphBitmap {
+	rows, _ := g.glyphRows()
+	minR, maxR, minC, maxC := len(rows), -1, 5, -1
+	for r, row := range rows {
+		for c, ch := range row {
+			if ch == '#' {
+				minR, maxR = min(minR, r), max(maxR, r)
+				minC, maxC = min(minC, c), max(maxC, c)
+			}
+		}
+	}
+
+	bm := make(glyphBitmap, 0, maxR-minR+1)
+	for r := minR; r <= maxR; r++ {
+		line := make([]bool, 0, maxC-minC+1)
+		for c := minC; c <= maxC; c++ {
+			line = append(line, rows[r][c] == '#')
+		}
+		bm = append(bm, line)
+	}
+	return bm
+}
+
+// glyphTemplates holds the trimmed bitmaps of glyphSet, built once
+var glyphTemplates = func() []glyphBitmap {
+	templates := make([]glyphBitmap, len(glyphSet))
+	for i, g := range glyphSet {
+		templates[i] = g.trimmed()
+	}
+	return templates
+}()
+
+// textRecognizer reads the text of a page image with an external OCR engine. It is nil
+// unless one is installed with SetTextRecognizer.
+var textRecognizer func(img image.Image) (string, error)
+
+// SetTextRecognizer installs an external OCR engine that ParseImage and
+// ExtractVKNFromImageData read image text with before the pure-Go recognizers, such as
+// the Tesseract engine of the tesseract submodule; on an error or empty text they fall
+// back to the pure-Go ones. nil removes it. It must not be called while images are read.
+func SetTextRecognizer(recognize func(img image.Image) (string, error)) {
+	textRecognizer = recognize
+}
+
+// recognizeImageText reads the printed text of an image with the installed OCR engine,
+// falling back to the template recognizer when there is none or it reads nothing
+func (p *OCRParser) recognizeImageText(img image.Image) string {
+	if textRecognizer != nil {
+		text, err := textRecognizer(img)
+		if err == nil && strings.TrimSpace(text) != "" {
+			return text
+		}
+		if p.debug {
+			fmt.Printf("Text recognizer failed, using templates: %v\n", err)
+		}
+	}
+	return recognizeText(toGrayscale(img))
+}
+
+// maxGlyphMismatch is the largest fraction of differing cells accepted for a glyph match
+const maxGlyphMismatch = 0.2
+
+// recognizeText reads uppercase printed text from a grayscale image, one line of output
+// per text line. It is a lightweight template matcher for cleanly rendered plates, not a
+// general-purpose OCR engine: characters must be upright and separated by blank columns.
+func recognizeText(gray *image.Gray) string {
+	ink := func(x, y int) bool {
+		return gray.GrayAt(x, y).Y < 128
+	}
+	bounds := gray.Bounds()
+
+	var lines []string
+	for _, band := range findTextLines(gray, ink) {
+		if line := recognizeLine(bounds.Min.X, bounds.Max.X, band, ink); line != "" {
+			lines = append(lines, line)
+		}
+	}
+	return strings.Join(lines, "\n")
+}
+
+// rowBand is a horizontal band of image rows [top, bottom)
+type rowBand struct {
+	top, bottom int
+}
+
+// findTextLines splits the image into bands of inked rows. A thin band just above a
+// taller one (the dots and breves of İ, Ö, Ü, Ğ) is merged into the line below it.
+func findTextLines(gray *image.Gray, ink func(x, y int) bool) []rowBand {
+	bounds := gray.Bounds()
+
+	var bands []rowBand
+	inBand := false
+	for y := bounds.Min.Y; y < bounds.Max.Y; y++ {
+		hasInk := false
+		for x := bounds.Min.X; x < bounds.Max.X; x++ {
+			if ink(x, y) {
+				hasInk = true
+				break
+			}
+		}
+		switch {
+		case hasInk && !inBand:
+			bands = append(bands, rowBand{top: y})
+			inBand = true
+		case !hasInk && inBand:
+			bands[len(bands)-1].bottom = y
+			inBand = false
+		}
+	}
+	if inBand {
+		bands[len(bands)-1].bottom = bounds.Max.Y
+	}
+
+	var merged []rowBand
+	for i := 0; i < len(bands); i++ {
+		band := bands[i]
+		if i+1 < len(bands) {
+			next := bands[i+1]
+			height, nextHeight := band.bottom-band.top, next.bottom-next.top
+			if height*2 < nextHeight && (next.top-band.bottom)*2 < nextHeight {
+				band.bottom = next.bottom
+				i++
+			}
+		}
+		merged = append(merged, band)
+	}
+	return merged
+}
+
+// recognizeLine segments a text line into characters by blank columns and matches each
+// against the glyph templates. Lines that are mostly unrecognizable (barcodes, logos)
+// are dropped.
+func recognizeLine(minX, maxX int, band rowBand, ink func(x, y int) bool) string {
+	// Character boxes, found by vertical projection
+	var boxes []image.Rectangle
+	inChar := false
+	for x := minX; x < maxX; x++ {
+		hasInk := false
+		for y := band.top; y < band.bottom; y++ {
+			if ink(x, y) {
+				hasInk = true
+				break
+			}
+		}
+		switch {
+		case hasInk && !inChar:
+			boxes = append(boxes, image.Rect(x, band.top, x+1, band.bottom))
+			inChar = true
+		case hasInk:
+			boxes[len(boxes)-1].Max.X = x + 1
+		case inChar:
+			inChar = false
+		}
+	}
+	if len(boxes) == 0 {
+		return ""
+	}
+
+	// Trim each box vertically to its ink
+	heights := make([]int, 0, len(boxes))
+	for i, box := range boxes {
+		top, bottom := box.Max.Y, box.Min.Y
+		for y := box.Min.Y; y < box.Max.Y; y++ {
+			for x := box.Min.X; x < box.Max.X; x++ {
+				if ink(x, y) {
+					top, bottom = min(top, y), max(bottom, y+1)
+					break
+				}
+			}
+		}
+		boxes[i].Min.Y, boxes[i].Max.Y = top, bottom
+		heights = append(heights, bottom-top)
+	}
+
+	// Most characters are plain 7-row letters, so the median height gives the cell size
+	sort.Ints(heights)
+	cell := float64(heights[len(heights)/2]) / 7
+	if cell < 1 {
+		return ""
+	}
+
+	var line strings.Builder
+	unknown := 0
+	for i, box := range boxes {
+		// Narrow glyphs leave up to four blank cells to their neighbours; a space adds six
+		if i > 0 && float64(box.Min.X-boxes[i-1].Max.X) > 5*cell {
+			line.WriteByte(' ')
+		}
+		r, ok := matchGlyph(sampleGlyph(box, cell, ink))
+		if !ok {
+			unknown++
+			continue
+		}
+		line.WriteRune(r)
+	}
+
+	if unknown*2 > len(boxes) {
+		return ""
+	}
+	return strings.TrimSpace(line.String())
+}
+
+// sampleGlyph converts a character box to a bitmap with one entry per font cell
+func sampleGlyph(box image.Rectangle, cell float64, ink func(x, y int) bool) glyphBitmap {
+	cols := max(1, int(math.Round(float64(box.Dx())/cell)))
+	rows := max(1, int(math.Round(float64(box.Dy())/cell)))
+	cellW := float64(box.Dx()) / float64(cols)
+	cellH := float64(box.Dy()) / float64(rows)
+
+	bm := make(glyphBitmap, rows)
+	for r := 0; r < rows; r++ {
+		bm[r] = make([]bool, cols)
+		for c := 0; c < cols; c++ {
+			x0, x1 := box.Min.X+int(float64(c)*cellW), box.Min.X+int(float64(c+1)*cellW)
+			y0, y1 := box.Min.Y+int(float64(r)*cellH), box.Min.Y+int(float64(r+1)*cellH)
+			inked, total := 0, 0
+			for y := y0; y < max(y1, y0+1); y++ {
+				for x := x0; x < max(x1, x0+1); x++ {
+					total++
+					if ink(x, y) {
+						inked++
+					}
+				}
+			}
+			bm[r][c] = inked*2 >= total
+		}
+	}
+	return bm
+}
+
+// matchGlyph returns the glyph whose template best matches bm. Only templates with the
+// same dimensions are compared, which also keeps I apart from İ and O apart from Ö.
+func matchGlyph(bm glyphBitmap) (rune, bool) {
+	best, bestMismatch := rune(0), math.MaxFloat64
+	for i, tmpl := range glyphTemplates {
+		if len(tmpl) != len(bm) || len(tmpl[0]) != len(bm[0]) {
+			continue
+		}
+		diff := 0
+		for r := range tmpl {
+			for c := range tmpl[r] {
+				if tmpl[r][c] != bm[r][c] {
+					diff++
+				}
+			}
+		}
+		mismatch := float64(diff) / float64(len(tmpl)*len(tmpl[0]))
+		if mismatch < bestMismatch {
+			best, bestMismatch = glyphSet[i].r, mismatch
+		}
+	}
+	return best, bestMismatch <= maxGlyphMismatch
+}
+
+// ParseImage extracts as many fields as possible from an image of a tax plate, such as a
+// scan or a rendered page. Printed text is read with the recognizer installed with
+// SetTextRecognizer, such as Tesseract from the tesseract submodule, falling back to a
+// lightweight template recognizer, and parsed like PDF text; the VKN is taken from the
+// barcode when one is present, and from the digit classifier as a last resort.
+func (p *OCRParser) ParseImage(img image.Image) (*VergiLevhasi, error) {
+	text := p.recognizeImageText(img)
+
+	if p.debug {
+		fmt.Println("Recognized Text:")
+		fmt.Println(text)
+	}
+
+	vkn, _, err := p.scanImageBarcode(img)
+	if err != nil || vkn == "" {
+		vkn = ""
+		if !tableVKNRe.MatchString(text) {
+			vkn, _ = p.ExtractVKNFromImageData(img)
+		}
+	} else {
+		p.checkExpectedVKN(vkn)
+	}
+	if vkn != "" {
+		text += "\nVKN: " + vkn + "\n"
+	}
+
+	if strings.TrimSpace(text) == "" {
+		return nil, fmt.Errorf("no text recognized in image")
+	}
+
+	vergiLevhasi := &VergiLevhasi{
+		RawText: text,
+	}
+	// An image has no positioned text, so there are no layout rows
+	p.parseText(vergiLevhasi, text, nil)
+	p.runPostProcessors(vergiLevhasi)
+
+	return vergiLevhasi, nil
+}
+
+// ParseWithPreview decodes image bytes (PNG, JPEG or GIF) once and returns both the
+// parse result and the decoded page image, for UIs that show the document next to the
+// extracted fields. The image is returned even when parsing fails. PDFs are rejected:
+// there is no page renderer, so pass a scan or an externally rendered page instead.
+func (p *OCRParser) ParseWithPreview(data []byte) (*VergiLevhasi, image.Image, error) {
+	if bytes.HasPrefix(data, []byte("%PDF-")) {
+		return nil, nil, fmt.Errorf("rendering PDF pages is not supported; pass a page image")
+	}
+
+	img, _, err := image.Decode(bytes.NewReader(data))
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to decode image: %w", err)
+	}
+
+	vergiLevhasi, err := p.ParseImage(img)
+	if err != nil {
+		return nil, img, err
+	}
+	return vergiLevhasi, img, nil
+}
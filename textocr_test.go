@@ -0,0 +1,182 @@
+package vergilevhasi
+
+import (
+	"bytes"
+	"image"
+	"image/color"
+	"image/draw"
+	"image/png"
+	"strings"
+	"testing"
+)
+
+// renderPlateText draws lines of text with the glyph templates at the given scale,
+// leaving room above each line for accents
+func renderPlateText(lines []string, scale int) *image.Gray {
+	glyphs := make(map[rune]glyph, len(glyphSet))
+	for _, g := range glyphSet {
+		glyphs[g.r] = g
+	}
+
+	width := 0
+	for _, line := range lines {
+		width = max(width, len([]rune(line))*6+4)
+	}
+	lineHeight := 14
+	img := newWhiteGray(width*scale, (len(lines)*lineHeight+4)*scale)
+
+	for i, line := range lines {
+		x, baseTop := 2, 2+i*lineHeight+3
+		for _, r := range line {
+			g, ok := glyphs[r]
+			if ok {
+				rows, top := g.glyphRows()
+				for ry, row := range rows {
+					for cx, ch := range row {
+						if ch != '#' {
+							continue
+						}
+						cell := image.Rect((x+cx)*scale, (baseTop-top+ry)*scale, (x+cx+1)*scale, (baseTop-top+ry+1)*scale)
+						draw.Draw(img, cell, &image.Uniform{color.Gray{0}}, image.Point{}, draw.Src)
+					}
+				}
+			}
+			x += 6
+		}
+	}
+	return img
+}
+
+func TestRecognizeText(t *testing.T) {
+	lines := []string{
+		"ADI SOYADI: ALİ ÖRNEK",
+		"VERGİ DAİRESİ: ÇANKAYA",
+		"İŞE BAŞLAMA TARİHİ: 01.02.2020",
+		"ĞÜZ-QWXJ/789,3456",
+	}
+
+	got := recognizeText(renderPlateText(lines, 3))
+	if want := strings.Join(lines, "\n"); got != want {
+		t.Errorf("recognizeText() =\n%s\nwant\n%s", got, want)
+	}
+}
+
+// renderedPlate draws a synthetic plate: a name and tax office above a barcode for
+// VKN 1234567890
+func renderedPlate(t *testing.T) *image.Gray {
+	text := renderPlateText([]string{
+		"ADI SOYADI: ALİ ÖRNEK",
+		"VERGİ DAİRESİ: ÇANKAYA",
+	}, 3)
+	barcode := drawCode128(t, "1234567890")
+
+	plate := newWhiteGray(max(text.Bounds().Dx(), barcode.Bounds().Dx()), text.Bounds().Dy()+barcode.Bounds().Dy()+40)
+	draw.Draw(plate, text.Bounds(), text, image.Point{}, draw.Src)
+	draw.Draw(plate, barcode.Bounds().Add(image.Pt(0, text.Bounds().Dy()+20)), barcode, image.Point{}, draw.Src)
+	return plate
+}
+
+func TestParseImageRenderedPlate(t *testing.T) {
+	parser, err := NewOCRParser()
+	if err != nil {
+		t.Fatalf("NewOCRParser() error = %v", err)
+	}
+
+	vl, err := parser.ParseImage(renderedPlate(t))
+	if err != nil {
+		t.Fatalf("ParseImage() error = %v", err)
+	}
+	if vl.VergiKimlikNo != "1234567890" {
+		t.Errorf("VergiKimlikNo = %q, want %q", vl.VergiKimlikNo, "1234567890")
+	}
+	if vl.AdiSoyadi != "ALİ ÖRNEK" {
+		t.Errorf("AdiSoyadi = %q, want %q", vl.AdiSoyadi, "ALİ ÖRNEK")
+	}
+}
+
+func TestParseImageRunsTextPipeline(t *testing.T) {
+	const (
+		operating = "ÖRNEK MAH. TEST CAD. NO:1 ÇANKAYA/ANKARA"
+		billing   = "YENİ MAH. FATURA SOK. NO:7 KADIKÖY/İSTANBUL"
+	)
+	text := "VERGİ LEVHASI\nTicaret Ünvanı: ÖRNEK YAZILIM LİMİTED ŞİRKETİ\n" +
+		"İş Yeri Adresi: " + operating + "\n" +
+		"Fatura Adresi: " + billing + "\n" +
+		"Vergi Dairesi: CANKAYA VERGI DAIRES\n" +
+		"Vergi Kimlik No: 1234567890\n" +
+		"471101 - BAKKAL VE MARKETLERDE YAPILAN PERAKENDE TİCARET\n"
+	SetTextRecognizer(func(image.Image) (string, error) { return text, nil })
+	t.Cleanup(func() { SetTextRecognizer(nil) })
+
+	parser, err := NewOCRParser()
+	if err != nil {
+		t.Fatalf("NewOCRParser() error = %v", err)
+	}
+	parser.SetTaxOfficeReferences([]string{"ÇANKAYA VERGİ DAİRESİ"})
+
+	// The steps PDF parses run after the field patterns apply to images too
+	vl, err := parser.ParseImage(newWhiteGray(100, 50))
+	if err != nil {
+		t.Fatalf("ParseImage() error = %v", err)
+	}
+	if len(vl.IsYeriAdresleri) != 2 || vl.IsYeriAdresleri[1] != billing {
+		t.Errorf("IsYeriAdresleri = %q, want the operating and billing addresses", vl.IsYeriAdresleri)
+	}
+	if vl.SektorGrubu == "" {
+		t.Error("SektorGrubu is empty, want it derived from the activity code")
+	}
+	if vl.VergiDairesi != "ÇANKAYA VERGİ DAİRESİ" {
+		t.Errorf("VergiDairesi = %q, want it snapped to the reference", vl.VergiDairesi)
+	}
+}
+
+func TestParseImageBlank(t *testing.T) {
+	parser, err := NewOCRParser()
+	if err != nil {
+		t.Fatalf("NewOCRParser() error = %v", err)
+	}
+
+	if _, err := parser.ParseImage(newWhiteGray(100, 50)); err == nil {
+		t.Error("ParseImage() on a blank image should return an error")
+	}
+}
+
+func TestParseWithPreview(t *testing.T) {
+	parser, err := NewOCRParser()
+	if err != nil {
+		t.Fatalf("NewOCRParser() error = %v", err)
+	}
+
+	plate := renderedPlate(t)
+	var buf bytes.Buffer
+	if err := png.Encode(&buf, plate); err != nil {
+		t.Fatalf("png.Encode() error = %v", err)
+	}
+
+	vl, preview, err := parser.ParseWithPreview(buf.Bytes())
+	if err != nil {
+		t.Fatalf("ParseWithPreview() error = %v", err)
+	}
+	if vl == nil || preview == nil {
+		t.Fatalf("ParseWithPreview() = %v, %v; want a result and a preview", vl, preview)
+	}
+	if vl.VergiKimlikNo != "1234567890" || vl.AdiSoyadi != "ALİ ÖRNEK" {
+		t.Errorf("ParseWithPreview() result = %+v, want the plate's fields", vl)
+	}
+	if preview.Bounds() != plate.Bounds() {
+		t.Errorf("preview bounds = %v, want %v", preview.Bounds(), plate.Bounds())
+	}
+
+	// A blank page fails to parse but still yields its preview
+	buf.Reset()
+	if err := png.Encode(&buf, newWhiteGray(100, 50)); err != nil {
+		t.Fatalf("png.Encode() error = %v", err)
+	}
+	if vl, preview, err := parser.ParseWithPreview(buf.Bytes()); err == nil || vl != nil || preview == nil {
+		t.Errorf("ParseWithPreview() of a blank page = %v, %v, %v; want an error and a preview", vl, preview, err)
+	}
+
+	if _, _, err := parser.ParseWithPreview([]byte("%PDF-1.4")); err == nil {
+		t.Error("ParseWithPreview() of a PDF should return an error")
+	}
+}
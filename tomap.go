@@ -0,0 +1,87 @@
+package vergilevhasi
+
+import (
+	"strings"
+	"time"
+)
+
+// listSeparator joins the values of string list fields in ToMap
+const listSeparator = ", "
+
+// addressSeparator joins addresses in ToMap; addresses may contain commas themselves
+const addressSeparator = "; "
+
+// ToMap returns the parsed data as a flat map keyed by the JSON field names, for
+// inserting into a database row or a key-value store without reflection. Every data
+// field is present, with its zero value when it was not found, so the key set is the
+// same for every plate:
+//
+//   - text fields and enumerations are strings, flags are bools
+//   - dates are strings in the format set with SetJSONDateFormat, or nil when missing
+//   - string lists (vergi_turu, ortaklar, okc_seri_nolari) are joined with ", ", and
+//     is_yeri_adresleri with "; "
+//   - activities and tax bases are slices of maps keyed by the JSON names of their fields
+//
+// RawText and the parse diagnostics (FieldPages, Warnings, IsImageOnly, RequiresOCR,
+// VKNCheckDigitVerified) are left out.
+func (v *VergiLevhasi) ToMap() map[string]interface{} {
+	faaliyetler := make([]map[string]interface{}, len(v.FaaliyetKodlari))
+	for i, f := range v.FaaliyetKodlari {
+		faaliyetler[i] = map[string]interface{}{"kod": f.Kod, "ad": f.Ad}
+	}
+
+	matrahlar := make([]map[string]interface{}, len(v.GecmisMatra))
+	for i, m := range v.GecmisMatra {
+		matrahlar[i] = map[string]interface{}{
+			"yil":         m.Yil,
+			"donem":       m.Donem,
+			"tur":         m.Tur,
+			"tutar":       m.Tutar,
+			"tutar_kurus": m.TutarKurus,
+			"vergi":       m.Vergi,
+			"vergi_kurus": m.VergiKurus,
+		}
+	}
+
+	return map[string]interface{}{
+		"adi_soyadi":            v.AdiSoyadi,
+		"ticaret_unvani":        v.TicaretUnvani,
+		"ham_unvan":             v.HamUnvan,
+		"ortaklar":              strings.Join(v.Ortaklar, listSeparator),
+		"is_yeri_adresi":        v.IsYeriAdresi,
+		"is_yeri_adresleri":     strings.Join(v.IsYeriAdresleri, addressSeparator),
+		"vergi_turu":            strings.Join(v.VergiTuru, listSeparator),
+		"vergi_usulu":           string(v.VergiUsulu),
+		"gelir_unsuru":          v.GelirUnsuru,
+		"faaliyet_kodlari":      faaliyetler,
+		"sektor_grubu":          v.SektorGrubu,
+		"vergi_dairesi":         v.VergiDairesi,
+		"vergi_kimlik_no":       v.VergiKimlikNo,
+		"sube_kodu":             v.SubeKodu,
+		"is_yeri_turu":          string(v.IsYeriTuru),
+		"tc_kimlik_no":          v.TCKimlikNo,
+		"uyruk":                 v.Uyruk,
+		"pasaport_no":           v.PasaportNo,
+		"kayit_no":              v.KayitNo,
+		"yabanci_vergi_no":      v.YabanciVergiNo,
+		"is_potansiyel_vkn":     v.IsPotansiyelVKN,
+		"uses_tckn_as_vergi_no": v.UsesTCKNAsVergiNo,
+		"ise_baslama_tarihi":    mapDate(v.IseBaslamaTarihi),
+		"olusturulma_tarihi":    mapDate(v.OlusturulmaTarihi),
+		"okc_seri_nolari":       strings.Join(v.OkcSeriNolari, listSeparator),
+		"imza_var":              v.ImzaVar,
+		"imzalayan_ad":          v.ImzalayanAd,
+		"gecmis_matrahlar":      matrahlar,
+		"document_type":         string(v.DocumentType),
+		"mukellef_turu":         string(v.MukellefTuru),
+		"mukellef_turu_guveni":  v.MukellefTuruGuveni,
+	}
+}
+
+// mapDate returns t formatted for ToMap, or nil when there is no date
+func mapDate(t *time.Time) interface{} {
+	if t == nil {
+		return nil
+	}
+	return formatDate(*t)
+}
@@ -0,0 +1,92 @@
+package vergilevhasi
+
+import (
+	"encoding/json"
+	"testing"
+	"time"
+)
+
+func TestToMap(t *testing.T) {
+	start := time.Date(2020, 1, 15, 0, 0, 0, 0, time.UTC)
+	vl := &VergiLevhasi{
+		TicaretUnvani:    "ÖRNEK YAZILIM LİMİTED ŞİRKETİ",
+		VergiKimlikNo:    "1234567890",
+		VergiTuru:        []string{"Kurumlar Vergisi", "KDV"},
+		FaaliyetKodlari:  []Faaliyet{{Kod: "012345", Ad: "TAHIL YETİŞTİRİCİLİĞİ"}},
+		GecmisMatra:      []Matrah{{Yil: 2023, Tutar: 1500.5, TutarKurus: 150050}},
+		IsYeriTuru:       IsYeriTuruMerkez,
+		MukellefTuru:     MukellefTuruKurumsal,
+		IseBaslamaTarihi: &start,
+		Warnings:         []string{"ignored"},
+	}
+
+	m := vl.ToMap()
+
+	for key, want := range map[string]interface{}{
+		"ticaret_unvani":     "ÖRNEK YAZILIM LİMİTED ŞİRKETİ",
+		"vergi_kimlik_no":    "1234567890",
+		"vergi_turu":         "Kurumlar Vergisi, KDV",
+		"ortaklar":           "",
+		"is_yeri_turu":       "merkez",
+		"mukellef_turu":      "kurumsal",
+		"is_potansiyel_vkn":  false,
+		"ise_baslama_tarihi": "2020-01-15T00:00:00Z",
+		"olusturulma_tarihi": nil,
+	} {
+		if got, ok := m[key]; !ok || got != want {
+			t.Errorf("ToMap()[%q] = %#v (present %v), want %#v", key, got, ok, want)
+		}
+	}
+
+	faaliyetler, ok := m["faaliyet_kodlari"].([]map[string]interface{})
+	if !ok || len(faaliyetler) != 1 || faaliyetler[0]["kod"] != "012345" {
+		t.Errorf("ToMap()[faaliyet_kodlari] = %#v, want one activity with code 012345", m["faaliyet_kodlari"])
+	}
+	matrahlar, ok := m["gecmis_matrahlar"].([]map[string]interface{})
+	if !ok || len(matrahlar) != 1 || matrahlar[0]["yil"] != 2023 || matrahlar[0]["tutar_kurus"] != int64(150050) {
+		t.Errorf("ToMap()[gecmis_matrahlar] = %#v, want one tax base for 2023", m["gecmis_matrahlar"])
+	}
+	if _, ok := m["warnings"]; ok {
+		t.Error("ToMap() contains the warnings diagnostics")
+	}
+
+	SetJSONDateFormat(DateFormatTurkish)
+	defer SetJSONDateFormat(DateFormatISO)
+	if got := vl.ToMap()["ise_baslama_tarihi"]; got != "15.01.2020" {
+		t.Errorf("ToMap()[ise_baslama_tarihi] with Turkish dates = %#v, want %q", got, "15.01.2020")
+	}
+}
+
+func TestToMapStableKeys(t *testing.T) {
+	empty := (&VergiLevhasi{}).ToMap()
+
+	// Every data field of the JSON output has a key, found or not
+	start := time.Now()
+	full := &VergiLevhasi{
+		AdiSoyadi: "a", TicaretUnvani: "a", HamUnvan: "a", Ortaklar: []string{"a"}, IsYeriAdresi: "a", IsYeriAdresleri: []string{"a"},
+		VergiTuru: []string{"a"}, VergiUsulu: VergiUsuluBasit, GelirUnsuru: "a", FaaliyetKodlari: []Faaliyet{{Kod: "1"}}, SektorGrubu: "a",
+		VergiDairesi: "a", VergiKimlikNo: "a", SubeKodu: "a", IsYeriTuru: IsYeriTuruSube, TCKimlikNo: "a",
+		Uyruk: "a", PasaportNo: "a", KayitNo: "a", YabanciVergiNo: "a", IsPotansiyelVKN: true,
+		UsesTCKNAsVergiNo: true, IseBaslamaTarihi: &start, OlusturulmaTarihi: &start, OkcSeriNolari: []string{"a"},
+		ImzaVar: true, ImzalayanAd: "a",
+		GecmisMatra: []Matrah{{Yil: 1}}, DocumentType: DocumentTypeVergiLevhasi, MukellefTuru: MukellefTuruBireysel,
+		MukellefTuruGuveni: 1,
+	}
+	data, err := json.Marshal(full)
+	if err != nil {
+		t.Fatalf("json.Marshal() error = %v", err)
+	}
+	var fields map[string]interface{}
+	if err := json.Unmarshal(data, &fields); err != nil {
+		t.Fatalf("json.Unmarshal() error = %v", err)
+	}
+
+	if len(empty) != len(fields) {
+		t.Errorf("ToMap() has %d keys, want the %d data fields", len(empty), len(fields))
+	}
+	for key := range fields {
+		if _, ok := empty[key]; !ok {
+			t.Errorf("ToMap() of an empty result lacks %q", key)
+		}
+	}
+}
@@ -0,0 +1,113 @@
+package vergilevhasi
+
+import (
+	"regexp"
+	"strings"
+)
+
+// turkishLetterGroups lists the letters that encoding problems and ASCII-only keyboards
+// turn into one another. Case-insensitive matching already pairs most of them, but İ and
+// ı have no case partner in Go's simple case folding, so "KİMLİK" would never match
+// "kimlik".
+var turkishLetterGroups = []string{"iIİı", "sSşŞ", "gGğĞ", "oOöÖ", "uUüÜ", "cCçÇ"}
+
+// turkishLetterGroup returns the group of letters interchangeable with r, or ""
+func turkishLetterGroup(r rune) string {
+	for _, group := range turkishLetterGroups {
+		if strings.ContainsRune(group, r) {
+			return group
+		}
+	}
+	return ""
+}
+
+// expandTurkishLetters rewrites a case-insensitive label pattern so that each letter
+// with Turkish variants matches all of them: "kimlik" becomes "k[iIİı]ml[iIİı]k" and a
+// hand-written class such as "[şs]" gains the missing variants. Escapes, group flags and
+// classes with ranges or negation are left alone, as are case-sensitive patterns, which
+// match printed uppercase text deliberately.
+func expandTurkishLetters(pattern string) string {
+	if !strings.HasPrefix(pattern, "(?i") {
+		return pattern
+	}
+
+	runes := []rune(pattern)
+	var b strings.Builder
+	for i := 0; i < len(runes); i++ {
+		r := runes[i]
+		switch {
+		case r == '\\':
+			// Copy the escape, including a \p{...} class name
+			end := i + 1
+			if end+1 < len(runes) && (runes[end] == 'p' || runes[end] == 'P') && runes[end+1] == '{' {
+				for end < len(runes) && runes[end] != '}' {
+					end++
+				}
+			}
+			end = min(end, len(runes)-1)
+			b.WriteString(string(runes[i : end+1]))
+			i = end
+
+		case r == '(' && i+1 < len(runes) && runes[i+1] == '?':
+			// Copy flags and group syntax such as (?i), (?im) and (?:
+			end := i + 2
+			for end < len(runes) && runes[end] != ')' && runes[end] != ':' && runes[end] != '>' {
+				end++
+			}
+			end = min(end, len(runes)-1)
+			b.WriteString(string(runes[i : end+1]))
+			i = end
+
+		case r == '[':
+			end := i + 1
+			for end < len(runes) && runes[end] != ']' {
+				if runes[end] == '\\' {
+					end++
+				}
+				end++
+			}
+			end = min(end, len(runes)-1)
+			b.WriteString(expandTurkishClass(runes[i : end+1]))
+			i = end
+
+		default:
+			if group := turkishLetterGroup(r); group != "" {
+				b.WriteString("[" + group + "]")
+			} else {
+				b.WriteRune(r)
+			}
+		}
+	}
+	return b.String()
+}
+
+// expandTurkishClass adds the Turkish variants of its letters to a character class made
+// only of letters, such as "[ıi]"; other classes are returned unchanged
+func expandTurkishClass(class []rune) string {
+	body := class[1 : len(class)-1]
+	if len(body) == 0 || body[0] == '^' {
+		return string(class)
+	}
+	var letters strings.Builder
+	for _, r := range body {
+		if r == '-' || r == '\\' {
+			return string(class)
+		}
+		if group := turkishLetterGroup(r); group != "" {
+			for _, v := range group {
+				if !strings.ContainsRune(letters.String(), v) {
+					letters.WriteRune(v)
+				}
+			}
+		} else if !strings.ContainsRune(letters.String(), r) {
+			letters.WriteRune(r)
+		}
+	}
+	return "[" + letters.String() + "]"
+}
+
+// mustCompileLabel compiles a label pattern with expandTurkishLetters, panicking on an
+// invalid one like regexp.MustCompile
+func mustCompileLabel(pattern string) *regexp.Regexp {
+	return regexp.MustCompile(expandTurkishLetters(pattern))
+}
@@ -0,0 +1,56 @@
+package vergilevhasi
+
+import (
+	"regexp"
+	"testing"
+)
+
+func TestExpandTurkishLetters(t *testing.T) {
+	tests := []struct {
+		pattern string
+		want    string
+	}{
+		{`(?i)kimlik`, `(?i)k[iIİı]ml[iIİı]k`},
+		{`(?i)ad[ıi]\s*[:：]`, `(?i)ad[iIİı]\s*[:：]`},
+		{`(?i)[şs]ube\s*no`, `(?i)[sSşŞ][uUüÜ]be\s*n[oOöÖ]`},
+		// Escapes, flags, ranges and negated classes are kept
+		{`(?im)^\s*(?:x)\d{2}[./-][A-Z0-9][^s]`, `(?im)^\s*(?:x)\d{2}[./-][A-Z0-9][^s]`},
+		// Case-sensitive patterns are left alone
+		{`GELİR\s+VERGİSİ`, `GELİR\s+VERGİSİ`},
+	}
+
+	for _, tt := range tests {
+		if got := expandTurkishLetters(tt.pattern); got != tt.want {
+			t.Errorf("expandTurkishLetters(%q) = %q, want %q", tt.pattern, got, tt.want)
+		}
+	}
+}
+
+func TestLabelPatternsLetterVariants(t *testing.T) {
+	parser := NewParser()
+
+	tests := []struct {
+		name     string
+		labels   []string
+		value    string
+		patterns []*regexp.Regexp
+	}{
+		{"VKN", []string{"Vergi Kimlik No", "VERGİ KİMLİK NO", "VERGI KIMLIK NO", "VERGI KIMLİK NO", "vergı kımlık no"}, "1234567890", labelVKNPatterns},
+		{"TCKN", []string{"T.C. Kimlik No", "T.C. KİMLİK NO", "T.C. KIMLIK NO", "TC KIMLİK NO"}, "10000000146", labelTCKNPatterns},
+		{"Vergi dairesi", []string{"Vergi Dairesi", "VERGİ DAİRESİ", "VERGI DAIRESI", "VERGİ DAIRESI"}, "ÇANKAYA", labelVergiDairesiPatterns},
+		{"Ticaret ünvanı", []string{"Ticaret Ünvanı", "TİCARET ÜNVANI", "TICARET UNVANI", "Ticaret Unvani"}, "ÖRNEK A.Ş.", labelTicaretUnvaniPatterns},
+		{"Adı soyadı", []string{"Adı Soyadı", "ADI SOYADI", "ADİ SOYADİ", "Adi Soyadi"}, "ALİ ÖRNEK", labelAdiSoyadiPatterns},
+		{"İş yeri adresi", []string{"İş Yeri Adresi", "İŞ YERİ ADRESİ", "IS YERI ADRESI", "Is Yeri Adresi"}, "ÖRNEK MAH. NO:1", labelIsYeriAdresiPatterns},
+		{"İşe başlama tarihi", []string{"İşe Başlama Tarihi", "İŞE BAŞLAMA TARİHİ", "ISE BASLAMA TARIHI", "Ise Baslama Tarihi"}, "15.01.2020", labelIseBaslamaPatterns},
+	}
+
+	for _, tt := range tests {
+		for _, label := range tt.labels {
+			t.Run(tt.name+"/"+label, func(t *testing.T) {
+				if got := parser.extractField(label+": "+tt.value+"\n", tt.patterns); got != tt.value {
+					t.Errorf("extractField(%q) = %q, want %q", label, got, tt.value)
+				}
+			})
+		}
+	}
+}
@@ -0,0 +1,75 @@
+package vergilevhasi
+
+import (
+	"regexp"
+	"strings"
+	"unicode"
+)
+
+// Legal-form suffixes in their common spellings: "LTD.ŞTİ", "LTD. ŞTİ.", "Ltd Şti",
+// "LİMİTED ŞİRKETİ", "A.Ş.", "AŞ", "ANONİM ŞİRKETİ"
+var (
+	limitedSuffixRe = regexp.MustCompile(`(?i)(^|\s)(?:LTD|L[İIiı]M[İIiı]TED)\.?\s*(?:ŞT[İIiı]|Ş[İIiı]RKET[İIiı])\.?$`)
+	anonimSuffixRe  = regexp.MustCompile(`(?i)(^|\s)(?:A\.?\s*Ş|ANON[İIiı]M\s+Ş[İIiı]RKET[İIiı])\.?$`)
+)
+
+// unvanStrayPunctuation is trimmed from both ends of a trade name
+const unvanStrayPunctuation = " ,;:-_/|*"
+
+// hasLegalFormSuffix reports whether s ends in a limited or joint-stock company suffix, or
+// names a company ("... ŞİRKETİ") outright
+func hasLegalFormSuffix(s string) bool {
+	s = strings.TrimRight(strings.TrimSpace(s), unvanStrayPunctuation)
+	if limitedSuffixRe.MatchString(s) || anonimSuffixRe.MatchString(s) {
+		return true
+	}
+	upper := strings.ToUpperSpecial(unicode.TurkishCase, s)
+	return strings.Contains(upper, "ŞİRKETİ") || strings.Contains(upper, "ŞTİ")
+}
+
+// SetUnvanNormalization enables or disables cleaning up TicaretUnvani with NormalizeUnvan.
+// Enabled by default; disable it to keep the trade name as printed.
+func (p *Parser) SetUnvanNormalization(enabled bool) {
+	p.normalizeUnvan = enabled
+}
+
+// normalizeUnvanField applies NormalizeUnvan to TicaretUnvani when enabled
+func (p *Parser) normalizeUnvanField(vl *VergiLevhasi) {
+	if p.normalizeUnvan {
+		vl.TicaretUnvani = NormalizeUnvan(vl.TicaretUnvani)
+	}
+}
+
+// NormalizeUnvan cleans up a trade name: runs of whitespace are collapsed, stray
+// punctuation is trimmed from both ends, and the limited and joint-stock company suffixes
+// are spelled out as "LİMİTED ŞİRKETİ" and "ANONİM ŞİRKETİ". The suffix follows the casing
+// of the rest of the name, so "Örnek Ltd. Şti." becomes "Örnek Limited Şirketi".
+func NormalizeUnvan(s string) string {
+	s = strings.Join(strings.Fields(s), " ")
+	s = strings.TrimLeft(s, unvanStrayPunctuation+".")
+	s = strings.TrimRight(s, unvanStrayPunctuation)
+
+	for _, suffix := range []struct {
+		re           *regexp.Regexp
+		upper, lower string
+	}{
+		{limitedSuffixRe, "LİMİTED ŞİRKETİ", "Limited Şirketi"},
+		{anonimSuffixRe, "ANONİM ŞİRKETİ", "Anonim Şirketi"},
+	} {
+		loc := suffix.re.FindStringIndex(s)
+		if loc == nil {
+			continue
+		}
+		name := strings.TrimRight(s[:loc[0]], unvanStrayPunctuation)
+		canonical := suffix.upper
+		if name != "" && strings.ToUpperSpecial(unicode.TurkishCase, name) != name {
+			canonical = suffix.lower
+		}
+		if name == "" {
+			return canonical
+		}
+		return name + " " + canonical
+	}
+
+	return s
+}
@@ -0,0 +1,59 @@
+package vergilevhasi
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestNormalizeUnvan(t *testing.T) {
+	tests := []struct {
+		in   string
+		want string
+	}{
+		{"ÖRNEK TEKNOLOJİ LTD.ŞTİ", "ÖRNEK TEKNOLOJİ LİMİTED ŞİRKETİ"},
+		{"ÖRNEK TEKNOLOJİ LTD. ŞTİ.", "ÖRNEK TEKNOLOJİ LİMİTED ŞİRKETİ"},
+		{"ÖRNEK TEKNOLOJİ LTD ŞTİ", "ÖRNEK TEKNOLOJİ LİMİTED ŞİRKETİ"},
+		{"ÖRNEK  TEKNOLOJİ\n LİMİTED   ŞİRKETİ", "ÖRNEK TEKNOLOJİ LİMİTED ŞİRKETİ"},
+		{"ÖRNEK TEKNOLOJİ LIMITED SIRKETI", "ÖRNEK TEKNOLOJİ LIMITED SIRKETI"},
+		{"ACME BİLİŞİM A.Ş.", "ACME BİLİŞİM ANONİM ŞİRKETİ"},
+		{"ACME BİLİŞİM A. Ş", "ACME BİLİŞİM ANONİM ŞİRKETİ"},
+		{"ACME BİLİŞİM AŞ", "ACME BİLİŞİM ANONİM ŞİRKETİ"},
+		{"Örnek Teknoloji Ltd. Şti.", "Örnek Teknoloji Limited Şirketi"},
+		{"Acme Bilişim a.ş.", "Acme Bilişim Anonim Şirketi"},
+		{", ÖRNEK TEKNOLOJİ LTD.ŞTİ. -", "ÖRNEK TEKNOLOJİ LİMİTED ŞİRKETİ"},
+		{"ÖRNEK İNŞ. TİC.", "ÖRNEK İNŞ. TİC."},
+		{"KAŞ TURİZM", "KAŞ TURİZM"},
+		{"", ""},
+	}
+
+	for _, tt := range tests {
+		if got := NormalizeUnvan(tt.in); got != tt.want {
+			t.Errorf("NormalizeUnvan(%q) = %q, want %q", tt.in, got, tt.want)
+		}
+	}
+}
+
+func TestParseUnvanNormalization(t *testing.T) {
+	text := "Ticaret Ünvanı: ÖRNEK  TEKNOLOJİ LTD.ŞTİ.\nVergi Dairesi: Örnek VD\nKurumlar Vergisi\n"
+
+	for _, tt := range []struct {
+		enabled bool
+		want    string
+	}{
+		{true, "ÖRNEK TEKNOLOJİ LİMİTED ŞİRKETİ"},
+		{false, "ÖRNEK  TEKNOLOJİ LTD.ŞTİ."},
+	} {
+		parser := NewParser()
+		parser.SetBackend(&fakeBackend{pages: []PageText{{Number: 1, Text: text}}})
+		parser.SetFields(AllFields &^ FieldVergiKimlikNo)
+		parser.SetUnvanNormalization(tt.enabled)
+
+		vl, err := parser.Parse(strings.NewReader("not a real pdf"))
+		if err != nil {
+			t.Fatalf("Parse() error = %v", err)
+		}
+		if vl.TicaretUnvani != tt.want {
+			t.Errorf("SetUnvanNormalization(%v): TicaretUnvani = %q, want %q", tt.enabled, vl.TicaretUnvani, tt.want)
+		}
+	}
+}
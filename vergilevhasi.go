@@ -24,6 +24,9 @@ The parser extracts the following information:
   - Vergi Dairesi (Tax Office)
   - Vergi Kimlik No (Tax ID Number - VKN)
   - TC Kimlik No (Turkish ID Number - TCKN) - for individuals
+  - Uyruk and Pasaport No (Nationality and Passport Number) - for foreign individuals
+  - İş Yeri Türü (Workplace Type) - head office (merkez) or branch (şube)
+  - Kayıt No (Registration Number) and taxpayer type for associations (dernek) and foundations (vakıf)
   - İşe Başlama Tarihi (Business Start Date)
   - Geçmiş Matrahlar (Historical Tax Bases)
 
@@ -41,6 +44,10 @@ use the OCR parser:
 package vergilevhasi
 
 import (
+	"errors"
+	"fmt"
+	"slices"
+	"sort"
 	"time"
 )
 
@@ -52,36 +59,200 @@ type VergiLevhasi struct {
 	// Ticaret Ünvanı (Trade Name) - for companies, can be empty
 	TicaretUnvani string `json:"ticaret_unvani"`
 
+	// Ham Ünvan (Raw Name) - the taxpayer name exactly as printed in the MÜKELLEFİN block,
+	// before it is classified into AdiSoyadi or TicaretUnvani and cleaned up
+	HamUnvan string `json:"ham_unvan,omitempty"`
+
+	// Ortaklar (Partners) - for an ordinary partnership (adi ortaklık), whose own name is
+	// in TicaretUnvani
+	Ortaklar []string `json:"ortaklar,omitempty"`
+
 	// İş Yeri Adresi (Business Address)
 	IsYeriAdresi string `json:"is_yeri_adresi,omitempty"`
 
+	// İş Yeri Adresleri (Business Addresses) - when the plate lists more than one
+	// distinct address, such as separate billing and operating addresses, all of them,
+	// starting with IsYeriAdresi
+	IsYeriAdresleri []string `json:"is_yeri_adresleri,omitempty"`
+
 	// Vergi Türü (Tax Type)
 	VergiTuru []string `json:"vergi_turu,omitempty"`
 
+	// Vergi Usulü (Taxation Regime) - the real (gerçek) or simplified (basit) regime, when
+	// the plate states it
+	VergiUsulu VergiUsulu `json:"vergi_usulu,omitempty"`
+
+	// Gelir Unsuru (Income Element) - for individuals, e.g. "Ticari Kazanç", "Serbest Meslek Kazancı" or
+	// "Gayrimenkul Sermaye İradı" (rental income, GMSİ)
+	GelirUnsuru string `json:"gelir_unsuru,omitempty"`
+
 	// Faaliyet Kodları ve Adları (Activity Codes and Names)
 	FaaliyetKodlari []Faaliyet `json:"faaliyet_kodlari,omitempty"`
 
+	// Sektör Grubu (Sector Group) - the NACE section of the first activity code, e.g.
+	// "Toptan ve Perakende Ticaret" for 471101
+	SektorGrubu string `json:"sektor_grubu,omitempty"`
+
 	// Vergi Dairesi (Tax Office)
 	VergiDairesi string `json:"vergi_dairesi,omitempty"`
 
 	// Vergi Kimlik No (Tax ID Number)
 	VergiKimlikNo string `json:"vergi_kimlik_no,omitempty"`
 
+	// Şube Kodu (Branch Code) - for branch tax plates; VergiKimlikNo stays the 10-digit base
+	SubeKodu string `json:"sube_kodu,omitempty"`
+
+	// İş Yeri Türü (Workplace Type) - whether the address is the head office or a branch
+	IsYeriTuru IsYeriTuru `json:"is_yeri_turu,omitempty"`
+
 	// TC Kimlik No (Turkish ID Number) - for individuals
 	TCKimlikNo string `json:"tc_kimlik_no,omitempty"`
 
+	// Uyruk (Nationality) - for foreign individuals
+	Uyruk string `json:"uyruk,omitempty"`
+
+	// Pasaport No (Passport Number) - for foreign individuals, who may have no TCKN
+	PasaportNo string `json:"pasaport_no,omitempty"`
+
+	// Kayıt No (Registration Number) - for associations and foundations, distinct from the VKN
+	KayitNo string `json:"kayit_no,omitempty"`
+
+	// Yabancı Vergi No (Foreign Tax Number) - the home country tax number of a foreign entity
+	YabanciVergiNo string `json:"yabanci_vergi_no,omitempty"`
+
+	// IsPotansiyelVKN is set when VergiKimlikNo is a potential (provisional) VKN, assigned to
+	// foreign entities with limited tax liability, rather than a regular one
+	IsPotansiyelVKN bool `json:"is_potansiyel_vkn,omitempty"`
+
+	// UsesTCKNAsVergiNo is set for individuals whose TCKN serves as the tax identifier
+	// (no separate VKN on the plate). Only populated when enabled via Parser.SetTCKNAsVergiNo.
+	UsesTCKNAsVergiNo bool `json:"uses_tckn_as_vergi_no,omitempty"`
+
 	// İşe Başlama Tarihi (Business Start Date)
 	IseBaslamaTarihi *time.Time `json:"ise_baslama_tarihi,omitempty"`
 
+	// Oluşturulma Tarihi (Document generation/print timestamp), if printed on the document
+	OlusturulmaTarihi *time.Time `json:"olusturulma_tarihi,omitempty"`
+
+	// ÖKC Seri Numaraları (Cash Register Serial Numbers) - the registered payment recorder
+	// devices (ödeme kaydedici cihaz) of a retail taxpayer, when listed
+	OkcSeriNolari []string `json:"okc_seri_nolari,omitempty"`
+
+	// İmza Var (Digitally Signed) - the PDF carries an electronic signature (e-imza)
+	ImzaVar bool `json:"imza_var,omitempty"`
+
+	// İmzalayan Adı (Signer Name) - the name of the signer of a digitally signed PDF,
+	// when the signature reveals it
+	ImzalayanAd string `json:"imzalayan_ad,omitempty"`
+
 	// Geçmiş Matrahlar (Historical Tax Bases)
 	GecmisMatra []Matrah `json:"gecmis_matrahlar,omitempty"`
 
+	// Belge Türü (Document Type) - tax plate or activity certificate
+	DocumentType DocumentType `json:"document_type,omitempty"`
+
+	// Mükellef Türü (Taxpayer Type) - individual, company, association or foundation
+	MukellefTuru MukellefTuru `json:"mukellef_turu,omitempty"`
+
+	// MukellefTuruGuveni is the confidence, from 0 to 1, of MukellefTuru: 1 when the plate
+	// states the kind of taxpayer, low when only the form of the name decided it. Types
+	// below 0.5 are also reported in Warnings.
+	MukellefTuruGuveni float64 `json:"mukellef_turu_guveni,omitempty"`
+
+	// FieldPages maps the JSON name of each extracted field to the 1-based page it was
+	// found on. Only populated when enabled via Parser.SetFieldPages.
+	FieldPages map[string]int `json:"field_pages,omitempty"`
+
+	// Warnings lists problems that did not stop parsing, e.g. pages skipped beyond the
+	// Parser.SetMaxPages cap
+	Warnings []string `json:"warnings,omitempty"`
+
+	// IsImageOnly is set when the PDF has no text layer, e.g. a scanned plate. Only the
+	// VKN is read from such a document, from its barcode or printed digits.
+	IsImageOnly bool `json:"is_image_only,omitempty"`
+
+	// RequiresOCR lists the requested fields that an image-only PDF left unread and that
+	// need a full OCR of the page images; JSON lists them by their JSON names
+	RequiresOCR FieldSet `json:"requires_ocr,omitempty"`
+
+	// VKNCheckDigitVerified is set when VergiKimlikNo was read from a barcode whose payload
+	// carried the VKN's check digit apart from its first nine digits, and it matched
+	VKNCheckDigitVerified bool `json:"vkn_check_digit_verified,omitempty"`
+
 	// Raw text extracted from PDF
 	RawText string `json:"-"`
 }
 
+// DocumentType identifies which GİB document layout was parsed
+type DocumentType string
+
+const (
+	// DocumentTypeVergiLevhasi is the standard tax plate (Vergi Levhası)
+	DocumentTypeVergiLevhasi DocumentType = "vergi_levhasi"
+
+	// DocumentTypeFaaliyetBelgesi is the activity certificate (Faaliyet Belgesi)
+	DocumentTypeFaaliyetBelgesi DocumentType = "faaliyet_belgesi"
+)
+
+// IsYeriTuru tells whether the plate's address is the head office or a branch
+type IsYeriTuru string
+
+const (
+	// IsYeriTuruBilinmiyor means the plate does not say (the zero value)
+	IsYeriTuruBilinmiyor IsYeriTuru = ""
+
+	// IsYeriTuruMerkez is the head office (merkez)
+	IsYeriTuruMerkez IsYeriTuru = "merkez"
+
+	// IsYeriTuruSube is a branch (şube)
+	IsYeriTuruSube IsYeriTuru = "sube"
+)
+
+// VergiUsulu is the taxation regime of the taxpayer
+type VergiUsulu string
+
+const (
+	// VergiUsuluBilinmiyor means the plate does not say (the zero value)
+	VergiUsuluBilinmiyor VergiUsulu = ""
+
+	// VergiUsuluGercek is the real regime (gerçek usul), taxed on the declared tax base
+	VergiUsuluGercek VergiUsulu = "gercek"
+
+	// VergiUsuluBasit is the simplified regime (basit usul) of small businesses, which
+	// have no tax base in the usual sense
+	VergiUsuluBasit VergiUsulu = "basit"
+)
+
+// MukellefTuru classifies the taxpayer
+type MukellefTuru string
+
+const (
+	// MukellefTuruBireysel is an individual taxpayer (gerçek kişi)
+	MukellefTuruBireysel MukellefTuru = "bireysel"
+
+	// MukellefTuruKurumsal is a company (kurumlar vergisi mükellefi)
+	MukellefTuruKurumsal MukellefTuru = "kurumsal"
+
+	// MukellefTuruDernek is an association (dernek); it is a legal entity like a company
+	MukellefTuruDernek MukellefTuru = "dernek"
+
+	// MukellefTuruVakif is a foundation (vakıf); it is a legal entity like a company
+	MukellefTuruVakif MukellefTuru = "vakif"
+
+	// MukellefTuruAdiOrtaklik is an ordinary partnership (adi ortaklık); it is not a legal
+	// entity, its partners are listed in Ortaklar
+	MukellefTuruAdiOrtaklik MukellefTuru = "adi_ortaklik"
+)
+
+// IsKurumsal reports whether the taxpayer is a legal entity: a company, association or foundation
+func (t MukellefTuru) IsKurumsal() bool {
+	return t == MukellefTuruKurumsal || t == MukellefTuruDernek || t == MukellefTuruVakif
+}
+
 // Faaliyet represents an activity code and name
 type Faaliyet struct {
+	// Kod is the code as printed; it is a string so that leading zeros (e.g. "012345"
+	// for crop growing) are kept
 	Kod string `json:"kod"`
 	Ad  string `json:"ad"`
 }
@@ -92,4 +263,168 @@ type Matrah struct {
 	Donem string  `json:"donem,omitempty"`
 	Tutar float64 `json:"tutar,omitempty"`
 	Tur   string  `json:"tur,omitempty"`
+
+	// TutarKurus is the exact amount in kuruş, parsed from the printed text without
+	// float rounding; prefer it over Tutar for sums and comparisons
+	TutarKurus int64 `json:"tutar_kurus,omitempty"`
+
+	// Vergi is the tax accrued on the year's tax base ("Tahakkuk eden vergi"), when the
+	// plate prints it; it is a different number from Tutar, not part of it. VergiKurus is
+	// the same amount in kuruş.
+	Vergi      float64 `json:"vergi,omitempty"`
+	VergiKurus int64   `json:"vergi_kurus,omitempty"`
+}
+
+// Equal reports whether two parse results carry the same data. RawText, FieldPages,
+// Warnings, MukellefTuruGuveni, the image-only and check digit diagnostics and the
+// generation timestamp (OlusturulmaTarihi) are ignored since they change between prints
+// or parser settings for the same document. İşe başlama tarihi is compared by calendar
+// day and VergiTuru ignores order; activities and tax bases must match in order.
+func (v *VergiLevhasi) Equal(other *VergiLevhasi) bool {
+	if v == nil || other == nil {
+		return v == other
+	}
+
+	if v.AdiSoyadi != other.AdiSoyadi ||
+		v.TicaretUnvani != other.TicaretUnvani ||
+		v.HamUnvan != other.HamUnvan ||
+		v.IsYeriAdresi != other.IsYeriAdresi ||
+		v.GelirUnsuru != other.GelirUnsuru ||
+		v.SektorGrubu != other.SektorGrubu ||
+		v.VergiUsulu != other.VergiUsulu ||
+		v.VergiDairesi != other.VergiDairesi ||
+		v.VergiKimlikNo != other.VergiKimlikNo ||
+		v.SubeKodu != other.SubeKodu ||
+		v.IsYeriTuru != other.IsYeriTuru ||
+		v.TCKimlikNo != other.TCKimlikNo ||
+		v.Uyruk != other.Uyruk ||
+		v.PasaportNo != other.PasaportNo ||
+		v.KayitNo != other.KayitNo ||
+		v.YabanciVergiNo != other.YabanciVergiNo ||
+		v.IsPotansiyelVKN != other.IsPotansiyelVKN ||
+		v.UsesTCKNAsVergiNo != other.UsesTCKNAsVergiNo ||
+		v.ImzaVar != other.ImzaVar ||
+		v.ImzalayanAd != other.ImzalayanAd ||
+		v.DocumentType != other.DocumentType ||
+		v.MukellefTuru != other.MukellefTuru {
+		return false
+	}
+
+	if !sameDay(v.IseBaslamaTarihi, other.IseBaslamaTarihi) {
+		return false
+	}
+
+	if !sameStringSet(v.VergiTuru, other.VergiTuru) {
+		return false
+	}
+
+	if !slices.Equal(v.IsYeriAdresleri, other.IsYeriAdresleri) {
+		return false
+	}
+	if !slices.Equal(v.Ortaklar, other.Ortaklar) {
+		return false
+	}
+
+	if !slices.Equal(v.OkcSeriNolari, other.OkcSeriNolari) {
+		return false
+	}
+
+	if len(v.FaaliyetKodlari) != len(other.FaaliyetKodlari) {
+		return false
+	}
+	for i := range v.FaaliyetKodlari {
+		if v.FaaliyetKodlari[i] != other.FaaliyetKodlari[i] {
+			return false
+		}
+	}
+
+	if len(v.GecmisMatra) != len(other.GecmisMatra) {
+		return false
+	}
+	for i := range v.GecmisMatra {
+		if v.GecmisMatra[i] != other.GecmisMatra[i] {
+			return false
+		}
+	}
+
+	return true
+}
+
+// MatrahForYear returns the tax bases declared for year, in document order
+func (v *VergiLevhasi) MatrahForYear(year int) []Matrah {
+	var result []Matrah
+	for _, m := range v.GecmisMatra {
+		if m.Yil == year {
+			result = append(result, m)
+		}
+	}
+	return result
+}
+
+// MissingMatrahYears returns the years without a tax base between the earliest and the
+// latest year in GecmisMatra. A gap usually means a row was not recognized.
+func (v *VergiLevhasi) MissingMatrahYears() []int {
+	years := make(map[int]bool)
+	first, last := 0, 0
+	for _, m := range v.GecmisMatra {
+		years[m.Yil] = true
+		if first == 0 || m.Yil < first {
+			first = m.Yil
+		}
+		last = max(last, m.Yil)
+	}
+
+	var missing []int
+	for year := first + 1; year < last; year++ {
+		if !years[year] {
+			missing = append(missing, year)
+		}
+	}
+	return missing
+}
+
+// ErrTaxpayerTypeMismatch is wrapped by the errors Validate returns when the identifiers
+// found do not fit the taxpayer type, which usually means a field was misread or the
+// taxpayer was misclassified
+var ErrTaxpayerTypeMismatch = errors.New("identifiers do not match the taxpayer type")
+
+// Validate checks that the identifiers fit MukellefTuru: a legal entity is identified by
+// a VKN, and an individual with a VKN also has a TCKN (or, for foreigners, a passport
+// number). The problems found are joined into one error; nil means none were found.
+func (v *VergiLevhasi) Validate() error {
+	var errs []error
+	if v.MukellefTuru.IsKurumsal() && v.VergiKimlikNo == "" {
+		errs = append(errs, fmt.Errorf("%w: %s taxpayer has no VKN", ErrTaxpayerTypeMismatch, v.MukellefTuru))
+	}
+	if v.MukellefTuru == MukellefTuruBireysel && v.VergiKimlikNo != "" && v.TCKimlikNo == "" && v.PasaportNo == "" {
+		errs = append(errs, fmt.Errorf("%w: %s taxpayer has a VKN but no TCKN", ErrTaxpayerTypeMismatch, v.MukellefTuru))
+	}
+	return errors.Join(errs...)
+}
+
+// sameDay reports whether two optional dates fall on the same calendar day
+func sameDay(a, b *time.Time) bool {
+	if a == nil || b == nil {
+		return a == b
+	}
+	ay, am, ad := a.Date()
+	by, bm, bd := b.Date()
+	return ay == by && am == bm && ad == bd
+}
+
+// sameStringSet reports whether a and b hold the same strings, ignoring order
+func sameStringSet(a, b []string) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	sortedA := append([]string(nil), a...)
+	sortedB := append([]string(nil), b...)
+	sort.Strings(sortedA)
+	sort.Strings(sortedB)
+	for i := range sortedA {
+		if sortedA[i] != sortedB[i] {
+			return false
+		}
+	}
+	return true
 }
@@ -0,0 +1,133 @@
+package vergilevhasi
+
+import (
+	"errors"
+	"testing"
+	"time"
+)
+
+// newEqualFixture returns a fully populated result with clearly fictional data
+func newEqualFixture() *VergiLevhasi {
+	start := time.Date(2020, 1, 1, 0, 0, 0, 0, time.UTC)
+	generated := time.Date(2024, 3, 15, 14, 32, 0, 0, time.UTC)
+	return &VergiLevhasi{
+		AdiSoyadi:         "Ali Örnek",
+		IsYeriAdresi:      "Örnek Mah. Test Cad. No:1",
+		VergiTuru:         []string{"Gelir Vergisi", "KDV"},
+		FaaliyetKodlari:   []Faaliyet{{Kod: "4711", Ad: "Perakende ticaret"}},
+		VergiDairesi:      "Örnek VD",
+		TCKimlikNo:        "11111111110",
+		IseBaslamaTarihi:  &start,
+		OlusturulmaTarihi: &generated,
+		GecmisMatra:       []Matrah{{Yil: 2020, Tutar: 150000}},
+		DocumentType:      DocumentTypeVergiLevhasi,
+		RawText:           "raw",
+	}
+}
+
+func TestVergiLevhasiEqual(t *testing.T) {
+	base := newEqualFixture()
+
+	tests := []struct {
+		name   string
+		modify func(vl *VergiLevhasi)
+		want   bool
+	}{
+		{"Identical", func(vl *VergiLevhasi) {}, true},
+		{"Reordered tax types", func(vl *VergiLevhasi) { vl.VergiTuru = []string{"KDV", "Gelir Vergisi"} }, true},
+		{"Different raw text", func(vl *VergiLevhasi) { vl.RawText = "other" }, true},
+		{"Different generation time", func(vl *VergiLevhasi) { vl.OlusturulmaTarihi = nil }, true},
+		{"Same day, different time and zone", func(vl *VergiLevhasi) {
+			d := time.Date(2020, 1, 1, 23, 0, 0, 0, time.FixedZone("TRT", 3*60*60))
+			vl.IseBaslamaTarihi = &d
+		}, true},
+		{"Different day", func(vl *VergiLevhasi) {
+			d := time.Date(2020, 1, 2, 0, 0, 0, 0, time.UTC)
+			vl.IseBaslamaTarihi = &d
+		}, false},
+		{"Missing date", func(vl *VergiLevhasi) { vl.IseBaslamaTarihi = nil }, false},
+		{"Different tax type", func(vl *VergiLevhasi) { vl.VergiTuru = []string{"Gelir Vergisi", "Damga Vergisi"} }, false},
+		{"Extra tax type", func(vl *VergiLevhasi) { vl.VergiTuru = append(vl.VergiTuru, "Muhtasar") }, false},
+		{"Different name", func(vl *VergiLevhasi) { vl.AdiSoyadi = "Veli Örnek" }, false},
+		{"Different matrah", func(vl *VergiLevhasi) { vl.GecmisMatra = []Matrah{{Yil: 2020, Tutar: 1}} }, false},
+		{"Different activity", func(vl *VergiLevhasi) { vl.FaaliyetKodlari = nil }, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			other := newEqualFixture()
+			tt.modify(other)
+			if got := base.Equal(other); got != tt.want {
+				t.Errorf("Equal() = %v, want %v", got, tt.want)
+			}
+			if got := other.Equal(base); got != tt.want {
+				t.Errorf("Equal() reversed = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestVergiLevhasiEqualNil(t *testing.T) {
+	var a, b *VergiLevhasi
+	if !a.Equal(b) {
+		t.Error("nil.Equal(nil) = false, want true")
+	}
+	if a.Equal(newEqualFixture()) || newEqualFixture().Equal(nil) {
+		t.Error("Equal() with one nil side = true, want false")
+	}
+}
+
+func TestVergiLevhasiValidate(t *testing.T) {
+	tests := []struct {
+		name    string
+		vl      VergiLevhasi
+		wantErr bool
+	}{
+		{"Company with VKN", VergiLevhasi{MukellefTuru: MukellefTuruKurumsal, VergiKimlikNo: "4827193056"}, false},
+		{"Company without VKN", VergiLevhasi{MukellefTuru: MukellefTuruKurumsal, TCKimlikNo: "10000000146"}, true},
+		{"Foundation without VKN", VergiLevhasi{MukellefTuru: MukellefTuruVakif}, true},
+		{"Individual with VKN and TCKN", VergiLevhasi{MukellefTuru: MukellefTuruBireysel, VergiKimlikNo: "4827193056", TCKimlikNo: "10000000146"}, false},
+		{"Individual with VKN but no TCKN", VergiLevhasi{MukellefTuru: MukellefTuruBireysel, VergiKimlikNo: "4827193056"}, true},
+		{"Foreign individual with passport", VergiLevhasi{MukellefTuru: MukellefTuruBireysel, VergiKimlikNo: "4827193056", PasaportNo: "C01X00T47"}, false},
+		{"Individual with TCKN only", VergiLevhasi{MukellefTuru: MukellefTuruBireysel, TCKimlikNo: "10000000146"}, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := tt.vl.Validate()
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("Validate() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if err != nil && !errors.Is(err, ErrTaxpayerTypeMismatch) {
+				t.Errorf("Validate() error = %v, want ErrTaxpayerTypeMismatch", err)
+			}
+		})
+	}
+}
+
+func TestMatrahForYear(t *testing.T) {
+	vl := &VergiLevhasi{GecmisMatra: []Matrah{
+		{Yil: 2021, Tutar: 300000},
+		{Yil: 2022, Tur: "KDV", Donem: "01", Tutar: 1000},
+		{Yil: 2022, Tur: "KDV", Donem: "02", Tutar: 2000},
+	}}
+
+	if got := vl.MatrahForYear(2022); len(got) != 2 || got[0].Donem != "01" || got[1].Donem != "02" {
+		t.Errorf("MatrahForYear(2022) = %+v, want both 2022 periods in order", got)
+	}
+	if got := vl.MatrahForYear(2020); got != nil {
+		t.Errorf("MatrahForYear(2020) = %+v, want nil", got)
+	}
+}
+
+func TestMissingMatrahYears(t *testing.T) {
+	vl := &VergiLevhasi{GecmisMatra: []Matrah{{Yil: 2019}, {Yil: 2023}, {Yil: 2021}}}
+	got := vl.MissingMatrahYears()
+	if len(got) != 2 || got[0] != 2020 || got[1] != 2022 {
+		t.Errorf("MissingMatrahYears() = %v, want [2020 2022]", got)
+	}
+
+	if got := (&VergiLevhasi{}).MissingMatrahYears(); got != nil {
+		t.Errorf("MissingMatrahYears() with no matrah = %v, want nil", got)
+	}
+}
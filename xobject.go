@@ -0,0 +1,193 @@
+package vergilevhasi
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/pdfcpu/pdfcpu/pkg/pdfcpu/types"
+)
+
+// maxFormNesting bounds how deeply Form XObjects drawn inside other forms are followed;
+// broken PDFs may contain cycles
+const maxFormNesting = 8
+
+// formExpander inlines the Form XObjects drawn by a content stream, so text drawn inside
+// them is extracted together with the page's own text
+type formExpander struct {
+	r     objectResolver
+	fonts fontCMaps
+	// forms counts the inlined forms with their own fonts, to give their font names a
+	// unique prefix
+	forms int
+}
+
+// expandFormXObjects replaces each "/Name Do" operator in content that draws a Form
+// XObject with the form's own content, wrapped in q/Q and its /Matrix. Fonts of forms
+// with their own resources are renamed in the inlined content and their ToUnicode CMaps
+// added to fonts, which is returned, since a form's /F1 need not be the page's /F1.
+func expandFormXObjects(r objectResolver, content string, resources types.Dict, fonts fontCMaps) (string, fontCMaps) {
+	if !strings.Contains(content, "Do") {
+		return content, fonts
+	}
+	e := &formExpander{r: r, fonts: fonts}
+	return e.expand(content, resources, "", 0, nil), e.fonts
+}
+
+// expand inlines the forms drawn by content. prefix is prepended to the font names of
+// Tf operators; active holds the forms being expanded, to break cycles.
+func (e *formExpander) expand(content string, resources types.Dict, prefix string, depth int, active []types.IndirectRef) string {
+	type operand struct {
+		tok        contentToken
+		start, end int
+	}
+
+	var b strings.Builder
+	copied := 0
+	var operands []operand
+	t := newContentTokenizer(content)
+	for {
+		tok, ok := t.next()
+		if !ok {
+			break
+		}
+		if tok.Kind != tokenOperator {
+			// Only names are ever rewritten, and a name token is its value as written
+			operands = append(operands, operand{tok: tok, start: t.pos - len(tok.Value), end: t.pos})
+			continue
+		}
+
+		n := len(operands)
+		switch {
+		case tok.Value == "Tf" && prefix != "" && n >= 2 && operands[n-2].tok.Kind == tokenName:
+			name := operands[n-2]
+			b.WriteString(content[copied:name.start])
+			b.WriteString("/" + prefix + strings.TrimPrefix(name.tok.Value, "/"))
+			copied = name.end
+
+		case tok.Value == "Do" && n >= 1 && operands[n-1].tok.Kind == tokenName && depth < maxFormNesting:
+			name := operands[n-1]
+			inlined, ok := e.inlineForm(fontResourceName(name.tok.Value), resources, prefix, depth, active)
+			if ok {
+				b.WriteString(content[copied:name.start])
+				b.WriteString(inlined)
+				copied = t.pos
+			}
+		}
+		operands = operands[:0]
+	}
+	b.WriteString(content[copied:])
+	return b.String()
+}
+
+// inlineForm returns the content of the Form XObject named name in resources, expanded
+// and ready to be drawn in place of its Do operator. It returns false for image XObjects
+// and forms that can't be read.
+func (e *formExpander) inlineForm(name string, resources types.Dict, prefix string, depth int, active []types.IndirectRef) (string, bool) {
+	xobjects := e.dict(resources["XObject"])
+	obj, ok := xobjects[name]
+	if !ok {
+		return "", false
+	}
+	ref, isRef := obj.(types.IndirectRef)
+	if isRef {
+		for _, a := range active {
+			if a.ObjectNumber == ref.ObjectNumber {
+				return "", false
+			}
+		}
+		active = append(active, ref)
+	}
+
+	sd := e.stream(obj)
+	if sd == nil {
+		return "", false
+	}
+	if subtype, ok := e.deref(sd.Dict["Subtype"]).(types.Name); !ok || subtype != "Form" {
+		return "", false
+	}
+	if sd.Content == nil {
+		if err := sd.Decode(); err != nil {
+			return "", false
+		}
+	}
+
+	// A form without resources uses those of the content drawing it
+	formResources := e.dict(sd.Dict["Resources"])
+	if formResources == nil {
+		formResources = resources
+	} else if fonts := e.dict(formResources["Font"]); len(fonts) > 0 {
+		e.forms++
+		prefix = fmt.Sprintf("Form%d_", e.forms)
+		cmaps := resourceFontCMaps(e.r, formResources)
+		if len(cmaps) > 0 && e.fonts == nil {
+			e.fonts = make(fontCMaps)
+		}
+		for font, cmap := range cmaps {
+			e.fonts[prefix+font] = cmap
+		}
+	}
+
+	var b strings.Builder
+	b.WriteString("\nq\n")
+	if m := e.matrix(sd.Dict["Matrix"]); m != "" {
+		b.WriteString(m + " cm\n")
+	}
+	b.WriteString(e.expand(string(sd.Content), formResources, prefix, depth+1, active))
+	b.WriteString("\nQ\n")
+	return b.String(), true
+}
+
+// matrix formats a form's /Matrix as the operands of a cm operator, or returns ""
+func (e *formExpander) matrix(obj types.Object) string {
+	arr, ok := e.deref(obj).(types.Array)
+	if !ok || len(arr) != 6 {
+		return ""
+	}
+	values := make([]string, len(arr))
+	for i, v := range arr {
+		switch n := e.deref(v).(type) {
+		case types.Integer:
+			values[i] = fmt.Sprint(int(n))
+		case types.Float:
+			values[i] = strconv.FormatFloat(float64(n), 'f', -1, 64)
+		default:
+			return ""
+		}
+	}
+	return strings.Join(values, " ")
+}
+
+// deref resolves an indirect reference, returning nil if it can't be resolved
+func (e *formExpander) deref(obj types.Object) types.Object {
+	if obj == nil {
+		return nil
+	}
+	resolved, err := e.r.Dereference(obj)
+	if err != nil {
+		return nil
+	}
+	return resolved
+}
+
+// dict resolves obj to a dictionary, or nil
+func (e *formExpander) dict(obj types.Object) types.Dict {
+	d, _ := e.deref(obj).(types.Dict)
+	return d
+}
+
+// stream resolves obj to a stream, or nil
+func (e *formExpander) stream(obj types.Object) *types.StreamDict {
+	return streamOf(e.deref(obj))
+}
+
+// streamOf returns obj as a stream, or nil; resolvers return streams by value or pointer
+func streamOf(obj types.Object) *types.StreamDict {
+	switch sd := obj.(type) {
+	case types.StreamDict:
+		return &sd
+	case *types.StreamDict:
+		return sd
+	}
+	return nil
+}
@@ -0,0 +1,138 @@
+package vergilevhasi
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	"github.com/pdfcpu/pdfcpu/pkg/pdfcpu/types"
+)
+
+// encodeTestCIDText renders s as the glyph IDs testCIDCMap maps back to it
+func encodeTestCIDText(s string) string {
+	special := map[rune]int{' ': 0x03, 'İ': 0x11, 'Ş': 0x12, 'Ğ': 0x13, 'Ü': 0x14, 'Ç': 0x15, 'Ö': 0x40, 'ı': 0x41, 'ş': 0x42}
+	var cids []int
+	for _, r := range s {
+		if cid, ok := special[r]; ok {
+			cids = append(cids, cid)
+		} else {
+			cids = append(cids, 0x20+int(r-'A'))
+		}
+	}
+	return encodeCIDs(cids...)
+}
+
+// formXObjectFixture is a page whose name is drawn by a Form XObject with its own CID
+// font, which in turn draws a nested form using its resources. The nested form draws
+// the outer one again, and the page also draws an image XObject.
+func formXObjectFixture() (objectTable, string, types.Dict) {
+	objects := objectTable{
+		1: types.StreamDict{
+			Dict: types.Dict{
+				"Subtype": types.Name("Form"),
+				"Matrix":  types.Array{types.Integer(1), types.Integer(0), types.Integer(0), types.Integer(1), types.Integer(0), types.Float(-20.5)},
+				"Resources": types.Dict{
+					"Font":    types.Dict{"F1": ref(3)},
+					"XObject": types.Dict{"Fm1": ref(1), "Fm2": ref(2)},
+				},
+			},
+			Content: []byte("BT /F1 12 Tf 72 700 Td <" + encodeTestCIDText("ALİ ÖRNEK") + "> Tj ET /Fm2 Do"),
+		},
+		2: &types.StreamDict{
+			Dict:    types.Dict{"Subtype": types.Name("Form")},
+			Content: []byte("BT /F1 12 Tf 72 600 Td <" + encodeTestCIDText("TİCARET") + "> Tj ET /Fm1 Do"),
+		},
+		3: types.Dict{"Subtype": types.Name("Type0"), "ToUnicode": ref(4)},
+		4: types.StreamDict{Content: []byte(testCIDCMap)},
+		5: types.StreamDict{Dict: types.Dict{"Subtype": types.Name("Image")}},
+	}
+	resources := types.Dict{
+		"Font":    types.Dict{"F1": types.Dict{"Subtype": types.Name("Type1")}},
+		"XObject": types.Dict{"Fm1": ref(1), "Im1": ref(5)},
+	}
+	content := "BT /F1 12 Tf 72 750 Td (VERGI LEVHASI) Tj ET\nq 100 0 0 100 0 0 cm /Im1 Do Q\n/Fm1 Do\n"
+	return objects, content, resources
+}
+
+func TestExpandFormXObjects(t *testing.T) {
+	objects, content, resources := formXObjectFixture()
+
+	expanded, fonts := expandFormXObjects(objects, content, resources, resourceFontCMaps(objects, resources))
+	if !strings.Contains(expanded, "/Im1 Do") {
+		t.Errorf("image XObject was replaced:\n%s", expanded)
+	}
+	// The nested form drawing the outer one again is a cycle and is left as written
+	if !strings.Contains(expanded, "/Fm1 Do") {
+		t.Errorf("cyclic form draw was not left in place:\n%s", expanded)
+	}
+	if strings.Count(expanded, encodeTestCIDText("ALİ ÖRNEK")) != 1 {
+		t.Errorf("outer form inlined more than once:\n%s", expanded)
+	}
+
+	page := pageFromContent(expanded, fonts, 0)
+	for _, want := range []string{"VERGI LEVHASI", "ALİ ÖRNEK", "TİCARET"} {
+		if !strings.Contains(page.Text, want) {
+			t.Errorf("page text missing %q:\n%s", want, page.Text)
+		}
+	}
+
+	// The form's /Matrix moves its text down by 20.5
+	found := false
+	for _, row := range page.rows {
+		if row.Y == 679.5 && len(row.Cells) > 0 && row.Cells[0].Text == "ALİ ÖRNEK" {
+			found = true
+		}
+	}
+	if !found {
+		t.Errorf("rows = %+v, want ALİ ÖRNEK at y 679.5", page.rows)
+	}
+}
+
+func TestExpandFormXObjectsWithoutForms(t *testing.T) {
+	content := "BT /F1 12 Tf (VERGI LEVHASI) Tj ET"
+	expanded, fonts := expandFormXObjects(objectTable{}, content, nil, nil)
+	if expanded != content || fonts != nil {
+		t.Errorf("expandFormXObjects() = %q, %v; want the content unchanged", expanded, fonts)
+	}
+}
+
+func TestParseTextInsideFormXObject(t *testing.T) {
+	// A template form draws the labels; the taxpayer's values sit in a nested form
+	objects := objectTable{
+		1: types.StreamDict{
+			Dict: types.Dict{
+				"Subtype":   types.Name("Form"),
+				"Resources": types.Dict{"XObject": types.Dict{"Values": ref(2)}},
+			},
+			Content: []byte("BT 72 700 Td (ADI SOYADI) Tj ET\nBT 72 680 Td (VERGI KIMLIK NO) Tj ET\n/Values Do"),
+		},
+		2: types.StreamDict{
+			Dict:    types.Dict{"Subtype": types.Name("Form")},
+			Content: []byte("BT 250 700 Td (ALI ORNEK) Tj ET\nBT 250 680 Td (1234567890) Tj ET"),
+		},
+	}
+	resources := types.Dict{"XObject": types.Dict{"Template": ref(1)}}
+	content := "BT 72 750 Td (VERGI LEVHASI) Tj ET\n/Template Do\n"
+
+	expanded, fonts := expandFormXObjects(objects, content, resources, nil)
+	page := pageFromContent(expanded, fonts, 0)
+	page.Number = 1
+
+	parser := NewParser()
+	parser.SetBackend(&fakeBackend{pages: []PageText{page}})
+	vl, err := parser.Parse(bytes.NewReader(nil))
+	if err != nil {
+		t.Fatalf("Parse() error = %v", err)
+	}
+	if vl.VergiKimlikNo != "1234567890" {
+		t.Errorf("VergiKimlikNo = %q, want 1234567890", vl.VergiKimlikNo)
+	}
+	if vl.AdiSoyadi != "ALI ORNEK" {
+		t.Errorf("AdiSoyadi = %q, want ALI ORNEK", vl.AdiSoyadi)
+	}
+
+	// Without the expansion the page has no values at all
+	if plain := pageFromContent(content, nil, 0); strings.Contains(plain.Text, "1234567890") {
+		t.Errorf("unexpanded page text = %q", plain.Text)
+	}
+}